@@ -0,0 +1,45 @@
+// Package ginverify is the gin middleware of the authverify package. It is a package of its own so services
+// without gin do not depend on it.
+//
+//	router.Use(ginverify.Middleware(verifier))
+//
+//	func orders(c *gin.Context) {
+//		principal, _ := ginverify.Principal(c)
+//		...
+//	}
+package ginverify
+
+import (
+	"test-task/authverify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key Middleware stores the principal under
+const principalKey = "authverify.principal"
+
+// Middleware is authverify.Middleware for gin, the handlers find the user with Principal. The principal is
+// in the context of the request too, for code reading it with authverify.FromContext.
+func Middleware(v *authverify.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, status, message := v.Authenticate(c.Request)
+		if principal == nil {
+			c.JSON(status, gin.H{"message": message, "status": status})
+			c.Abort()
+			return
+		}
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(authverify.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// Principal returns the principal Middleware stored for the request
+func Principal(c *gin.Context) (*authverify.Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*authverify.Principal)
+	return principal, ok
+}
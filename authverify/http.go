@@ -0,0 +1,77 @@
+package authverify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal Middleware put in the context of the request
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok
+}
+
+// Middleware lets a request through only with a valid access token in its Authorization header, the handler
+// finds the user with FromContext. Refused requests are answered like the service does, with a JSON message
+// and status.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, status, message := v.Authenticate(r)
+		if principal == nil {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]interface{}{"message": message, "status": status})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+	})
+}
+
+// Authenticate verifies the token of the request's Authorization header like Middleware does, for adapters to
+// other routers. A refused token comes with the status and message to answer.
+func (v *Verifier) Authenticate(r *http.Request) (*Principal, int, string) {
+	token, ok := v.authorizationToken(r.Header.Get("Authorization"))
+	if !ok {
+		return nil, http.StatusUnauthorized, "Your request is not authorized"
+	}
+	if token == "" {
+		return nil, http.StatusUnauthorized, "An authorization token was not supplied"
+	}
+
+	principal, err := v.Verify(r.Context(), token)
+	switch {
+	case err == nil:
+		return principal, 0, ""
+	case errors.Is(err, ErrUnavailable):
+		return nil, http.StatusServiceUnavailable, "the authorization token can not be checked right now"
+	case errors.Is(err, ErrExpiredToken):
+		return nil, http.StatusUnauthorized, "The authorization token is expired"
+	case errors.Is(err, ErrRevokedToken):
+		return nil, http.StatusUnauthorized, "The authorization token has been revoked"
+	}
+	return nil, http.StatusUnauthorized, "invalid authorization token"
+}
+
+// authorizationToken reads a Bearer token, or a DPoP one with WithBoundTokens
+func (v *Verifier) authorizationToken(header string) (string, bool) {
+	schemes := []string{"Bearer"}
+	if v.boundTokens {
+		schemes = append(schemes, "DPoP")
+	}
+	for _, scheme := range schemes {
+		if strings.HasPrefix(header, scheme+" ") {
+			return strings.TrimPrefix(header, scheme+" "), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+package authverify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	introspectPath = "/api/v1/introspect"
+	// maxRevocationEntries bounds the cached answers, the expired ones are dropped when it is reached
+	maxRevocationEntries = 100000
+	maxIntrospectionSize = 64 << 10
+	userAgent            = "test-task-authverify"
+)
+
+// introspectionRequest is the body of POST /api/v1/introspect
+type introspectionRequest struct {
+	Token string `json:"token"`
+}
+
+// introspectionAnswer is the answer of POST /api/v1/introspect, only the fields the cache needs. Errors are
+// answered with res_code and message instead of meta and data.
+type introspectionAnswer struct {
+	Meta *struct {
+		ResCode int `json:"res_code"`
+	} `json:"meta"`
+	Data struct {
+		Active bool   `json:"active"`
+		Reason string `json:"reason"`
+	} `json:"data"`
+	ResCode int    `json:"res_code"`
+	Message string `json:"message"`
+}
+
+type revocationEntry struct {
+	active bool
+	reason string
+	until  time.Time
+}
+
+// revocationCache asks the introspection endpoint of the service whether tokens are still accepted and keeps
+// the answers by jti
+type revocationCache struct {
+	url          string
+	http         *http.Client
+	ttl          time.Duration
+	clientID     string
+	clientSecret string
+
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+func newRevocationCache(url string, hc *http.Client, ttl time.Duration, clientID, clientSecret string) *revocationCache {
+	return &revocationCache{
+		url:          url,
+		http:         hc,
+		ttl:          ttl,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		entries:      map[string]revocationEntry{},
+	}
+}
+
+func (r *revocationCache) check(ctx context.Context, token string, principal *Principal) error {
+	now := time.Now()
+	r.mu.Lock()
+	entry, ok := r.entries[principal.TokenID]
+	r.mu.Unlock()
+
+	if !ok || now.After(entry.until) {
+		answer, err := r.introspect(ctx, token)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnavailable, err.Error())
+		}
+		entry = revocationEntry{active: answer.Data.Active, reason: answer.Data.Reason, until: now.Add(r.ttl)}
+		if principal.ExpiresAt.Before(entry.until) {
+			entry.until = principal.ExpiresAt
+		}
+		r.store(principal.TokenID, entry, now)
+	}
+
+	if !entry.active {
+		return fmt.Errorf("%w: %s", ErrRevokedToken, entry.reason)
+	}
+	return nil
+}
+
+// introspect asks the service about the token, a refused token is an inactive answer and not an error
+func (r *revocationCache) introspect(ctx context.Context, token string) (*introspectionAnswer, error) {
+	body, err := json.Marshal(introspectionRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(r.clientID, r.clientSecret)
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%s refused the client credentials of the introspection", r.url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %d", r.url, resp.StatusCode)
+	}
+
+	var answer introspectionAnswer
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxIntrospectionSize)).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("%s answered an unreadable introspection: %s", r.url, err.Error())
+	}
+	// the services answer most of their errors with 200, the status is in res_code
+	if answer.Meta == nil {
+		return nil, fmt.Errorf("%s answered %d: %s", r.url, answer.ResCode, answer.Message)
+	}
+	return &answer, nil
+}
+
+func (r *revocationCache) store(tokenID string, entry revocationEntry, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) >= maxRevocationEntries {
+		for id, cached := range r.entries {
+			if now.After(cached.until) {
+				delete(r.entries, id)
+			}
+		}
+		if len(r.entries) >= maxRevocationEntries {
+			r.entries = map[string]revocationEntry{}
+		}
+	}
+	r.entries[tokenID] = entry
+}
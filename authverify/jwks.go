@@ -0,0 +1,246 @@
+package authverify
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// minRefreshInterval is the least time between two fetches of the keys, a token with an unknown kid does
+	// not make them fetched again sooner
+	minRefreshInterval = 30 * time.Second
+	maxKeysTTL         = 24 * time.Hour
+	// fetchTimeout bounds a fetch of the keys, it does not run on the context of the token that started it
+	fetchTimeout = 10 * time.Second
+	maxJWKSSize  = 1 << 20
+	minRSABits   = 2048
+)
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// jwk is a key of the JWK Set of the service
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type verificationKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// keyCache holds the keys of the JWK Set, fetched again when they are older than the max-age of the answer
+// or a token is signed with a key they do not have, as happens after a rotation. The lock is not held
+// during a fetch, the requests needing the keys meanwhile share it.
+type keyCache struct {
+	url     string
+	http    *http.Client
+	ttl     time.Duration
+	fetches singleflight.Group
+
+	mu      sync.Mutex
+	keys    map[string]verificationKey
+	err     error     // of the last fetch, answered while no keys were fetched yet
+	expires time.Time // when the keys are fetched again
+	fetched time.Time // last fetch, successful or not
+}
+
+func newKeyCache(url string, hc *http.Client, ttl time.Duration) *keyCache {
+	return &keyCache{url: url, http: hc, ttl: ttl}
+}
+
+// key returns the public key with the kid, checking it is meant for alg
+func (c *keyCache) key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	expired := time.Now().After(c.expires)
+	c.mu.Unlock()
+	if expired {
+		c.refresh(ctx)
+	}
+
+	c.mu.Lock()
+	key, ok := c.keys[kid]
+	retry := !ok && time.Since(c.fetched) >= minRefreshInterval
+	c.mu.Unlock()
+	if retry {
+		c.refresh(ctx)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if retry {
+		key, ok = c.keys[kid]
+	}
+	if c.keys == nil {
+		err := c.err
+		if err == nil {
+			// the caller gave up before the first fetch ended
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err.Error())
+	}
+	if !ok || key.alg != alg {
+		return nil, errUnknownKey
+	}
+	return key.key, nil
+}
+
+// refresh fetches the keys once for every caller asking meanwhile. The fetch runs on a context of its own so
+// a caller giving up does not fail it for the others, ctx only ends the wait of this caller.
+func (c *keyCache) refresh(ctx context.Context) {
+	done := c.fetches.DoChan("jwks", func() (interface{}, error) {
+		c.mu.Lock()
+		c.fetched = time.Now()
+		c.mu.Unlock()
+
+		fetchCtx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		keys, ttl, err := c.fetch(fetchCtx)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		now := time.Now()
+		if err != nil {
+			// the keys fetched before stay in use, the next try waits a little
+			c.err = err
+			c.expires = now.Add(minRefreshInterval)
+			return nil, nil
+		}
+		c.keys, c.err = keys, nil
+		c.expires = now.Add(ttl)
+		return nil, nil
+	})
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (c *keyCache) fetch(ctx context.Context) (map[string]verificationKey, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("%s answered %d", c.url, resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, 0, fmt.Errorf("%s is not a JWK Set: %s", c.url, err.Error())
+	}
+	keys := map[string]verificationKey{}
+	for _, k := range set.Keys {
+		// keys of other uses or types are skipped, they can not have signed an access token
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.verificationKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		// the set of a service signing with HS256 is empty, none of its tokens can be checked here
+		return nil, 0, fmt.Errorf("%s holds no usable key, the service must sign with Signing.Algorithm RS256 or ES256", c.url)
+	}
+	return keys, c.maxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// maxAge is how long the answer may be cached, bounded so a rotation is seen in time
+func (c *keyCache) maxAge(cacheControl string) time.Duration {
+	ttl := c.ttl
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "max-age") {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if ttl < minRefreshInterval {
+		return minRefreshInterval
+	}
+	if ttl > maxKeysTTL {
+		return maxKeysTTL
+	}
+	return ttl
+}
+
+func (k jwk) verificationKey() (verificationKey, error) {
+	switch {
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == "RS256"):
+		n, err := decodeMember("n", k.N)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		e, err := decodeMember("e", k.E)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits || key.E < 3 || key.E%2 == 0 {
+			return verificationKey{}, errors.New("rsa key is too weak")
+		}
+		return verificationKey{alg: "RS256", key: key}, nil
+	case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == "ES256"):
+		x, err := decodeMember("x", k.X)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		y, err := decodeMember("y", k.Y)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return verificationKey{}, errors.New("invalid ec key")
+		}
+		// the ecdh package refuses points that are not on the curve
+		if _, err = ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return verificationKey{}, errors.New("invalid ec key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return verificationKey{alg: "ES256", key: key}, nil
+	}
+	return verificationKey{}, fmt.Errorf("unsupported %s key", k.Kty)
+}
+
+func decodeMember(name, value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("invalid %q member in jwk", name)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,89 @@
+package authverify
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Principal is the user an access token was issued to, the userData claim the service reads into its
+// middleware.UserTokenData
+type Principal struct {
+	Id        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// OrgId is the active organization of the session, nil when the user has none
+	OrgId      *uuid.UUID `json:"org_id,omitempty"`
+	OrgRole    string     `json:"org_role,omitempty"`
+	AuthMethod string     `json:"auth_method,omitempty"`
+
+	// Actor is the admin impersonating the user, nil for regular tokens
+	Actor *Actor `json:"-"`
+
+	// TokenID is the jti of the token
+	TokenID   string    `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+	// Jkt is the thumbprint of the DPoP key the token is bound to, empty for bearer tokens
+	Jkt string `json:"-"`
+}
+
+// Actor is the admin acting on behalf of the user, carried in the "act" claim (RFC 8693)
+type Actor struct {
+	Id    uuid.UUID `json:"sub"`
+	Email string    `json:"email"`
+}
+
+// HasRole reports whether the user has one of the roles
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Impersonated reports whether an admin is acting on behalf of the user
+func (p *Principal) Impersonated() bool {
+	return p.Actor != nil
+}
+
+// principalFromClaims reads the userData and act claims of an access token
+func principalFromClaims(claims jwt.MapClaims) (*Principal, error) {
+	principal := &Principal{}
+	if err := remarshal(claims["userData"], principal); err != nil || principal.Id == uuid.Nil {
+		return nil, errors.New("userData not found in the token")
+	}
+	if act, ok := claims["act"]; ok {
+		principal.Actor = &Actor{}
+		if err := remarshal(act, principal.Actor); err != nil || principal.Actor.Id == uuid.Nil {
+			return nil, errors.New("invalid act claim")
+		}
+	}
+
+	principal.TokenID, _ = claims["jti"].(string)
+	if principal.TokenID == "" {
+		return nil, errors.New("jti not found in the token")
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		principal.ExpiresAt = exp.Time
+	}
+	if cnf, ok := claims["cnf"].(map[string]interface{}); ok {
+		principal.Jkt, _ = cnf["jkt"].(string)
+	}
+	return principal, nil
+}
+
+// remarshal decodes a claim parsed into maps into the struct out
+func remarshal(claim interface{}, out interface{}) error {
+	raw, err := json.Marshal(claim)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
@@ -0,0 +1,192 @@
+// Package authverify checks the access tokens of the service in other Go services, locally and without the
+// secret of the service. The service has to sign its tokens with a key pair (Signing.Algorithm RS256 or
+// ES256), the public keys are fetched from its /.well-known/jwks.json and cached. The key set of a service
+// signing with HS256, its default, is empty and every token is refused with ErrUnavailable:
+//
+//	verifier := authverify.New("https://auth.example.com", authverify.WithIntrospection(30*time.Second, "orders", secret))
+//	mux.Handle("/orders", verifier.Middleware(http.HandlerFunc(orders)))
+//
+//	func orders(w http.ResponseWriter, r *http.Request) {
+//		principal, _ := authverify.FromContext(r.Context())
+//		...
+//	}
+//
+// Gin services use the ginverify package instead.
+//
+// The signature tells that the service issued the token, not that it was not signed out since or that the
+// user is still active. With WithIntrospection the service is asked about every token too, its answers are
+// cached for the given time, so a revocation takes at most that long to be seen.
+package authverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultLeeway  = 30 * time.Second
+	DefaultKeysTTL = 15 * time.Minute
+
+	jwksPath = "/.well-known/jwks.json"
+)
+
+// algorithms are the ones of the key pairs, HS256 tokens can not be checked without the secret
+var algorithms = []string{"RS256", "ES256"}
+
+// The reasons a token is refused, match them with errors.Is
+var (
+	ErrInvalidToken = errors.New("authverify: invalid token")
+	ErrExpiredToken = errors.New("authverify: token is expired")
+	ErrRevokedToken = errors.New("authverify: token is no longer accepted")
+	ErrBoundToken   = errors.New("authverify: token is bound to a DPoP key")
+	// ErrUnavailable is returned when the keys or the introspection could not be fetched from the service
+	ErrUnavailable = errors.New("authverify: token can not be checked right now")
+)
+
+type Option func(*Verifier)
+
+// WithHTTPClient fetches the keys and introspects the tokens with hc, the default has a 10 seconds timeout
+func WithHTTPClient(hc *http.Client) Option {
+	return func(v *Verifier) {
+		v.http = hc
+	}
+}
+
+// WithIssuer sets the iss claim the tokens must have, the base URL by default as the service uses its
+// App.PublicURL unless Signing.Issuer is set
+func WithIssuer(issuer string) Option {
+	return func(v *Verifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithAudience requires the aud claim to hold audience, the Signing.Audience of the service
+func WithAudience(audience string) Option {
+	return func(v *Verifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway sets how far the clocks of the services may be apart when exp, nbf and iat are checked
+func WithLeeway(leeway time.Duration) Option {
+	return func(v *Verifier) {
+		v.leeway = leeway
+	}
+}
+
+// WithKeysTTL sets how long the keys are cached when the service does not tell it with Cache-Control
+func WithKeysTTL(ttl time.Duration) Option {
+	return func(v *Verifier) {
+		v.keysTTL = ttl
+	}
+}
+
+// WithIntrospection asks the service whether a token is still accepted and caches the answer for ttl, never
+// past the expiry of the token. It catches signed out tokens, revoked sessions and blocked users. The service
+// answers only the clients of its [Introspection] section, clientID and clientSecret are one of them.
+func WithIntrospection(ttl time.Duration, clientID, clientSecret string) Option {
+	return func(v *Verifier) {
+		v.introspectTTL = ttl
+		v.clientID = clientID
+		v.clientSecret = clientSecret
+	}
+}
+
+// WithBoundTokens accepts tokens bound to a DPoP key, sent with the DPoP scheme. Checking the proof of the key
+// is left to the caller, Principal.Jkt is the thumbprint of the key.
+func WithBoundTokens() Option {
+	return func(v *Verifier) {
+		v.boundTokens = true
+	}
+}
+
+// Verifier checks the access tokens of one service. It is safe for concurrent use.
+type Verifier struct {
+	baseURL       string
+	issuer        string
+	audience      string
+	leeway        time.Duration
+	keysTTL       time.Duration
+	introspectTTL time.Duration
+	clientID      string
+	clientSecret  string
+	boundTokens   bool
+	http          *http.Client
+
+	keys       *keyCache
+	revocation *revocationCache // nil without WithIntrospection
+}
+
+// New is a verifier of the tokens issued by the service at baseURL, e.g. https://auth.example.com
+func New(baseURL string, opts ...Option) *Verifier {
+	baseURL = strings.TrimRight(baseURL, "/")
+	v := &Verifier{
+		baseURL: baseURL,
+		issuer:  baseURL,
+		leeway:  DefaultLeeway,
+		keysTTL: DefaultKeysTTL,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	v.keys = newKeyCache(baseURL+jwksPath, v.http, v.keysTTL)
+	if v.introspectTTL > 0 {
+		v.revocation = newRevocationCache(baseURL+introspectPath, v.http, v.introspectTTL, v.clientID, v.clientSecret)
+	}
+	return v
+}
+
+// Verify checks the signature and the claims of the token, and with WithIntrospection whether the service
+// still accepts it. It returns the user the token was issued to.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithLeeway(v.leeway),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	var keyErr error
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.key(ctx, kid, t.Method.Alg())
+		keyErr = err
+		return key, err
+	}, opts...)
+	if err != nil {
+		if errors.Is(keyErr, ErrUnavailable) {
+			return nil, keyErr
+		}
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+
+	principal, err := principalFromClaims(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	if principal.Jkt != "" && !v.boundTokens {
+		return nil, ErrBoundToken
+	}
+	if v.revocation != nil {
+		if err = v.revocation.check(ctx, token, principal); err != nil {
+			return nil, err
+		}
+	}
+	return principal, nil
+}
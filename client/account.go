@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+)
+
+// RevokeSessions revokes the sessions of the user with the token of the "this wasn't me" link
+func (c *Client) RevokeSessions(ctx context.Context, req v1req.RevokeSessionsRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/account/revoke-sessions", body: req}, nil)
+}
+
+// VerifyEmail verifies the email address with the token of the verification link
+func (c *Client) VerifyEmail(ctx context.Context, req v1req.VerifyEmailRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/account/verify-email", body: req}, nil)
+}
+
+// ResendVerification mails a new verification link to a pending account
+func (c *Client) ResendVerification(ctx context.Context, req v1req.ResendVerificationRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/account/verify-email/resend", body: req}, nil)
+}
+
+// VerifyPhone stores a phone number with the code texted to it
+func (c *Client) VerifyPhone(ctx context.Context, req v1req.VerifyPhoneRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/account/phone/verify", body: req}, nil)
+}
+
+// GetActivity lists the recent sign-ins of the user
+func (c *Client) GetActivity(ctx context.Context, req v1req.ActivityRequest) (*v1resp.LoginActivityListResponse, error) {
+	var resp v1resp.LoginActivityListResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/account/activity", query: values(req), auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPermissions lists the roles and permissions of the user, the ones of their groups included
+func (c *Client) GetPermissions(ctx context.Context) (*v1resp.PermissionsResponse, error) {
+	var resp v1resp.PermissionsResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/account/permissions", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AddPhone texts a verification code to a new phone number of the user
+func (c *Client) AddPhone(ctx context.Context, req v1req.PhoneRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/account/phone", body: req, auth: true}, nil)
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// SearchAuditEvents searches the security audit log
+func (c *Client) SearchAuditEvents(ctx context.Context, req v1req.AuditSearchRequest) (*v1resp.AuditEventListResponse, error) {
+	var resp v1resp.AuditEventListResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/audit-events", query: values(req), auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifyAuditChain checks that the audit log has not been tampered with
+func (c *Client) VerifyAuditChain(ctx context.Context) (*v1resp.AuditChainResponse, error) {
+	var resp v1resp.AuditChainResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/audit-events/verify", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Impersonate issues a short lived token to act as the user. The client keeps its own tokens, make another
+// client WithTokens for the impersonation token.
+func (c *Client) Impersonate(ctx context.Context, userID uuid.UUID, req v1req.ImpersonateRequest) (*v1resp.ImpersonationResponse, error) {
+	var resp v1resp.ImpersonationResponse
+	path := "/admin/users/" + userID.String() + "/impersonate"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ChangeUserStatus suspends, locks, reactivates or deletes a user
+func (c *Client) ChangeUserStatus(ctx context.Context, userID uuid.UUID, req v1req.UserStatusRequest) (*v1resp.UserStatusResponse, error) {
+	var resp v1resp.UserStatusResponse
+	path := "/admin/users/" + userID.String() + "/status"
+	if err := c.do(ctx, request{method: http.MethodPatch, path: path, body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RestoreUser brings back a deleted user within the restore window
+func (c *Client) RestoreUser(ctx context.Context, userID uuid.UUID, req v1req.RestoreUserRequest) (*v1resp.UserStatusResponse, error) {
+	var resp v1resp.UserStatusResponse
+	path := "/admin/users/" + userID.String() + "/restore"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateGroup creates a group with its roles
+func (c *Client) CreateGroup(ctx context.Context, req v1req.CreateGroupRequest) (*v1resp.GroupResponse, error) {
+	var resp v1resp.GroupResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/groups", body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetGroups lists the groups
+func (c *Client) GetGroups(ctx context.Context) ([]v1resp.GroupResponse, error) {
+	var resp []v1resp.GroupResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/groups", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetGroup returns a group with its members
+func (c *Client) GetGroup(ctx context.Context, groupID uuid.UUID) (*v1resp.GroupDetailResponse, error) {
+	var resp v1resp.GroupDetailResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/groups/" + groupID.String(), auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteGroup deletes a group
+func (c *Client) DeleteGroup(ctx context.Context, groupID uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/admin/groups/" + groupID.String(), auth: true}, nil)
+}
+
+// AddGroupMembers adds users to a group
+func (c *Client) AddGroupMembers(ctx context.Context, groupID uuid.UUID, req v1req.GroupMembersRequest) (*v1resp.GroupMembersAddedResponse, error) {
+	var resp v1resp.GroupMembersAddedResponse
+	path := "/admin/groups/" + groupID.String() + "/members"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RemoveGroupMember removes a user from a group
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	path := "/admin/groups/" + groupID.String() + "/members/" + userID.String()
+	return c.do(ctx, request{method: http.MethodDelete, path: path, auth: true}, nil)
+}
+
+// AddGroupRole grants a role to the members of a group
+func (c *Client) AddGroupRole(ctx context.Context, groupID uuid.UUID, req v1req.GroupRoleRequest) (*v1resp.GroupResponse, error) {
+	var resp v1resp.GroupResponse
+	path := "/admin/groups/" + groupID.String() + "/roles"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RemoveGroupRole takes a role away from the members of a group
+func (c *Client) RemoveGroupRole(ctx context.Context, groupID uuid.UUID, role string) error {
+	path := "/admin/groups/" + groupID.String() + "/roles/" + url.PathEscape(role)
+	return c.do(ctx, request{method: http.MethodDelete, path: path, auth: true}, nil)
+}
+
+// GetJobs lists the scheduled jobs with their next and last run
+func (c *Client) GetJobs(ctx context.Context) (*v1resp.JobListResponse, error) {
+	var resp v1resp.JobListResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/jobs", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetJobRuns returns the run history of a job, newest first
+func (c *Client) GetJobRuns(ctx context.Context, job string, req v1req.JobRunsRequest) (*v1resp.JobRunListResponse, error) {
+	var resp v1resp.JobRunListResponse
+	path := "/admin/jobs/" + url.PathEscape(job) + "/runs"
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, query: values(req), auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+)
+
+// SignUp registers a user. A phone number in the request gets a code texted to it, see VerifyPhone.
+func (c *Client) SignUp(ctx context.Context, req v1req.SignUpRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/sign-up", body: req}, nil)
+}
+
+// SignIn starts a session with the password, the client keeps its tokens
+func (c *Client) SignIn(ctx context.Context, req v1req.SignInRequest) (*v1resp.SigninResponse, error) {
+	return c.signIn(ctx, request{method: http.MethodPost, path: "/sign-in", body: req})
+}
+
+// SendSignInOTP texts a sign-in code to a verified phone number
+func (c *Client) SendSignInOTP(ctx context.Context, req v1req.OTPRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/sign-in/otp/send", body: req}, nil)
+}
+
+// SignInWithOTP starts a session with the texted code, the client keeps its tokens
+func (c *Client) SignInWithOTP(ctx context.Context, req v1req.OTPSignInRequest) (*v1resp.SigninResponse, error) {
+	return c.signIn(ctx, request{method: http.MethodPost, path: "/sign-in/otp", body: req})
+}
+
+// RefreshToken issues a new access token with the refresh token of the client. The calls needing an access
+// token do it on their own when theirs expired.
+func (c *Client) RefreshToken(ctx context.Context) (*v1resp.RefreshTokenResponse, error) {
+	refresh := c.Tokens().RefreshToken
+	if refresh == "" {
+		return nil, ErrNoRefreshToken
+	}
+	var resp v1resp.RefreshTokenResponse
+	req := v1req.RefreshTokenRequest{RefreshToken: refresh}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/refresh-token", body: req}, &resp); err != nil {
+		return nil, err
+	}
+	c.setAccessToken(resp.AccessToken)
+	return &resp, nil
+}
+
+// IntrospectToken tells whether the service still accepts an access token, made for services checking the
+// tokens sent to them. It needs the credentials of WithClientCredentials.
+func (c *Client) IntrospectToken(ctx context.Context, req v1req.IntrospectTokenRequest) (*v1resp.IntrospectionResponse, error) {
+	var resp v1resp.IntrospectionResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/introspect", body: req, client: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ForgotPassword mails a password reset link
+func (c *Client) ForgotPassword(ctx context.Context, req v1req.ForgotPasswordRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/password/forgot", body: req}, nil)
+}
+
+// ResetPassword sets a new password with the token of the reset link
+func (c *Client) ResetPassword(ctx context.Context, req v1req.ResetPasswordRequest) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/password/reset", body: req}, nil)
+}
+
+// GetProfile returns the signed in user
+func (c *Client) GetProfile(ctx context.Context) (*v1resp.UserResponse, error) {
+	var resp v1resp.UserResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/user-profile", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SignOut revokes the access token and forgets the tokens of the session
+func (c *Client) SignOut(ctx context.Context) error {
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/sign-out", auth: true}, nil); err != nil {
+		return err
+	}
+	c.SetTokens(Tokens{})
+	return nil
+}
+
+// ChangePassword changes the password of the signed in user, the other sessions end and the client keeps
+// the new tokens of this one
+func (c *Client) ChangePassword(ctx context.Context, req v1req.ChangePasswordRequest) (*v1resp.SigninResponse, error) {
+	return c.signIn(ctx, request{method: http.MethodPost, path: "/change-password", body: req, auth: true})
+}
+
+// signIn makes a call answering with the tokens of a session and keeps them. Some calls answer without
+// tokens, e.g. when the organization does not allow the sign-in method, the result is nil then.
+func (c *Client) signIn(ctx context.Context, r request) (*v1resp.SigninResponse, error) {
+	var resp *v1resp.SigninResponse
+	if err := c.do(ctx, r, &resp); err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		c.SetTokens(Tokens{AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken})
+	}
+	return resp, nil
+}
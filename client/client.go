@@ -0,0 +1,373 @@
+// Package client is a Go client of the v1 REST API. It has a typed method for every /api/v1 endpoint,
+// decoding the data of the responses into the types of resources/response/v1:
+//
+//	api := client.New("https://auth.example.com", client.WithHTTPClient(httpClient))
+//	if _, err := api.SignIn(ctx, v1req.SignInRequest{Email: email, Password: password}); err != nil {
+//		return err
+//	}
+//	profile, err := api.GetProfile(ctx)
+//
+// The tokens of the sign-in calls are kept by the client and sent as a Bearer token. When a call is refused
+// with 401 the access token is refreshed once with the refresh token and the call is made again.
+// Idempotent calls are retried on network errors and on 429, 502, 503 and 504 answers.
+//
+// DPoP bound tokens and cookie sessions are made for browsers and are not supported, the service has to
+// accept bearer tokens (DPoP.AllowBearer).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRetries = 2
+	DefaultRetryWait  = 200 * time.Millisecond
+
+	maxRetryWait = 5 * time.Second
+	maxBodySize  = 10 << 20
+	apiPrefix    = "/api/v1"
+
+	captchaHeader   = "X-Captcha-Token"
+	requestIDHeader = "X-Request-Id"
+)
+
+// ErrNoRefreshToken is returned by RefreshToken when the client holds no refresh token
+var ErrNoRefreshToken = errors.New("client: no refresh token")
+
+// Tokens are the tokens of a session
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient makes the calls with hc instead of http.DefaultClient, for timeouts, proxies or TLS settings
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithTokens starts the client with the tokens of an existing session
+func WithTokens(tokens Tokens) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithTokenHook calls hook whenever the tokens change, after a sign-in, a refresh or a sign-out, so they
+// can be kept across restarts
+func WithTokenHook(hook func(Tokens)) Option {
+	return func(c *Client) {
+		c.hook = hook
+	}
+}
+
+// WithRetries sets how often an idempotent call is retried and the wait before the first retry, doubled for
+// every next one. Zero retries turns retrying off.
+func WithRetries(max int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = max
+		c.retryWait = wait
+	}
+}
+
+// WithClientCredentials sets the id and secret IntrospectToken sends, one of the clients of the
+// [Introspection] section of the service
+func WithClientCredentials(clientID, clientSecret string) Option {
+	return func(c *Client) {
+		c.clientID = clientID
+		c.clientSecret = clientSecret
+	}
+}
+
+// WithUserAgent sets the User-Agent of the calls, the service shows it in the sign-in activity
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+type contextKey int
+
+const (
+	captchaKey contextKey = iota
+	requestIDKey
+)
+
+// WithCaptcha sends the token of a solved challenge with the calls made with the returned context. Sign-up,
+// sign-in and the password reset ask for one as configured in the [Captcha] section of the service.
+func WithCaptcha(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, captchaKey, token)
+}
+
+// WithRequestID sends the id with the calls made with the returned context, the service logs and audits
+// them under it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// Client calls the v1 API of one service. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	http       *http.Client
+	maxRetries int
+	retryWait  time.Duration
+	userAgent  string
+	hook       func(Tokens)
+
+	clientID     string
+	clientSecret string
+
+	mu     sync.Mutex // guards tokens
+	tokens Tokens
+
+	refreshing sync.Mutex // one refresh at a time
+}
+
+// New is a client of the service at baseURL, e.g. https://auth.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		http:       http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		retryWait:  DefaultRetryWait,
+		userAgent:  "test-task-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokens returns the tokens of the current session
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+// SetTokens replaces the tokens of the session
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mu.Lock()
+	c.tokens = tokens
+	c.mu.Unlock()
+	if c.hook != nil {
+		c.hook(tokens)
+	}
+}
+
+func (c *Client) setAccessToken(accessToken string) {
+	c.mu.Lock()
+	c.tokens.AccessToken = accessToken
+	tokens := c.tokens
+	c.mu.Unlock()
+	if c.hook != nil {
+		c.hook(tokens)
+	}
+}
+
+// request is a call of the API, path is below /api/v1
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	auth   bool // sends the access token and refreshes it on 401
+	client bool // sends the client credentials instead, for the calls made by services
+}
+
+// do makes the call and decodes the data of the response into out, which may be nil
+func (c *Client) do(ctx context.Context, r request, out interface{}) error {
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return err
+		}
+	}
+
+	access := c.Tokens().AccessToken
+	err := c.call(ctx, r, body, access, out)
+
+	var apiErr *Error
+	if r.auth && errors.As(err, &apiErr) && apiErr.httpStatus == http.StatusUnauthorized && c.Tokens().RefreshToken != "" {
+		if refreshErr := c.refresh(ctx, access); refreshErr != nil {
+			return refreshErr
+		}
+		err = c.call(ctx, r, body, c.Tokens().AccessToken, out)
+	}
+	return err
+}
+
+// refresh renews the access token, unless a concurrent call already replaced the stale one
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+	if c.Tokens().AccessToken != stale {
+		return nil
+	}
+	_, err := c.RefreshToken(ctx)
+	return err
+}
+
+// call sends the request, retrying idempotent methods after transient failures
+func (c *Client) call(ctx context.Context, r request, body []byte, access string, out interface{}) error {
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, r, body, access, out)
+		if err == nil || attempt >= c.maxRetries || !idempotent(r.method) || !transient(ctx, err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r request, body []byte, access string, out interface{}) error {
+	target := c.baseURL + apiPrefix + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.auth && access != "" {
+		req.Header.Set("Authorization", "Bearer "+access)
+	}
+	if r.client {
+		req.SetBasicAuth(c.clientID, c.clientSecret)
+	}
+	if token, ok := ctx.Value(captchaKey).(string); ok && token != "" {
+		req.Header.Set(captchaHeader, token)
+	}
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok && requestID != "" {
+		req.Header.Set(requestIDHeader, requestID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	return decode(resp, data, out)
+}
+
+// envelope covers the three shapes of the answers: a success with meta and data, an error of a service
+// with res_code and message, and an error of a middleware with status and message
+type envelope struct {
+	Meta *struct {
+		ResCode int    `json:"res_code"`
+		Message string `json:"message"`
+	} `json:"meta"`
+	Data            json.RawMessage `json:"data"`
+	ResCode         int             `json:"res_code"`
+	Status          int             `json:"status"`
+	Message         string          `json:"message"`
+	Error           string          `json:"error"`
+	CaptchaRequired bool            `json:"captcha_required"`
+}
+
+func decode(resp *http.Response, data []byte, out interface{}) error {
+	var env envelope
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &env); err != nil && resp.StatusCode < http.StatusBadRequest {
+			return err
+		}
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp, resp.StatusCode, env)
+	}
+	// the services answer most of their errors with 200, the status is in res_code
+	if env.Meta == nil && env.ResCode >= http.StatusBadRequest {
+		return newError(resp, env.ResCode, env)
+	}
+
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// transient tells whether the failure may pass when the call is made again
+func transient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.httpStatus {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// http.Client.Do fails with a *url.Error when the service could not be reached or the connection broke
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// values encodes a request struct bound from the query string by its form tags, zero fields are left out
+func values(v interface{}) url.Values {
+	query := url.Values{}
+	rv := reflect.ValueOf(v)
+	for i := 0; i < rv.NumField(); i++ {
+		name := strings.Split(rv.Type().Field(i).Tag.Get("form"), ",")[0]
+		field := rv.Field(i)
+		if name == "" || name == "-" || field.IsZero() {
+			continue
+		}
+		switch value := field.Interface().(type) {
+		case time.Time:
+			query.Set(name, value.Format(time.RFC3339))
+		case string:
+			query.Set(name, value)
+		case int:
+			query.Set(name, strconv.Itoa(value))
+		}
+	}
+	return query
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1req "test-task/resources/request/v1"
+)
+
+// testService stands in for the service: the profile needs the current access token, a refresh issues the
+// next one, the introspection needs the client credentials and the sign-in code is refused with 503
+type testService struct {
+	mu        sync.Mutex
+	access    string
+	issued    int
+	refreshes int32
+	profiles  int32
+	otpSends  int32
+	// unavailable answers the first calls of the profile with 503
+	unavailable int32
+}
+
+func (s *testService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case apiPrefix + "/refresh-token":
+		atomic.AddInt32(&s.refreshes, 1)
+		// slow enough for the concurrent calls to be refused with the stale token meanwhile
+		time.Sleep(50 * time.Millisecond)
+		s.mu.Lock()
+		s.issued++
+		s.access = "access-" + strconv.Itoa(s.issued)
+		access := s.access
+		s.mu.Unlock()
+		writeSuccess(w, map[string]string{"access_token": access})
+	case apiPrefix + "/user-profile":
+		calls := atomic.AddInt32(&s.profiles, 1)
+		if calls <= atomic.LoadInt32(&s.unavailable) {
+			writeError(w, http.StatusServiceUnavailable)
+			return
+		}
+		s.mu.Lock()
+		access := s.access
+		s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+access {
+			writeError(w, http.StatusUnauthorized)
+			return
+		}
+		writeSuccess(w, map[string]string{"email": "user@example.com"})
+	case apiPrefix + "/introspect":
+		if id, secret, ok := r.BasicAuth(); !ok || id != "orders" || secret != "orders-secret" {
+			writeError(w, http.StatusUnauthorized)
+			return
+		}
+		writeSuccess(w, map[string]interface{}{"active": true})
+	case apiPrefix + "/sign-in/otp/send":
+		atomic.AddInt32(&s.otpSends, 1)
+		writeError(w, http.StatusServiceUnavailable)
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func writeSuccess(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"meta": map[string]interface{}{"res_code": http.StatusOK, "message": "success"},
+		"data": data,
+	})
+}
+
+func writeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "message": http.StatusText(status)})
+}
+
+func newTestClient(t *testing.T, service *testService, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+	opts = append([]Option{WithTokens(Tokens{AccessToken: "expired", RefreshToken: "refresh"}), WithRetries(2, time.Millisecond)}, opts...)
+	return New(server.URL, opts...)
+}
+
+func TestRefreshOn401(t *testing.T) {
+	service := &testService{access: "access-0"}
+	var hooked []Tokens
+	c := newTestClient(t, service, WithTokenHook(func(tokens Tokens) { hooked = append(hooked, tokens) }))
+
+	profile, err := c.GetProfile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Email != "user@example.com" {
+		t.Fatalf("profile email %q", profile.Email)
+	}
+	if got := atomic.LoadInt32(&service.refreshes); got != 1 {
+		t.Fatalf("%d refreshes, want 1", got)
+	}
+	if got := atomic.LoadInt32(&service.profiles); got != 2 {
+		t.Fatalf("profile asked %d times, want 2", got)
+	}
+	want := Tokens{AccessToken: "access-1", RefreshToken: "refresh"}
+	if c.Tokens() != want || len(hooked) != 1 || hooked[0] != want {
+		t.Fatalf("tokens %+v, hooked %+v, want %+v", c.Tokens(), hooked, want)
+	}
+}
+
+func TestRefreshWithoutRefreshToken(t *testing.T) {
+	service := &testService{access: "access-0"}
+	c := newTestClient(t, service, WithTokens(Tokens{AccessToken: "expired"}))
+
+	if _, err := c.GetProfile(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("error %v, want %v", err, ErrUnauthorized)
+	}
+	if got := atomic.LoadInt32(&service.refreshes); got != 0 {
+		t.Fatalf("%d refreshes without a refresh token, want 0", got)
+	}
+}
+
+func TestConcurrent401RefreshOnce(t *testing.T) {
+	service := &testService{access: "access-0"}
+	c := newTestClient(t, service)
+
+	const calls = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetProfile(context.Background())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&service.refreshes); got != 1 {
+		t.Fatalf("%d refreshes for %d concurrent calls, want 1", got, calls)
+	}
+	if got := c.Tokens().AccessToken; got != "access-1" {
+		t.Fatalf("access token %q, want access-1", got)
+	}
+}
+
+func TestPostNotRetried(t *testing.T) {
+	service := &testService{}
+	c := newTestClient(t, service)
+
+	err := c.SendSignInOTP(context.Background(), v1req.OTPRequest{})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("error %v, want %v", err, ErrUnavailable)
+	}
+	if got := atomic.LoadInt32(&service.otpSends); got != 1 {
+		t.Fatalf("POST sent %d times, want 1", got)
+	}
+}
+
+func TestGetRetriedOn503(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable int32
+		retries     int
+		wantCalls   int32
+		wantErr     error
+	}{
+		{"passes on the last retry", 2, 2, 3, nil},
+		{"gives up after the limit", 5, 2, 3, ErrUnavailable},
+		{"retries turned off", 5, 0, 1, ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &testService{access: "access-0", unavailable: tt.unavailable}
+			c := newTestClient(t, service, WithTokens(Tokens{AccessToken: "access-0"}), WithRetries(tt.retries, time.Millisecond))
+
+			_, err := c.GetProfile(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&service.profiles); got != tt.wantCalls {
+				t.Fatalf("GET sent %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIntrospectClientCredentials(t *testing.T) {
+	service := &testService{}
+	req := v1req.IntrospectTokenRequest{Token: "token"}
+
+	c := newTestClient(t, service, WithClientCredentials("orders", "orders-secret"))
+	resp, err := c.IntrospectToken(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Active {
+		t.Fatal("introspection answered inactive")
+	}
+
+	c = newTestClient(t, service)
+	if _, err = c.IntrospectToken(context.Background(), req); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("without client credentials: error %v, want %v", err, ErrUnauthorized)
+	}
+	if got := atomic.LoadInt32(&service.refreshes); got != 0 {
+		t.Fatalf("%d refreshes for a refused introspection, want 0", got)
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// The kinds of errors, match them with errors.Is and use errors.As with *Error for the details
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrPrecondition    = errors.New("precondition failed")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrUnavailable     = errors.New("service unavailable")
+	ErrInternal        = errors.New("internal server error")
+	ErrUnexpectedError = errors.New("unexpected error")
+)
+
+// Error is an error answered by the service
+type Error struct {
+	// StatusCode is the status of the error, the HTTP status or the res_code of the errors answered with 200
+	StatusCode int
+	Message    string
+	// Code is the error of the DPoP errors, invalid_dpop_proof or use_dpop_nonce
+	Code string
+	// CaptchaRequired tells that the call has to be made again with WithCaptcha
+	CaptchaRequired bool
+	// RequestID is the id the service logged the call under
+	RequestID string
+
+	httpStatus int
+}
+
+func newError(resp *http.Response, status int, env envelope) *Error {
+	message := env.Message
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &Error{
+		StatusCode:      status,
+		Message:         message,
+		Code:            env.Error,
+		CaptchaRequired: env.CaptchaRequired,
+		RequestID:       resp.Header.Get(requestIDHeader),
+		httpStatus:      resp.StatusCode,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: %d %s", e.StatusCode, e.Message)
+}
+
+// Unwrap is the kind of the error
+func (e *Error) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusPreconditionFailed:
+		return ErrPrecondition
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return ErrUnavailable
+	case http.StatusInternalServerError:
+		return ErrInternal
+	}
+	return ErrUnexpectedError
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateOrganization creates an organization owned by the user
+func (c *Client) CreateOrganization(ctx context.Context, req v1req.CreateOrganizationRequest) (*v1resp.OrganizationResponse, error) {
+	var resp v1resp.OrganizationResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/orgs", body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetOrganizations lists the organizations of the user
+func (c *Client) GetOrganizations(ctx context.Context) ([]v1resp.OrganizationResponse, error) {
+	var resp []v1resp.OrganizationResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/orgs", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SwitchOrganization re-issues the tokens for another organization of the user, the client keeps them
+func (c *Client) SwitchOrganization(ctx context.Context, req v1req.SwitchOrganizationRequest) (*v1resp.SigninResponse, error) {
+	return c.signIn(ctx, request{method: http.MethodPost, path: "/orgs/switch", body: req, auth: true})
+}
+
+// GetCurrentOrganization returns the organization of the access token
+func (c *Client) GetCurrentOrganization(ctx context.Context) (*v1resp.OrganizationResponse, error) {
+	var resp v1resp.OrganizationResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/orgs/current", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetMembers lists the members of the current organization
+func (c *Client) GetMembers(ctx context.Context) ([]v1resp.OrganizationMemberResponse, error) {
+	var resp []v1resp.OrganizationMemberResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/orgs/current/members", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateSettings overrides the sign-in and token settings for the current organization
+func (c *Client) UpdateSettings(ctx context.Context, req v1req.OrganizationSettingsRequest) (*v1resp.OrganizationResponse, error) {
+	var resp v1resp.OrganizationResponse
+	if err := c.do(ctx, request{method: http.MethodPatch, path: "/orgs/current/settings", body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateMemberRole changes the role of a member of the current organization
+func (c *Client) UpdateMemberRole(ctx context.Context, userID uuid.UUID, req v1req.OrganizationMemberRequest) error {
+	return c.do(ctx, request{method: http.MethodPatch, path: "/orgs/current/members/" + userID.String(), body: req, auth: true}, nil)
+}
+
+// RemoveOrganizationMember removes a member from the current organization
+func (c *Client) RemoveOrganizationMember(ctx context.Context, userID uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/orgs/current/members/" + userID.String(), auth: true}, nil)
+}
+
+// CreateInvitation invites an email address to the current organization
+func (c *Client) CreateInvitation(ctx context.Context, req v1req.InvitationRequest) (*v1resp.InvitationResponse, error) {
+	var resp v1resp.InvitationResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/orgs/current/invitations", body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetInvitations lists the pending invitations of the current organization
+func (c *Client) GetInvitations(ctx context.Context) ([]v1resp.InvitationResponse, error) {
+	var resp []v1resp.InvitationResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/orgs/current/invitations", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ResendInvitation mails a new link of a pending invitation
+func (c *Client) ResendInvitation(ctx context.Context, invitationID uuid.UUID) (*v1resp.InvitationResponse, error) {
+	var resp v1resp.InvitationResponse
+	path := "/orgs/current/invitations/" + invitationID.String() + "/resend"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeInvitation cancels a pending invitation
+func (c *Client) RevokeInvitation(ctx context.Context, invitationID uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/orgs/current/invitations/" + invitationID.String(), auth: true}, nil)
+}
+
+// PreviewInvitation shows the invitation behind an acceptance link, whether to sign in or sign up to accept it
+func (c *Client) PreviewInvitation(ctx context.Context, req v1req.InvitationTokenRequest) (*v1resp.InvitationPreviewResponse, error) {
+	var resp v1resp.InvitationPreviewResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/invitations/preview", query: values(req)}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SignUpWithInvitation creates the account of an invitee, joins the organization and keeps the tokens of
+// the new session
+func (c *Client) SignUpWithInvitation(ctx context.Context, req v1req.InvitationSignUpRequest) (*v1resp.SigninResponse, error) {
+	return c.signIn(ctx, request{method: http.MethodPost, path: "/invitations/sign-up", body: req})
+}
+
+// AcceptInvitation joins the organization of an invitation with the signed in account. The client keeps the
+// tokens issued for the organization, nil is returned when it does not allow the sign-in method of the session.
+func (c *Client) AcceptInvitation(ctx context.Context, req v1req.InvitationTokenRequest) (*v1resp.SigninResponse, error) {
+	return c.signIn(ctx, request{method: http.MethodPost, path: "/invitations/accept", body: req, auth: true})
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateWebhook subscribes an endpoint to events. The secret signing the deliveries is only returned here.
+func (c *Client) CreateWebhook(ctx context.Context, req v1req.CreateWebhookRequest) (*v1resp.WebhookResponse, error) {
+	var resp v1resp.WebhookResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/webhooks", body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetWebhooks lists the webhook endpoints
+func (c *Client) GetWebhooks(ctx context.Context) ([]v1resp.WebhookResponse, error) {
+	var resp []v1resp.WebhookResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/webhooks", auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetWebhook returns a webhook endpoint
+func (c *Client) GetWebhook(ctx context.Context, webhookID uuid.UUID) (*v1resp.WebhookResponse, error) {
+	var resp v1resp.WebhookResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/webhooks/" + webhookID.String(), auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateWebhook changes an endpoint, enables or disables it and rotates its secret
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID uuid.UUID, req v1req.UpdateWebhookRequest) (*v1resp.WebhookResponse, error) {
+	var resp v1resp.WebhookResponse
+	if err := c.do(ctx, request{method: http.MethodPatch, path: "/admin/webhooks/" + webhookID.String(), body: req, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteWebhook removes an endpoint with its delivery logs
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/admin/webhooks/" + webhookID.String(), auth: true}, nil)
+}
+
+// GetWebhookDeliveries returns the delivery log of an endpoint
+func (c *Client) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, req v1req.WebhookDeliveriesRequest) (*v1resp.WebhookDeliveryListResponse, error) {
+	var resp v1resp.WebhookDeliveryListResponse
+	path := "/admin/webhooks/" + webhookID.String() + "/deliveries"
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, query: values(req), auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RedeliverWebhook sends a past delivery again
+func (c *Client) RedeliverWebhook(ctx context.Context, webhookID, deliveryID uuid.UUID) (*v1resp.WebhookDeliveryResponse, error) {
+	var resp v1resp.WebhookDeliveryResponse
+	path := "/admin/webhooks/" + webhookID.String() + "/deliveries/" + deliveryID.String() + "/redeliver"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,75 @@
+// Command openapi writes the OpenAPI document of the REST API, built from the routes of the router and the
+// request and response structs they use, to resources/openapi/openapi.json. Run it from the repository root
+// after changing a route or one of those structs:
+//
+//	go run ./cmd/openapi
+//
+// With -check it writes nothing and fails when the committed document differs from the code, the Docker
+// build runs it that way so a stale document can not be shipped.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"test-task/routers/api"
+	v1Service "test-task/services/v1"
+	"test-task/shared/config"
+	"test-task/shared/log"
+	"test-task/shared/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	configFile := flag.String("c", "", "configuration file without extension. For config.toml then put \" -c config-development\"")
+	out := flag.String("o", "resources/openapi/openapi.json", "file the document is written to, or compared with")
+	check := flag.Bool("check", false, "fail when the file differs from the code instead of writing it")
+	flag.Parse()
+
+	pwd, err := os.Getwd()
+	if err != nil {
+		os.Exit(1)
+	}
+	var cf config.IConfig
+	if *configFile == "" {
+		cf = config.NewRealtimeConfig("config", pwd)
+	} else {
+		cf = config.NewConfig(*configFile)
+	}
+	log.Init("openapi", cf.AppVersion(), os.TempDir(), cf.Info().Level, cf.Info().MaxAge)
+
+	// the routes are only listed, nothing is served and no connection is made
+	gin.SetMode(gin.ReleaseMode)
+	rt := api.NewRouter(cf, scheduler.NewScheduler(cf, v1Service.NewJobRunRecorder()))
+	rt.Setup()
+
+	doc, err := rt.OpenAPI()
+	if err != nil {
+		fail(err.Error())
+	}
+	spec, err := doc.JSON()
+	if err != nil {
+		fail(err.Error())
+	}
+
+	if *check {
+		committed, err := os.ReadFile(*out)
+		if err != nil {
+			fail(err.Error())
+		}
+		if !bytes.Equal(committed, spec) {
+			fail(fmt.Sprintf("%s is out of date, run go run ./cmd/openapi and commit the result", *out))
+		}
+		return
+	}
+	if err = os.WriteFile(*out, spec, 0644); err != nil {
+		fail(err.Error())
+	}
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, "openapi: "+message)
+	os.Exit(1)
+}
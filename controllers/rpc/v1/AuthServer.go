@@ -0,0 +1,189 @@
+package v1RPC
+
+import (
+	"context"
+	"time"
+
+	authv1 "test-task/resources/proto/auth/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	v1Service "test-task/services/v1"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	valid "test-task/validator"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AuthServer serves the auth.v1.AuthService of resources/proto/auth/v1 with the services of the HTTP API
+type AuthServer struct {
+	authv1.UnimplementedAuthServiceServer
+	AuthService       v1Service.IAuthService
+	APIValidator      valid.IAPIValidatorService
+	MiddlewareService middleware.IMiddleware
+}
+
+// SignUp is made for registering a user
+func (as *AuthServer) SignUp(ctx context.Context, in *authv1.SignUpRequest) (*authv1.SignUpResponse, error) {
+	log.GetLog().Info("INFO : ", "Auth RPC Called(SignUp).")
+	req := v1req.SignUpRequest{
+		FirstName: in.GetFirstName(),
+		LastName:  in.GetLastName(),
+		Email:     in.GetEmail(),
+		Password:  in.GetPassword(),
+		Phone:     in.GetPhone(),
+	}
+
+	// Struct field validation
+	if resp, ok := as.APIValidator.ValidateStruct(req, "SignUpRequest"); !ok {
+		return nil, status.Error(codes.InvalidArgument, resp)
+	}
+
+	//call service
+	resp := as.AuthService.SignUpUser(req, middleware.GetRPCRequestInfo(ctx))
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	return &authv1.SignUpResponse{Message: responseMessage(resp)}, nil
+}
+
+// SignIn is made for starting a session with the password
+func (as *AuthServer) SignIn(ctx context.Context, in *authv1.SignInRequest) (*authv1.SignInResponse, error) {
+	log.GetLog().Info("INFO : ", "Auth RPC Called(SignIn).")
+	req := v1req.SignInRequest{Email: in.GetEmail(), Password: in.GetPassword(), OrgID: in.GetOrgId()}
+
+	// Struct field validation
+	if resp, ok := as.APIValidator.ValidateStruct(req, "SignInRequest"); !ok {
+		return nil, status.Error(codes.InvalidArgument, resp)
+	}
+
+	//call service
+	resp := as.AuthService.SignInUser(req, middleware.GetRPCRequestInfo(ctx))
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	data, _ := resp["data"].(v1resp.SigninResponse)
+	out := &authv1.SignInResponse{AccessToken: data.AccessToken, RefreshToken: data.RefreshToken}
+	if data.OrgId != nil {
+		out.OrgId = data.OrgId.String()
+	}
+	return out, nil
+}
+
+// RefreshToken is made for renewing the access token of a session
+func (as *AuthServer) RefreshToken(ctx context.Context, in *authv1.RefreshTokenRequest) (*authv1.RefreshTokenResponse, error) {
+	log.GetLog().Info("INFO : ", "Auth RPC Called(RefreshToken).")
+	if in.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, msg.InvalidRequest)
+	}
+
+	//call service
+	resp := as.AuthService.RefreshToken(v1req.RefreshTokenRequest{RefreshToken: in.GetRefreshToken()}, middleware.GetRPCRequestInfo(ctx))
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	data, _ := resp["data"].(v1resp.RefreshTokenResponse)
+	return &authv1.RefreshTokenResponse{AccessToken: data.AccessToken}, nil
+}
+
+// GetProfile is made for fetching the user of the access token
+func (as *AuthServer) GetProfile(ctx context.Context, in *authv1.GetProfileRequest) (*authv1.GetProfileResponse, error) {
+	log.GetLog().Info("INFO : ", "Auth RPC Called(GetProfile).")
+	access := middleware.GetRPCAccessToken(ctx)
+	if access == nil {
+		return nil, status.Error(codes.Unauthenticated, msg.SomethingWrong)
+	}
+
+	//call service
+	resp := as.AuthService.GetUserDetails(access.User.Id)
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	data, _ := resp["data"].(v1resp.UserResponse)
+	user := &authv1.User{
+		Id:        data.Id.String(),
+		FirstName: data.FirstName,
+		LastName:  data.LastName,
+		Email:     data.Email,
+		Status:    data.Status,
+		CreatedAt: timestamppb.New(data.CreatedAt),
+	}
+	if data.Phone != nil {
+		user.Phone = *data.Phone
+	}
+	return &authv1.GetProfileResponse{User: user}, nil
+}
+
+// SignOut is made for revoking the access token
+func (as *AuthServer) SignOut(ctx context.Context, in *authv1.SignOutRequest) (*authv1.SignOutResponse, error) {
+	log.GetLog().Info("INFO : ", "Auth RPC Called(SignOut).")
+	access := middleware.GetRPCAccessToken(ctx)
+	if access == nil || access.TokenID == "" {
+		return nil, status.Error(codes.Unauthenticated, msg.SomethingWrong)
+	}
+
+	//call service
+	resp := as.AuthService.SignOutUser(ctx, access.User.Id, access.Expiry, access.TokenID, middleware.GetRPCRequestInfo(ctx))
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	return &authv1.SignOutResponse{}, nil
+}
+
+// ValidateToken is made for services checking the access tokens sent to them, the clients of
+// Introspection.Clients checked by IntrospectionClientInterceptor
+func (as *AuthServer) ValidateToken(ctx context.Context, in *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+	log.GetLog().Info("INFO : ", "Auth RPC Called(ValidateToken).")
+	if in.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, msg.InvalidRequest)
+	}
+
+	access, authErr := as.MiddlewareService.Authenticate(ctx, in.GetAccessToken())
+	if authErr != nil {
+		// the token could not be checked, that is not an answer about the token
+		if code := middleware.RPCCode(authErr.Status); code == codes.Unavailable || code == codes.Internal {
+			return nil, status.Error(code, authErr.Message)
+		}
+		return &authv1.ValidateTokenResponse{Active: false, Reason: authErr.Message}, nil
+	}
+
+	user := access.User
+	tokenID, _ := access.Claims["jti"].(string)
+	out := &authv1.ValidateTokenResponse{
+		Active:     true,
+		UserId:     user.Id.String(),
+		Email:      user.Email,
+		Role:       user.Role,
+		OrgRole:    user.OrgRole,
+		AuthMethod: user.AuthMethod,
+		TokenId:    tokenID,
+		ExpiresAt:  timestamppb.New(time.Unix(int64(access.Expiry), 0)),
+		DpopJkt:    access.Jkt,
+	}
+	if user.OrgId != nil {
+		out.OrgId = user.OrgId.String()
+	}
+	if user.Actor != nil {
+		out.ActorId = user.Actor.Id.String()
+	}
+	return out, nil
+}
+
+// responseError turns an error response of a service into the status of the call, nil for a success
+func responseError(resp map[string]interface{}) error {
+	code, ok := resp["res_code"].(int)
+	if !ok {
+		return nil
+	}
+	message, _ := resp["message"].(string)
+	return status.Error(middleware.RPCCode(code), message)
+}
+
+func responseMessage(resp map[string]interface{}) string {
+	meta, _ := resp["meta"].(map[string]interface{})
+	message, _ := meta["message"].(string)
+	return message
+}
@@ -0,0 +1,17 @@
+package v1RPC
+
+import (
+	v1Service "test-task/services/v1"
+	"test-task/shared/utils/middleware"
+	validator "test-task/validator"
+)
+
+func AuthServerController(validatorService validator.IAPIValidatorService, authService v1Service.IAuthService, middlwareService middleware.IMiddleware) *AuthServer {
+	authServer := AuthServer{
+		AuthService:       authService,
+		APIValidator:      validatorService,
+		MiddlewareService: middlwareService,
+	}
+
+	return &authServer
+}
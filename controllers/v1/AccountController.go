@@ -0,0 +1,305 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"html/template"
+	"net/http"
+	"test-task/resources/pages"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type AccountCtl struct {
+	AccountService    v1Service.IAccountService
+	PermissionService v1Service.IPermissionService
+	APIValidator      valid.IAPIValidatorService
+}
+
+// GetActivity is made for listing the recent sign-ins of the user
+// @router /api/v1/account/activity [get]
+func (ac *AccountCtl) GetActivity(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(GetActivity).")
+	var req v1req.ActivityRequest
+
+	userData, err := middleware.GetUserDataFromToken(c)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.SomethingWrong))
+		return
+	}
+
+	//decode the query string into struct and failed if any error occurs
+	if err := c.ShouldBindQuery(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "ActivityRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AccountService.GetActivity(userData.Id, req)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ConfirmRevokeSessions is made for the "this wasn't me" link of the new device alert. The page only asks,
+// mail scanners opening the link do not use it up.
+// @router /api/v1/account/revoke-sessions [get]
+func (ac *AccountCtl) ConfirmRevokeSessions(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(ConfirmRevokeSessions).")
+	confirmActionPage(c, revokeSessionsPage)
+}
+
+// RevokeSessions is made for the confirmation page of the "this wasn't me" link
+// @router /api/v1/account/revoke-sessions [post]
+func (ac *AccountCtl) RevokeSessions(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(RevokeSessions).")
+	var req v1req.RevokeSessionsRequest
+
+	//decode the JSON body, or the form of the confirmation page, into struct and failed if any error occurs
+	if err := c.ShouldBind(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "RevokeSessionsRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AccountService.RevokeSessions(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	// the form of the confirmation page gets a page back
+	if c.ContentType() == binding.MIMEPOSTForm {
+		renderActionPage(c, statusCode, actionPage{Title: revokeSessionsPage.Title, Text: responseMessage(resp)})
+		return
+	}
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetPermissions is made for listing the roles and permissions of the user, group-derived ones included
+// @router /api/v1/account/permissions [get]
+func (ac *AccountCtl) GetPermissions(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(GetPermissions).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := ac.PermissionService.GetPermissions(c.Request.Context(), userData.Id)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ConfirmVerifyEmail is made for the link of the email verification mail, the page asks before the
+// account is activated
+// @router /api/v1/account/verify-email [get]
+func (ac *AccountCtl) ConfirmVerifyEmail(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(ConfirmVerifyEmail).")
+	confirmActionPage(c, verifyEmailPage)
+}
+
+// VerifyEmail is made for the confirmation page of the email verification link
+// @router /api/v1/account/verify-email [post]
+func (ac *AccountCtl) VerifyEmail(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(VerifyEmail).")
+	var req v1req.VerifyEmailRequest
+
+	//decode the JSON body, or the form of the confirmation page, into struct and failed if any error occurs
+	if err := c.ShouldBind(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "VerifyEmailRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AccountService.VerifyEmail(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	// the form of the confirmation page gets a page back
+	if c.ContentType() == binding.MIMEPOSTForm {
+		renderActionPage(c, statusCode, actionPage{Title: verifyEmailPage.Title, Text: responseMessage(resp)})
+		return
+	}
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ResendVerification is made for mailing a new verification link to a pending account
+// @router /api/v1/account/verify-email/resend [post]
+func (ac *AccountCtl) ResendVerification(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(ResendVerification).")
+	var req v1req.ResendVerificationRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "ResendVerificationRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AccountService.ResendVerification(req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// AddPhone is made for texting a verification code to a new phone number of the user
+// @router /api/v1/account/phone [post]
+func (ac *AccountCtl) AddPhone(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(AddPhone).")
+	var req v1req.PhoneRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "PhoneRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AccountService.AddPhone(c.Request.Context(), userData.Id, req)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// VerifyPhone is made for storing a phone number with the code texted to it
+// @router /api/v1/account/phone/verify [post]
+func (ac *AccountCtl) VerifyPhone(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Account Controller Called(VerifyPhone).")
+	var req v1req.VerifyPhoneRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "VerifyPhoneRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AccountService.VerifyPhone(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// actionPage is the data of pages.Action
+type actionPage struct {
+	Title  string
+	Text   string
+	Action string
+	Token  string
+	Button string
+}
+
+var actionTemplate = template.Must(template.New("action").Parse(pages.Action))
+
+var (
+	revokeSessionsPage = actionPage{
+		Title:  "Sign out everywhere",
+		Text:   "Every session of your account ends, on this device too. Sign in again and change your password afterwards.",
+		Action: "revoke-sessions",
+		Button: "Sign out all sessions",
+	}
+	verifyEmailPage = actionPage{
+		Title:  "Verify your email address",
+		Text:   "Confirm the email address to activate your account.",
+		Action: "verify-email",
+		Button: "Verify email address",
+	}
+)
+
+// confirmActionPage answers a mail link with the page asking to confirm, it changes nothing
+func confirmActionPage(c *gin.Context, page actionPage) {
+	page.Token = c.Query("token")
+	if page.Token == "" {
+		renderActionPage(c, http.StatusBadRequest, actionPage{Title: page.Title, Text: msg.InvalidActionToken})
+		return
+	}
+	renderActionPage(c, http.StatusOK, page)
+}
+
+func renderActionPage(c *gin.Context, status int, page actionPage) {
+	// the token is in the page, it is not cached or sent on
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := actionTemplate.Execute(c.Writer, page); err != nil {
+		log.GetLog().Error("ERROR : ", "Action page not rendered: %s", err.Error())
+	}
+}
+
+// responseMessage is the message of a service response, success or error
+func responseMessage(resp map[string]interface{}) string {
+	if meta, ok := resp["meta"].(map[string]interface{}); ok {
+		resp = meta
+	}
+	message, _ := resp["message"].(string)
+	return message
+}
@@ -0,0 +1,140 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type AdminCtl struct {
+	AdminService  v1Service.IAdminService
+	StatusService v1Service.IUserStatusService
+	APIValidator  valid.IAPIValidatorService
+}
+
+// Impersonate is made for issuing a short lived token to act as the user
+// @router /api/v1/admin/users/:id/impersonate [post]
+func (ac *AdminCtl) Impersonate(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Admin Controller Called(Impersonate).")
+	var req v1req.ImpersonateRequest
+
+	userData, err := middleware.GetUserDataFromToken(c)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.SomethingWrong))
+		return
+	}
+
+	targetID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "ImpersonateRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AdminService.ImpersonateUser(userData, targetID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ChangeUserStatus is made for suspending, locking, reactivating or deleting a user
+// @router /api/v1/admin/users/:id/status [patch]
+func (ac *AdminCtl) ChangeUserStatus(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Admin Controller Called(ChangeUserStatus).")
+	var req v1req.UserStatusRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	targetID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "UserStatusRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.StatusService.ChangeStatus(c.Request.Context(), userData, targetID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// RestoreUser is made for bringing back a deleted user within the restore window
+// @router /api/v1/admin/users/:id/restore [post]
+func (ac *AdminCtl) RestoreUser(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Admin Controller Called(RestoreUser).")
+	var req v1req.RestoreUserRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	targetID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "RestoreUserRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.StatusService.RestoreUser(c.Request.Context(), userData, targetID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
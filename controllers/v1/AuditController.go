@@ -0,0 +1,68 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditCtl struct {
+	AuditService v1Service.IAuditService
+	APIValidator valid.IAPIValidatorService
+}
+
+// SearchEvents is made for searching the security audit log
+// @router /api/v1/admin/audit-events [get]
+func (ac *AuditCtl) SearchEvents(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Audit Controller Called(SearchEvents).")
+	var req v1req.AuditSearchRequest
+
+	userData, err := middleware.GetUserDataFromToken(c)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.SomethingWrong))
+		return
+	}
+
+	//decode the query string into struct and failed if any error occurs
+	if err := c.ShouldBindQuery(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "AuditSearchRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AuditService.SearchEvents(userData.Id, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// VerifyChain is made for checking that the audit log has not been tampered with
+// @router /api/v1/admin/audit-events/verify [get]
+func (ac *AuditCtl) VerifyChain(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Audit Controller Called(VerifyChain).")
+
+	//call service
+	resp := ac.AuditService.VerifyChain()
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
@@ -2,8 +2,9 @@ package v1Ctl
 
 import (
 	"context"
-	"strings"
+	"errors"
 	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
 	v1Service "test-task/services/v1"
 	u "test-task/shared/common"
 	"test-task/shared/log"
@@ -12,6 +13,7 @@ import (
 
 	"net/http"
 	valid "test-task/validator"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -43,7 +45,7 @@ func (ac *AuthCtl) SignUp(c *gin.Context) {
 	}
 
 	//call service
-	resp := ac.AuthService.SignUpUser(req)
+	resp := ac.AuthService.SignUpUser(req, middleware.GetRequestInfo(c))
 	statusCode := u.GetHTTPStatusCode(resp["res_code"])
 
 	//return response using api helper
@@ -71,7 +73,7 @@ func (ac *AuthCtl) SignIn(c *gin.Context) {
 	}
 
 	//call service
-	resp := ac.AuthService.SignInUser(req)
+	resp := ac.AuthService.SignInUser(req, middleware.GetRequestInfo(c))
 	statusCode := u.GetHTTPStatusCode(resp["res_code"])
 
 	//return response using api helper
@@ -118,16 +120,18 @@ func (ac *AuthCtl) SignOut(c *gin.Context) {
 		expiryTime = exp.(int)
 	}
 
-	//getting the token from header
-	bearerToken := c.Request.Header.Get("Authorization")
-	token := strings.Split(bearerToken, "Bearer ")
-	if len(token) < 2 {
+	//getting the id of the token set by AuthHandler
+	tokenID := middleware.GetTokenID(c)
+	if tokenID == "" {
 		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.SomethingWrong))
 		return
 	}
 
 	//call service
-	resp := ac.AuthService.SignOutUser(context.Background(), userData.Id, expiryTime, token[1])
+	resp := ac.AuthService.SignOutUser(context.Background(), userData.Id, expiryTime, tokenID, middleware.GetRequestInfo(c))
+	if resp["res_code"] == nil {
+		middleware.EndSession(c)
+	}
 
 	//return response using api helper
 	u.Respond(c.Writer, http.StatusNoContent, resp)
@@ -139,8 +143,20 @@ func (ac *AuthCtl) RefreshToken(c *gin.Context) {
 	// Declare the request struct to bind JSON body
 	var req v1req.RefreshTokenRequest
 
-	// Decode the request body into the struct and fail if any error occurs
-	if err := c.BindJSON(&req); err != nil {
+	if middleware.IsCookieSession(c) {
+		// browser sessions keep the refresh token in an HttpOnly cookie, it is never read from the body
+		refreshToken, err := middleware.SessionRefreshToken(c)
+		if errors.Is(err, middleware.ErrInvalidCSRFToken) {
+			u.Respond(c.Writer, http.StatusForbidden, u.ResponseErrorWithCode(http.StatusForbidden, msg.InvalidCSRFToken))
+			return
+		}
+		if err != nil {
+			u.Respond(c.Writer, http.StatusUnauthorized, u.ResponseErrorWithCode(http.StatusUnauthorized, msg.InvalidRefreshToken))
+			return
+		}
+		req.RefreshToken = refreshToken
+	} else if err := c.BindJSON(&req); err != nil {
+		// Decode the request body into the struct and fail if any error occurs
 		log.GetLog().Info("ERROR : ", err.Error())
 		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
 		return
@@ -154,9 +170,210 @@ func (ac *AuthCtl) RefreshToken(c *gin.Context) {
 	}
 
 	// Call the service to handle the token refresh logic
-	resp := ac.AuthService.RefreshToken(req)
+	resp := ac.AuthService.RefreshToken(req, middleware.GetRequestInfo(c))
 	statusCode := u.GetHTTPStatusCode(resp["res_code"])
 
 	// Return the response using api helper
 	u.Respond(c.Writer, statusCode, resp)
 }
+
+// ChangePassword is made for changing the password of the signed in user
+// @router /api/v1/change-password [post]
+func (ac *AuthCtl) ChangePassword(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Auth Controller Called(ChangePassword).")
+	var req v1req.ChangePasswordRequest
+
+	userData, err := middleware.GetUserDataFromToken(c)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.SomethingWrong))
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "ChangePasswordRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AuthService.ChangePassword(c.Request.Context(), userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ForgotPassword is made for requesting a password reset link
+// @router /api/v1/password/forgot [post]
+func (ac *AuthCtl) ForgotPassword(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Auth Controller Called(ForgotPassword).")
+	var req v1req.ForgotPasswordRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "ForgotPasswordRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AuthService.ForgotPassword(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ResetPassword is made for setting a new password with a reset token
+// @router /api/v1/password/reset [post]
+func (ac *AuthCtl) ResetPassword(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Auth Controller Called(ResetPassword).")
+	var req v1req.ResetPasswordRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "ResetPasswordRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AuthService.ResetPassword(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// SendSignInOTP is made for texting a sign-in code to a verified phone number
+// @router /api/v1/sign-in/otp/send [post]
+func (ac *AuthCtl) SendSignInOTP(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Auth Controller Called(SendSignInOTP).")
+	var req v1req.OTPRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "OTPRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AuthService.SendSignInOTP(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// SignInWithOTP is made for signing in with a phone number and the texted code
+// @router /api/v1/sign-in/otp [post]
+func (ac *AuthCtl) SignInWithOTP(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Auth Controller Called(SignInWithOTP).")
+	var req v1req.OTPSignInRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "OTPSignInRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ac.AuthService.SignInWithOTP(c.Request.Context(), req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// IntrospectToken is made for services checking the access tokens sent to them, the counterpart of the
+// ValidateToken RPC. IntrospectionClientHandler lets only the clients of Introspection.Clients through.
+// A token that is not accepted is answered as inactive with the reason.
+// @router /api/v1/introspect [post]
+func (ac *AuthCtl) IntrospectToken(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Auth Controller Called(IntrospectToken).")
+	var req v1req.IntrospectTokenRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ac.APIValidator.ValidateStruct(req, "IntrospectTokenRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	access, authErr := ac.MiddlewareService.Authenticate(c.Request.Context(), req.Token)
+	if authErr != nil {
+		// the token could not be checked, that is not an answer about the token
+		if authErr.Status == http.StatusServiceUnavailable || authErr.Status == http.StatusInternalServerError {
+			u.Respond(c.Writer, authErr.Status, u.ResponseErrorWithCode(authErr.Status, authErr.Message))
+			return
+		}
+		u.Respond(c.Writer, http.StatusOK, u.ResponseSuccessWithObj(msg.TokenIntrospected, v1resp.IntrospectionResponse{Reason: authErr.Message}))
+		return
+	}
+
+	user := access.User
+	tokenID, _ := access.Claims["jti"].(string)
+	expiresAt := time.Unix(int64(access.Expiry), 0).UTC()
+	out := v1resp.IntrospectionResponse{
+		Active:     true,
+		UserId:     &user.Id,
+		Email:      user.Email,
+		Role:       user.Role,
+		OrgId:      user.OrgId,
+		OrgRole:    user.OrgRole,
+		AuthMethod: user.AuthMethod,
+		TokenId:    tokenID,
+		ExpiresAt:  &expiresAt,
+		DpopJkt:    access.Jkt,
+	}
+	if user.Actor != nil {
+		out.ActorId = &user.Actor.Id
+	}
+	u.Respond(c.Writer, http.StatusOK, u.ResponseSuccessWithObj(msg.TokenIntrospected, out))
+}
@@ -0,0 +1,240 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type GroupCtl struct {
+	GroupService v1Service.IGroupService
+	APIValidator valid.IAPIValidatorService
+}
+
+// CreateGroup is made for creating a group with its roles
+// @router /api/v1/admin/groups [post]
+func (gc *GroupCtl) CreateGroup(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(CreateGroup).")
+	var req v1req.CreateGroupRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := gc.APIValidator.ValidateStruct(req, "CreateGroupRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.CreateGroup(c.Request.Context(), userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetGroups is made for listing the groups
+// @router /api/v1/admin/groups [get]
+func (gc *GroupCtl) GetGroups(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(GetGroups).")
+
+	//call service
+	resp := gc.GroupService.GetGroups()
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetGroup is made for fetching a group with its members
+// @router /api/v1/admin/groups/:id [get]
+func (gc *GroupCtl) GetGroup(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(GetGroup).")
+
+	groupID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.GetGroup(groupID)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// DeleteGroup is made for deleting a group
+// @router /api/v1/admin/groups/:id [delete]
+func (gc *GroupCtl) DeleteGroup(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(DeleteGroup).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	groupID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.DeleteGroup(c.Request.Context(), userData, groupID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// AddMembers is made for adding users to a group
+// @router /api/v1/admin/groups/:id/members [post]
+func (gc *GroupCtl) AddMembers(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(AddMembers).")
+	var req v1req.GroupMembersRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	groupID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := gc.APIValidator.ValidateStruct(req, "GroupMembersRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.AddMembers(c.Request.Context(), userData, groupID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// RemoveMember is made for removing a user from a group
+// @router /api/v1/admin/groups/:id/members/:user_id [delete]
+func (gc *GroupCtl) RemoveMember(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(RemoveMember).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	groupID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := uuidParamOrAbort(c, "user_id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.RemoveMember(c.Request.Context(), userData, groupID, userID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// AddRole is made for granting a role to the members of a group
+// @router /api/v1/admin/groups/:id/roles [post]
+func (gc *GroupCtl) AddRole(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(AddRole).")
+	var req v1req.GroupRoleRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	groupID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := gc.APIValidator.ValidateStruct(req, "GroupRoleRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.AddRole(c.Request.Context(), userData, groupID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// RemoveRole is made for taking a role away from the members of a group
+// @router /api/v1/admin/groups/:id/roles/:role [delete]
+func (gc *GroupCtl) RemoveRole(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Group Controller Called(RemoveRole).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	groupID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := gc.GroupService.RemoveRole(c.Request.Context(), userData, groupID, c.Param("role"), middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// uuidParamOrAbort reads a uuid path parameter and answers the request when it is malformed
+func uuidParamOrAbort(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.FromString(c.Param(name))
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,211 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type InvitationCtl struct {
+	InvitationService v1Service.IInvitationService
+	APIValidator      valid.IAPIValidatorService
+}
+
+// CreateInvitation is made for inviting an email address to the active organization
+// @router /api/v1/orgs/current/invitations [post]
+func (ic *InvitationCtl) CreateInvitation(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(CreateInvitation).")
+	var req v1req.InvitationRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ic.APIValidator.ValidateStruct(req, "InvitationRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.CreateInvitation(userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetInvitations is made for listing the pending invitations of the active organization
+// @router /api/v1/orgs/current/invitations [get]
+func (ic *InvitationCtl) GetInvitations(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(GetInvitations).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.GetInvitations(userData)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// ResendInvitation is made for mailing a new link of a pending invitation
+// @router /api/v1/orgs/current/invitations/:id/resend [post]
+func (ic *InvitationCtl) ResendInvitation(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(ResendInvitation).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.ResendInvitation(userData, invitationID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// RevokeInvitation is made for cancelling a pending invitation
+// @router /api/v1/orgs/current/invitations/:id [delete]
+func (ic *InvitationCtl) RevokeInvitation(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(RevokeInvitation).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.RevokeInvitation(userData, invitationID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// PreviewInvitation is made for showing the invitation behind an acceptance link
+// @router /api/v1/invitations/preview [get]
+func (ic *InvitationCtl) PreviewInvitation(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(PreviewInvitation).")
+	var req v1req.InvitationTokenRequest
+
+	//decode the query string into struct and failed if any error occurs
+	if err := c.ShouldBindQuery(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ic.APIValidator.ValidateStruct(req, "InvitationTokenRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.PreviewInvitation(req)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// AcceptInvitation is made for joining the organization of an invitation with the signed in account
+// @router /api/v1/invitations/accept [post]
+func (ic *InvitationCtl) AcceptInvitation(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(AcceptInvitation).")
+	var req v1req.InvitationTokenRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ic.APIValidator.ValidateStruct(req, "InvitationTokenRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.AcceptInvitation(userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// SignUpWithInvitation is made for creating the account of an invitee and joining the organization
+// @router /api/v1/invitations/sign-up [post]
+func (ic *InvitationCtl) SignUpWithInvitation(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Invitation Controller Called(SignUpWithInvitation).")
+	var req v1req.InvitationSignUpRequest
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := ic.APIValidator.ValidateStruct(req, "InvitationSignUpRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := ic.InvitationService.SignUpWithInvitation(req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
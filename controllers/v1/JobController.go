@@ -0,0 +1,60 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobCtl struct {
+	JobService   v1Service.IJobService
+	APIValidator valid.IAPIValidatorService
+}
+
+// GetJobs is made for listing the scheduled jobs
+// @router /api/v1/admin/jobs [get]
+func (jc *JobCtl) GetJobs(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Job Controller Called(GetJobs).")
+
+	//call service
+	resp := jc.JobService.GetJobs(c.Request.Context())
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetJobRuns is made for the run history of a job
+// @router /api/v1/admin/jobs/:name/runs [get]
+func (jc *JobCtl) GetJobRuns(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Job Controller Called(GetJobRuns).")
+	var req v1req.JobRunsRequest
+
+	//decode the query string into struct and failed if any error occurs
+	if err := c.ShouldBindQuery(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := jc.APIValidator.ValidateStruct(req, "JobRunsRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := jc.JobService.GetRuns(c.Param("name"), req)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
@@ -0,0 +1,250 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type OrganizationCtl struct {
+	OrganizationService v1Service.IOrganizationService
+	APIValidator        valid.IAPIValidatorService
+}
+
+// CreateOrganization is made for creating an organization owned by the user
+// @router /api/v1/orgs [post]
+func (oc *OrganizationCtl) CreateOrganization(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(CreateOrganization).")
+	var req v1req.CreateOrganizationRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := oc.APIValidator.ValidateStruct(req, "CreateOrganizationRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.CreateOrganization(userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetOrganizations is made for listing the organizations of the user
+// @router /api/v1/orgs [get]
+func (oc *OrganizationCtl) GetOrganizations(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(GetOrganizations).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.GetOrganizations(userData)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// SwitchOrganization is made for re-issuing the tokens for another organization of the user
+// @router /api/v1/orgs/switch [post]
+func (oc *OrganizationCtl) SwitchOrganization(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(SwitchOrganization).")
+	var req v1req.SwitchOrganizationRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := oc.APIValidator.ValidateStruct(req, "SwitchOrganizationRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.SwitchOrganization(userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetCurrentOrganization is made for fetching the active organization of the token
+// @router /api/v1/orgs/current [get]
+func (oc *OrganizationCtl) GetCurrentOrganization(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(GetCurrentOrganization).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.GetCurrentOrganization(userData)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// UpdateSettings is made for overriding the global sign-in and token settings for the organization
+// @router /api/v1/orgs/current/settings [patch]
+func (oc *OrganizationCtl) UpdateSettings(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(UpdateSettings).")
+	var req v1req.OrganizationSettingsRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := oc.APIValidator.ValidateStruct(req, "OrganizationSettingsRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.UpdateSettings(userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetMembers is made for listing the members of the active organization
+// @router /api/v1/orgs/current/members [get]
+func (oc *OrganizationCtl) GetMembers(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(GetMembers).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.GetMembers(userData)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// UpdateMemberRole is made for changing the role of a member of the active organization
+// @router /api/v1/orgs/current/members/:user_id [patch]
+func (oc *OrganizationCtl) UpdateMemberRole(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(UpdateMemberRole).")
+	var req v1req.OrganizationMemberRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.FromString(c.Param("user_id"))
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := oc.APIValidator.ValidateStruct(req, "OrganizationMemberRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.UpdateMemberRole(userData, memberID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// RemoveMember is made for removing a member from the active organization
+// @router /api/v1/orgs/current/members/:user_id [delete]
+func (oc *OrganizationCtl) RemoveMember(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Organization Controller Called(RemoveMember).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.FromString(c.Param("user_id"))
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	//call service
+	resp := oc.OrganizationService.RemoveMember(userData, memberID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// userDataOrAbort reads the signed in user from the context and answers the request when it can not
+func userDataOrAbort(c *gin.Context) (middleware.UserTokenData, bool) {
+	userData, err := middleware.GetUserDataFromToken(c)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.SomethingWrong))
+		return middleware.UserTokenData{}, false
+	}
+	return userData, true
+}
@@ -0,0 +1,202 @@
+package v1Ctl
+
+import (
+	v1req "test-task/resources/request/v1"
+	v1Service "test-task/services/v1"
+	u "test-task/shared/common"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	"net/http"
+	valid "test-task/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookCtl struct {
+	WebhookService v1Service.IWebhookService
+	APIValidator   valid.IAPIValidatorService
+}
+
+// CreateWebhook is made for subscribing an endpoint to events
+// @router /api/v1/admin/webhooks [post]
+func (wc *WebhookCtl) CreateWebhook(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(CreateWebhook).")
+	var req v1req.CreateWebhookRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := wc.APIValidator.ValidateStruct(req, "CreateWebhookRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := wc.WebhookService.CreateEndpoint(userData, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetWebhooks is made for listing the webhook endpoints
+// @router /api/v1/admin/webhooks [get]
+func (wc *WebhookCtl) GetWebhooks(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(GetWebhooks).")
+
+	//call service
+	resp := wc.WebhookService.GetEndpoints()
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetWebhook is made for fetching a webhook endpoint
+// @router /api/v1/admin/webhooks/:id [get]
+func (wc *WebhookCtl) GetWebhook(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(GetWebhook).")
+
+	endpointID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := wc.WebhookService.GetEndpoint(endpointID)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// UpdateWebhook is made for changing, enabling or disabling an endpoint and rotating its secret
+// @router /api/v1/admin/webhooks/:id [patch]
+func (wc *WebhookCtl) UpdateWebhook(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(UpdateWebhook).")
+	var req v1req.UpdateWebhookRequest
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	endpointID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//decode the request body into struct and failed if any error occurs
+	if err := c.BindJSON(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := wc.APIValidator.ValidateStruct(req, "UpdateWebhookRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := wc.WebhookService.UpdateEndpoint(userData, endpointID, req, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// DeleteWebhook is made for removing an endpoint with its delivery logs
+// @router /api/v1/admin/webhooks/:id [delete]
+func (wc *WebhookCtl) DeleteWebhook(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(DeleteWebhook).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	endpointID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := wc.WebhookService.DeleteEndpoint(userData, endpointID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// GetDeliveries is made for the delivery log of an endpoint
+// @router /api/v1/admin/webhooks/:id/deliveries [get]
+func (wc *WebhookCtl) GetDeliveries(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(GetDeliveries).")
+	var req v1req.WebhookDeliveriesRequest
+
+	endpointID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+
+	//decode the query string into struct and failed if any error occurs
+	if err := c.ShouldBindQuery(&req); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, msg.InvalidRequest))
+		return
+	}
+
+	// Struct field validation
+	if resp, ok := wc.APIValidator.ValidateStruct(req, "WebhookDeliveriesRequest"); !ok {
+		log.GetLog().Info("ERROR : ", "Struct validation error")
+		u.Respond(c.Writer, http.StatusBadRequest, u.ResponseErrorWithCode(u.CodeBadRequest, resp))
+		return
+	}
+
+	//call service
+	resp := wc.WebhookService.GetDeliveries(endpointID, req)
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
+
+// Redeliver is made for sending a past delivery again
+// @router /api/v1/admin/webhooks/:id/deliveries/:delivery_id/redeliver [post]
+func (wc *WebhookCtl) Redeliver(c *gin.Context) {
+	log.GetLog().Info("INFO : ", "Webhook Controller Called(Redeliver).")
+
+	userData, ok := userDataOrAbort(c)
+	if !ok {
+		return
+	}
+	endpointID, ok := uuidParamOrAbort(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := uuidParamOrAbort(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	//call service
+	resp := wc.WebhookService.Redeliver(userData, endpointID, deliveryID, middleware.GetRequestInfo(c))
+	statusCode := u.GetHTTPStatusCode(resp["res_code"])
+
+	//return response using api helper
+	u.Respond(c.Writer, statusCode, resp)
+}
@@ -15,3 +15,77 @@ func AuthController(validatorService validator.IAPIValidatorService, authService
 
 	return &authCtl
 }
+
+func AuditController(validatorService validator.IAPIValidatorService, auditService v1Service.IAuditService) *AuditCtl {
+	auditCtl := AuditCtl{
+		AuditService: auditService,
+		APIValidator: validatorService,
+	}
+
+	return &auditCtl
+}
+
+func AccountController(validatorService validator.IAPIValidatorService, accountService v1Service.IAccountService, permissionService v1Service.IPermissionService) *AccountCtl {
+	accountCtl := AccountCtl{
+		AccountService:    accountService,
+		PermissionService: permissionService,
+		APIValidator:      validatorService,
+	}
+
+	return &accountCtl
+}
+
+func AdminController(validatorService validator.IAPIValidatorService, adminService v1Service.IAdminService, statusService v1Service.IUserStatusService) *AdminCtl {
+	adminCtl := AdminCtl{
+		AdminService:  adminService,
+		StatusService: statusService,
+		APIValidator:  validatorService,
+	}
+
+	return &adminCtl
+}
+
+func OrganizationController(validatorService validator.IAPIValidatorService, organizationService v1Service.IOrganizationService) *OrganizationCtl {
+	organizationCtl := OrganizationCtl{
+		OrganizationService: organizationService,
+		APIValidator:        validatorService,
+	}
+
+	return &organizationCtl
+}
+
+func InvitationController(validatorService validator.IAPIValidatorService, invitationService v1Service.IInvitationService) *InvitationCtl {
+	invitationCtl := InvitationCtl{
+		InvitationService: invitationService,
+		APIValidator:      validatorService,
+	}
+
+	return &invitationCtl
+}
+
+func GroupController(validatorService validator.IAPIValidatorService, groupService v1Service.IGroupService) *GroupCtl {
+	groupCtl := GroupCtl{
+		GroupService: groupService,
+		APIValidator: validatorService,
+	}
+
+	return &groupCtl
+}
+
+func WebhookController(validatorService validator.IAPIValidatorService, webhookService v1Service.IWebhookService) *WebhookCtl {
+	webhookCtl := WebhookCtl{
+		WebhookService: webhookService,
+		APIValidator:   validatorService,
+	}
+
+	return &webhookCtl
+}
+
+func JobController(validatorService validator.IAPIValidatorService, jobService v1Service.IJobService) *JobCtl {
+	jobCtl := JobCtl{
+		JobService:   jobService,
+		APIValidator: validatorService,
+	}
+
+	return &jobCtl
+}
@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"os"
 	"test-task/model"
 	"test-task/routers/api"
+	v1Service "test-task/services/v1"
 	"test-task/shared/cache"
 	"test-task/shared/config"
 	"test-task/shared/database"
+	"test-task/shared/geoip"
 	"test-task/shared/log"
+	"test-task/shared/scheduler"
 	"test-task/shared/utils"
 )
 
+//go:generate go run ./cmd/openapi
+
 // Execution starts from main function
 func main() {
 	configFile := flag.String("c", "", "configuration file without extension. For config.toml then put \" -c config-development\"")
@@ -36,19 +42,28 @@ func main() {
 
 	log.GetLog().Info("", "DB connected")
 	model.AutoMigrate()
-	rt := api.NewRouter(cf)
+	sched := scheduler.NewScheduler(cf, v1Service.NewJobRunRecorder())
+	rt := api.NewRouter(cf, sched)
 	rt.Setup()
+	rpcSrv := rt.RPC()
+	rpcSrv.Setup()
 
 	go rt.Run()
+	go rpcSrv.Run()
+	sched.Start()
 
 	utils.GracefulStop(log.GetLog(), func(ctx context.Context) error {
-		var err error
-		if err = rt.Close(ctx); err != nil {
-			return err
-		}
-		if err = database.Close(); err != nil {
-			return err
-		}
-		return nil
+		// the HTTP and gRPC servers finish their requests at the same time
+		rpcClosed := make(chan error, 1)
+		go func() {
+			rpcClosed <- rpcSrv.Close(ctx)
+		}()
+		errs := []error{rt.Close(ctx), <-rpcClosed}
+
+		// a server that did not close cleanly does not keep the jobs running or the connections open
+		errs = append(errs, sched.Stop(ctx))
+		errs = append(errs, database.Close())
+		errs = append(errs, geoip.Close())
+		return errors.Join(errs...)
 	})
 }
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Audit event types
+const (
+	AuditSignUp          = "auth.sign_up"
+	AuditSignIn          = "auth.sign_in"
+	AuditRefreshToken    = "auth.refresh_token"
+	AuditSignOut         = "auth.sign_out"
+	AuditPasswordChange  = "auth.password_change"
+	AuditPasswordForgot  = "auth.password_forgot"
+	AuditPasswordReset   = "auth.password_reset"
+	AuditSessionsRevoked = "auth.sessions_revoked"
+	AuditNewDeviceAlert  = "auth.new_device_alert"
+	AuditEmailVerified   = "auth.email_verified"
+	AuditPhoneVerified   = "auth.phone_verified"
+	AuditOTPSent         = "auth.otp_sent"
+	AuditOrgCreate       = "org.create"
+	AuditOrgSwitch       = "org.switch"
+	AuditOrgSettings     = "org.settings_update"
+	AuditOrgMemberRole   = "org.member_role_update"
+	AuditOrgMemberRemove = "org.member_remove"
+	AuditOrgInvite       = "org.invite"
+	AuditOrgInviteResend = "org.invite_resend"
+	AuditOrgInviteRevoke = "org.invite_revoke"
+	AuditOrgInviteAccept = "org.invite_accept"
+	AuditAdminAuditQuery = "admin.audit_search"
+	AuditImpersonate     = "admin.impersonate"
+	AuditImpersonatedReq = "admin.impersonated_request"
+	AuditUserStatus      = "admin.user_status_change"
+	AuditUserRestore     = "admin.user_restore"
+	AuditUserPurge       = "system.user_purge"
+	AuditGroupCreate     = "admin.group_create"
+	AuditGroupDelete     = "admin.group_delete"
+	AuditGroupMemberAdd  = "admin.group_member_add"
+	AuditGroupMemberDel  = "admin.group_member_remove"
+	AuditGroupRoleAdd    = "admin.group_role_add"
+	AuditGroupRoleDel    = "admin.group_role_remove"
+	AuditWebhookCreate   = "admin.webhook_create"
+	AuditWebhookUpdate   = "admin.webhook_update"
+	AuditWebhookDelete   = "admin.webhook_delete"
+	AuditWebhookResend   = "admin.webhook_redeliver"
+	AuditWebhookDisabled = "system.webhook_disabled"
+)
+
+// Audit event outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent is a security relevant action. Every row carries the hash of the
+// previous row, so editing or removing a row breaks the chain from that point on.
+type AuditEvent struct {
+	ID        uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	Sequence  int64     `gorm:"unique_index;not null" json:"sequence"`
+	EventType string    `gorm:"type:varchar(50);index;not null" json:"event_type"`
+	ActorID   string    `gorm:"type:varchar(50);index" json:"actor_id"`
+	TargetID  string    `gorm:"type:varchar(50);index" json:"target_id"`
+	IPAddress string    `gorm:"type:varchar(64)" json:"ip_address"`
+	UserAgent string    `gorm:"type:text" json:"user_agent"`
+	RequestID string    `gorm:"type:varchar(64);index" json:"request_id"`
+	Outcome   string    `gorm:"type:varchar(20);not null" json:"outcome"`
+	Metadata  string    `gorm:"type:text" json:"metadata"`
+	PrevHash  string    `gorm:"type:varchar(64);not null" json:"prev_hash"`
+	Hash      string    `gorm:"type:varchar(64);not null" json:"hash"`
+	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
+}
+
+// TableName returns the table name for the AuditEvent model
+func (a *AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// ComputeHash returns the chain hash of the event over its content and the previous hash
+func (a *AuditEvent) ComputeHash() string {
+	fields := []string{
+		a.PrevHash,
+		strconv.FormatInt(a.Sequence, 10),
+		a.ID.String(),
+		a.EventType,
+		a.ActorID,
+		a.TargetID,
+		a.IPAddress,
+		a.UserAgent,
+		a.RequestID,
+		a.Outcome,
+		a.Metadata,
+		a.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	for i, f := range fields {
+		// length prefixes keep "ab"+"c" and "a"+"bc" apart
+		fields[i] = strconv.Itoa(len(f)) + ":" + f
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "|")))
+	return hex.EncodeToString(sum[:])
+}
@@ -13,6 +13,19 @@ func AutoMigrate() {
 		// For auto migrate database tables, need to add model below
 		&User{},
 		&UserRefreshToken{},
+		&AuditEvent{},
+		&UserLoginActivity{},
+		&Organization{},
+		&OrganizationMember{},
+		&OrganizationInvitation{},
+		&Group{},
+		&GroupMember{},
+		&GroupRole{},
+		&WebhookEndpoint{},
+		&WebhookDelivery{},
+		&WebhookAttempt{},
+		&OutboxMessage{},
+		&JobRun{},
 	)
 
 }
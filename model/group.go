@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Group bundles users so roles can be granted to all of them at once
+type Group struct {
+	ID          uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);unique;not null" json:"name"`
+	Description string    `gorm:"type:varchar(500)" json:"description"`
+	CreatedBy   uuid.UUID `gorm:"type:varchar(50);not null" json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Roles []GroupRole `gorm:"foreignkey:GroupID" json:"roles,omitempty"`
+}
+
+// TableName returns the table name for the Group model
+func (g *Group) TableName() string {
+	return "user_groups"
+}
+
+// GroupMember is the membership of a user in a group
+type GroupMember struct {
+	ID        uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	GroupID   uuid.UUID `gorm:"type:varchar(50);not null;unique_index:idx_group_member" json:"group_id"`
+	UserID    uuid.UUID `gorm:"type:varchar(50);not null;unique_index:idx_group_member;index" json:"user_id"`
+	AddedBy   uuid.UUID `gorm:"type:varchar(50);not null" json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User *User `gorm:"foreignkey:UserID" json:"user,omitempty"`
+}
+
+// TableName returns the table name for the GroupMember model
+func (m *GroupMember) TableName() string {
+	return "group_members"
+}
+
+// GroupRole is a role granted to every member of the group
+type GroupRole struct {
+	ID        uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	GroupID   uuid.UUID `gorm:"type:varchar(50);not null;unique_index:idx_group_role" json:"group_id"`
+	Role      string    `gorm:"type:varchar(20);not null;unique_index:idx_group_role" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the GroupRole model
+func (r *GroupRole) TableName() string {
+	return "group_roles"
+}
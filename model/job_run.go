@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// JobRun is one execution of a scheduled job, see shared/scheduler
+type JobRun struct {
+	ID          uuid.UUID  `gorm:"type:varchar(50);primaryKey" json:"id"`
+	Job         string     `gorm:"type:varchar(100);not null;index" json:"job"`
+	Instance    string     `gorm:"type:varchar(255);not null" json:"instance"`
+	ScheduledAt time.Time  `gorm:"not null" json:"scheduled_at"`
+	StartedAt   time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	Status      string     `gorm:"type:varchar(20);not null" json:"status"`
+	Result      string     `gorm:"type:varchar(500)" json:"result"`
+	Error       string     `gorm:"type:varchar(500)" json:"error"`
+}
+
+// TableName returns the table name for the JobRun model
+func (j *JobRun) TableName() string {
+	return "job_runs"
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// UserLoginActivity is a successful sign-in of a user
+type UserLoginActivity struct {
+	ID          uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:varchar(50);index;not null" json:"user_id"`
+	IPAddress   string    `gorm:"type:varchar(64)" json:"ip_address"`
+	UserAgent   string    `gorm:"type:text" json:"user_agent"`
+	Browser     string    `gorm:"type:varchar(50)" json:"browser"`
+	OS          string    `gorm:"type:varchar(50)" json:"os"`
+	Device      string    `gorm:"type:varchar(20)" json:"device"`
+	Country     string    `gorm:"type:varchar(2)" json:"country"`
+	City        string    `gorm:"type:varchar(100)" json:"city"`
+	DeviceHash  string    `gorm:"type:varchar(64);index" json:"-"`
+	NetworkHash string    `gorm:"type:varchar(64);index" json:"-"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName returns the table name for the UserLoginActivity model
+func (a *UserLoginActivity) TableName() string {
+	return "user_login_activities"
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Organization roles
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization is a tenant. Its settings override the global App config for its members.
+type Organization struct {
+	ID        uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Slug      string    `gorm:"type:varchar(50);unique;not null" json:"slug"`
+	CreatedBy uuid.UUID `gorm:"type:varchar(50);not null" json:"created_by"`
+
+	// AllowedSignInMethods is a comma separated list, empty allows the global App.SignInMethods
+	AllowedSignInMethods string `gorm:"type:varchar(200)" json:"allowed_sign_in_methods"`
+	// AccessTokenTTL in minutes and RefreshTokenTTL in hours, 0 keeps the global value
+	AccessTokenTTL  int `gorm:"not null;default:0" json:"access_token_ttl"`
+	RefreshTokenTTL int `gorm:"not null;default:0" json:"refresh_token_ttl"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the Organization model
+func (o *Organization) TableName() string {
+	return "organizations"
+}
+
+// SignInMethods returns the methods configured for the organization, nil when it keeps the global ones
+func (o *Organization) SignInMethods() []string {
+	if strings.TrimSpace(o.AllowedSignInMethods) == "" {
+		return nil
+	}
+	var methods []string
+	for _, m := range strings.Split(o.AllowedSignInMethods, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// OrganizationMember is the membership of a user in an organization with its per-org role
+type OrganizationMember struct {
+	ID        uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	OrgID     uuid.UUID `gorm:"type:varchar(50);not null;unique_index:idx_org_member" json:"org_id"`
+	UserID    uuid.UUID `gorm:"type:varchar(50);not null;unique_index:idx_org_member;index" json:"user_id"`
+	Role      string    `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Organization *Organization `gorm:"foreignkey:OrgID" json:"organization,omitempty"`
+	User         *User         `gorm:"foreignkey:UserID" json:"user,omitempty"`
+}
+
+// TableName returns the table name for the OrganizationMember model
+func (m *OrganizationMember) TableName() string {
+	return "organization_members"
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+)
+
+// OrganizationInvitation is an invite of an email address to join an organization with a role
+type OrganizationInvitation struct {
+	ID        uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	OrgID     uuid.UUID `gorm:"type:varchar(50);not null;index" json:"org_id"`
+	Email     string    `gorm:"type:varchar(255);not null;index" json:"email"`
+	Role      string    `gorm:"type:varchar(20);not null" json:"role"`
+	InvitedBy uuid.UUID `gorm:"type:varchar(50);not null" json:"invited_by"`
+	Status    string    `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	// TokenID is the jti of the last mailed acceptance token, resending replaces it so older links stop working
+	TokenID    string     `gorm:"type:varchar(50);not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedBy *uuid.UUID `gorm:"type:varchar(50)" json:"accepted_by"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Organization *Organization `gorm:"foreignkey:OrgID" json:"organization,omitempty"`
+}
+
+// TableName returns the table name for the OrganizationInvitation model
+func (i *OrganizationInvitation) TableName() string {
+	return "organization_invitations"
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i *OrganizationInvitation) IsPending() bool {
+	return i.Status == InvitationPending && time.Now().Before(i.ExpiresAt)
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Outbox aggregate types, the kind of record an outbox message is about
+const (
+	AggregateUser = "user"
+)
+
+// OutboxMessage is a domain event written in the transaction of the change it is about, so the event
+// exists exactly when the change was committed. The relay publishes it to the outbox sink afterwards.
+type OutboxMessage struct {
+	ID uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	// Sequence is given by the database on insert, the messages of one aggregate are published in its order
+	Sequence      int64  `gorm:"AUTO_INCREMENT;unique_index;not null" json:"sequence"`
+	AggregateType string `gorm:"type:varchar(50);not null;index:idx_outbox_aggregate" json:"aggregate_type"`
+	AggregateID   string `gorm:"type:varchar(50);not null;index:idx_outbox_aggregate" json:"aggregate_id"`
+	EventType     string `gorm:"type:varchar(50);not null" json:"event_type"`
+	// Version is the schema version of the payload, see eventbus.Versions
+	Version int `gorm:"not null;default:1" json:"version"`
+	// Payload is the JSON encoded event data
+	Payload string `gorm:"type:text;not null" json:"payload"`
+	// Attempts counts the failed publish attempts, NextAttemptAt is when the next one is due
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	LastError     string    `gorm:"type:varchar(500)" json:"last_error"`
+	NextAttemptAt time.Time `gorm:"not null;index" json:"next_attempt_at"`
+	// LockedUntil is the lease of the instance publishing the message, once it ran out another instance takes it
+	LockedUntil  *time.Time `json:"locked_until"`
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at"`
+	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName returns the table name for the OutboxMessage model
+func (o *OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// NewOutboxMessage encodes the event data into a message that is due right away
+func NewOutboxMessage(aggregateType, aggregateID, eventType string, version int, data interface{}) (*OutboxMessage, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &OutboxMessage{
+		ID:            uuid.NewV4(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Version:       version,
+		Payload:       string(payload),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package model
+
+import "sort"
+
+// Permissions checked by the API
+const (
+	PermAuditRead        = "audit:read"
+	PermUsersImpersonate = "users:impersonate"
+	PermGroupsManage     = "groups:manage"
+	PermUsersManage      = "users:manage"
+	PermWebhooksManage   = "webhooks:manage"
+	PermJobsRead         = "jobs:read"
+)
+
+// Roles granting a part of the admin permissions, assigned to users or groups
+const (
+	RoleAuditor = "auditor"
+	RoleSupport = "support"
+)
+
+// RolePermissions maps every role to the permissions it grants
+var RolePermissions = map[string][]string{
+	RoleUser:    {},
+	RoleAdmin:   {PermAuditRead, PermUsersImpersonate, PermGroupsManage, PermUsersManage, PermWebhooksManage, PermJobsRead},
+	RoleAuditor: {PermAuditRead},
+	RoleSupport: {PermUsersImpersonate},
+}
+
+// IsRole reports whether the role is known
+func IsRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// PermissionsOf returns the sorted, de-duplicated permissions granted by the roles
+func PermissionsOf(roles ...string) []string {
+	set := map[string]bool{}
+	for _, role := range roles {
+		for _, perm := range RolePermissions[role] {
+			set[perm] = true
+		}
+	}
+	perms := make([]string, 0, len(set))
+	for perm := range set {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
@@ -12,6 +12,9 @@ type UserRefreshToken struct {
 	UserID       uuid.UUID `gorm:"type:uuid;index;not null;" json:"user_id"`
 	RefreshToken string    `gorm:"type:text;unique;not null;" json:"refresh_token"`
 	ExpiresAt    time.Time `gorm:"not null;" json:"expires_at"`
+	ActiveOrgID  uuid.UUID `gorm:"type:varchar(50);" json:"active_org_id"`
+	AuthMethod   string    `gorm:"type:varchar(20);" json:"auth_method"`
+	DPoPJkt      string    `gorm:"column:dpop_jkt;type:varchar(64);" json:"dpop_jkt"` // thumbprint of the DPoP key the session is bound to
 	CreatedAt    time.Time `gorm:"autoCreateTime;" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime;" json:"updated_at"`
 }
@@ -15,8 +15,56 @@ type User struct {
 	LastName  string    `gorm:"varchar(30)" json:"last_name" validate:"required"`
 	Email     string    `gorm:"unique;not null" json:"email"`
 	Password  string    `gorm:"not null" json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Role      string    `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	// Status is checked on sign-in, token refresh and every authenticated request
+	Status          string     `gorm:"type:varchar(30);not null;default:'active';index" json:"status"`
+	StatusReason    string     `gorm:"type:varchar(500)" json:"status_reason"`
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+	// EmailVerifiedAt is set once the user proved they own the email, nil while unverified
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// Phone is an E.164 number, only stored once verified by a one-time passcode
+	Phone           *string    `gorm:"type:varchar(16);unique" json:"phone"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	// DeletedAt makes gorm skip the row in every query, Unscoped reaches soft deleted users
+	DeletedAt *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+	// PurgedAt is set once the personal data of a soft deleted user was removed, it can not be restored after that
+	PurgedAt *time.Time `json:"purged_at,omitempty"`
+}
+
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User statuses, only active users can sign in or use their tokens
+const (
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusActive              = "active"
+	UserStatusSuspended           = "suspended"
+	UserStatusLocked              = "locked"
+	UserStatusDeleted             = "deleted"
+)
+
+// UserStatusTransitions lists the statuses a user can be moved to from each status
+var UserStatusTransitions = map[string][]string{
+	UserStatusPendingVerification: {UserStatusActive, UserStatusSuspended, UserStatusDeleted},
+	UserStatusActive:              {UserStatusSuspended, UserStatusLocked, UserStatusDeleted},
+	UserStatusSuspended:           {UserStatusActive, UserStatusDeleted},
+	UserStatusLocked:              {UserStatusActive, UserStatusDeleted},
+	UserStatusDeleted:             {},
+}
+
+// CanTransition reports whether the status can be changed from one value to the other
+func CanTransition(from, to string) bool {
+	for _, status := range UserStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
 }
 
 // TableName returns the table name for the User model
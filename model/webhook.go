@@ -0,0 +1,131 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"test-task/shared/eventbus"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Webhook event types, sent to the endpoints subscribed to them. They are the event bus types, their data
+// follows the schema of eventbus.Versions.
+const (
+	WebhookUserSignedUp      = eventbus.UserSignedUp
+	WebhookUserEmailVerified = eventbus.UserEmailVerified
+	WebhookUserUpdated       = eventbus.UserProfileUpdated
+	WebhookUserDeleted       = eventbus.UserDeleted
+)
+
+// WebhookEvents lists every event an endpoint can subscribe to, "*" subscribes to all of them
+var WebhookEvents = []string{WebhookUserSignedUp, WebhookUserEmailVerified, WebhookUserUpdated, WebhookUserDeleted}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// IsWebhookEvent reports whether the event can be subscribed to
+func IsWebhookEvent(event string) bool {
+	if event == "*" {
+		return true
+	}
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint is a URL receiving the signed events it is subscribed to
+type WebhookEndpoint struct {
+	ID          uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	URL         string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Description string    `gorm:"type:varchar(500)" json:"description"`
+	// Events is the comma separated event filter
+	Events string `gorm:"type:text;not null" json:"events"`
+	// Secret signs the payloads, it is shown once when the endpoint is created or the secret rotated
+	Secret  string `gorm:"type:varchar(100);not null" json:"-"`
+	Enabled bool   `gorm:"not null;default:true;index" json:"enabled"`
+	// ConsecutiveFailures counts the failed attempts since the last successful one, reaching
+	// Webhook.DisableAfter disables the endpoint
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      string     `gorm:"type:varchar(500)" json:"disabled_reason"`
+	CreatedBy           uuid.UUID  `gorm:"type:varchar(50);not null" json:"created_by"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the WebhookEndpoint model
+func (w *WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// EventList returns the event filter of the endpoint
+func (w *WebhookEndpoint) EventList() []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// Subscribed reports whether the endpoint receives the event
+func (w *WebhookEndpoint) Subscribed(event string) bool {
+	for _, e := range w.EventList() {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event to be sent to one endpoint, retried until it succeeds or runs out of attempts
+type WebhookDelivery struct {
+	ID         uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	EndpointID uuid.UUID `gorm:"type:varchar(50);not null;index" json:"endpoint_id"`
+	// EventID is shared by the deliveries of the same event, receivers use it to drop duplicates
+	EventID   uuid.UUID `gorm:"type:varchar(50);not null;index" json:"event_id"`
+	EventType string    `gorm:"type:varchar(50);not null" json:"event_type"`
+	// AggregateID is the record the event is about, the purge of a user finds the deliveries to redact by it
+	AggregateID string `gorm:"type:varchar(50);index" json:"-"`
+	Payload     string `gorm:"type:text;not null" json:"-"`
+	Status      string `gorm:"type:varchar(20);not null;index" json:"status"`
+	Attempts    int    `gorm:"not null;default:0" json:"attempts"`
+	// NextAttemptAt is when the delivery is due, a worker sending it pushes it out for the time it holds it
+	NextAttemptAt time.Time `gorm:"not null;index" json:"next_attempt_at"`
+	// RedeliveryOf is the delivery this one was manually created from
+	RedeliveryOf *uuid.UUID `gorm:"type:varchar(50)" json:"redelivery_of,omitempty"`
+	DeliveredAt  *time.Time `json:"delivered_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	Log []WebhookAttempt `gorm:"foreignkey:DeliveryID" json:"attempts_log,omitempty"`
+}
+
+// TableName returns the table name for the WebhookDelivery model
+func (w *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookAttempt is the outcome of one HTTP request of a delivery
+type WebhookAttempt struct {
+	ID         uuid.UUID `gorm:"type:varchar(50);primaryKey" json:"id"`
+	DeliveryID uuid.UUID `gorm:"type:varchar(50);not null;index" json:"delivery_id"`
+	Attempt    int       `gorm:"not null" json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `gorm:"type:varchar(500)" json:"error"`
+	// ResponseBody keeps the start of the answer of the endpoint
+	ResponseBody string    `gorm:"type:text" json:"response_body"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the WebhookAttempt model
+func (w *WebhookAttempt) TableName() string {
+	return "webhook_attempts"
+}
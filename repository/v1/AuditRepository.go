@@ -0,0 +1,121 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"fmt"
+	"test-task/model"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// AuditFilter narrows down an audit event search, empty fields are ignored
+type AuditFilter struct {
+	EventType string
+	ActorID   string
+	TargetID  string
+	Outcome   string
+	IPAddress string
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	Size      int
+}
+
+type IAuditRepository interface {
+	AppendEvent(conn database.IConnection, event *model.AuditEvent) error
+	SearchEvents(conn database.IConnection, filter AuditFilter) ([]model.AuditEvent, int, error)
+	GetEventsAfter(conn database.IConnection, sequence int64, limit int) ([]model.AuditEvent, error)
+}
+
+type auditRepo struct {
+	DB *sql.DB
+}
+
+func NewAuditWriter() IAuditRepository {
+	return &auditRepo{}
+}
+
+// AppendEvent links the event to the last row of the chain and stores it.
+// conn must be a transaction, the advisory lock serialises concurrent writers until commit.
+func (ar *auditRepo) AppendEvent(conn database.IConnection, event *model.AuditEvent) error {
+	db := conn.GetDB()
+	if err := db.Exec("SELECT pg_advisory_xact_lock(hashtext('audit_events'))").Error; err != nil {
+		return fmt.Errorf("error locking audit chain: %v", err)
+	}
+
+	var last model.AuditEvent
+	err := db.Order("sequence desc").First(&last).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("error reading audit chain head: %v", err)
+	}
+
+	event.Sequence = last.Sequence + 1
+	event.PrevHash = last.Hash
+	// postgres keeps microseconds, the hash must survive the round trip
+	event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Microsecond)
+	event.Hash = event.ComputeHash()
+
+	if err = db.Create(event).Error; err != nil {
+		return fmt.Errorf("error creating audit event: %v", err)
+	}
+	return nil
+}
+
+func (ar *auditRepo) SearchEvents(conn database.IConnection, filter AuditFilter) ([]model.AuditEvent, int, error) {
+	log.GetLog().Info("INFO:", "Audit Repo Called (SearchEvents).")
+
+	query := conn.GetDB().Model(&model.AuditEvent{})
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []model.AuditEvent
+	err := query.Order("sequence desc").
+		Offset((filter.Page - 1) * filter.Size).
+		Limit(filter.Size).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// GetEventsAfter returns the next part of the chain in sequence order
+func (ar *auditRepo) GetEventsAfter(conn database.IConnection, sequence int64, limit int) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+	err := conn.GetDB().Where("sequence > ?", sequence).Order("sequence asc").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
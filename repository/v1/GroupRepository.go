@@ -0,0 +1,147 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"test-task/shared/log"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+type IGroupRepository interface {
+	CreateGroup(conn database.IConnection, group *model.Group) error
+	GetGroups(conn database.IConnection) ([]model.Group, error)
+	GetGroupById(conn database.IConnection, groupID uuid.UUID) (*model.Group, error)
+	GetGroupByName(conn database.IConnection, name string) (*model.Group, error)
+	DeleteGroup(conn database.IConnection, groupID uuid.UUID) error
+
+	AddMember(conn database.IConnection, member *model.GroupMember) error
+	RemoveMember(conn database.IConnection, groupID, userID uuid.UUID) (bool, error)
+	RemoveUserFromGroups(conn database.IConnection, userID uuid.UUID) error
+	GetMembers(conn database.IConnection, groupID uuid.UUID) ([]model.GroupMember, error)
+	GetMemberIDs(conn database.IConnection, groupID uuid.UUID) ([]uuid.UUID, error)
+	IsMember(conn database.IConnection, groupID, userID uuid.UUID) (bool, error)
+
+	AddRole(conn database.IConnection, role *model.GroupRole) error
+	RemoveRole(conn database.IConnection, groupID uuid.UUID, role string) (bool, error)
+	GetUserGroupRoles(conn database.IConnection, userID uuid.UUID) ([]string, error)
+}
+
+type groupRepo struct {
+	DB *sql.DB
+}
+
+func NewGroupWriter() IGroupRepository {
+	return &groupRepo{}
+}
+
+func (gr *groupRepo) CreateGroup(conn database.IConnection, group *model.Group) error {
+	log.GetLog().Info("INFO : ", "Group Repo Called(CreateGroup).")
+	return conn.GetDB().Create(group).Error
+}
+
+func (gr *groupRepo) GetGroups(conn database.IConnection) ([]model.Group, error) {
+	var groups []model.Group
+	err := conn.GetDB().Preload("Roles").Order("name asc").Find(&groups).Error
+	return groups, err
+}
+
+func (gr *groupRepo) GetGroupById(conn database.IConnection, groupID uuid.UUID) (*model.Group, error) {
+	var group model.Group
+	err := conn.GetDB().Preload("Roles").First(&group, "id = ?", groupID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (gr *groupRepo) GetGroupByName(conn database.IConnection, name string) (*model.Group, error) {
+	var group model.Group
+	err := conn.GetDB().First(&group, "lower(name) = lower(?)", name).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// DeleteGroup removes the group with its memberships and roles
+func (gr *groupRepo) DeleteGroup(conn database.IConnection, groupID uuid.UUID) error {
+	log.GetLog().Info("INFO : ", "Group Repo Called(DeleteGroup).")
+	db := conn.GetDB()
+	if err := db.Where("group_id = ?", groupID).Delete(&model.GroupMember{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("group_id = ?", groupID).Delete(&model.GroupRole{}).Error; err != nil {
+		return err
+	}
+	return db.Where("id = ?", groupID).Delete(&model.Group{}).Error
+}
+
+func (gr *groupRepo) AddMember(conn database.IConnection, member *model.GroupMember) error {
+	return conn.GetDB().Create(member).Error
+}
+
+// RemoveMember reports whether the user was a member
+func (gr *groupRepo) RemoveMember(conn database.IConnection, groupID, userID uuid.UUID) (bool, error) {
+	result := conn.GetDB().Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RemoveUserFromGroups drops the user from every group they belong to
+func (gr *groupRepo) RemoveUserFromGroups(conn database.IConnection, userID uuid.UUID) error {
+	return conn.GetDB().Where("user_id = ?", userID).Delete(&model.GroupMember{}).Error
+}
+
+func (gr *groupRepo) GetMembers(conn database.IConnection, groupID uuid.UUID) ([]model.GroupMember, error) {
+	var members []model.GroupMember
+	err := conn.GetDB().Preload("User").
+		Where("group_id = ? AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)", groupID).
+		Order("created_at asc").Find(&members).Error
+	return members, err
+}
+
+func (gr *groupRepo) GetMemberIDs(conn database.IConnection, groupID uuid.UUID) ([]uuid.UUID, error) {
+	var members []model.GroupMember
+	if err := conn.GetDB().Select("user_id").Where("group_id = ?", groupID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids, nil
+}
+
+func (gr *groupRepo) IsMember(conn database.IConnection, groupID, userID uuid.UUID) (bool, error) {
+	var total int
+	err := conn.GetDB().Model(&model.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&total).Error
+	return total > 0, err
+}
+
+func (gr *groupRepo) AddRole(conn database.IConnection, role *model.GroupRole) error {
+	return conn.GetDB().Create(role).Error
+}
+
+// RemoveRole reports whether the group had the role
+func (gr *groupRepo) RemoveRole(conn database.IConnection, groupID uuid.UUID, role string) (bool, error) {
+	result := conn.GetDB().Where("group_id = ? AND role = ?", groupID, role).Delete(&model.GroupRole{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserGroupRoles returns the distinct roles the user receives through groups
+func (gr *groupRepo) GetUserGroupRoles(conn database.IConnection, userID uuid.UUID) ([]string, error) {
+	var roles []string
+	err := conn.GetDB().Table("group_roles").
+		Joins("JOIN group_members ON group_members.group_id = group_roles.group_id").
+		Where("group_members.user_id = ?", userID).
+		Pluck("DISTINCT group_roles.role", &roles).Error
+	return roles, err
+}
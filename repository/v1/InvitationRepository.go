@@ -0,0 +1,85 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// IInvitationRepository is. Only GetInvitationById is used without a tenant,
+// when an invitee opens the link and the organization is not known yet.
+type IInvitationRepository interface {
+	CreateInvitation(conn database.IConnection, invitation *model.OrganizationInvitation) error
+	GetInvitationById(conn database.IConnection, invitationID uuid.UUID) (*model.OrganizationInvitation, error)
+	GetPendingInvitationByEmail(conn database.IConnection, email string) (*model.OrganizationInvitation, error)
+	GetPendingInvitations(conn database.IConnection) ([]model.OrganizationInvitation, error)
+	UpdateInvitation(conn database.IConnection, invitation *model.OrganizationInvitation) error
+	DeleteInvitationsByEmail(conn database.IConnection, email string) error
+}
+
+type invitationRepo struct {
+	DB *sql.DB
+}
+
+func NewInvitationWriter() IInvitationRepository {
+	return &invitationRepo{}
+}
+
+func (ir *invitationRepo) CreateInvitation(conn database.IConnection, invitation *model.OrganizationInvitation) error {
+	log.GetLog().Info("INFO : ", "Invitation Repo Called(CreateInvitation).")
+	return conn.GetDB().Create(invitation).Error
+}
+
+func (ir *invitationRepo) GetInvitationById(conn database.IConnection, invitationID uuid.UUID) (*model.OrganizationInvitation, error) {
+	var invitation model.OrganizationInvitation
+	err := conn.GetDB().Preload("Organization").First(&invitation, "id = ?", invitationID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (ir *invitationRepo) GetPendingInvitationByEmail(conn database.IConnection, email string) (*model.OrganizationInvitation, error) {
+	var invitation model.OrganizationInvitation
+	err := conn.GetDB().
+		Where("lower(email) = lower(?) AND status = ? AND expires_at > ?", email, model.InvitationPending, time.Now()).
+		First(&invitation).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetPendingInvitations lists the invitations not accepted or revoked yet, expired ones included so they can be resent
+func (ir *invitationRepo) GetPendingInvitations(conn database.IConnection) ([]model.OrganizationInvitation, error) {
+	var invitations []model.OrganizationInvitation
+	err := conn.GetDB().
+		Where("status = ?", model.InvitationPending).
+		Order("created_at desc").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (ir *invitationRepo) UpdateInvitation(conn database.IConnection, invitation *model.OrganizationInvitation) error {
+	log.GetLog().Info("INFO : ", "Invitation Repo Called(UpdateInvitation).")
+	// the preloaded organization is read only here
+	return conn.GetDB().Set("gorm:association_autoupdate", false).Set("gorm:association_autocreate", false).Save(invitation).Error
+}
+
+// DeleteInvitationsByEmail removes the invitations sent to the email in every organization
+func (ir *invitationRepo) DeleteInvitationsByEmail(conn database.IConnection, email string) error {
+	log.GetLog().Info("INFO : ", "Invitation Repo Called(DeleteInvitationsByEmail).")
+
+	return conn.GetDB().Where("lower(email) = lower(?)", email).Delete(&model.OrganizationInvitation{}).Error
+}
@@ -0,0 +1,68 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"time"
+)
+
+type IJobRunRepository interface {
+	CreateRun(conn database.IConnection, run *model.JobRun) error
+	UpdateRun(conn database.IConnection, run *model.JobRun) error
+	GetRuns(conn database.IConnection, job string, page, size int) ([]model.JobRun, int, error)
+	GetLastRuns(conn database.IConnection) ([]model.JobRun, error)
+	DeleteRunsBefore(conn database.IConnection, before time.Time) (int64, error)
+}
+
+type jobRunRepo struct {
+	DB *sql.DB
+}
+
+func NewJobRunWriter() IJobRunRepository {
+	return &jobRunRepo{}
+}
+
+func (jr *jobRunRepo) CreateRun(conn database.IConnection, run *model.JobRun) error {
+	return conn.GetDB().Create(run).Error
+}
+
+func (jr *jobRunRepo) UpdateRun(conn database.IConnection, run *model.JobRun) error {
+	return conn.GetDB().Model(run).UpdateColumns(map[string]interface{}{
+		"finished_at": run.FinishedAt,
+		"status":      run.Status,
+		"result":      run.Result,
+		"error":       run.Error,
+	}).Error
+}
+
+// GetRuns returns a page of the runs of the job, newest first
+func (jr *jobRunRepo) GetRuns(conn database.IConnection, job string, page, size int) ([]model.JobRun, int, error) {
+	query := conn.GetDB().Model(&model.JobRun{}).Where("job = ?", job)
+
+	var total int
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []model.JobRun
+	err := query.Order("started_at desc").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&runs).Error
+	return runs, total, err
+}
+
+// GetLastRuns returns the latest run of every job
+func (jr *jobRunRepo) GetLastRuns(conn database.IConnection) ([]model.JobRun, error) {
+	var runs []model.JobRun
+	err := conn.GetDB().
+		Where("started_at = (SELECT MAX(latest.started_at) FROM job_runs latest WHERE latest.job = job_runs.job)").
+		Find(&runs).Error
+	return runs, err
+}
+
+func (jr *jobRunRepo) DeleteRunsBefore(conn database.IConnection, before time.Time) (int64, error) {
+	result := conn.GetDB().Where("started_at < ?", before).Delete(&model.JobRun{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,85 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"test-task/shared/log"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type ILoginActivityRepository interface {
+	CreateActivity(conn database.IConnection, activity *model.UserLoginActivity) error
+	GetActivities(conn database.IConnection, userID uuid.UUID, page, size int) ([]model.UserLoginActivity, int, error)
+	CountActivities(conn database.IConnection, userID uuid.UUID) (int, error)
+	HasDevice(conn database.IConnection, userID uuid.UUID, deviceHash string) (bool, error)
+	HasNetwork(conn database.IConnection, userID uuid.UUID, networkHash string) (bool, error)
+	TrimActivities(conn database.IConnection, userID uuid.UUID, keep int) error
+	DeleteActivities(conn database.IConnection, userID uuid.UUID) error
+}
+
+type loginActivityRepo struct {
+	DB *sql.DB
+}
+
+func NewLoginActivityWriter() ILoginActivityRepository {
+	return &loginActivityRepo{}
+}
+
+func (lr *loginActivityRepo) CreateActivity(conn database.IConnection, activity *model.UserLoginActivity) error {
+	log.GetLog().Info("INFO : ", "Login Activity Repo Called(CreateActivity).")
+	return conn.GetDB().Create(activity).Error
+}
+
+func (lr *loginActivityRepo) GetActivities(conn database.IConnection, userID uuid.UUID, page, size int) ([]model.UserLoginActivity, int, error) {
+	log.GetLog().Info("INFO : ", "Login Activity Repo Called(GetActivities).")
+
+	query := conn.GetDB().Model(&model.UserLoginActivity{}).Where("user_id = ?", userID)
+
+	var total int
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var activities []model.UserLoginActivity
+	err := query.Order("created_at desc").Offset((page - 1) * size).Limit(size).Find(&activities).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return activities, total, nil
+}
+
+func (lr *loginActivityRepo) CountActivities(conn database.IConnection, userID uuid.UUID) (int, error) {
+	var total int
+	err := conn.GetDB().Model(&model.UserLoginActivity{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (lr *loginActivityRepo) HasDevice(conn database.IConnection, userID uuid.UUID, deviceHash string) (bool, error) {
+	var total int
+	err := conn.GetDB().Model(&model.UserLoginActivity{}).
+		Where("user_id = ? AND device_hash = ?", userID, deviceHash).
+		Limit(1).Count(&total).Error
+	return total > 0, err
+}
+
+func (lr *loginActivityRepo) HasNetwork(conn database.IConnection, userID uuid.UUID, networkHash string) (bool, error) {
+	var total int
+	err := conn.GetDB().Model(&model.UserLoginActivity{}).
+		Where("user_id = ? AND network_hash = ?", userID, networkHash).
+		Limit(1).Count(&total).Error
+	return total > 0, err
+}
+
+// TrimActivities keeps only the newest sign-ins of the user
+func (lr *loginActivityRepo) TrimActivities(conn database.IConnection, userID uuid.UUID, keep int) error {
+	return conn.GetDB().Exec(`DELETE FROM user_login_activities WHERE user_id = ? AND id NOT IN (
+		SELECT id FROM user_login_activities WHERE user_id = ? ORDER BY created_at DESC LIMIT ?)`,
+		userID, userID, keep).Error
+}
+
+// DeleteActivities removes the whole sign-in history of the user
+func (lr *loginActivityRepo) DeleteActivities(conn database.IConnection, userID uuid.UUID) error {
+	return conn.GetDB().Where("user_id = ?", userID).Delete(&model.UserLoginActivity{}).Error
+}
@@ -0,0 +1,120 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"test-task/shared/log"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// IOrganizationRepository is. Membership methods expect a connection scoped
+// with WithTenant, the organization filter is added by the connection.
+type IOrganizationRepository interface {
+	CreateOrganization(conn database.IConnection, org *model.Organization) error
+	GetOrganizationById(conn database.IConnection, orgID uuid.UUID) (*model.Organization, error)
+	GetOrganizationBySlug(conn database.IConnection, slug string) (*model.Organization, error)
+	UpdateOrganization(conn database.IConnection, org *model.Organization) error
+	GetUserMemberships(conn database.IConnection, userID uuid.UUID) ([]model.OrganizationMember, error)
+
+	AddMember(conn database.IConnection, member *model.OrganizationMember) error
+	GetMember(conn database.IConnection, userID uuid.UUID) (*model.OrganizationMember, error)
+	GetMembers(conn database.IConnection) ([]model.OrganizationMember, error)
+	UpdateMemberRole(conn database.IConnection, userID uuid.UUID, role string) error
+	RemoveMember(conn database.IConnection, userID uuid.UUID) error
+	CountMembersWithRole(conn database.IConnection, role string) (int, error)
+}
+
+type organizationRepo struct {
+	DB *sql.DB
+}
+
+func NewOrganizationWriter() IOrganizationRepository {
+	return &organizationRepo{}
+}
+
+func (or *organizationRepo) CreateOrganization(conn database.IConnection, org *model.Organization) error {
+	log.GetLog().Info("INFO : ", "Organization Repo Called(CreateOrganization).")
+	return conn.GetDB().Create(org).Error
+}
+
+func (or *organizationRepo) GetOrganizationById(conn database.IConnection, orgID uuid.UUID) (*model.Organization, error) {
+	var org model.Organization
+	err := conn.GetDB().First(&org, "id = ?", orgID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (or *organizationRepo) GetOrganizationBySlug(conn database.IConnection, slug string) (*model.Organization, error) {
+	var org model.Organization
+	err := conn.GetDB().First(&org, "slug = ?", slug).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (or *organizationRepo) UpdateOrganization(conn database.IConnection, org *model.Organization) error {
+	log.GetLog().Info("INFO : ", "Organization Repo Called(UpdateOrganization).")
+	return conn.GetDB().Save(org).Error
+}
+
+// GetUserMemberships lists every organization of the user, oldest membership first
+func (or *organizationRepo) GetUserMemberships(conn database.IConnection, userID uuid.UUID) ([]model.OrganizationMember, error) {
+	var members []model.OrganizationMember
+	err := conn.GetDB().Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at asc").
+		Find(&members).Error
+	return members, err
+}
+
+func (or *organizationRepo) AddMember(conn database.IConnection, member *model.OrganizationMember) error {
+	log.GetLog().Info("INFO : ", "Organization Repo Called(AddMember).")
+	return conn.GetDB().Create(member).Error
+}
+
+func (or *organizationRepo) GetMember(conn database.IConnection, userID uuid.UUID) (*model.OrganizationMember, error) {
+	var member model.OrganizationMember
+	err := conn.GetDB().Where("user_id = ?", userID).First(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMembers leaves out soft deleted users, they keep their memberships until they are purged
+func (or *organizationRepo) GetMembers(conn database.IConnection) ([]model.OrganizationMember, error) {
+	var members []model.OrganizationMember
+	err := conn.GetDB().Preload("User").
+		Where("user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)").
+		Order("created_at asc").Find(&members).Error
+	return members, err
+}
+
+func (or *organizationRepo) UpdateMemberRole(conn database.IConnection, userID uuid.UUID, role string) error {
+	return conn.GetDB().Model(&model.OrganizationMember{}).Where("user_id = ?", userID).Update("role", role).Error
+}
+
+func (or *organizationRepo) RemoveMember(conn database.IConnection, userID uuid.UUID) error {
+	return conn.GetDB().Where("user_id = ?", userID).Delete(&model.OrganizationMember{}).Error
+}
+
+func (or *organizationRepo) CountMembersWithRole(conn database.IConnection, role string) (int, error) {
+	var total int
+	err := conn.GetDB().Model(&model.OrganizationMember{}).Where("role = ?", role).Count(&total).Error
+	return total, err
+}
@@ -0,0 +1,93 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type IOutboxRepository interface {
+	Append(conn database.IConnection, message *model.OutboxMessage) error
+	ClaimDue(conn database.IConnection, lockedUntil time.Time, limit int) ([]model.OutboxMessage, error)
+	MarkDispatched(conn database.IConnection, messageID uuid.UUID, dispatchedAt time.Time) error
+	MarkFailed(conn database.IConnection, message *model.OutboxMessage) error
+	DeleteDispatched(conn database.IConnection, before time.Time, limit int) (int64, error)
+	ReplacePayloads(conn database.IConnection, aggregateType, aggregateID, payload string) error
+}
+
+type outboxRepo struct {
+	DB *sql.DB
+}
+
+func NewOutboxWriter() IOutboxRepository {
+	return &outboxRepo{}
+}
+
+// Append writes the message, conn must be the transaction of the change the message is about
+func (or *outboxRepo) Append(conn database.IConnection, message *model.OutboxMessage) error {
+	return conn.GetDB().Create(message).Error
+}
+
+// ClaimDue leases the due messages that are the oldest undispatched one of their aggregate until lockedUntil,
+// in sequence order. Later messages of an aggregate wait until the ones before them are dispatched, even while
+// those are leased by another instance or waiting for a retry. It must run in a transaction, the row locks
+// keep two instances from leasing the same message and are released on commit.
+func (or *outboxRepo) ClaimDue(conn database.IConnection, lockedUntil time.Time, limit int) ([]model.OutboxMessage, error) {
+	now := time.Now()
+	var messages []model.OutboxMessage
+	err := conn.GetDB().Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Where("dispatched_at IS NULL AND next_attempt_at <= ?", now).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_messages earlier
+			WHERE earlier.aggregate_type = outbox_messages.aggregate_type
+			AND earlier.aggregate_id = outbox_messages.aggregate_id
+			AND earlier.dispatched_at IS NULL
+			AND earlier.sequence < outbox_messages.sequence)`).
+		Order("sequence asc").Limit(limit).Find(&messages).Error
+	if err != nil || len(messages) == 0 {
+		return messages, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(messages))
+	for i := range messages {
+		ids = append(ids, messages[i].ID)
+		messages[i].LockedUntil = &lockedUntil
+	}
+	err = conn.GetDB().Model(&model.OutboxMessage{}).Where("id IN (?)", ids).
+		UpdateColumn("locked_until", lockedUntil).Error
+	return messages, err
+}
+
+func (or *outboxRepo) MarkDispatched(conn database.IConnection, messageID uuid.UUID, dispatchedAt time.Time) error {
+	return conn.GetDB().Model(&model.OutboxMessage{}).Where("id = ?", messageID).
+		UpdateColumns(map[string]interface{}{"dispatched_at": dispatchedAt, "locked_until": nil}).Error
+}
+
+// MarkFailed records the failed attempt and ends the lease, the message is due again at NextAttemptAt
+func (or *outboxRepo) MarkFailed(conn database.IConnection, message *model.OutboxMessage) error {
+	return conn.GetDB().Model(&model.OutboxMessage{}).Where("id = ?", message.ID).
+		UpdateColumns(map[string]interface{}{
+			"attempts":        message.Attempts,
+			"last_error":      message.LastError,
+			"next_attempt_at": message.NextAttemptAt,
+			"locked_until":    nil,
+		}).Error
+}
+
+// DeleteDispatched removes up to limit messages dispatched before the given time and returns how many it removed
+func (or *outboxRepo) DeleteDispatched(conn database.IConnection, before time.Time, limit int) (int64, error) {
+	result := conn.GetDB().
+		Where("id IN (SELECT id FROM outbox_messages WHERE dispatched_at IS NOT NULL AND dispatched_at < ? LIMIT ?)", before, limit).
+		Delete(&model.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
+
+// ReplacePayloads sets the payload of every message of the aggregate, dispatched or not
+func (or *outboxRepo) ReplacePayloads(conn database.IConnection, aggregateType, aggregateID, payload string) error {
+	return conn.GetDB().Model(&model.OutboxMessage{}).
+		Where("aggregate_type = ? AND aggregate_id = ?", aggregateType, aggregateID).
+		UpdateColumn("payload", payload).Error
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"test-task/model"
 	"test-task/shared/database"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
@@ -13,6 +14,8 @@ import (
 type ITokenRepository interface {
 	FindTokenData(conn database.IConnection, userID uuid.UUID, token string) (*model.UserRefreshToken, error)
 	SaveRefreshToken(conn database.IConnection, refreshToken *model.UserRefreshToken) error
+	DeleteUserTokens(conn database.IConnection, userID uuid.UUID) error
+	DeleteExpiredTokens(conn database.IConnection, before time.Time, limit int) (int64, error)
 }
 
 type tokenRepo struct {
@@ -55,6 +58,9 @@ func (r *tokenRepo) SaveRefreshToken(conn database.IConnection, refreshToken *mo
 	// Here, we simply replace the existing refresh token and expiration
 	existingToken.RefreshToken = refreshToken.RefreshToken
 	existingToken.ExpiresAt = refreshToken.ExpiresAt
+	existingToken.ActiveOrgID = refreshToken.ActiveOrgID
+	existingToken.AuthMethod = refreshToken.AuthMethod
+	existingToken.DPoPJkt = refreshToken.DPoPJkt
 
 	err = conn.GetDB().Save(&existingToken).Error
 	if err != nil {
@@ -63,3 +69,22 @@ func (r *tokenRepo) SaveRefreshToken(conn database.IConnection, refreshToken *mo
 
 	return nil
 }
+
+// DeleteUserTokens removes every refresh token issued to the user
+func (r *tokenRepo) DeleteUserTokens(conn database.IConnection, userID uuid.UUID) error {
+	if err := conn.GetDB().Where("user_id = ?", userID).Delete(&model.UserRefreshToken{}).Error; err != nil {
+		return fmt.Errorf("error deleting refresh tokens: %v", err)
+	}
+	return nil
+}
+
+// DeleteExpiredTokens removes up to limit refresh tokens that expired before the given time and returns how many it removed
+func (r *tokenRepo) DeleteExpiredTokens(conn database.IConnection, before time.Time, limit int) (int64, error) {
+	result := conn.GetDB().
+		Where("id IN (SELECT id FROM user_refresh_tokens WHERE expires_at < ? LIMIT ?)", before, limit).
+		Delete(&model.UserRefreshToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error deleting expired refresh tokens: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -2,9 +2,11 @@ package v1ORM
 
 import (
 	"database/sql"
+	"fmt"
 	"test-task/model"
 	"test-task/shared/database"
 	"test-task/shared/log"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
@@ -14,6 +16,18 @@ type IUserRepository interface {
 	CreateUser(conn database.IConnection, request *model.User) error
 	GetUserByEmail(conn database.IConnection, email string) (*model.User, error)
 	GetUserById(conn database.IConnection, userID uuid.UUID) (*model.User, error)
+	UpdatePassword(conn database.IConnection, userID uuid.UUID, password string) error
+	UpdateStatus(conn database.IConnection, userID uuid.UUID, status, reason string) error
+	MarkEmailVerified(conn database.IConnection, userID uuid.UUID) error
+	IsEmailTaken(conn database.IConnection, email string) (bool, error)
+	GetUserByPhone(conn database.IConnection, phone string) (*model.User, error)
+	SetVerifiedPhone(conn database.IConnection, userID uuid.UUID, phone string) error
+
+	SoftDeleteUser(conn database.IConnection, userID uuid.UUID, reason string) error
+	GetDeletedUser(conn database.IConnection, userID uuid.UUID) (*model.User, error)
+	RestoreUser(conn database.IConnection, userID uuid.UUID, reason string) error
+	GetPurgeableUsers(conn database.IConnection, deletedBefore time.Time, limit int) ([]model.User, error)
+	PurgeUser(conn database.IConnection, userID uuid.UUID) error
 }
 
 type userRepo struct {
@@ -65,8 +79,164 @@ func (ar *userRepo) GetUserById(conn database.IConnection, userID uuid.UUID) (*m
 	result := conn.GetDB().First(&user, "id = ?", userID)
 	// Handle error
 	if result.Error != nil {
-		// Return the user if found
-		return &user, result.Error
+		// A missing user is not an error, same as GetUserByEmail
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+func (ar *userRepo) UpdatePassword(conn database.IConnection, userID uuid.UUID, password string) error {
+	log.GetLog().Info("INFO:", "User Repo Called (UpdatePassword).")
+
+	result := conn.GetDB().Model(&model.User{}).Where("id = ?", userID).Update("password", password)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (ar *userRepo) UpdateStatus(conn database.IConnection, userID uuid.UUID, status, reason string) error {
+	log.GetLog().Info("INFO:", "User Repo Called (UpdateStatus).")
+
+	result := conn.GetDB().Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"status":            status,
+		"status_reason":     reason,
+		"status_changed_at": time.Now(),
+	})
+	return result.Error
+}
+
+// MarkEmailVerified activates a user waiting for verification and records when the email was verified
+func (ar *userRepo) MarkEmailVerified(conn database.IConnection, userID uuid.UUID) error {
+	log.GetLog().Info("INFO:", "User Repo Called (MarkEmailVerified).")
+
+	now := time.Now()
+	result := conn.GetDB().Model(&model.User{}).
+		Where("id = ? AND status = ?", userID, model.UserStatusPendingVerification).
+		Updates(map[string]interface{}{
+			"status":            model.UserStatusActive,
+			"status_reason":     "",
+			"status_changed_at": now,
+			"email_verified_at": now,
+		})
+	return result.Error
+}
+
+// IsEmailTaken reports whether any user has the email, soft deleted ones included since their address is
+// kept until the purge
+func (ar *userRepo) IsEmailTaken(conn database.IConnection, email string) (bool, error) {
+	log.GetLog().Info("INFO:", "User Repo Called (IsEmailTaken).")
+
+	var count int
+	err := conn.GetDB().Unscoped().Model(&model.User{}).Where("email = ?", email).Count(&count).Error
+	return count > 0, err
+}
+
+// GetUserByPhone returns the user with the verified phone, nil when there is none
+func (ar *userRepo) GetUserByPhone(conn database.IConnection, phone string) (*model.User, error) {
+	log.GetLog().Info("INFO:", "User Repo Called (GetUserByPhone).")
+
+	var user model.User
+	err := conn.GetDB().Where("phone = ?", phone).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
 	}
 	return &user, nil
 }
+
+// SetVerifiedPhone stores the phone of the user once a passcode sent to it was entered
+func (ar *userRepo) SetVerifiedPhone(conn database.IConnection, userID uuid.UUID, phone string) error {
+	log.GetLog().Info("INFO:", "User Repo Called (SetVerifiedPhone).")
+
+	result := conn.GetDB().Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"phone":             phone,
+		"phone_verified_at": time.Now(),
+	})
+	return result.Error
+}
+
+// SoftDeleteUser marks the user deleted, the row stays until the restore window ends
+func (ar *userRepo) SoftDeleteUser(conn database.IConnection, userID uuid.UUID, reason string) error {
+	log.GetLog().Info("INFO:", "User Repo Called (SoftDeleteUser).")
+
+	now := time.Now()
+	result := conn.GetDB().Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"status":            model.UserStatusDeleted,
+		"status_reason":     reason,
+		"status_changed_at": now,
+		"deleted_at":        now,
+	})
+	return result.Error
+}
+
+// GetDeletedUser returns a soft deleted user that was not purged yet, nil when there is none
+func (ar *userRepo) GetDeletedUser(conn database.IConnection, userID uuid.UUID) (*model.User, error) {
+	log.GetLog().Info("INFO:", "User Repo Called (GetDeletedUser).")
+
+	var user model.User
+	err := conn.GetDB().Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL AND purged_at IS NULL", userID).
+		First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RestoreUser brings a soft deleted user back as active
+func (ar *userRepo) RestoreUser(conn database.IConnection, userID uuid.UUID, reason string) error {
+	log.GetLog().Info("INFO:", "User Repo Called (RestoreUser).")
+
+	result := conn.GetDB().Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND purged_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"status":            model.UserStatusActive,
+			"status_reason":     reason,
+			"status_changed_at": time.Now(),
+			"deleted_at":        nil,
+		})
+	return result.Error
+}
+
+// GetPurgeableUsers returns soft deleted users whose restore window ended, oldest first
+func (ar *userRepo) GetPurgeableUsers(conn database.IConnection, deletedBefore time.Time, limit int) ([]model.User, error) {
+	log.GetLog().Info("INFO:", "User Repo Called (GetPurgeableUsers).")
+
+	var users []model.User
+	err := conn.GetDB().Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND purged_at IS NULL", deletedBefore).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// PurgeUser replaces the personal data of a soft deleted user. The row and its id stay so the audit log
+// and foreign keys keep pointing somewhere, the email is freed to be registered again.
+func (ar *userRepo) PurgeUser(conn database.IConnection, userID uuid.UUID) error {
+	log.GetLog().Info("INFO:", "User Repo Called (PurgeUser).")
+
+	result := conn.GetDB().Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND purged_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"first_name":        "",
+			"last_name":         "",
+			"email":             fmt.Sprintf("purged-%s@deleted.invalid", userID),
+			"password":          "",
+			"status_reason":     "",
+			"email_verified_at": nil,
+			"phone":             nil,
+			"phone_verified_at": nil,
+			"purged_at":         time.Now(),
+		})
+	return result.Error
+}
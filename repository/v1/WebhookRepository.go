@@ -0,0 +1,192 @@
+package v1ORM
+
+import (
+	"database/sql"
+	"test-task/model"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+type IWebhookRepository interface {
+	CreateEndpoint(conn database.IConnection, endpoint *model.WebhookEndpoint) error
+	GetEndpoints(conn database.IConnection) ([]model.WebhookEndpoint, error)
+	GetEnabledEndpoints(conn database.IConnection) ([]model.WebhookEndpoint, error)
+	GetEndpointById(conn database.IConnection, endpointID uuid.UUID) (*model.WebhookEndpoint, error)
+	UpdateEndpoint(conn database.IConnection, endpoint *model.WebhookEndpoint) error
+	DeleteEndpoint(conn database.IConnection, endpointID uuid.UUID) error
+	RecordEndpointSuccess(conn database.IConnection, endpointID uuid.UUID) error
+	RecordEndpointFailure(conn database.IConnection, endpointID uuid.UUID, disableAfter int, reason string) (bool, error)
+
+	CreateDelivery(conn database.IConnection, delivery *model.WebhookDelivery) error
+	ClaimDueDeliveries(conn database.IConnection, heldUntil time.Time, limit int) ([]model.WebhookDelivery, error)
+	GetDeliveries(conn database.IConnection, endpointID uuid.UUID, page, size int) ([]model.WebhookDelivery, int, error)
+	GetDeliveryById(conn database.IConnection, endpointID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+	UpdateDelivery(conn database.IConnection, delivery *model.WebhookDelivery) error
+	CreateAttempt(conn database.IConnection, attempt *model.WebhookAttempt) error
+	GetAggregateDeliveries(conn database.IConnection, aggregateID string) ([]model.WebhookDelivery, error)
+	UpdateDeliveryPayload(conn database.IConnection, deliveryID uuid.UUID, payload string) error
+}
+
+type webhookRepo struct {
+	DB *sql.DB
+}
+
+func NewWebhookWriter() IWebhookRepository {
+	return &webhookRepo{}
+}
+
+func (wr *webhookRepo) CreateEndpoint(conn database.IConnection, endpoint *model.WebhookEndpoint) error {
+	log.GetLog().Info("INFO : ", "Webhook Repo Called(CreateEndpoint).")
+	return conn.GetDB().Create(endpoint).Error
+}
+
+func (wr *webhookRepo) GetEndpoints(conn database.IConnection) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := conn.GetDB().Order("created_at asc").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (wr *webhookRepo) GetEnabledEndpoints(conn database.IConnection) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := conn.GetDB().Where("enabled = ?", true).Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (wr *webhookRepo) GetEndpointById(conn database.IConnection, endpointID uuid.UUID) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	err := conn.GetDB().First(&endpoint, "id = ?", endpointID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (wr *webhookRepo) UpdateEndpoint(conn database.IConnection, endpoint *model.WebhookEndpoint) error {
+	log.GetLog().Info("INFO : ", "Webhook Repo Called(UpdateEndpoint).")
+	return conn.GetDB().Save(endpoint).Error
+}
+
+// DeleteEndpoint removes the endpoint with its deliveries and their attempts
+func (wr *webhookRepo) DeleteEndpoint(conn database.IConnection, endpointID uuid.UUID) error {
+	log.GetLog().Info("INFO : ", "Webhook Repo Called(DeleteEndpoint).")
+	db := conn.GetDB()
+	err := db.Where("delivery_id IN (SELECT id FROM webhook_deliveries WHERE endpoint_id = ?)", endpointID).
+		Delete(&model.WebhookAttempt{}).Error
+	if err != nil {
+		return err
+	}
+	if err = db.Where("endpoint_id = ?", endpointID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return db.Where("id = ?", endpointID).Delete(&model.WebhookEndpoint{}).Error
+}
+
+func (wr *webhookRepo) RecordEndpointSuccess(conn database.IConnection, endpointID uuid.UUID) error {
+	return conn.GetDB().Model(&model.WebhookEndpoint{}).Where("id = ? AND consecutive_failures > 0", endpointID).
+		UpdateColumn("consecutive_failures", 0).Error
+}
+
+// RecordEndpointFailure counts a failed attempt and disables the endpoint once disableAfter failures
+// happened in a row. It reports whether this failure disabled the endpoint.
+func (wr *webhookRepo) RecordEndpointFailure(conn database.IConnection, endpointID uuid.UUID, disableAfter int, reason string) (bool, error) {
+	db := conn.GetDB()
+	err := db.Model(&model.WebhookEndpoint{}).Where("id = ?", endpointID).
+		UpdateColumn("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&model.WebhookEndpoint{}).
+		Where("id = ? AND enabled = ? AND consecutive_failures >= ?", endpointID, true, disableAfter).
+		UpdateColumns(map[string]interface{}{"enabled": false, "disabled_at": time.Now(), "disabled_reason": reason})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (wr *webhookRepo) CreateDelivery(conn database.IConnection, delivery *model.WebhookDelivery) error {
+	return conn.GetDB().Create(delivery).Error
+}
+
+// ClaimDueDeliveries takes the pending deliveries that are due for enabled endpoints and holds them until
+// heldUntil, so other instances skip them while they are sent. It must run in a transaction.
+func (wr *webhookRepo) ClaimDueDeliveries(conn database.IConnection, heldUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := conn.GetDB().Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, time.Now()).
+		Where("endpoint_id IN (SELECT id FROM webhook_endpoints WHERE enabled = ?)", true).
+		Order("next_attempt_at asc").Limit(limit).Find(&deliveries).Error
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(deliveries))
+	for i := range deliveries {
+		ids = append(ids, deliveries[i].ID)
+		deliveries[i].NextAttemptAt = heldUntil
+	}
+	err = conn.GetDB().Model(&model.WebhookDelivery{}).Where("id IN (?)", ids).
+		UpdateColumn("next_attempt_at", heldUntil).Error
+	return deliveries, err
+}
+
+// GetDeliveries returns a page of the deliveries of the endpoint, newest first, with their attempts
+func (wr *webhookRepo) GetDeliveries(conn database.IConnection, endpointID uuid.UUID, page, size int) ([]model.WebhookDelivery, int, error) {
+	query := conn.GetDB().Model(&model.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+
+	var total int
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []model.WebhookDelivery
+	err := query.Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("attempt asc") }).
+		Order("created_at desc").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (wr *webhookRepo) GetDeliveryById(conn database.IConnection, endpointID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := conn.GetDB().Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("attempt asc") }).
+		First(&delivery, "id = ? AND endpoint_id = ?", deliveryID, endpointID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (wr *webhookRepo) UpdateDelivery(conn database.IConnection, delivery *model.WebhookDelivery) error {
+	return conn.GetDB().Model(delivery).UpdateColumns(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"delivered_at":    delivery.DeliveredAt,
+		"updated_at":      time.Now(),
+	}).Error
+}
+
+func (wr *webhookRepo) CreateAttempt(conn database.IConnection, attempt *model.WebhookAttempt) error {
+	return conn.GetDB().Create(attempt).Error
+}
+
+// GetAggregateDeliveries returns the deliveries of every event about the record, without their attempts
+func (wr *webhookRepo) GetAggregateDeliveries(conn database.IConnection, aggregateID string) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := conn.GetDB().Where("aggregate_id = ?", aggregateID).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (wr *webhookRepo) UpdateDeliveryPayload(conn database.IConnection, deliveryID uuid.UUID, payload string) error {
+	return conn.GetDB().Model(&model.WebhookDelivery{}).Where("id = ?", deliveryID).
+		UpdateColumn("payload", payload).Error
+}
@@ -0,0 +1,20 @@
+// Package openapi holds the OpenAPI document of the REST API and the page showing it. The document is
+// written by cmd/openapi from the routes and the request and response structs, do not edit it by hand.
+package openapi
+
+import _ "embed"
+
+// Spec is openapi.json
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Page is the Swagger UI page, a template taking the base URL of the swagger-ui-dist files as Assets
+//
+//go:embed docs.html
+var Page string
+
+// Script starts Swagger UI on the page, it is a file of its own so the page needs no inline script
+//
+//go:embed init.js
+var Script []byte
@@ -0,0 +1,10 @@
+// Package pages holds the HTML pages the links of the mails open. They only ask for a confirmation, the
+// form on them makes the POST request that changes something.
+package pages
+
+import _ "embed"
+
+// Action is a template taking Title, Text and, for the page asking to confirm, Action, Token and Button
+//
+//go:embed action.html
+var Action string
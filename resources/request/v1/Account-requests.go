@@ -0,0 +1,27 @@
+package v1Request
+
+type ActivityRequest struct {
+	Page int `form:"page" validate:"omitempty,min=1"`
+	Size int `form:"size" validate:"omitempty,min=1,max=100"`
+}
+
+type RevokeSessionsRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PhoneRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+}
+
+type VerifyPhoneRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+	Code  string `json:"code" validate:"required,numeric"`
+}
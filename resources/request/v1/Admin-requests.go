@@ -0,0 +1,28 @@
+package v1Request
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type CreateGroupRequest struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Description string   `json:"description" validate:"max=500"`
+	Roles       []string `json:"roles"`
+}
+
+type GroupMembersRequest struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,max=100,dive,uuid"`
+}
+
+type GroupRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type RestoreUserRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type UserStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending_verification active suspended locked deleted"`
+	Reason string `json:"reason" validate:"max=500"`
+}
@@ -0,0 +1,16 @@
+package v1Request
+
+import "time"
+
+type AuditSearchRequest struct {
+	EventType string    `form:"event_type" json:"event_type,omitempty"`
+	ActorID   string    `form:"actor_id" json:"actor_id,omitempty"`
+	TargetID  string    `form:"target_id" json:"target_id,omitempty"`
+	Outcome   string    `form:"outcome" json:"outcome,omitempty" validate:"omitempty,oneof=success failure"`
+	IPAddress string    `form:"ip_address" json:"ip_address,omitempty"`
+	RequestID string    `form:"request_id" json:"request_id,omitempty"`
+	From      time.Time `form:"from" json:"from,omitempty" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" json:"to,omitempty" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page      int       `form:"page" json:"page,omitempty" validate:"omitempty,min=1"`
+	Size      int       `form:"size" json:"size,omitempty" validate:"omitempty,min=1,max=100"`
+}
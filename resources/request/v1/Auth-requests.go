@@ -4,14 +4,47 @@ type SignUpRequest struct {
 	FirstName string `json:"first_name" validate:"required,alpha"`
 	LastName  string `json:"last_name" validate:"required,alpha"`
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=8,max=32,alphanum"`
+	Password  string `json:"password" validate:"required"`
+	// Phone is optional, a code is texted to it and it is stored once verified
+	Phone string `json:"phone" validate:"omitempty,e164"`
 }
 
 type SignInRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// OrgID selects the organization of the session, the first one of the user when empty
+	OrgID string `json:"org_id" validate:"omitempty,uuid"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type OTPRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+}
+
+type OTPSignInRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+	Code  string `json:"code" validate:"required,numeric"`
+	// OrgID selects the organization of the session, the first one of the user when empty
+	OrgID string `json:"org_id" validate:"omitempty,uuid"`
+}
+
+type IntrospectTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
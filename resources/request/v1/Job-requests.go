@@ -0,0 +1,6 @@
+package v1Request
+
+type JobRunsRequest struct {
+	Page int `form:"page" json:"page,omitempty" validate:"omitempty,min=1"`
+	Size int `form:"size" json:"size,omitempty" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,39 @@
+package v1Request
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Slug string `json:"slug" validate:"required,min=3,max=50"`
+}
+
+type SwitchOrganizationRequest struct {
+	OrgID string `json:"org_id" validate:"required,uuid"`
+}
+
+// OrganizationSettingsRequest only changes the fields that are sent, a zero TTL or
+// an empty method list restores the global App value
+type OrganizationSettingsRequest struct {
+	Name                 *string   `json:"name" validate:"omitempty,max=100"`
+	AllowedSignInMethods *[]string `json:"allowed_sign_in_methods"`
+	AccessTokenTTL       *int      `json:"access_token_ttl" validate:"omitempty,min=0,max=1440"`
+	RefreshTokenTTL      *int      `json:"refresh_token_ttl" validate:"omitempty,min=0"`
+}
+
+type OrganizationMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type InvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type InvitationTokenRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+type InvitationSignUpRequest struct {
+	Token     string `json:"token" validate:"required"`
+	FirstName string `json:"first_name" validate:"required,alpha"`
+	LastName  string `json:"last_name" validate:"required,alpha"`
+	Password  string `json:"password" validate:"required"`
+}
@@ -0,0 +1,21 @@
+package v1Request
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Events      []string `json:"events" validate:"required,min=1,dive,required"`
+	Description string   `json:"description" validate:"max=500"`
+}
+
+// UpdateWebhookRequest changes the fields that are set. Enabling a disabled endpoint resets its failure count.
+type UpdateWebhookRequest struct {
+	URL          *string  `json:"url" validate:"omitempty,url,max=2048"`
+	Events       []string `json:"events" validate:"omitempty,min=1,dive,required"`
+	Description  *string  `json:"description" validate:"omitempty,max=500"`
+	Enabled      *bool    `json:"enabled"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+type WebhookDeliveriesRequest struct {
+	Page int `form:"page" json:"page,omitempty" validate:"omitempty,min=1"`
+	Size int `form:"size" json:"size,omitempty" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,30 @@
+package v1Response
+
+import (
+	"test-task/shared/utils"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type LoginActivityResponse struct {
+	Id        uuid.UUID `json:"id"`
+	IPAddress string    `json:"ip_address"`
+	Browser   string    `json:"browser"`
+	OS        string    `json:"os"`
+	Device    string    `json:"device"`
+	Country   string    `json:"country"`
+	City      string    `json:"city"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginActivityListResponse struct {
+	Activities []LoginActivityResponse `json:"activities"`
+	Pagination utils.PageAttr          `json:"pagination"`
+}
+
+// PermissionsResponse lists the roles of the user, the ones of their groups included, and what they allow
+type PermissionsResponse struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
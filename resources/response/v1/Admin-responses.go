@@ -0,0 +1,44 @@
+package v1Response
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type ImpersonationResponse struct {
+	AccessToken string       `json:"access_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	User        UserResponse `json:"user"`
+}
+
+type GroupResponse struct {
+	Id          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Roles       []string  `json:"roles"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type GroupMemberResponse struct {
+	UserId    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type GroupDetailResponse struct {
+	GroupResponse
+	Members []GroupMemberResponse `json:"members"`
+}
+
+type GroupMembersAddedResponse struct {
+	Added int `json:"added"`
+}
+
+type UserStatusResponse struct {
+	Id     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
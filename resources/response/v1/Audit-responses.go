@@ -0,0 +1,36 @@
+package v1Response
+
+import (
+	"encoding/json"
+	"test-task/shared/utils"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type AuditEventResponse struct {
+	Id        uuid.UUID       `json:"id"`
+	Sequence  int64           `json:"sequence"`
+	EventType string          `json:"event_type"`
+	ActorID   string          `json:"actor_id"`
+	TargetID  string          `json:"target_id"`
+	IPAddress string          `json:"ip_address"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Outcome   string          `json:"outcome"`
+	Metadata  json.RawMessage `json:"metadata"`
+	Hash      string          `json:"hash"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditEventListResponse struct {
+	Events     []AuditEventResponse `json:"events"`
+	Pagination utils.PageAttr       `json:"pagination"`
+}
+
+type AuditChainResponse struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
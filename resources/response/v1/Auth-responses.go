@@ -23,14 +23,33 @@ type UserResponse struct {
 	FirstName string    `json:"first_name"`
 	LastName  string    `json:"last_name"`
 	Email     string    `json:"email"`
+	Phone     *string   `json:"phone,omitempty"`
+	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 type SigninResponse struct {
-	RefreshToken string `json:"refresh_token"`
-	AccessToken  string `json:"access_token"`
+	RefreshToken string     `json:"refresh_token"`
+	AccessToken  string     `json:"access_token"`
+	OrgId        *uuid.UUID `json:"org_id,omitempty"`
 }
 
 type RefreshTokenResponse struct {
 	AccessToken string `json:"access_token"`
 }
+
+// IntrospectionResponse tells whether an access token is still accepted, Reason explains an inactive one
+type IntrospectionResponse struct {
+	Active     bool       `json:"active"`
+	Reason     string     `json:"reason,omitempty"`
+	UserId     *uuid.UUID `json:"user_id,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Role       string     `json:"role,omitempty"`
+	OrgId      *uuid.UUID `json:"org_id,omitempty"`
+	OrgRole    string     `json:"org_role,omitempty"`
+	AuthMethod string     `json:"auth_method,omitempty"`
+	ActorId    *uuid.UUID `json:"actor_id,omitempty"`
+	TokenId    string     `json:"token_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	DpopJkt    string     `json:"dpop_jkt,omitempty"`
+}
@@ -0,0 +1,40 @@
+package v1Response
+
+import (
+	"test-task/shared/utils"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type JobListResponse struct {
+	// Leader is the instance running the jobs, empty while none leads
+	Leader   string        `json:"leader"`
+	Instance string        `json:"instance"`
+	Jobs     []JobResponse `json:"jobs"`
+}
+
+type JobResponse struct {
+	Name      string          `json:"name"`
+	Schedule  string          `json:"schedule"`
+	NextRunAt time.Time       `json:"next_run_at"`
+	LastRun   *JobRunResponse `json:"last_run"`
+}
+
+type JobRunResponse struct {
+	Id          uuid.UUID  `json:"id"`
+	Job         string     `json:"job"`
+	Instance    string     `json:"instance"`
+	Status      string     `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	DurationMs  *int64     `json:"duration_ms"`
+}
+
+type JobRunListResponse struct {
+	Runs       []JobRunResponse `json:"runs"`
+	Pagination utils.PageAttr   `json:"pagination"`
+}
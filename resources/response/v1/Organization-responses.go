@@ -0,0 +1,46 @@
+package v1Response
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type OrganizationResponse struct {
+	Id                   uuid.UUID `json:"id"`
+	Name                 string    `json:"name"`
+	Slug                 string    `json:"slug"`
+	Role                 string    `json:"role"`
+	AllowedSignInMethods []string  `json:"allowed_sign_in_methods"`
+	AccessTokenTTL       int       `json:"access_token_ttl"`
+	RefreshTokenTTL      int       `json:"refresh_token_ttl"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+type OrganizationMemberResponse struct {
+	UserId    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+type InvitationResponse struct {
+	Id        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy uuid.UUID `json:"invited_by"`
+	Expired   bool      `json:"expired"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InvitationPreviewResponse tells the invitee whether to sign in or sign up to accept
+type InvitationPreviewResponse struct {
+	OrgName    string    `json:"org_name"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	HasAccount bool      `json:"has_account"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
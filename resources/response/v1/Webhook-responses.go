@@ -0,0 +1,59 @@
+package v1Response
+
+import (
+	"test-task/shared/utils"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type WebhookResponse struct {
+	Id                  uuid.UUID  `json:"id"`
+	URL                 string     `json:"url"`
+	Description         string     `json:"description"`
+	Events              []string   `json:"events"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	CreatedBy           uuid.UUID  `json:"created_by"`
+	CreatedAt           time.Time  `json:"created_at"`
+	// Secret is only returned when the endpoint is created or its secret rotated
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDeliveryResponse struct {
+	Id            uuid.UUID                `json:"id"`
+	EventId       uuid.UUID                `json:"event_id"`
+	EventType     string                   `json:"event_type"`
+	Status        string                   `json:"status"`
+	Attempts      int                      `json:"attempts"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at"`
+	DeliveredAt   *time.Time               `json:"delivered_at"`
+	RedeliveryOf  *uuid.UUID               `json:"redelivery_of,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+	Log           []WebhookAttemptResponse `json:"attempts_log"`
+}
+
+type WebhookAttemptResponse struct {
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Pagination utils.PageAttr            `json:"pagination"`
+}
+
+// WebhookPayload is the body POSTed to the endpoints
+type WebhookPayload struct {
+	Id        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	"test-task/shared/apispec"
+	"test-task/shared/dpop"
+	"test-task/shared/utils/middleware"
+)
+
+// operation documents a route of Setup, operations is keyed by "METHOD path" the way gin lists the routes
+type operation struct {
+	id      string // operationId, the name of the controller method
+	tag     string
+	summary string
+	body    interface{} // the JSON request body
+	cookie  bool        // the body can be left out by cookie sessions, the refresh token is their cookie
+	query   interface{} // the struct bound from the query string
+	data    interface{} // the data of the success response, nil when it has none
+	status  int         // the status of a success, http.StatusOK when zero
+	auth    bool        // needs an access token, the routes of the protected group
+	captcha bool        // behind CaptchaHandler
+	dpop    bool        // behind DPoPHandler
+	session bool        // behind SessionHandler
+	client  bool        // behind IntrospectionClientHandler, needs client credentials
+}
+
+// undocumented are the routes left out of the document, they are not part of the API
+var undocumented = map[string]bool{
+	"GET /ping":                  true,
+	"GET /.well-known/jwks.json": true,
+	"GET /openapi.json":          true,
+	"GET /docs":                  true,
+	"GET /docs/init.js":          true,
+	// the pages the mail links open, their form makes the POST request of the same path
+	"GET /api/v1/account/revoke-sessions": true,
+	"GET /api/v1/account/verify-email":    true,
+}
+
+// pathParams are the formats of the path parameters, a parameter missing here is a drift
+var pathParams = map[string]string{
+	"id":          "uuid",
+	"user_id":     "uuid",
+	"delivery_id": "uuid",
+	"role":        "",
+	"name":        "",
+}
+
+var tags = []apispec.Tag{
+	{Name: "Auth", Description: "Sign-up, sign-in and the session tokens"},
+	{Name: "Account", Description: "The account of the signed in user"},
+	{Name: "Organizations", Description: "Organizations and their members, the current one is carried by the access token"},
+	{Name: "Invitations", Description: "Invitations to join an organization"},
+	{Name: "Admin", Description: "User administration"},
+	{Name: "Audit", Description: "The security audit log"},
+	{Name: "Groups", Description: "Groups granting roles to their members"},
+	{Name: "Webhooks", Description: "Outbound webhooks for user lifecycle events"},
+	{Name: "Jobs", Description: "The scheduled housekeeping jobs"},
+}
+
+var operations = map[string]operation{
+	"POST /api/v1/sign-up":                     {id: "SignUp", tag: "Auth", summary: "Register a user", body: v1req.SignUpRequest{}, captcha: true},
+	"POST /api/v1/sign-in":                     {id: "SignIn", tag: "Auth", summary: "Sign in with the password", body: v1req.SignInRequest{}, data: v1resp.SigninResponse{}, captcha: true, dpop: true, session: true},
+	"POST /api/v1/sign-in/otp/send":            {id: "SendSignInOTP", tag: "Auth", summary: "Text a sign-in code to a verified phone number", body: v1req.OTPRequest{}, captcha: true},
+	"POST /api/v1/sign-in/otp":                 {id: "SignInWithOTP", tag: "Auth", summary: "Sign in with a phone number and the texted code", body: v1req.OTPSignInRequest{}, data: v1resp.SigninResponse{}, captcha: true, dpop: true, session: true},
+	"POST /api/v1/refresh-token":               {id: "RefreshToken", tag: "Auth", summary: "Issue a new access token for the session", body: v1req.RefreshTokenRequest{}, cookie: true, data: v1resp.RefreshTokenResponse{}, dpop: true, session: true},
+	"POST /api/v1/introspect":                  {id: "IntrospectToken", tag: "Auth", summary: "Check whether an access token is still accepted", body: v1req.IntrospectTokenRequest{}, data: v1resp.IntrospectionResponse{}, client: true},
+	"POST /api/v1/password/forgot":             {id: "ForgotPassword", tag: "Auth", summary: "Request a password reset link", body: v1req.ForgotPasswordRequest{}, captcha: true},
+	"POST /api/v1/password/reset":              {id: "ResetPassword", tag: "Auth", summary: "Set a new password with a reset token", body: v1req.ResetPasswordRequest{}},
+	"GET /api/v1/user-profile":                 {id: "GetProfile", tag: "Auth", summary: "Get the signed in user", data: v1resp.UserResponse{}, auth: true},
+	"POST /api/v1/sign-out":                    {id: "SignOut", tag: "Auth", summary: "Revoke the access token until it expires", status: http.StatusNoContent, auth: true, session: true},
+	"POST /api/v1/change-password":             {id: "ChangePassword", tag: "Auth", summary: "Change the password of the signed in user, ending the other sessions", body: v1req.ChangePasswordRequest{}, data: v1resp.SigninResponse{}, auth: true, session: true},
+	"POST /api/v1/account/revoke-sessions":     {id: "RevokeSessions", tag: "Account", summary: "Revoke the sessions with the token of the link of a new device alert", body: v1req.RevokeSessionsRequest{}},
+	"POST /api/v1/account/verify-email":        {id: "VerifyEmail", tag: "Account", summary: "Verify the email address with the token of the link of the verification mail", body: v1req.VerifyEmailRequest{}},
+	"POST /api/v1/account/verify-email/resend": {id: "ResendVerification", tag: "Account", summary: "Mail a new verification link to a pending account", body: v1req.ResendVerificationRequest{}},
+	"POST /api/v1/account/phone/verify":        {id: "VerifyPhone", tag: "Account", summary: "Store a phone number with the code texted to it", body: v1req.VerifyPhoneRequest{}},
+	"GET /api/v1/account/activity":             {id: "GetActivity", tag: "Account", summary: "List the recent sign-ins of the user", query: v1req.ActivityRequest{}, data: v1resp.LoginActivityListResponse{}, auth: true},
+	"GET /api/v1/account/permissions":          {id: "GetPermissions", tag: "Account", summary: "List the roles and permissions of the user", data: v1resp.PermissionsResponse{}, auth: true},
+	"POST /api/v1/account/phone":               {id: "AddPhone", tag: "Account", summary: "Text a verification code to a new phone number", body: v1req.PhoneRequest{}, auth: true},
+	"GET /api/v1/invitations/preview":          {id: "PreviewInvitation", tag: "Invitations", summary: "Show the invitation behind an acceptance link", query: v1req.InvitationTokenRequest{}, data: v1resp.InvitationPreviewResponse{}},
+	"POST /api/v1/invitations/sign-up":         {id: "SignUpWithInvitation", tag: "Invitations", summary: "Create the account of an invitee and join the organization", body: v1req.InvitationSignUpRequest{}, data: v1resp.SigninResponse{}, dpop: true, session: true},
+	"POST /api/v1/invitations/accept":          {id: "AcceptInvitation", tag: "Invitations", summary: "Join the organization of an invitation", body: v1req.InvitationTokenRequest{}, data: v1resp.SigninResponse{}, auth: true, session: true},
+
+	"POST /api/v1/orgs":                                {id: "CreateOrganization", tag: "Organizations", summary: "Create an organization owned by the user", body: v1req.CreateOrganizationRequest{}, data: v1resp.OrganizationResponse{}, auth: true},
+	"GET /api/v1/orgs":                                 {id: "GetOrganizations", tag: "Organizations", summary: "List the organizations of the user", data: []v1resp.OrganizationResponse{}, auth: true},
+	"POST /api/v1/orgs/switch":                         {id: "SwitchOrganization", tag: "Organizations", summary: "Re-issue the tokens for another organization of the user", body: v1req.SwitchOrganizationRequest{}, data: v1resp.SigninResponse{}, auth: true, session: true},
+	"GET /api/v1/orgs/current":                         {id: "GetCurrentOrganization", tag: "Organizations", summary: "Get the active organization of the token", data: v1resp.OrganizationResponse{}, auth: true},
+	"GET /api/v1/orgs/current/members":                 {id: "GetMembers", tag: "Organizations", summary: "List the members of the active organization", data: []v1resp.OrganizationMemberResponse{}, auth: true},
+	"PATCH /api/v1/orgs/current/settings":              {id: "UpdateSettings", tag: "Organizations", summary: "Override the sign-in and token settings for the organization", body: v1req.OrganizationSettingsRequest{}, data: v1resp.OrganizationResponse{}, auth: true},
+	"PATCH /api/v1/orgs/current/members/:user_id":      {id: "UpdateMemberRole", tag: "Organizations", summary: "Change the role of a member", body: v1req.OrganizationMemberRequest{}, auth: true},
+	"DELETE /api/v1/orgs/current/members/:user_id":     {id: "RemoveOrganizationMember", tag: "Organizations", summary: "Remove a member from the organization", auth: true},
+	"POST /api/v1/orgs/current/invitations":            {id: "CreateInvitation", tag: "Invitations", summary: "Invite an email address to the active organization", body: v1req.InvitationRequest{}, data: v1resp.InvitationResponse{}, auth: true},
+	"GET /api/v1/orgs/current/invitations":             {id: "GetInvitations", tag: "Invitations", summary: "List the pending invitations of the active organization", data: []v1resp.InvitationResponse{}, auth: true},
+	"POST /api/v1/orgs/current/invitations/:id/resend": {id: "ResendInvitation", tag: "Invitations", summary: "Mail a new link of a pending invitation", data: v1resp.InvitationResponse{}, auth: true},
+	"DELETE /api/v1/orgs/current/invitations/:id":      {id: "RevokeInvitation", tag: "Invitations", summary: "Cancel a pending invitation", auth: true},
+
+	"GET /api/v1/admin/audit-events":           {id: "SearchAuditEvents", tag: "Audit", summary: "Search the security audit log", query: v1req.AuditSearchRequest{}, data: v1resp.AuditEventListResponse{}, auth: true},
+	"GET /api/v1/admin/audit-events/verify":    {id: "VerifyAuditChain", tag: "Audit", summary: "Check that the audit log has not been tampered with", data: v1resp.AuditChainResponse{}, auth: true},
+	"POST /api/v1/admin/users/:id/impersonate": {id: "Impersonate", tag: "Admin", summary: "Issue a short lived token to act as the user", body: v1req.ImpersonateRequest{}, data: v1resp.ImpersonationResponse{}, auth: true},
+	"PATCH /api/v1/admin/users/:id/status":     {id: "ChangeUserStatus", tag: "Admin", summary: "Suspend, lock, reactivate or delete a user", body: v1req.UserStatusRequest{}, data: v1resp.UserStatusResponse{}, auth: true},
+	"POST /api/v1/admin/users/:id/restore":     {id: "RestoreUser", tag: "Admin", summary: "Bring back a deleted user within the restore window", body: v1req.RestoreUserRequest{}, data: v1resp.UserStatusResponse{}, auth: true},
+
+	"POST /api/v1/admin/groups":                        {id: "CreateGroup", tag: "Groups", summary: "Create a group with its roles", body: v1req.CreateGroupRequest{}, data: v1resp.GroupResponse{}, auth: true},
+	"GET /api/v1/admin/groups":                         {id: "GetGroups", tag: "Groups", summary: "List the groups", data: []v1resp.GroupResponse{}, auth: true},
+	"GET /api/v1/admin/groups/:id":                     {id: "GetGroup", tag: "Groups", summary: "Get a group with its members", data: v1resp.GroupDetailResponse{}, auth: true},
+	"DELETE /api/v1/admin/groups/:id":                  {id: "DeleteGroup", tag: "Groups", summary: "Delete a group", auth: true},
+	"POST /api/v1/admin/groups/:id/members":            {id: "AddGroupMembers", tag: "Groups", summary: "Add users to a group", body: v1req.GroupMembersRequest{}, data: v1resp.GroupMembersAddedResponse{}, auth: true},
+	"DELETE /api/v1/admin/groups/:id/members/:user_id": {id: "RemoveGroupMember", tag: "Groups", summary: "Remove a user from a group", auth: true},
+	"POST /api/v1/admin/groups/:id/roles":              {id: "AddGroupRole", tag: "Groups", summary: "Grant a role to the members of a group", body: v1req.GroupRoleRequest{}, data: v1resp.GroupResponse{}, auth: true},
+	"DELETE /api/v1/admin/groups/:id/roles/:role":      {id: "RemoveGroupRole", tag: "Groups", summary: "Take a role away from the members of a group", auth: true},
+
+	"POST /api/v1/admin/webhooks":                                       {id: "CreateWebhook", tag: "Webhooks", summary: "Subscribe an endpoint to events", body: v1req.CreateWebhookRequest{}, data: v1resp.WebhookResponse{}, auth: true},
+	"GET /api/v1/admin/webhooks":                                        {id: "GetWebhooks", tag: "Webhooks", summary: "List the webhook endpoints", data: []v1resp.WebhookResponse{}, auth: true},
+	"GET /api/v1/admin/webhooks/:id":                                    {id: "GetWebhook", tag: "Webhooks", summary: "Get a webhook endpoint", data: v1resp.WebhookResponse{}, auth: true},
+	"PATCH /api/v1/admin/webhooks/:id":                                  {id: "UpdateWebhook", tag: "Webhooks", summary: "Change an endpoint, enable or disable it and rotate its secret", body: v1req.UpdateWebhookRequest{}, data: v1resp.WebhookResponse{}, auth: true},
+	"DELETE /api/v1/admin/webhooks/:id":                                 {id: "DeleteWebhook", tag: "Webhooks", summary: "Remove an endpoint with its delivery logs", auth: true},
+	"GET /api/v1/admin/webhooks/:id/deliveries":                         {id: "GetWebhookDeliveries", tag: "Webhooks", summary: "Get the delivery log of an endpoint", query: v1req.WebhookDeliveriesRequest{}, data: v1resp.WebhookDeliveryListResponse{}, auth: true},
+	"POST /api/v1/admin/webhooks/:id/deliveries/:delivery_id/redeliver": {id: "RedeliverWebhook", tag: "Webhooks", summary: "Send a past delivery again", data: v1resp.WebhookDeliveryResponse{}, auth: true},
+
+	"GET /api/v1/admin/jobs":            {id: "GetJobs", tag: "Jobs", summary: "List the scheduled jobs with their next and last run", data: v1resp.JobListResponse{}, auth: true},
+	"GET /api/v1/admin/jobs/:name/runs": {id: "GetJobRuns", tag: "Jobs", summary: "Get the run history of a job, newest first", query: v1req.JobRunsRequest{}, data: v1resp.JobRunListResponse{}, auth: true},
+}
+
+const description = `The REST API of the auth service.
+
+A success is answered with the message and the data of the call in an envelope, ` + "`" + `{"meta": {"res_code": 200, "message": "..."}, "data": ...}` + "`" + `.
+An invalid request is answered with HTTP 400 and ` + "`" + `{"res_code": 400, "message": "..."}` + "`" + `. The other errors found by the
+services keep HTTP 200 and tell their status in res_code, the way clients of the API have always read them.
+The middlewares answer with their HTTP status and ` + "`" + `{"message": "...", "status": ...}` + "`" + `.
+
+Access tokens live App.AccessTokenTTL minutes, 15 by default, and refresh tokens App.RefreshTokenTTL hours, 7 days by default.
+Organizations can shorten both.`
+
+// OpenAPI documents the routes of Setup with the request and response structs they use. It fails when
+// a route has no operation or an operation no route, so the document can not drift from the router.
+func (rt *Routes) OpenAPI() (*apispec.Document, error) {
+	doc := apispec.NewDocument(apispec.Info{Title: "Auth Service API", Version: "v1", Description: description})
+	doc.Tags = tags
+	schemas := apispec.NewSchemas(doc)
+	addComponents(doc)
+
+	seen := map[string]bool{}
+	var missing []string
+	for _, route := range rt.router.Routes() {
+		key := route.Method + " " + route.Path
+		if undocumented[key] {
+			continue
+		}
+		op, ok := operations[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		seen[key] = true
+
+		path, params, err := openAPIPath(route.Path)
+		if err != nil {
+			return nil, err
+		}
+		doc.AddOperation(path, strings.ToLower(route.Method), op.document(schemas, params))
+	}
+
+	for key := range operations {
+		if !seen[key] {
+			missing = append(missing, key+" (no route)")
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("routes not documented in routers/api/openapi.go: %s", strings.Join(missing, ", "))
+	}
+	return doc, nil
+}
+
+// openAPIPath turns the gin parameters of the path, :id, into {id}
+func openAPIPath(route string) (string, []apispec.Parameter, error) {
+	parts := strings.Split(route, "/")
+	params := []apispec.Parameter{}
+	for i, part := range parts {
+		if !strings.HasPrefix(part, ":") {
+			continue
+		}
+		name := part[1:]
+		format, ok := pathParams[name]
+		if !ok {
+			return "", nil, fmt.Errorf("path parameter %s of %s has no format in routers/api/openapi.go", name, route)
+		}
+		parts[i] = "{" + name + "}"
+		params = append(params, apispec.Parameter{Name: name, In: "path", Required: true, Schema: &apispec.Schema{Type: "string", Format: format}})
+	}
+	return strings.Join(parts, "/"), params, nil
+}
+
+func (op operation) document(schemas *apispec.Schemas, params []apispec.Parameter) *apispec.Operation {
+	out := &apispec.Operation{
+		OperationID: op.id,
+		Summary:     op.summary,
+		Tags:        []string{op.tag},
+		Parameters:  params,
+		Responses:   map[string]apispec.Response{},
+	}
+	if op.query != nil {
+		out.Parameters = append(out.Parameters, schemas.Query(op.query)...)
+	}
+	if op.body != nil {
+		out.RequestBody = &apispec.RequestBody{
+			Required: !op.cookie,
+			Content:  jsonContent(schemas.Request(op.body)),
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status == http.StatusNoContent {
+		out.Responses["204"] = apispec.Response{Description: "Done"}
+	} else {
+		envelope := &apispec.Schema{
+			Type:       "object",
+			Required:   []string{"meta"},
+			Properties: map[string]*apispec.Schema{"meta": apispec.Ref("Meta")},
+		}
+		if op.data != nil {
+			envelope.Properties["data"] = schemas.Response(op.data)
+		}
+		out.Responses["200"] = apispec.Response{
+			Description: "Success, or an error of the service told by res_code",
+			Content:     jsonContent(&apispec.Schema{OneOf: []*apispec.Schema{envelope, apispec.Ref("Error")}}),
+		}
+	}
+	out.Responses["400"] = apispec.Response{Description: "Invalid request", Content: jsonContent(apispec.Ref("Error"))}
+
+	if op.auth {
+		out.Security = []map[string][]string{{"bearer": {}}, {"dpop": {}}}
+		out.Responses["401"] = apispec.Response{Description: "Missing, invalid or revoked access token", Content: jsonContent(apispec.Ref("MiddlewareError"))}
+		out.Responses["403"] = apispec.Response{Description: "Not allowed by the route policies", Content: jsonContent(apispec.Ref("MiddlewareError"))}
+	}
+	if op.client {
+		out.Security = []map[string][]string{{"client": {}}}
+		out.Responses["401"] = apispec.Response{Description: "Missing or wrong client credentials", Content: jsonContent(apispec.Ref("MiddlewareError"))}
+	}
+	if op.captcha {
+		out.Parameters = append(out.Parameters, header(middleware.CaptchaHeader, "The token of the solved challenge, when Captcha asks for one"))
+		out.Responses["403"] = apispec.Response{Description: "A captcha is required or failed", Content: jsonContent(apispec.Ref("MiddlewareError"))}
+	}
+	if op.dpop {
+		out.Parameters = append(out.Parameters, header(dpop.Header, "A DPoP proof, binds the issued tokens to its key"))
+	}
+	if op.session {
+		out.Parameters = append(out.Parameters,
+			header(middleware.SessionModeHeader, "cookie asks for a cookie session, the refresh token then moves into an HttpOnly cookie"),
+			header(middleware.ClientIDHeader, "Clients listed in Session.Clients always get cookie sessions"),
+			header(middleware.CSRFHeader, "The CSRF cookie, on requests using the refresh cookie"),
+		)
+	}
+	return out
+}
+
+// addComponents adds the envelopes and the security schemes shared by the operations
+func addComponents(doc *apispec.Document) {
+	code := &apispec.Schema{Type: "integer"}
+	message := &apispec.Schema{Type: "string"}
+	doc.Components.Schemas["Meta"] = &apispec.Schema{
+		Type:       "object",
+		Required:   []string{"res_code", "message"},
+		Properties: map[string]*apispec.Schema{"res_code": code, "message": message},
+	}
+	doc.Components.Schemas["Error"] = &apispec.Schema{
+		Type:       "object",
+		Required:   []string{"res_code", "message"},
+		Properties: map[string]*apispec.Schema{"res_code": code, "message": message},
+	}
+	doc.Components.Schemas["MiddlewareError"] = &apispec.Schema{
+		Type:     "object",
+		Required: []string{"message", "status"},
+		Properties: map[string]*apispec.Schema{
+			"message":          message,
+			"status":           code,
+			"error":            {Type: "string", Description: "invalid_dpop_proof or use_dpop_nonce for DPoP errors"},
+			"captcha_required": {Type: "boolean"},
+		},
+	}
+
+	doc.Components.SecuritySchemes["bearer"] = apispec.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Access token of a session, while DPoP.AllowBearer is on",
+	}
+	doc.Components.SecuritySchemes["dpop"] = apispec.SecurityScheme{
+		Type:        "http",
+		Scheme:      "DPoP",
+		Description: "Access token bound to a DPoP key, sent with a proof in the DPoP header",
+	}
+	doc.Components.SecuritySchemes["client"] = apispec.SecurityScheme{
+		Type:        "http",
+		Scheme:      "basic",
+		Description: "Id and secret of a service listed in Introspection.Clients",
+	}
+}
+
+func jsonContent(schema *apispec.Schema) map[string]apispec.MediaType {
+	return map[string]apispec.MediaType{"application/json": {Schema: schema}}
+}
+
+func header(name, description string) apispec.Parameter {
+	return apispec.Parameter{Name: name, In: "header", Description: description, Schema: &apispec.Schema{Type: "string"}}
+}
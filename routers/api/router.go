@@ -3,16 +3,25 @@ package api
 import (
 	"context"
 	"fmt"
+	"html/template"
 	"net/http"
+	"net/url"
+	v1RPC "test-task/controllers/rpc/v1"
 	v1Ctl "test-task/controllers/v1"
+	"test-task/resources/openapi"
+	"test-task/routers/rpc"
 	v1Service "test-task/services/v1"
+	"test-task/shared/apispec"
 	"test-task/shared/config"
+	"test-task/shared/denylist"
+	"test-task/shared/eventbus"
 	"test-task/shared/log"
+	"test-task/shared/policy"
+	"test-task/shared/scheduler"
+	"test-task/shared/signing"
 	"test-task/shared/utils/middleware"
 	"test-task/validator"
-	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,6 +30,8 @@ type IRoutes interface {
 	Setup()
 	Run()
 	Close(ctx context.Context) error
+	RPC() rpc.IServer
+	OpenAPI() (*apispec.Document, error)
 }
 
 // Routes is
@@ -29,18 +40,71 @@ type Routes struct {
 	server     *http.Server
 	config     config.IConfig
 	authCtl    *v1Ctl.AuthCtl
+	auditCtl   *v1Ctl.AuditCtl
+	accountCtl *v1Ctl.AccountCtl
+	adminCtl   *v1Ctl.AdminCtl
+	orgCtl     *v1Ctl.OrganizationCtl
+	inviteCtl  *v1Ctl.InvitationCtl
+	groupCtl   *v1Ctl.GroupCtl
+	webhookCtl *v1Ctl.WebhookCtl
+	jobCtl     *v1Ctl.JobCtl
 	middleware middleware.IMiddleware
+	rpc        rpc.IServer
+	webhookSrv v1Service.IWebhookService
+	outbox     v1Service.IOutboxRelay
+	denylist   denylist.IDenylist
+	policies   policy.IEngine
+	keys       signing.IKeySet
+	jobs       context.Context
+	stopJobs   context.CancelFunc
 }
 
-// NewRouter is
-func NewRouter(config config.IConfig) IRoutes {
+// NewRouter is, the housekeeping jobs are registered with sched
+func NewRouter(config config.IConfig, sched scheduler.IScheduler) IRoutes {
 	validation := validator.NewAPIValidatorService()
-	authSrv := v1Service.NewAuthService()
-	middlewareSrv := middleware.NewMiddlewareService(config)
+	auditSrv := v1Service.NewAuditService()
+	otpSrv := v1Service.NewOTPService(config)
+	webhookSrv := v1Service.NewWebhookService(config, auditSrv)
+	denylistSrv := denylist.NewDenylist(config)
+	accountSrv := v1Service.NewAccountService(config, auditSrv, otpSrv, denylistSrv)
+	permissionSrv := v1Service.NewPermissionService()
+	authSrv := v1Service.NewAuthService(config, auditSrv, accountSrv, denylistSrv, otpSrv)
+	adminSrv := v1Service.NewAdminService(config, auditSrv, permissionSrv)
+	orgSrv := v1Service.NewOrganizationService(config, auditSrv)
+	inviteSrv := v1Service.NewInvitationService(config, auditSrv)
+	groupSrv := v1Service.NewGroupService(permissionSrv, auditSrv)
+	statusSrv := v1Service.NewUserStatusService(config, auditSrv, denylistSrv)
+	policyEngine := policy.NewEngine(config)
+	keys := signing.NewKeySet(config)
+	middlewareSrv := middleware.NewMiddlewareService(config, auditSrv, permissionSrv, statusSrv, denylistSrv, policyEngine, keys)
+	purgeSrv := v1Service.NewUserPurgeService(config, auditSrv)
+	eventBus := eventbus.NewRedisBus(config)
+	outboxRelay := v1Service.NewOutboxRelay(config, v1Service.NewOutboxSink(config, webhookSrv, eventBus))
+	jobSrv := v1Service.NewJobService(config, sched)
+	if err := jobSrv.RegisterJobs(purgeSrv, denylistSrv, outboxRelay); err != nil {
+		panic(err)
+	}
 
 	authCtl := v1Ctl.AuthController(validation, authSrv, middlewareSrv)
+	auditCtl := v1Ctl.AuditController(validation, auditSrv)
+	accountCtl := v1Ctl.AccountController(validation, accountSrv, permissionSrv)
+	adminCtl := v1Ctl.AdminController(validation, adminSrv, statusSrv)
+	orgCtl := v1Ctl.OrganizationController(validation, orgSrv)
+	inviteCtl := v1Ctl.InvitationController(validation, inviteSrv)
+	groupCtl := v1Ctl.GroupController(validation, groupSrv)
+	webhookCtl := v1Ctl.WebhookController(validation, webhookSrv)
+	jobCtl := v1Ctl.JobController(validation, jobSrv)
+
+	// the gRPC API shares the services, so both APIs see the same denylist and policies
+	authServer := v1RPC.AuthServerController(validation, authSrv, middlewareSrv)
+	rpcSrv := rpc.NewServer(config, authServer, middlewareSrv)
 
 	router := gin.Default()
+	// gin believes X-Forwarded-For from anyone unless told which proxies are in front of it
+	if err := router.SetTrustedProxies(config.App().TrustedProxies); err != nil {
+		panic(fmt.Sprintf("Config - App.TrustedProxies: %s", err.Error()))
+	}
+	jobs, stopJobs := context.WithCancel(context.Background())
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", config.App().Port),
@@ -52,13 +116,35 @@ func NewRouter(config config.IConfig) IRoutes {
 		server,
 		config,
 		authCtl,
+		auditCtl,
+		accountCtl,
+		adminCtl,
+		orgCtl,
+		inviteCtl,
+		groupCtl,
+		webhookCtl,
+		jobCtl,
 		middlewareSrv,
+		rpcSrv,
+		webhookSrv,
+		outboxRelay,
+		denylistSrv,
+		policyEngine,
+		keys,
+		jobs,
+		stopJobs,
 	}
 }
 
 func (rt *Routes) Run() {
 	log.GetLog().Info("", "service listen on "+rt.config.App().Port)
 
+	// background jobs live as long as the server
+	go rt.denylist.Run(rt.jobs)
+	go rt.policies.Run(rt.jobs)
+	go rt.webhookSrv.Run(rt.jobs)
+	go rt.outbox.Run(rt.jobs)
+
 	err := rt.server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		log.GetLog().Fatal("", "listen: %s\n", err)
@@ -66,40 +152,120 @@ func (rt *Routes) Run() {
 }
 
 func (rt *Routes) Close(ctx context.Context) error {
+	rt.stopJobs()
 	if rt.server != nil {
 		return rt.server.Shutdown(ctx)
 	}
 	return nil
 }
 
+// RPC is the gRPC API, run and closed next to the HTTP server
+func (rt *Routes) RPC() rpc.IServer {
+	return rt.rpc
+}
+
 func (rt *Routes) Setup() {
 	router := rt.router
 	auth := rt.authCtl
+	audit := rt.auditCtl
+	account := rt.accountCtl
+	admin := rt.adminCtl
+	org := rt.orgCtl
+	invite := rt.inviteCtl
+	group := rt.groupCtl
+	webhook := rt.webhookCtl
+	job := rt.jobCtl
 	middleware := rt.middleware
 
+	router.Use(middleware.RequestIDHandler())
+	router.Use(middleware.SecurityHeadersHandler())
 	rt.setupCors()
 	rt.setupDefaultEndpoints()
+	rt.setupKeyEndpoints()
+	rt.setupDocsEndpoints()
 
 	app := router.Group("/api/v1")
 
-	app.POST("/sign-up", auth.SignUp)
-	app.POST("/sign-in", auth.SignIn)
-	app.POST("/refresh-token", auth.RefreshToken)
+	//public routes open to bots ask for a captcha as configured in [Captcha]
+	app.POST("/sign-up", middleware.CaptchaHandler(), auth.SignUp)
+	app.POST("/sign-in", middleware.CaptchaHandler(), middleware.DPoPHandler(), middleware.SessionHandler(), auth.SignIn)
+	app.POST("/sign-in/otp/send", middleware.CaptchaHandler(), auth.SendSignInOTP)
+	app.POST("/sign-in/otp", middleware.CaptchaHandler(), middleware.DPoPHandler(), middleware.SessionHandler(), auth.SignInWithOTP)
+	app.POST("/refresh-token", middleware.DPoPHandler(), middleware.SessionHandler(), auth.RefreshToken)
+	//asked by other services, they authenticate with their client credentials of [Introspection]
+	app.POST("/introspect", middleware.IntrospectionClientHandler(), auth.IntrospectToken)
+	app.POST("/password/forgot", middleware.CaptchaHandler(), auth.ForgotPassword)
+	app.POST("/password/reset", auth.ResetPassword)
+	app.GET("/account/revoke-sessions", account.ConfirmRevokeSessions)
+	app.POST("/account/revoke-sessions", account.RevokeSessions)
+	app.GET("/account/verify-email", account.ConfirmVerifyEmail)
+	app.POST("/account/verify-email", account.VerifyEmail)
+	app.POST("/account/verify-email/resend", account.ResendVerification)
+	app.POST("/account/phone/verify", account.VerifyPhone)
+	app.GET("/invitations/preview", invite.PreviewInvitation)
+	app.POST("/invitations/sign-up", middleware.DPoPHandler(), middleware.SessionHandler(), invite.SignUpWithInvitation)
 
-	//protected route
-	app.GET("/user-profile", middleware.AuthHandler(), auth.GetProfile)
-	app.POST("/sign-out", middleware.AuthHandler(), auth.SignOut)
+	//protected routes, authorized by the route policies of [Policy] (resources/policies/routes.yaml),
+	//account and organization changes are refused to impersonation tokens whatever the policies say
+	protected := app.Group("", middleware.AuthHandler(), middleware.PolicyHandler())
+	protected.GET("/user-profile", auth.GetProfile)
+	protected.POST("/sign-out", middleware.SessionHandler(), auth.SignOut)
+	protected.POST("/change-password", middleware.ImpersonationGuard(), middleware.SessionHandler(), auth.ChangePassword)
+	protected.GET("/account/activity", account.GetActivity)
+	protected.GET("/account/permissions", account.GetPermissions)
+	protected.POST("/account/phone", middleware.ImpersonationGuard(), account.AddPhone)
+	protected.POST("/invitations/accept", middleware.ImpersonationGuard(), middleware.SessionHandler(), invite.AcceptInvitation)
+
+	//organization routes, the current organization is the one carried by the access token
+	orgApp := protected.Group("/orgs")
+	orgApp.POST("", middleware.ImpersonationGuard(), org.CreateOrganization)
+	orgApp.GET("", org.GetOrganizations)
+	orgApp.POST("/switch", middleware.ImpersonationGuard(), middleware.SessionHandler(), org.SwitchOrganization)
+	orgApp.GET("/current", org.GetCurrentOrganization)
+	orgApp.GET("/current/members", org.GetMembers)
+	orgApp.PATCH("/current/settings", org.UpdateSettings)
+	orgApp.PATCH("/current/members/:user_id", org.UpdateMemberRole)
+	orgApp.DELETE("/current/members/:user_id", org.RemoveMember)
+	orgApp.POST("/current/invitations", invite.CreateInvitation)
+	orgApp.GET("/current/invitations", invite.GetInvitations)
+	orgApp.POST("/current/invitations/:id/resend", invite.ResendInvitation)
+	orgApp.DELETE("/current/invitations/:id", invite.RevokeInvitation)
+
+	//admin routes, allowed by the permissions of the user's roles and groups
+	adminApp := protected.Group("/admin")
+	adminApp.GET("/audit-events", audit.SearchEvents)
+	adminApp.GET("/audit-events/verify", audit.VerifyChain)
+	adminApp.POST("/users/:id/impersonate", admin.Impersonate)
+	adminApp.PATCH("/users/:id/status", admin.ChangeUserStatus)
+	adminApp.POST("/users/:id/restore", admin.RestoreUser)
+
+	groupApp := adminApp.Group("/groups")
+	groupApp.POST("", group.CreateGroup)
+	groupApp.GET("", group.GetGroups)
+	groupApp.GET("/:id", group.GetGroup)
+	groupApp.DELETE("/:id", group.DeleteGroup)
+	groupApp.POST("/:id/members", group.AddMembers)
+	groupApp.DELETE("/:id/members/:user_id", group.RemoveMember)
+	groupApp.POST("/:id/roles", group.AddRole)
+	groupApp.DELETE("/:id/roles/:role", group.RemoveRole)
+
+	webhookApp := adminApp.Group("/webhooks")
+	webhookApp.POST("", webhook.CreateWebhook)
+	webhookApp.GET("", webhook.GetWebhooks)
+	webhookApp.GET("/:id", webhook.GetWebhook)
+	webhookApp.PATCH("/:id", webhook.UpdateWebhook)
+	webhookApp.DELETE("/:id", webhook.DeleteWebhook)
+	webhookApp.GET("/:id/deliveries", webhook.GetDeliveries)
+	webhookApp.POST("/:id/deliveries/:delivery_id/redeliver", webhook.Redeliver)
+
+	jobApp := adminApp.Group("/jobs")
+	jobApp.GET("", job.GetJobs)
+	jobApp.GET("/:name/runs", job.GetJobRuns)
 
 }
 
 func (rt *Routes) setupCors() {
-	rt.router.Use(cors.New(cors.Config{
-		ExposeHeaders:   []string{"Data-Length"},
-		AllowMethods:    []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:    []string{"Content-Type", "Authorization"},
-		AllowAllOrigins: true,
-		MaxAge:          12 * time.Hour,
-	}))
+	rt.router.Use(rt.middleware.CORSHandler())
 }
 
 func (rt *Routes) setupDefaultEndpoints() {
@@ -113,3 +279,44 @@ func (rt *Routes) setupDefaultEndpoints() {
 		c.JSON(200, gin.H{"message": msg})
 	})
 }
+
+// setupKeyEndpoints publishes the public keys the access tokens are signed with, for the services verifying
+// them with the authverify package
+func (rt *Routes) setupKeyEndpoints() {
+	cacheControl := fmt.Sprintf("public, max-age=%d", rt.config.Signing().JWKSMaxAge)
+	rt.router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", cacheControl)
+		c.JSON(http.StatusOK, rt.keys.JWKS())
+	})
+}
+
+// setupDocsEndpoints serves the OpenAPI document committed in resources/openapi and a Swagger UI page for it.
+// The page loads Swagger UI from Docs.Assets, so it gets a Content-Security-Policy of its own.
+func (rt *Routes) setupDocsEndpoints() {
+	cf := rt.config.Docs()
+	if !cf.Enabled {
+		return
+	}
+	page := template.Must(template.New("docs").Parse(openapi.Page))
+	var assets string
+	if parsed, err := url.Parse(cf.Assets); err == nil && parsed.Host != "" {
+		assets = " " + parsed.Scheme + "://" + parsed.Host
+	}
+	csp := fmt.Sprintf("default-src 'none'; script-src 'self'%[1]s; style-src 'self' 'unsafe-inline'%[1]s; "+
+		"img-src 'self' data:%[1]s; connect-src 'self'; frame-ancestors 'none'; base-uri 'none'", assets)
+
+	rt.router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", openapi.Spec)
+	})
+	rt.router.GET("/docs", func(c *gin.Context) {
+		c.Header("Content-Security-Policy", csp)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := page.Execute(c.Writer, cf); err != nil {
+			log.GetLog().Error("ERROR : ", "Docs page not rendered: %s", err.Error())
+		}
+	})
+	rt.router.GET("/docs/init.js", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/javascript; charset=utf-8", openapi.Script)
+	})
+}
@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	v1RPC "test-task/controllers/rpc/v1"
+	authv1 "test-task/resources/proto/auth/v1"
+	"test-task/shared/config"
+	"test-task/shared/log"
+	"test-task/shared/utils/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// IServer is the gRPC API, the counterpart of api.IRoutes
+type IServer interface {
+	Setup()
+	Run()
+	Close(ctx context.Context) error
+}
+
+// Server serves the gRPC API on GRPC.Port with the standard health service and, when GRPC.Reflection is on,
+// server reflection
+type Server struct {
+	server     *grpc.Server
+	health     *health.Server
+	config     config.IConfig
+	authServer *v1RPC.AuthServer
+	middleware middleware.IMiddleware
+}
+
+// NewServer is
+func NewServer(config config.IConfig, authServer *v1RPC.AuthServer, middleware middleware.IMiddleware) IServer {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		middleware.RequestInterceptor(),
+		// sign-up and sign-in ask for a captcha and issue tokens like their HTTP routes do
+		middleware.CaptchaInterceptor(
+			authv1.AuthService_SignUp_FullMethodName,
+			authv1.AuthService_SignIn_FullMethodName,
+		),
+		middleware.DPoPInterceptor(
+			authv1.AuthService_SignIn_FullMethodName,
+			authv1.AuthService_RefreshToken_FullMethodName,
+		),
+		// the calls acting for the signed in user, sign-up, sign-in and refresh are open
+		middleware.AuthInterceptor(
+			authv1.AuthService_GetProfile_FullMethodName,
+			authv1.AuthService_SignOut_FullMethodName,
+		),
+		// validation is asked by other services with their client credentials of [Introspection]
+		middleware.IntrospectionClientInterceptor(
+			authv1.AuthService_ValidateToken_FullMethodName,
+		),
+	))
+
+	return &Server{
+		server,
+		health.NewServer(),
+		config,
+		authServer,
+		middleware,
+	}
+}
+
+func (s *Server) Setup() {
+	authv1.RegisterAuthServiceServer(s.server, s.authServer)
+
+	healthpb.RegisterHealthServer(s.server, s.health)
+	s.health.SetServingStatus(authv1.AuthService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	if s.config.GRPC().Reflection {
+		reflection.Register(s.server)
+	}
+}
+
+func (s *Server) Run() {
+	if !s.config.GRPC().Enabled {
+		return
+	}
+	log.GetLog().Info("", "gRPC service listen on "+s.config.GRPC().Port)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.config.GRPC().Port))
+	if err != nil {
+		log.GetLog().Fatal("", "listen: %s\n", err)
+	}
+	if err = s.server.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+		log.GetLog().Fatal("", "listen: %s\n", err)
+	}
+}
+
+// Close reports NOT_SERVING to the health checks, so balancers stop sending calls, and waits for the
+// calls in progress. Those still running when ctx is done, long lived streams included, are cancelled.
+func (s *Server) Close(ctx context.Context) error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		log.GetLog().Info("WARN : ", "gRPC service stopped with calls still running")
+		return nil
+	}
+}
@@ -0,0 +1,364 @@
+package v1Service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	"test-task/shared/cache"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/denylist"
+	"test-task/shared/geoip"
+	"test-task/shared/log"
+	"test-task/shared/mail"
+	"test-task/shared/utils"
+	_const "test-task/shared/utils/const"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	"github.com/mssola/useragent"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	revokeSessionsPurpose = "revoke_sessions"
+	verifyEmailPurpose    = "verify_email"
+	usedActionTokenPrefix = "action_token_used_"
+)
+
+type IAccountService interface {
+	RecordSignIn(user *model.User, info u.RequestInfo)
+	GetActivity(userID uuid.UUID, req v1req.ActivityRequest) map[string]interface{}
+	RevokeSessions(ctx context.Context, req v1req.RevokeSessionsRequest, info u.RequestInfo) map[string]interface{}
+	SendVerification(user *model.User) error
+	VerifyEmail(ctx context.Context, req v1req.VerifyEmailRequest, info u.RequestInfo) map[string]interface{}
+	ResendVerification(req v1req.ResendVerificationRequest, info u.RequestInfo) map[string]interface{}
+	AddPhone(ctx context.Context, userID uuid.UUID, req v1req.PhoneRequest) map[string]interface{}
+	SendPhoneVerification(ctx context.Context, userID uuid.UUID, phone string) error
+	VerifyPhone(ctx context.Context, req v1req.VerifyPhoneRequest, info u.RequestInfo) map[string]interface{}
+}
+
+type AccountService struct {
+	Config       config.IConfig
+	ActivityRepo v1repo.ILoginActivityRepository
+	TokenRepo    v1repo.ITokenRepository
+	UserRepo     v1repo.IUserRepository
+	OutboxRepo   v1repo.IOutboxRepository
+	Mailer       mail.IMailer
+	OTP          IOTPService
+	Audit        IAuditService
+	Denylist     denylist.IDenylist
+}
+
+func NewAccountService(cf config.IConfig, auditService IAuditService, otpService IOTPService, denied denylist.IDenylist) IAccountService {
+	geoip.Open(cf)
+	return &AccountService{
+		Config:       cf,
+		ActivityRepo: v1repo.NewLoginActivityWriter(),
+		TokenRepo:    v1repo.NewTokenWriter(),
+		UserRepo:     v1repo.NewUserWriter(),
+		OutboxRepo:   v1repo.NewOutboxWriter(),
+		Mailer:       mail.NewMailer(cf),
+		OTP:          otpService,
+		Audit:        auditService,
+		Denylist:     denied,
+	}
+}
+
+// RecordSignIn stores a successful sign-in and alerts the user when it comes
+// from a device or network that was not seen before for the account
+func (as *AccountService) RecordSignIn(user *model.User, info u.RequestInfo) {
+	log.GetLog().Info("INFO : ", "Account Service Called(RecordSignIn).")
+	conn := database.NewConnection()
+
+	ua := useragent.New(info.UserAgent)
+	browser, _ := ua.Browser()
+	device := "Desktop"
+	if ua.Bot() {
+		device = "Bot"
+	} else if ua.Mobile() {
+		device = "Mobile"
+	}
+	location := geoip.Lookup(info.IPAddress)
+
+	activity := model.UserLoginActivity{
+		ID:          uuid.NewV4(),
+		UserID:      user.ID,
+		IPAddress:   info.IPAddress,
+		UserAgent:   info.UserAgent,
+		Browser:     browser,
+		OS:          ua.OSInfo().Name,
+		Device:      device,
+		Country:     location.Country,
+		City:        location.City,
+		DeviceHash:  fingerprint(browser, ua.OSInfo().Name, device),
+		NetworkHash: fingerprint(networkOf(info.IPAddress)),
+		CreatedAt:   time.Now(),
+	}
+
+	previous, err := as.ActivityRepo.CountActivities(conn, user.ID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return
+	}
+
+	// the very first sign-in has nothing to compare with
+	if previous > 0 && as.Config.Account().NewDeviceAlert {
+		knownDevice, err := as.ActivityRepo.HasDevice(conn, user.ID, activity.DeviceHash)
+		if err != nil {
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		}
+		knownNetwork, err := as.ActivityRepo.HasNetwork(conn, user.ID, activity.NetworkHash)
+		if err != nil {
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		}
+		if !knownDevice || !knownNetwork {
+			go as.sendNewDeviceAlert(*user, activity, info)
+		}
+	}
+
+	if err = as.ActivityRepo.CreateActivity(conn, &activity); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return
+	}
+	if err = as.ActivityRepo.TrimActivities(conn, user.ID, as.Config.Account().ActivityHistory); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+	}
+}
+
+func (as *AccountService) GetActivity(userID uuid.UUID, req v1req.ActivityRequest) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Account Service Called(GetActivity).")
+	conn := database.NewSlaveConnection()
+
+	page, size := req.Page, req.Size
+	if page < 1 {
+		page = _const.PageNo
+	}
+	if size < 1 {
+		size = _const.PerPageLimit
+	}
+
+	activities, total, err := as.ActivityRepo.GetActivities(conn, userID, page, size)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	resp := v1resp.LoginActivityListResponse{
+		Activities: make([]v1resp.LoginActivityResponse, 0, len(activities)),
+		Pagination: utils.PageAttr{Page: page, Size: size, Total: total, LastPage: (total + size - 1) / size},
+	}
+	for _, a := range activities {
+		resp.Activities = append(resp.Activities, v1resp.LoginActivityResponse{
+			Id:        a.ID,
+			IPAddress: a.IPAddress,
+			Browser:   a.Browser,
+			OS:        a.OS,
+			Device:    a.Device,
+			Country:   a.Country,
+			City:      a.City,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+
+	return u.ResponseSuccessWithObj(msg.ActivityFetched, resp)
+}
+
+// RevokeSessions is made for the "this wasn't me" link: every refresh token of
+// the user is deleted and access tokens issued until now stop working
+func (as *AccountService) RevokeSessions(ctx context.Context, req v1req.RevokeSessionsRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Account Service Called(RevokeSessions).")
+	conn := database.NewConnection()
+
+	userID, jti, expiresAt, err := middleware.ValidateActionToken(req.Token, revokeSessionsPurpose)
+	if err != nil {
+		log.GetLog().Info("WARN : ", "Invalid revoke sessions token: %s", err.Error())
+		as.Audit.Record(AuditEntry{EventType: model.AuditSessionsRevoked, Outcome: model.AuditFailure, Info: info,
+			Metadata: map[string]interface{}{"reason": err.Error()}})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidActionToken)
+	}
+
+	// the link works once
+	redisConn, err := cache.GetConnection()
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	first, err := redisConn.SetNX(ctx, usedActionTokenPrefix+jti, "1", time.Until(expiresAt)).Result()
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if !first {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidActionToken)
+	}
+
+	if err = endSessions(ctx, conn, as.TokenRepo, as.Denylist, userID, time.Now()); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	as.Audit.Record(AuditEntry{
+		EventType: model.AuditSessionsRevoked,
+		ActorID:   userID.String(),
+		TargetID:  userID.String(),
+		Outcome:   model.AuditSuccess,
+		Info:      info,
+	})
+
+	return u.ResponseSuccessWithObj(msg.SessionsRevoked, nil)
+}
+
+// endSessions deletes the refresh tokens of the user and makes the access tokens issued up to the given
+// second stop working
+func endSessions(ctx context.Context, conn database.IConnection, tokenRepo v1repo.ITokenRepository, denied denylist.IDenylist, userID uuid.UUID, until time.Time) error {
+	if err := tokenRepo.DeleteUserTokens(conn, userID); err != nil {
+		return err
+	}
+	return denied.RevokeSessions(ctx, userID.String(), until, until.Add(middleware.MaxAccessTokenTTL))
+}
+
+func (as *AccountService) sendNewDeviceAlert(user model.User, activity model.UserLoginActivity, info u.RequestInfo) {
+	ttl := time.Duration(as.Config.Account().RevokeLinkTTL) * time.Hour
+	token, _, err := middleware.GenerateActionToken(revokeSessionsPurpose, user.ID, ttl)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "New device alert token failed: %s", err.Error())
+		return
+	}
+
+	where := activity.IPAddress
+	if activity.City != "" || activity.Country != "" {
+		where = fmt.Sprintf("%s (%s)", strings.Trim(activity.City+", "+activity.Country, ", "), activity.IPAddress)
+	}
+	data := map[string]interface{}{
+		"FirstName": user.FirstName,
+		"Time":      activity.CreatedAt.UTC().Format(time.RFC1123),
+		"Browser":   activity.Browser,
+		"OS":        activity.OS,
+		"Device":    activity.Device,
+		"Location":  where,
+		"Link":      fmt.Sprintf("%s/api/v1/account/revoke-sessions?token=%s", as.Config.App().PublicURL, token),
+	}
+
+	outcome := model.AuditSuccess
+	if err = as.Mailer.SendTemplate(user.Email, mail.TemplateNewDeviceAlert, data); err != nil {
+		log.GetLog().Error("ERROR : ", "New device alert not sent: %s", err.Error())
+		outcome = model.AuditFailure
+	}
+	as.Audit.Record(AuditEntry{
+		EventType: model.AuditNewDeviceAlert,
+		TargetID:  user.ID.String(),
+		Outcome:   outcome,
+		Info:      info,
+	})
+}
+
+// networkOf returns the /24 (IPv4) or /48 (IPv6) network of the address
+func networkOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+func fingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendVerification is made for mailing the link that activates a pending_verification account
+func (as *AccountService) SendVerification(user *model.User) error {
+	ttl := as.Config.Account().VerificationLinkTTL
+	token, _, err := middleware.GenerateActionToken(verifyEmailPurpose, user.ID, time.Duration(ttl)*time.Hour)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{
+		"FirstName":      user.FirstName,
+		"ExpiresInHours": ttl,
+		"Link":           fmt.Sprintf("%s/api/v1/account/verify-email?token=%s", as.Config.App().PublicURL, token),
+	}
+	return as.Mailer.SendTemplate(user.Email, mail.TemplateVerification, data)
+}
+
+// VerifyEmail is made for the link of the verification mail, it activates the account
+func (as *AccountService) VerifyEmail(ctx context.Context, req v1req.VerifyEmailRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Account Service Called(VerifyEmail).")
+	conn := database.NewConnection()
+
+	userID, _, _, err := middleware.ValidateActionToken(req.Token, verifyEmailPurpose)
+	if err != nil {
+		log.GetLog().Info("WARN : ", "Invalid verification token: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidActionToken)
+	}
+
+	user, err := as.UserRepo.GetUserById(conn, userID)
+	if err != nil || user == nil {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidActionToken)
+	}
+	// the link stops working once the account left pending_verification, whichever way it did
+	if user.Status != model.UserStatusPendingVerification {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidActionToken)
+	}
+
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	if err = as.UserRepo.MarkEmailVerified(tx, userID); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	verifiedAt := time.Now()
+	user.Status, user.EmailVerifiedAt, user.UpdatedAt = model.UserStatusActive, &verifiedAt, verifiedAt
+	if err = appendUserEvent(tx, as.OutboxRepo, model.WebhookUserEmailVerified, user); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	tx.CommitTransaction()
+	clearUserStatus(ctx, as.Denylist, userID)
+
+	as.Audit.Record(AuditEntry{
+		EventType: model.AuditEmailVerified,
+		ActorID:   userID.String(),
+		TargetID:  userID.String(),
+		Outcome:   model.AuditSuccess,
+		Info:      info,
+	})
+
+	return u.ResponseSuccessWithObj(msg.EmailVerified, nil)
+}
+
+// ResendVerification is made for mailing a new verification link, the answer is the same for every email
+func (as *AccountService) ResendVerification(req v1req.ResendVerificationRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Account Service Called(ResendVerification).")
+	conn := database.NewConnection()
+
+	user, err := as.UserRepo.GetUserByEmail(conn, req.Email)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if user != nil && user.Status == model.UserStatusPendingVerification {
+		if err = as.SendVerification(user); err != nil {
+			// answering with an error only for registered emails would tell which ones are
+			log.GetLog().Error("ERROR : ", "Verification mail not sent: %s", err.Error())
+		}
+	}
+
+	return u.ResponseSuccessWithObj(msg.VerificationSent, nil)
+}
@@ -0,0 +1,108 @@
+package v1Service
+
+import (
+	"context"
+	"net/http"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type IAdminService interface {
+	ImpersonateUser(admin middleware.UserTokenData, targetID uuid.UUID, req v1req.ImpersonateRequest, info u.RequestInfo) map[string]interface{}
+}
+
+type AdminService struct {
+	Config      config.IConfig
+	UserRepo    v1repo.IUserRepository
+	Audit       IAuditService
+	Permissions IPermissionService
+}
+
+func NewAdminService(cf config.IConfig, auditService IAuditService, permissionService IPermissionService) IAdminService {
+	return &AdminService{
+		Config:      cf,
+		UserRepo:    v1repo.NewUserWriter(),
+		Audit:       auditService,
+		Permissions: permissionService,
+	}
+}
+
+// ImpersonateUser is made for issuing a short lived access token of the target user to a support admin
+func (as *AdminService) ImpersonateUser(admin middleware.UserTokenData, targetID uuid.UUID, req v1req.ImpersonateRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Admin Service Called(ImpersonateUser).")
+	conn := database.NewConnection()
+
+	entry := AuditEntry{
+		EventType: model.AuditImpersonate,
+		ActorID:   admin.Id.String(),
+		TargetID:  targetID.String(),
+		Info:      info,
+		Metadata:  map[string]interface{}{"reason": req.Reason},
+	}
+
+	// an impersonation token can not be used to start another impersonation
+	if admin.Actor != nil || admin.Id == targetID {
+		entry.Outcome = model.AuditFailure
+		as.Audit.Record(entry)
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.CannotImpersonate)
+	}
+
+	target, err := as.UserRepo.GetUserById(conn, targetID)
+	if err != nil || target == nil || target.ID == uuid.Nil {
+		log.GetLog().Info("WARN : ", "User not found.")
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+	}
+
+	// users holding any permission, directly or through a group, are never impersonated,
+	// it would hand out their privileges
+	effective, err := as.Permissions.EffectivePermissions(context.Background(), target.ID)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if target.Role == model.RoleAdmin || len(effective.Permissions) > 0 {
+		entry.Outcome = model.AuditFailure
+		as.Audit.Record(entry)
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.CannotImpersonate)
+	}
+
+	ttl := time.Duration(as.Config.App().ImpersonationTTL) * time.Minute
+	accessToken, expiresAt, err := middleware.GenerateImpersonationToken(
+		middleware.UserTokenData{Id: target.ID, Email: target.Email, Role: target.Role, CreatedAt: target.CreatedAt},
+		middleware.TokenActor{Id: admin.Id, Email: admin.Email},
+		ttl,
+		info.DPoPThumbprint,
+	)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", "Error generating impersonation token")
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	entry.Outcome = model.AuditSuccess
+	entry.Metadata["expires_at"] = expiresAt
+	as.Audit.Record(entry)
+
+	resp := v1resp.ImpersonationResponse{
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+		User: v1resp.UserResponse{
+			Id:        target.ID,
+			FirstName: target.FirstName,
+			LastName:  target.LastName,
+			Email:     target.Email,
+			CreatedAt: target.CreatedAt,
+		},
+	}
+	return u.ResponseSuccessWithObj(msg.ImpersonationStarted, resp)
+}
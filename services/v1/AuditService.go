@@ -0,0 +1,235 @@
+package v1Service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"test-task/shared/utils"
+	_const "test-task/shared/utils/const"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const auditVerifyBatchSize = 500
+
+// AuditEntry is a security event to be appended to the audit log
+type AuditEntry struct {
+	EventType string
+	ActorID   string
+	TargetID  string
+	Outcome   string
+	Metadata  map[string]interface{}
+	Info      u.RequestInfo
+}
+
+// auditEmail is what the audit log keeps of an email address. The log is hash-chained and its rows can not
+// be changed, so the address itself would outlive the purge of the user; the digest still tells the events
+// of one address apart.
+func auditEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+type IAuditService interface {
+	Record(entry AuditEntry)
+	RecordImpersonatedRequest(userData middleware.UserTokenData, info u.RequestInfo, method, path string)
+	SearchEvents(actorID uuid.UUID, req v1req.AuditSearchRequest, info u.RequestInfo) map[string]interface{}
+	VerifyChain() map[string]interface{}
+}
+
+type AuditService struct {
+	AuditRepo v1repo.IAuditRepository
+}
+
+func NewAuditService() IAuditService {
+	return &AuditService{
+		AuditRepo: v1repo.NewAuditWriter(),
+	}
+}
+
+// Record appends the entry to the audit log. Failing to audit never fails the
+// action being audited, the error is logged instead.
+func (as *AuditService) Record(entry AuditEntry) {
+	// while impersonating, the admin is the one acting
+	if entry.Info.ImpersonatorID != "" && entry.ActorID != entry.Info.ImpersonatorID {
+		if entry.Metadata == nil {
+			entry.Metadata = map[string]interface{}{}
+		}
+		entry.Metadata["on_behalf_of"] = entry.ActorID
+		entry.ActorID = entry.Info.ImpersonatorID
+	}
+
+	event := model.AuditEvent{
+		ID:        uuid.NewV4(),
+		EventType: entry.EventType,
+		ActorID:   entry.ActorID,
+		TargetID:  entry.TargetID,
+		IPAddress: entry.Info.IPAddress,
+		UserAgent: entry.Info.UserAgent,
+		RequestID: entry.Info.RequestID,
+		Outcome:   entry.Outcome,
+		CreatedAt: time.Now(),
+	}
+	if len(entry.Metadata) > 0 {
+		metadata, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			log.GetLog().Error("ERROR : ", "Audit metadata encoding failed: %s", err.Error())
+		}
+		event.Metadata = string(metadata)
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	if err := as.AuditRepo.AppendEvent(conn, &event); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Error("ERROR(from repo) : ", "Audit event %s not recorded: %s", entry.EventType, err.Error())
+		return
+	}
+	conn.CommitTransaction()
+}
+
+// RecordImpersonatedRequest is made for tagging every request made with an impersonation token
+func (as *AuditService) RecordImpersonatedRequest(userData middleware.UserTokenData, info u.RequestInfo, method, path string) {
+	as.Record(AuditEntry{
+		EventType: model.AuditImpersonatedReq,
+		ActorID:   userData.Actor.Id.String(),
+		TargetID:  userData.Id.String(),
+		Outcome:   model.AuditSuccess,
+		Metadata:  map[string]interface{}{"method": method, "path": path},
+		Info:      info,
+	})
+}
+
+func (as *AuditService) SearchEvents(actorID uuid.UUID, req v1req.AuditSearchRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Audit Service Called(SearchEvents).")
+	conn := database.NewSlaveConnection()
+
+	filter := v1repo.AuditFilter{
+		EventType: req.EventType,
+		ActorID:   req.ActorID,
+		TargetID:  req.TargetID,
+		Outcome:   req.Outcome,
+		IPAddress: req.IPAddress,
+		RequestID: req.RequestID,
+		Page:      req.Page,
+		Size:      req.Size,
+	}
+	if !req.From.IsZero() {
+		filter.From = &req.From
+	}
+	if !req.To.IsZero() {
+		filter.To = &req.To
+	}
+	if filter.Page < 1 {
+		filter.Page = _const.PageNo
+	}
+	if filter.Size < 1 {
+		filter.Size = _const.PerPageLimit
+	}
+
+	events, total, err := as.AuditRepo.SearchEvents(conn, filter)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	as.Record(AuditEntry{
+		EventType: model.AuditAdminAuditQuery,
+		ActorID:   actorID.String(),
+		Outcome:   model.AuditSuccess,
+		Metadata:  map[string]interface{}{"filter": req},
+		Info:      info,
+	})
+
+	resp := v1resp.AuditEventListResponse{
+		Events: make([]v1resp.AuditEventResponse, 0, len(events)),
+		Pagination: utils.PageAttr{
+			Page:     filter.Page,
+			Size:     filter.Size,
+			Total:    total,
+			LastPage: (total + filter.Size - 1) / filter.Size,
+		},
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, v1resp.AuditEventResponse{
+			Id:        e.ID,
+			Sequence:  e.Sequence,
+			EventType: e.EventType,
+			ActorID:   e.ActorID,
+			TargetID:  e.TargetID,
+			IPAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+			RequestID: e.RequestID,
+			Outcome:   e.Outcome,
+			Metadata:  json.RawMessage(nullIfEmpty(e.Metadata)),
+			Hash:      e.Hash,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	return u.ResponseSuccessWithObj(msg.AuditEventsFetched, resp)
+}
+
+// VerifyChain walks the whole audit log and reports the first row where the hash chain breaks
+func (as *AuditService) VerifyChain() map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Audit Service Called(VerifyChain).")
+	conn := database.NewSlaveConnection()
+
+	result := v1resp.AuditChainResponse{Valid: true}
+	prevHash := ""
+	var sequence int64
+	for {
+		events, err := as.AuditRepo.GetEventsAfter(conn, sequence, auditVerifyBatchSize)
+		if err != nil {
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+
+		for _, e := range events {
+			switch {
+			case e.Sequence != sequence+1:
+				result.Reason = msg.AuditChainGap
+			case e.PrevHash != prevHash:
+				result.Reason = msg.AuditChainLinkBroken
+			case e.ComputeHash() != e.Hash:
+				result.Reason = msg.AuditChainHashMismatch
+			}
+			if result.Reason != "" {
+				broken := sequence + 1
+				result.Valid = false
+				result.BrokenAt = &broken
+				return u.ResponseSuccessWithObj(msg.AuditChainVerified, result)
+			}
+
+			sequence = e.Sequence
+			prevHash = e.Hash
+			result.Checked++
+		}
+
+		if len(events) < auditVerifyBatchSize {
+			break
+		}
+	}
+
+	return u.ResponseSuccessWithObj(msg.AuditChainVerified, result)
+}
+
+func nullIfEmpty(s string) string {
+	if s == "" {
+		return "null"
+	}
+	return s
+}
@@ -3,85 +3,164 @@ package v1Service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"test-task/model"
 	v1repo "test-task/repository/v1"
 	v1req "test-task/resources/request/v1"
 	v1resp "test-task/resources/response/v1"
-	"test-task/shared/cache"
 	u "test-task/shared/common"
+	"test-task/shared/config"
 	"test-task/shared/database"
+	"test-task/shared/denylist"
 	"test-task/shared/log"
-	"test-task/shared/utils"
-	"test-task/shared/utils/crypto"
+	"test-task/shared/mail"
 	msg "test-task/shared/utils/message"
 	"test-task/shared/utils/middleware"
+	"test-task/shared/utils/password"
 	"time"
 
 	"net/http"
 
 	"github.com/golang-jwt/jwt/v5"
 	uuid "github.com/satori/go.uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type IAuthService interface {
-	SignUpUser(req v1req.SignUpRequest) map[string]interface{}
-	SignInUser(req v1req.SignInRequest) map[string]interface{}
+	SignUpUser(req v1req.SignUpRequest, info u.RequestInfo) map[string]interface{}
+	SignInUser(req v1req.SignInRequest, info u.RequestInfo) map[string]interface{}
 	GetUserDetails(userId uuid.UUID) map[string]interface{}
-	SignOutUser(ctx context.Context, userID uuid.UUID, expiry int, token string) map[string]interface{}
-	RefreshToken(req v1req.RefreshTokenRequest) map[string]interface{}
+	SignOutUser(ctx context.Context, userID uuid.UUID, expiry int, tokenID string, info u.RequestInfo) map[string]interface{}
+	RefreshToken(req v1req.RefreshTokenRequest, info u.RequestInfo) map[string]interface{}
+	ChangePassword(ctx context.Context, userData middleware.UserTokenData, req v1req.ChangePasswordRequest, info u.RequestInfo) map[string]interface{}
+	ForgotPassword(ctx context.Context, req v1req.ForgotPasswordRequest, info u.RequestInfo) map[string]interface{}
+	ResetPassword(ctx context.Context, req v1req.ResetPasswordRequest, info u.RequestInfo) map[string]interface{}
+	SendSignInOTP(ctx context.Context, req v1req.OTPRequest, info u.RequestInfo) map[string]interface{}
+	SignInWithOTP(ctx context.Context, req v1req.OTPSignInRequest, info u.RequestInfo) map[string]interface{}
 }
 
 type AuthService struct {
-	UserRepo  v1repo.IUserRepository
-	TokenRepo v1repo.ITokenRepository
+	Config         config.IConfig
+	UserRepo       v1repo.IUserRepository
+	TokenRepo      v1repo.ITokenRepository
+	OutboxRepo     v1repo.IOutboxRepository
+	PasswordPolicy password.IPolicy
+	PasswordHasher password.IHasher
+	Mailer         mail.IMailer
+	Audit          IAuditService
+	Account        IAccountService
+	TokenIssuer    ITokenIssuer
+	Denylist       denylist.IDenylist
+	OTP            IOTPService
 }
 
-func NewAuthService() IAuthService {
+func NewAuthService(cf config.IConfig, auditService IAuditService, accountService IAccountService, denied denylist.IDenylist, otpService IOTPService) IAuthService {
 	userRepo := v1repo.NewUserWriter()
 	tokenRepo := v1repo.NewTokenWriter()
 	return &AuthService{
-		UserRepo:  userRepo,
-		TokenRepo: tokenRepo,
+		Config:         cf,
+		UserRepo:       userRepo,
+		TokenRepo:      tokenRepo,
+		OutboxRepo:     v1repo.NewOutboxWriter(),
+		PasswordPolicy: password.NewPolicy(cf),
+		PasswordHasher: password.NewHasher(cf),
+		Mailer:         mail.NewMailer(cf),
+		Audit:          auditService,
+		Account:        accountService,
+		TokenIssuer:    NewTokenIssuer(cf),
+		Denylist:       denied,
+		OTP:            otpService,
 	}
 }
 
-func (as *AuthService) SignUpUser(req v1req.SignUpRequest) map[string]interface{} {
+func (as *AuthService) SignUpUser(req v1req.SignUpRequest, info u.RequestInfo) map[string]interface{} {
 	log.GetLog().Info("INFO : ", "Auth Service Called(SignUpUser).")
 	conn := database.NewConnection()
 	var user model.User
 
+	if err := as.PasswordPolicy.Validate(req.Password, req.FirstName, req.LastName, req.Email); err != nil {
+		log.GetLog().Info("WARN : ", "Password policy failed: %s", err.Error())
+		as.audit(model.AuditSignUp, "", "", model.AuditFailure, info, map[string]interface{}{"email_sha256": auditEmail(req.Email), "reason": err.Error()})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, err.Error())
+	}
+
 	//adding the request data to user model
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
 	user.Email = req.Email
+	user.Role = model.RoleUser
+	user.Status = model.UserStatusActive
+	if as.Config.Account().RequireEmailVerification {
+		user.Status = model.UserStatusPendingVerification
+	}
 	user.TimeStamp()
-	user.Password = utils.HashedPassword(req.Password)
+
+	hashedPassword, err := as.PasswordHasher.Hash(req.Password)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password hashing failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	user.Password = hashedPassword
 
 	user.ID = uuid.NewV1()
 
-	existingUser, err := as.UserRepo.GetUserByEmail(conn, user.Email)
+	// soft deleted users keep their email until they are purged, so they can still be restored
+	taken, err := as.UserRepo.IsEmailTaken(conn, user.Email)
 	if err != nil {
 		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidRequest)
 	}
 
-	if existingUser != nil && existingUser.ID != uuid.Nil {
+	if taken {
+		as.audit(model.AuditSignUp, "", "", model.AuditFailure, info, map[string]interface{}{"email_sha256": auditEmail(req.Email), "reason": msg.EmailInUse})
 		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.EmailInUse)
 	}
 
-	//Call article repository
-	err = as.UserRepo.CreateUser(conn, &user)
-	if err != nil {
+	if req.Phone != "" {
+		owner, err := as.UserRepo.GetUserByPhone(conn, req.Phone)
+		if err != nil {
+			return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidRequest)
+		}
+		if owner != nil {
+			as.audit(model.AuditSignUp, "", "", model.AuditFailure, info, map[string]interface{}{"email_sha256": auditEmail(req.Email), "reason": msg.PhoneInUse})
+			return u.ResponseErrorWithCode(http.StatusBadRequest, msg.PhoneInUse)
+		}
+	}
+
+	// the user and its signed_up event are committed together
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	if err = as.UserRepo.CreateUser(tx, &user); err != nil {
+		tx.RollbackTransaction()
 		log.GetLog().Info("ERROR(from repo) : ", err.Error())
 		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidRequest)
 	}
+	if err = appendUserEvent(tx, as.OutboxRepo, model.WebhookUserSignedUp, &user); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	tx.CommitTransaction()
+
+	as.audit(model.AuditSignUp, user.ID.String(), user.ID.String(), model.AuditSuccess, info, nil)
+
+	// the phone is stored once the texted code is entered
+	if req.Phone != "" {
+		if err = as.Account.SendPhoneVerification(context.Background(), user.ID, req.Phone); err != nil {
+			log.GetLog().Error("ERROR : ", "Phone verification code not sent: %s", err.Error())
+		}
+	}
+
+	if user.Status == model.UserStatusPendingVerification {
+		// the account exists either way, a lost mail is fixed with the resend endpoint
+		if err = as.Account.SendVerification(&user); err != nil {
+			log.GetLog().Error("ERROR : ", "Verification mail not sent: %s", err.Error())
+		}
+		return u.ResponseSuccessWithObj(msg.SignUpVerifyEmail, nil)
+	}
 
 	response := u.ResponseSuccessWithObj(msg.SignUpSuccess, nil)
 	return response
 }
 
-func (as *AuthService) SignInUser(req v1req.SignInRequest) map[string]interface{} {
+func (as *AuthService) SignInUser(req v1req.SignInRequest, info u.RequestInfo) map[string]interface{} {
 	log.GetLog().Info("INFO : ", "Auth Service Called(SignInUser).")
 	conn := database.NewConnection()
 
@@ -96,42 +175,53 @@ func (as *AuthService) SignInUser(req v1req.SignInRequest) map[string]interface{
 	if existingUser == nil || existingUser.ID == uuid.Nil {
 		// If user does not exist, return an error response
 		log.GetLog().Info("WARN : ", "Email not found...")
+		as.audit(model.AuditSignIn, "", "", model.AuditFailure, info, map[string]interface{}{"email_sha256": auditEmail(req.Email), "reason": msg.EmailNotRegistered})
 		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.EmailNotRegistered)
 	}
 	//  Compare the provided password with the hashed password in the database
-	err = bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(req.Password))
-	if err != nil && err == bcrypt.ErrMismatchedHashAndPassword {
+	match, rehash, err := as.PasswordHasher.Verify(req.Password, existingUser.Password)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password verification failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if !match {
 		// If passwords do not match, return an error
 		log.GetLog().Error("ERROR : ", "Password mismatch")
+		as.audit(model.AuditSignIn, "", existingUser.ID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.InvalidPassword})
 		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidPassword)
 	}
 
-	// Step 3: Generate an authentication token
-	accessToken, err := crypto.GenerateAuthToken(existingUser.ID, existingUser.Email, existingUser.CreatedAt)
-	if err != nil {
-		// If user does not exist, return an error response
-		log.GetLog().Info("ERROR Generating token : ", err.Error())
-		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InternalServer)
+	// the status is checked only after the password so it does not tell which emails are registered
+	if existingUser.Status != model.UserStatusActive {
+		log.GetLog().Info("WARN : ", "Sign in refused for status %s", existingUser.Status)
+		as.audit(model.AuditSignIn, "", existingUser.ID.String(), model.AuditFailure, info, map[string]interface{}{"reason": "status " + existingUser.Status})
+		return statusError(existingUser.Status)
 	}
 
-	refreshToken, err := middleware.GenerateRefreshToken(existingUser.ID)
-	if err != nil {
-		log.GetLog().Info("ERROR : ", "Error generating refresh token")
-		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	// Upgrade hashes made with an older algorithm or weaker parameters while the plain password is at hand
+	if rehash {
+		as.upgradePasswordHash(conn, existingUser.ID, req.Password)
 	}
 
-	refreshTokenData := &model.UserRefreshToken{
-		UserID:       existingUser.ID,
-		RefreshToken: refreshToken,
-		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	// Step 3: Generate the tokens of the requested or the default organization
+	var orgID *uuid.UUID
+	if req.OrgID != "" {
+		id := uuid.FromStringOrNil(req.OrgID)
+		orgID = &id
 	}
-
-	if err := as.TokenRepo.SaveRefreshToken(conn, refreshTokenData); err != nil {
-		log.GetLog().Info("ERROR : ", "Error saving refresh token")
-		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	issued, err := as.TokenIssuer.Issue(conn, existingUser, orgID, config.SignInPassword, info.DPoPThumbprint)
+	if err != nil {
+		return as.tokenIssueError(model.AuditSignIn, existingUser.ID, info, err)
 	}
+
+	as.audit(model.AuditSignIn, existingUser.ID.String(), existingUser.ID.String(), model.AuditSuccess, info, nil)
+	as.Account.RecordSignIn(existingUser, info)
+
 	// Step 4: Prepare the response with user details and the generated token
-	SignInResp := v1resp.SigninResponse{RefreshToken: refreshToken, AccessToken: accessToken}
+	SignInResp := v1resp.SigninResponse{RefreshToken: issued.RefreshToken, AccessToken: issued.AccessToken}
+	if issued.Organization != nil {
+		SignInResp.OrgId = &issued.Organization.ID
+	}
 
 	// Prepare the final response with success message and token
 	response := u.ResponseSuccessWithObj(msg.SignInSuccess, SignInResp)
@@ -160,29 +250,31 @@ func (as *AuthService) GetUserDetails(userId uuid.UUID) map[string]interface{} {
 		FirstName: existingUser.FirstName,
 		LastName:  existingUser.LastName,
 		Email:     existingUser.Email,
+		Phone:     existingUser.Phone,
+		Status:    existingUser.Status,
 		CreatedAt: existingUser.CreatedAt,
 	}
 
 	return u.ResponseSuccessWithObj(msg.UserProfileFetched, userData)
 }
 
-func (as *AuthService) SignOutUser(ctx context.Context, userID uuid.UUID, expiry int, token string) map[string]interface{} {
+func (as *AuthService) SignOutUser(ctx context.Context, userID uuid.UUID, expiry int, tokenID string, info u.RequestInfo) map[string]interface{} {
 	log.GetLog().Info("INFO : ", "Auth Service Called(SignOut).")
 
 	userIdString := userID.String()
-	redisKey := fmt.Sprintf("%s_%d", userIdString, expiry)
-	UnixTime := time.Unix(int64(expiry), 0)
-	expiryTime := time.Until(UnixTime)
-	//setting the cache key-value
-	err := cache.SetToken(ctx, redisKey, token, expiryTime)
+	//the token stays on the denylist until it expires
+	err := as.Denylist.Revoke(ctx, tokenID, time.Unix(int64(expiry), 0))
 	if err != nil {
 		log.GetLog().Info("ERROR : ", err.Error())
+		as.audit(model.AuditSignOut, userIdString, userIdString, model.AuditFailure, info, map[string]interface{}{"reason": err.Error()})
 		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
 	}
+
+	as.audit(model.AuditSignOut, userIdString, userIdString, model.AuditSuccess, info, nil)
 	return u.ResponseSuccessWithCode("", nil)
 }
 
-func (as *AuthService) RefreshToken(req v1req.RefreshTokenRequest) map[string]interface{} {
+func (as *AuthService) RefreshToken(req v1req.RefreshTokenRequest, info u.RequestInfo) map[string]interface{} {
 	conn := database.NewConnection()
 
 	// Validate the refresh token and extract the user ID
@@ -190,31 +282,100 @@ func (as *AuthService) RefreshToken(req v1req.RefreshTokenRequest) map[string]in
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			log.GetLog().Info("ERROR : ", "Token expired")
+			as.audit(model.AuditRefreshToken, "", "", model.AuditFailure, info, map[string]interface{}{"reason": msg.RefreshTokenExpired})
 			return u.ResponseErrorWithCode(http.StatusUnauthorized, msg.RefreshTokenExpired)
 		}
 
 		log.GetLog().Info("ERROR : ", "Invalid refresh token")
+		as.audit(model.AuditRefreshToken, "", "", model.AuditFailure, info, map[string]interface{}{"reason": msg.InvalidRefreshToken})
 		return u.ResponseErrorWithCode(http.StatusUnauthorized, msg.InvalidRefreshToken)
 	}
 
 	refreshTokenDetails, err := as.TokenRepo.FindTokenData(conn, userID, req.RefreshToken)
 	if err != nil || refreshTokenDetails == nil {
 		log.GetLog().Info("ERROR : ", "Refresh token not found in database")
+		as.audit(model.AuditRefreshToken, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.RefreshTokenNotFound})
 		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidRefreshToken)
 	}
 
+	// a bound session is renewed only with a proof made with its key, the new access token keeps the binding
+	if refreshTokenDetails.DPoPJkt != info.DPoPThumbprint && refreshTokenDetails.DPoPJkt != "" {
+		log.GetLog().Info("WARN : ", "Refresh token used without the DPoP key it is bound to")
+		as.audit(model.AuditRefreshToken, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.DPoPKeyMismatch})
+		return u.ResponseErrorWithCode(http.StatusUnauthorized, msg.DPoPKeyMismatch)
+	}
+	if refreshTokenDetails.DPoPJkt == "" && !as.Config.DPoP().AllowBearer {
+		as.audit(model.AuditRefreshToken, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.DPoPRequired})
+		return u.ResponseErrorWithCode(http.StatusUnauthorized, msg.DPoPRequired)
+	}
+
 	user, err := as.UserRepo.GetUserById(conn, userID)
 	if err != nil || user == nil {
 		log.GetLog().Info("ERROR : ", "User not found")
 		return u.ResponseErrorWithCode(http.StatusUnauthorized, msg.UserNotFound)
 	}
+	if user.Status != model.UserStatusActive {
+		log.GetLog().Info("WARN : ", "Refresh refused for status %s", user.Status)
+		as.audit(model.AuditRefreshToken, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": "status " + user.Status})
+		return statusError(user.Status)
+	}
 
-	accessToken, err := middleware.GenerateToken(middleware.UserTokenData{Id: userID, Email: user.Email, CreatedAt: user.CreatedAt})
+	// the session keeps its organization and sign-in method, the membership and the org settings are checked again
+	var orgID *uuid.UUID
+	if refreshTokenDetails.ActiveOrgID != uuid.Nil {
+		orgID = &refreshTokenDetails.ActiveOrgID
+	}
+	method := refreshTokenDetails.AuthMethod
+	if method == "" {
+		method = config.SignInPassword
+	}
+	issued, err := as.TokenIssuer.AccessToken(conn, user, orgID, method, refreshTokenDetails.DPoPJkt)
 	if err != nil {
-		log.GetLog().Info("ERROR : ", "Error generating access token")
-		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		return as.tokenIssueError(model.AuditRefreshToken, userID, info, err)
 	}
 
-	tokenResp := v1resp.RefreshTokenResponse{AccessToken: accessToken}
+	as.audit(model.AuditRefreshToken, userID.String(), userID.String(), model.AuditSuccess, info, nil)
+
+	tokenResp := v1resp.RefreshTokenResponse{AccessToken: issued.AccessToken}
 	return u.ResponseSuccessWithObj(msg.TokenRefreshSuccess, tokenResp)
 }
+
+// upgradePasswordHash replaces the stored hash of the user, a failure only costs the upgrade
+func (as *AuthService) upgradePasswordHash(conn database.IConnection, userID uuid.UUID, plain string) {
+	hashedPassword, err := as.PasswordHasher.Hash(plain)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password rehash failed: %s", err.Error())
+		return
+	}
+	if err = as.UserRepo.UpdatePassword(conn, userID, hashedPassword); err != nil {
+		log.GetLog().Error("ERROR(from repo) : ", "Password rehash not saved: %s", err.Error())
+		return
+	}
+	log.GetLog().Info("INFO : ", "Password hash upgraded for user %s", userID.String())
+}
+
+// tokenIssueError maps a TokenIssuer failure to the response and audits the refused attempt
+func (as *AuthService) tokenIssueError(eventType string, userID uuid.UUID, info u.RequestInfo, err error) map[string]interface{} {
+	switch {
+	case errors.Is(err, ErrNotOrgMember):
+		as.audit(eventType, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.NotOrgMember})
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.NotOrgMember)
+	case errors.Is(err, ErrSignInMethodNotAllowed):
+		as.audit(eventType, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.SignInMethodDenied})
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.SignInMethodDenied)
+	}
+	log.GetLog().Error("ERROR : ", "Error issuing tokens: %s", err.Error())
+	return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+}
+
+// audit is a shorthand for recording an event of the auth flows
+func (as *AuthService) audit(eventType, actorID, targetID, outcome string, info u.RequestInfo, metadata map[string]interface{}) {
+	as.Audit.Record(AuditEntry{
+		EventType: eventType,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		Outcome:   outcome,
+		Metadata:  metadata,
+		Info:      info,
+	})
+}
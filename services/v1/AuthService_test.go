@@ -0,0 +1,128 @@
+package v1Service
+
+import (
+	"net/http"
+	"testing"
+
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/signing"
+	"test-task/shared/utils/middleware"
+	"test-task/shared/utils/password"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const testUserPassword = "correct horse battery staple"
+
+// testAccount skips the sign-in history, the tests only look at the tokens
+type testAccount struct {
+	IAccountService
+}
+
+func (a *testAccount) RecordSignIn(user *model.User, info u.RequestInfo) {}
+
+func newTestAuthService(t *testing.T) (*AuthService, *model.User) {
+	t.Helper()
+	conn := database.NewConnection()
+	conn.GetDB().Delete(&model.UserRefreshToken{})
+	conn.GetDB().Unscoped().Delete(&model.User{})
+
+	cf := &testConfig{
+		app: config.App{
+			AccessTokenKey:  "access-key",
+			RefreshTokenKey: "refresh-key",
+			SignInMethods:   []string{config.SignInPassword},
+		},
+		password: config.Password{HashAlgorithm: password.AlgorithmBcrypt, BcryptCost: 4},
+		signing:  config.Signing{Algorithm: signing.HS256},
+		dpop:     config.DPoP{AllowBearer: true},
+	}
+	middleware.AccessTokenKeys = signing.NewKeySet(cf)
+	middleware.RefreshTokenKey = cf.App().RefreshTokenKey
+
+	as := &AuthService{
+		Config:         cf,
+		UserRepo:       v1repo.NewUserWriter(),
+		TokenRepo:      v1repo.NewTokenWriter(),
+		PasswordHasher: password.NewHasher(cf),
+		Audit:          &testAudit{},
+		Account:        &testAccount{},
+		TokenIssuer:    NewTokenIssuer(cf),
+	}
+
+	hashed, err := as.PasswordHasher.Hash(testUserPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: uuid.NewV4(), FirstName: "Test", LastName: "User", Email: "user@example.com", Password: hashed, Role: model.RoleUser, Status: model.UserStatusActive}
+	if err = as.UserRepo.CreateUser(conn, user); err != nil {
+		t.Fatal(err)
+	}
+	return as, user
+}
+
+// signInWithKey signs the user in with a proof of the key, an empty thumbprint is a bearer sign-in
+func signInWithKey(t *testing.T, as *AuthService, user *model.User, jkt string) string {
+	t.Helper()
+	resp := as.SignInUser(v1req.SignInRequest{Email: user.Email, Password: testUserPassword}, u.RequestInfo{DPoPThumbprint: jkt})
+	data, ok := resp["data"].(v1resp.SigninResponse)
+	if !ok {
+		t.Fatalf("sign in with key %q: %v", jkt, resp)
+	}
+	return data.RefreshToken
+}
+
+// refreshWithKey answers the status code of a refresh made with a proof of the key
+func refreshWithKey(as *AuthService, refreshToken, jkt string) int {
+	resp := as.RefreshToken(v1req.RefreshTokenRequest{RefreshToken: refreshToken}, u.RequestInfo{DPoPThumbprint: jkt})
+	if meta, ok := resp["meta"].(map[string]interface{}); ok {
+		return meta["res_code"].(int)
+	}
+	return resp["res_code"].(int)
+}
+
+func TestRefreshFollowsSignInBinding(t *testing.T) {
+	as, user := newTestAuthService(t)
+
+	// each sign-in replaces the stored session, the refresh has to follow the binding of the last one
+	steps := []struct {
+		name     string
+		jkt      string
+		accepted []string
+		refused  []string
+	}{
+		{name: "bearer", jkt: "", accepted: []string{"", "key-a"}},
+		{name: "key A", jkt: "key-a", accepted: []string{"key-a"}, refused: []string{"", "key-b"}},
+		{name: "key B", jkt: "key-b", accepted: []string{"key-b"}, refused: []string{"", "key-a"}},
+		{name: "bearer again", jkt: "", accepted: []string{"", "key-b"}},
+		{name: "key A again", jkt: "key-a", accepted: []string{"key-a"}, refused: []string{"", "key-b"}},
+	}
+	for _, step := range steps {
+		refreshToken := signInWithKey(t, as, user, step.jkt)
+
+		stored, err := as.TokenRepo.FindTokenData(database.NewConnection(), user.ID, refreshToken)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if stored.DPoPJkt != step.jkt {
+			t.Errorf("%s: stored thumbprint %q, want %q", step.name, stored.DPoPJkt, step.jkt)
+		}
+
+		for _, jkt := range step.accepted {
+			if code := refreshWithKey(as, refreshToken, jkt); code != http.StatusOK {
+				t.Errorf("%s: refresh with %q answered %d, want %d", step.name, jkt, code, http.StatusOK)
+			}
+		}
+		for _, jkt := range step.refused {
+			if code := refreshWithKey(as, refreshToken, jkt); code != http.StatusUnauthorized {
+				t.Errorf("%s: refresh with %q answered %d, want %d", step.name, jkt, code, http.StatusUnauthorized)
+			}
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package v1Service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type IGroupService interface {
+	CreateGroup(ctx context.Context, admin middleware.UserTokenData, req v1req.CreateGroupRequest, info u.RequestInfo) map[string]interface{}
+	GetGroups() map[string]interface{}
+	GetGroup(groupID uuid.UUID) map[string]interface{}
+	DeleteGroup(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, info u.RequestInfo) map[string]interface{}
+	AddMembers(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, req v1req.GroupMembersRequest, info u.RequestInfo) map[string]interface{}
+	RemoveMember(ctx context.Context, admin middleware.UserTokenData, groupID, userID uuid.UUID, info u.RequestInfo) map[string]interface{}
+	AddRole(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, req v1req.GroupRoleRequest, info u.RequestInfo) map[string]interface{}
+	RemoveRole(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, role string, info u.RequestInfo) map[string]interface{}
+}
+
+type GroupService struct {
+	GroupRepo   v1repo.IGroupRepository
+	UserRepo    v1repo.IUserRepository
+	Permissions IPermissionService
+	Audit       IAuditService
+}
+
+func NewGroupService(permissionService IPermissionService, auditService IAuditService) IGroupService {
+	return &GroupService{
+		GroupRepo:   v1repo.NewGroupWriter(),
+		UserRepo:    v1repo.NewUserWriter(),
+		Permissions: permissionService,
+		Audit:       auditService,
+	}
+}
+
+// CreateGroup is made for creating a group, optionally with its roles
+func (gs *GroupService) CreateGroup(ctx context.Context, admin middleware.UserTokenData, req v1req.CreateGroupRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(CreateGroup).")
+
+	for _, role := range req.Roles {
+		if !model.IsRole(role) {
+			return u.ResponseErrorWithCode(http.StatusBadRequest, fmt.Sprintf(msg.UnknownRole, role))
+		}
+	}
+	if resp := gs.checkGrant(ctx, admin, req.Roles...); resp != nil {
+		return resp
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	name := strings.TrimSpace(req.Name)
+	existing, err := gs.GroupRepo.GetGroupByName(conn, name)
+	if err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if existing != nil {
+		conn.RollbackTransaction()
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.GroupNameInUse)
+	}
+
+	now := time.Now()
+	group := model.Group{
+		ID:          uuid.NewV4(),
+		Name:        name,
+		Description: req.Description,
+		CreatedBy:   admin.Id,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err = gs.GroupRepo.CreateGroup(conn, &group); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	for _, role := range uniqueStrings(req.Roles) {
+		groupRole := model.GroupRole{ID: uuid.NewV4(), GroupID: group.ID, Role: role, CreatedAt: now}
+		if err = gs.GroupRepo.AddRole(conn, &groupRole); err != nil {
+			conn.RollbackTransaction()
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+		group.Roles = append(group.Roles, groupRole)
+	}
+	conn.CommitTransaction()
+
+	gs.audit(model.AuditGroupCreate, admin, group.ID.String(), info, map[string]interface{}{"name": group.Name, "roles": req.Roles})
+
+	return u.ResponseSuccessWithObj(msg.GroupCreated, groupResponse(&group))
+}
+
+// GetGroups is made for listing every group with its roles
+func (gs *GroupService) GetGroups() map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(GetGroups).")
+	conn := database.NewSlaveConnection()
+
+	groups, err := gs.GroupRepo.GetGroups(conn)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	list := make([]v1resp.GroupResponse, 0, len(groups))
+	for i := range groups {
+		list = append(list, groupResponse(&groups[i]))
+	}
+	return u.ResponseSuccessWithObj(msg.GroupsFetched, list)
+}
+
+// GetGroup is made for fetching a group with its roles and members
+func (gs *GroupService) GetGroup(groupID uuid.UUID) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(GetGroup).")
+	conn := database.NewSlaveConnection()
+
+	group, resp := gs.loadGroup(conn, groupID)
+	if resp != nil {
+		return resp
+	}
+	members, err := gs.GroupRepo.GetMembers(conn, groupID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	detail := v1resp.GroupDetailResponse{GroupResponse: groupResponse(group), Members: make([]v1resp.GroupMemberResponse, 0, len(members))}
+	for _, member := range members {
+		item := v1resp.GroupMemberResponse{UserId: member.UserID, AddedAt: member.CreatedAt}
+		if member.User != nil {
+			item.Email = member.User.Email
+			item.FirstName = member.User.FirstName
+			item.LastName = member.User.LastName
+		}
+		detail.Members = append(detail.Members, item)
+	}
+	return u.ResponseSuccessWithObj(msg.GroupFetched, detail)
+}
+
+// DeleteGroup is made for deleting a group, its members lose the group roles at once
+func (gs *GroupService) DeleteGroup(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(DeleteGroup).")
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	group, resp := gs.loadGroup(conn, groupID)
+	if resp != nil {
+		conn.RollbackTransaction()
+		return resp
+	}
+	memberIDs, err := gs.GroupRepo.GetMemberIDs(conn, groupID)
+	if err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if err = gs.GroupRepo.DeleteGroup(conn, groupID); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	conn.CommitTransaction()
+
+	gs.Permissions.Invalidate(ctx, memberIDs...)
+	gs.audit(model.AuditGroupDelete, admin, groupID.String(), info, map[string]interface{}{"name": group.Name, "members": len(memberIDs)})
+
+	return u.ResponseSuccessWithObj(msg.GroupDeleted, nil)
+}
+
+// AddMembers is made for adding users to a group, users already in it are skipped
+func (gs *GroupService) AddMembers(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, req v1req.GroupMembersRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(AddMembers).")
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	group, resp := gs.loadGroup(conn, groupID)
+	if resp != nil {
+		conn.RollbackTransaction()
+		return resp
+	}
+	if resp = gs.checkGrant(ctx, admin, groupRoles(group)...); resp != nil {
+		conn.RollbackTransaction()
+		return resp
+	}
+
+	var added []uuid.UUID
+	for _, rawID := range uniqueStrings(req.UserIDs) {
+		userID := uuid.FromStringOrNil(rawID)
+		user, err := gs.UserRepo.GetUserById(conn, userID)
+		if err != nil {
+			conn.RollbackTransaction()
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+		if user == nil {
+			conn.RollbackTransaction()
+			return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+		}
+		isMember, err := gs.GroupRepo.IsMember(conn, groupID, userID)
+		if err != nil {
+			conn.RollbackTransaction()
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+		if isMember {
+			continue
+		}
+		member := model.GroupMember{ID: uuid.NewV4(), GroupID: groupID, UserID: userID, AddedBy: admin.Id, CreatedAt: time.Now()}
+		if err = gs.GroupRepo.AddMember(conn, &member); err != nil {
+			conn.RollbackTransaction()
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+		added = append(added, userID)
+	}
+	conn.CommitTransaction()
+
+	gs.Permissions.Invalidate(ctx, added...)
+	for _, userID := range added {
+		gs.audit(model.AuditGroupMemberAdd, admin, groupID.String(), info, map[string]interface{}{"user_id": userID.String()})
+	}
+
+	return u.ResponseSuccessWithObj(msg.GroupMembersAdded, v1resp.GroupMembersAddedResponse{Added: len(added)})
+}
+
+// RemoveMember is made for removing a user from a group
+func (gs *GroupService) RemoveMember(ctx context.Context, admin middleware.UserTokenData, groupID, userID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(RemoveMember).")
+	conn := database.NewConnection()
+
+	if _, resp := gs.loadGroup(conn, groupID); resp != nil {
+		return resp
+	}
+	removed, err := gs.GroupRepo.RemoveMember(conn, groupID, userID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if !removed {
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.GroupMemberNotFound)
+	}
+
+	gs.Permissions.Invalidate(ctx, userID)
+	gs.audit(model.AuditGroupMemberDel, admin, groupID.String(), info, map[string]interface{}{"user_id": userID.String()})
+
+	return u.ResponseSuccessWithObj(msg.GroupMemberRemoved, nil)
+}
+
+// AddRole is made for granting a role to every member of a group
+func (gs *GroupService) AddRole(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, req v1req.GroupRoleRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(AddRole).")
+	if !model.IsRole(req.Role) {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, fmt.Sprintf(msg.UnknownRole, req.Role))
+	}
+	if resp := gs.checkGrant(ctx, admin, req.Role); resp != nil {
+		return resp
+	}
+	conn := database.NewConnection()
+
+	group, resp := gs.loadGroup(conn, groupID)
+	if resp != nil {
+		return resp
+	}
+	if contains(groupRoles(group), req.Role) {
+		return u.ResponseSuccessWithObj(msg.GroupRoleAdded, groupResponse(group))
+	}
+
+	groupRole := model.GroupRole{ID: uuid.NewV4(), GroupID: groupID, Role: req.Role, CreatedAt: time.Now()}
+	if err := gs.GroupRepo.AddRole(conn, &groupRole); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	group.Roles = append(group.Roles, groupRole)
+
+	gs.invalidateMembers(ctx, conn, groupID)
+	gs.audit(model.AuditGroupRoleAdd, admin, groupID.String(), info, map[string]interface{}{"role": req.Role})
+
+	return u.ResponseSuccessWithObj(msg.GroupRoleAdded, groupResponse(group))
+}
+
+// RemoveRole is made for taking a role away from every member of a group
+func (gs *GroupService) RemoveRole(ctx context.Context, admin middleware.UserTokenData, groupID uuid.UUID, role string, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Group Service Called(RemoveRole).")
+	conn := database.NewConnection()
+
+	if _, resp := gs.loadGroup(conn, groupID); resp != nil {
+		return resp
+	}
+	removed, err := gs.GroupRepo.RemoveRole(conn, groupID, role)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if !removed {
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.GroupRoleNotFound)
+	}
+
+	gs.invalidateMembers(ctx, conn, groupID)
+	gs.audit(model.AuditGroupRoleDel, admin, groupID.String(), info, map[string]interface{}{"role": role})
+
+	return u.ResponseSuccessWithObj(msg.GroupRoleRemoved, nil)
+}
+
+func (gs *GroupService) loadGroup(conn database.IConnection, groupID uuid.UUID) (*model.Group, map[string]interface{}) {
+	group, err := gs.GroupRepo.GetGroupById(conn, groupID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if group == nil {
+		return nil, u.ResponseErrorWithCode(http.StatusNotFound, msg.GroupNotFound)
+	}
+	return group, nil
+}
+
+// checkGrant keeps a group manager from handing out permissions they do not hold themselves
+func (gs *GroupService) checkGrant(ctx context.Context, admin middleware.UserTokenData, roles ...string) map[string]interface{} {
+	if len(roles) == 0 {
+		return nil
+	}
+	effective, err := gs.Permissions.EffectivePermissions(ctx, admin.Id)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	for _, perm := range model.PermissionsOf(roles...) {
+		if !effective.Has(perm) {
+			return u.ResponseErrorWithCode(http.StatusForbidden, msg.Forbidden)
+		}
+	}
+	return nil
+}
+
+func (gs *GroupService) invalidateMembers(ctx context.Context, conn database.IConnection, groupID uuid.UUID) {
+	memberIDs, err := gs.GroupRepo.GetMemberIDs(conn, groupID)
+	if err != nil {
+		log.GetLog().Error("ERROR(from repo) : ", "Group members not loaded for invalidation: %s", err.Error())
+		return
+	}
+	gs.Permissions.Invalidate(ctx, memberIDs...)
+}
+
+func (gs *GroupService) audit(eventType string, admin middleware.UserTokenData, targetID string, info u.RequestInfo, metadata map[string]interface{}) {
+	gs.Audit.Record(AuditEntry{
+		EventType: eventType,
+		ActorID:   admin.Id.String(),
+		TargetID:  targetID,
+		Outcome:   model.AuditSuccess,
+		Metadata:  metadata,
+		Info:      info,
+	})
+}
+
+func groupRoles(group *model.Group) []string {
+	roles := make([]string, 0, len(group.Roles))
+	for _, role := range group.Roles {
+		roles = append(roles, role.Role)
+	}
+	return roles
+}
+
+func groupResponse(group *model.Group) v1resp.GroupResponse {
+	return v1resp.GroupResponse{
+		Id:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		Roles:       groupRoles(group),
+		CreatedBy:   group.CreatedBy,
+		CreatedAt:   group.CreatedAt,
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	var unique []string
+	for _, v := range values {
+		if !contains(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
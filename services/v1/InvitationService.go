@@ -0,0 +1,513 @@
+package v1Service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"test-task/shared/mail"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"test-task/shared/utils/password"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const invitationPurpose = "org_invitation"
+
+type IInvitationService interface {
+	CreateInvitation(userData middleware.UserTokenData, req v1req.InvitationRequest, info u.RequestInfo) map[string]interface{}
+	GetInvitations(userData middleware.UserTokenData) map[string]interface{}
+	ResendInvitation(userData middleware.UserTokenData, invitationID uuid.UUID, info u.RequestInfo) map[string]interface{}
+	RevokeInvitation(userData middleware.UserTokenData, invitationID uuid.UUID, info u.RequestInfo) map[string]interface{}
+	PreviewInvitation(req v1req.InvitationTokenRequest) map[string]interface{}
+	AcceptInvitation(userData middleware.UserTokenData, req v1req.InvitationTokenRequest, info u.RequestInfo) map[string]interface{}
+	SignUpWithInvitation(req v1req.InvitationSignUpRequest, info u.RequestInfo) map[string]interface{}
+}
+
+type InvitationService struct {
+	Config         config.IConfig
+	InvitationRepo v1repo.IInvitationRepository
+	OrgRepo        v1repo.IOrganizationRepository
+	UserRepo       v1repo.IUserRepository
+	OutboxRepo     v1repo.IOutboxRepository
+	TokenIssuer    ITokenIssuer
+	PasswordPolicy password.IPolicy
+	PasswordHasher password.IHasher
+	Mailer         mail.IMailer
+	Audit          IAuditService
+}
+
+func NewInvitationService(cf config.IConfig, auditService IAuditService) IInvitationService {
+	return &InvitationService{
+		Config:         cf,
+		InvitationRepo: v1repo.NewInvitationWriter(),
+		OrgRepo:        v1repo.NewOrganizationWriter(),
+		UserRepo:       v1repo.NewUserWriter(),
+		OutboxRepo:     v1repo.NewOutboxWriter(),
+		TokenIssuer:    NewTokenIssuer(cf),
+		PasswordPolicy: password.NewPolicy(cf),
+		PasswordHasher: password.NewHasher(cf),
+		Mailer:         mail.NewMailer(cf),
+		Audit:          auditService,
+	}
+}
+
+// CreateInvitation is made for inviting an email address to the active organization
+func (is *InvitationService) CreateInvitation(userData middleware.UserTokenData, req v1req.InvitationRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(CreateInvitation).")
+	if userData.OrgId == nil {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+	conn := database.NewConnection()
+	tenant := conn.WithTenant(*userData.OrgId)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	if resp := is.checkInviter(tenant, userData, req.Role); resp != nil {
+		return resp
+	}
+
+	existingUser, err := is.UserRepo.GetUserByEmail(conn, email)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if existingUser != nil && existingUser.ID != uuid.Nil {
+		member, err := is.OrgRepo.GetMember(tenant, existingUser.ID)
+		if err != nil {
+			log.GetLog().Info("ERROR(from repo) : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+		if member != nil {
+			return u.ResponseErrorWithCode(http.StatusBadRequest, msg.AlreadyOrgMember)
+		}
+	}
+
+	pending, err := is.InvitationRepo.GetPendingInvitationByEmail(tenant, email)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if pending != nil {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvitationExists)
+	}
+
+	now := time.Now()
+	invitation := model.OrganizationInvitation{
+		ID:        uuid.NewV4(),
+		Email:     email,
+		Role:      req.Role,
+		InvitedBy: userData.Id,
+		Status:    model.InvitationPending,
+		CreatedAt: now,
+	}
+	token, err := is.renewToken(&invitation)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Invitation token failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if err = is.InvitationRepo.CreateInvitation(tenant, &invitation); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	if err = is.sendInvitation(conn, userData, &invitation, token); err != nil {
+		log.GetLog().Error("ERROR : ", "Invitation mail not sent: %s", err.Error())
+		is.audit(model.AuditOrgInvite, userData.Id.String(), invitation.ID.String(), model.AuditFailure, info, invitation.OrgID, map[string]interface{}{"email_sha256": auditEmail(email), "role": req.Role, "reason": err.Error()})
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	is.audit(model.AuditOrgInvite, userData.Id.String(), invitation.ID.String(), model.AuditSuccess, info, invitation.OrgID, map[string]interface{}{"email_sha256": auditEmail(email), "role": req.Role})
+
+	return u.ResponseSuccessWithObj(msg.InvitationSent, invitationResponse(&invitation))
+}
+
+// GetInvitations is made for listing the pending invitations of the active organization
+func (is *InvitationService) GetInvitations(userData middleware.UserTokenData) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(GetInvitations).")
+	if userData.OrgId == nil {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+	conn := database.NewSlaveConnection()
+
+	invitations, err := is.InvitationRepo.GetPendingInvitations(conn.WithTenant(*userData.OrgId))
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	list := make([]v1resp.InvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		list = append(list, invitationResponse(&invitations[i]))
+	}
+	return u.ResponseSuccessWithObj(msg.InvitationsFetched, list)
+}
+
+// ResendInvitation is made for mailing a fresh link, links sent before stop working
+func (is *InvitationService) ResendInvitation(userData middleware.UserTokenData, invitationID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(ResendInvitation).")
+	conn := database.NewConnection()
+
+	invitation, tenant, resp := is.managedInvitation(conn, userData, invitationID)
+	if resp != nil {
+		return resp
+	}
+
+	token, err := is.renewToken(invitation)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Invitation token failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if err = is.InvitationRepo.UpdateInvitation(tenant, invitation); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	outcome := model.AuditSuccess
+	if err = is.sendInvitation(conn, userData, invitation, token); err != nil {
+		log.GetLog().Error("ERROR : ", "Invitation mail not sent: %s", err.Error())
+		outcome = model.AuditFailure
+	}
+	is.audit(model.AuditOrgInviteResend, userData.Id.String(), invitation.ID.String(), outcome, info, invitation.OrgID, map[string]interface{}{"email_sha256": auditEmail(invitation.Email)})
+	if outcome == model.AuditFailure {
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	return u.ResponseSuccessWithObj(msg.InvitationResent, invitationResponse(invitation))
+}
+
+// RevokeInvitation is made for cancelling a pending invitation
+func (is *InvitationService) RevokeInvitation(userData middleware.UserTokenData, invitationID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(RevokeInvitation).")
+	conn := database.NewConnection()
+
+	invitation, tenant, resp := is.managedInvitation(conn, userData, invitationID)
+	if resp != nil {
+		return resp
+	}
+
+	invitation.Status = model.InvitationRevoked
+	invitation.UpdatedAt = time.Now()
+	if err := is.InvitationRepo.UpdateInvitation(tenant, invitation); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	is.audit(model.AuditOrgInviteRevoke, userData.Id.String(), invitation.ID.String(), model.AuditSuccess, info, invitation.OrgID, map[string]interface{}{"email_sha256": auditEmail(invitation.Email)})
+
+	return u.ResponseSuccessWithObj(msg.InvitationRevoked, nil)
+}
+
+// PreviewInvitation is made for the accept page, it tells whether the invitee has to sign in or sign up
+func (is *InvitationService) PreviewInvitation(req v1req.InvitationTokenRequest) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(PreviewInvitation).")
+	conn := database.NewSlaveConnection()
+
+	invitation, resp := is.invitationFromToken(conn, req.Token)
+	if resp != nil {
+		return resp
+	}
+	user, err := is.UserRepo.GetUserByEmail(conn, invitation.Email)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	preview := v1resp.InvitationPreviewResponse{
+		Email:      invitation.Email,
+		Role:       invitation.Role,
+		HasAccount: user != nil && user.ID != uuid.Nil,
+		ExpiresAt:  invitation.ExpiresAt,
+	}
+	if invitation.Organization != nil {
+		preview.OrgName = invitation.Organization.Name
+	}
+	return u.ResponseSuccessWithObj(msg.InvitationFetched, preview)
+}
+
+// AcceptInvitation is made for attaching the signed in user to the organization of the invitation.
+// The tokens returned are switched to that organization.
+func (is *InvitationService) AcceptInvitation(userData middleware.UserTokenData, req v1req.InvitationTokenRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(AcceptInvitation).")
+	conn := database.NewConnection()
+
+	invitation, resp := is.invitationFromToken(conn, req.Token)
+	if resp != nil {
+		is.audit(model.AuditOrgInviteAccept, userData.Id.String(), "", model.AuditFailure, info, uuid.Nil, map[string]interface{}{"reason": msg.InvalidInvitation})
+		return resp
+	}
+
+	user, err := is.UserRepo.GetUserById(conn, userData.Id)
+	if err != nil || user == nil {
+		log.GetLog().Info("WARN : ", "User not found.")
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		is.audit(model.AuditOrgInviteAccept, user.ID.String(), invitation.ID.String(), model.AuditFailure, info, invitation.OrgID, map[string]interface{}{"reason": msg.InvitationMismatch})
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.InvitationMismatch)
+	}
+
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	if resp = is.join(tx, user, invitation); resp != nil {
+		tx.RollbackTransaction()
+		return resp
+	}
+	tx.CommitTransaction()
+
+	is.audit(model.AuditOrgInviteAccept, user.ID.String(), invitation.ID.String(), model.AuditSuccess, info, invitation.OrgID, map[string]interface{}{"role": invitation.Role})
+
+	method := userData.AuthMethod
+	if method == "" {
+		method = config.SignInPassword
+	}
+	return is.issueTokens(conn, user, invitation.OrgID, method, info.DPoPThumbprint)
+}
+
+// SignUpWithInvitation is made for creating the account of an invitee. The email is verified by the
+// invitation link, so the account starts verified and signed in to the organization.
+func (is *InvitationService) SignUpWithInvitation(req v1req.InvitationSignUpRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Invitation Service Called(SignUpWithInvitation).")
+	conn := database.NewConnection()
+
+	invitation, resp := is.invitationFromToken(conn, req.Token)
+	if resp != nil {
+		is.audit(model.AuditOrgInviteAccept, "", "", model.AuditFailure, info, uuid.Nil, map[string]interface{}{"reason": msg.InvalidInvitation})
+		return resp
+	}
+
+	taken, err := is.UserRepo.IsEmailTaken(conn, invitation.Email)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if taken {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.AccountExists)
+	}
+
+	if err = is.PasswordPolicy.Validate(req.Password, req.FirstName, req.LastName, invitation.Email); err != nil {
+		log.GetLog().Info("WARN : ", "Password policy failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusBadRequest, err.Error())
+	}
+	hashedPassword, err := is.PasswordHasher.Hash(req.Password)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password hashing failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	now := time.Now()
+	user := model.User{
+		ID:              uuid.NewV1(),
+		FirstName:       req.FirstName,
+		LastName:        req.LastName,
+		Email:           invitation.Email,
+		Password:        hashedPassword,
+		Role:            model.RoleUser,
+		Status:          model.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+	user.TimeStamp()
+
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	if err = is.UserRepo.CreateUser(tx, &user); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidRequest)
+	}
+	if resp = is.join(tx, &user, invitation); resp != nil {
+		tx.RollbackTransaction()
+		return resp
+	}
+	if err = appendUserEvent(tx, is.OutboxRepo, model.WebhookUserSignedUp, &user); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	tx.CommitTransaction()
+
+	is.audit(model.AuditSignUp, user.ID.String(), user.ID.String(), model.AuditSuccess, info, invitation.OrgID, map[string]interface{}{"invitation_id": invitation.ID.String()})
+	is.audit(model.AuditOrgInviteAccept, user.ID.String(), invitation.ID.String(), model.AuditSuccess, info, invitation.OrgID, map[string]interface{}{"role": invitation.Role})
+
+	return is.issueTokens(conn, &user, invitation.OrgID, config.SignInPassword, info.DPoPThumbprint)
+}
+
+// join adds the user to the organization and marks the invitation accepted
+func (is *InvitationService) join(tx database.IConnection, user *model.User, invitation *model.OrganizationInvitation) map[string]interface{} {
+	tenant := tx.WithTenant(invitation.OrgID)
+
+	member, err := is.OrgRepo.GetMember(tenant, user.ID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if member != nil {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.AlreadyOrgMember)
+	}
+
+	now := time.Now()
+	member = &model.OrganizationMember{
+		ID:        uuid.NewV4(),
+		UserID:    user.ID,
+		Role:      invitation.Role,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = is.OrgRepo.AddMember(tenant, member); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	invitation.Status = model.InvitationAccepted
+	invitation.AcceptedBy = &user.ID
+	invitation.AcceptedAt = &now
+	invitation.UpdatedAt = now
+	if err = is.InvitationRepo.UpdateInvitation(tenant, invitation); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	return nil
+}
+
+func (is *InvitationService) issueTokens(conn database.IConnection, user *model.User, orgID uuid.UUID, method, jkt string) map[string]interface{} {
+	issued, err := is.TokenIssuer.Issue(conn, user, &orgID, method, jkt)
+	if err == ErrSignInMethodNotAllowed {
+		// the membership exists, the user signs in with a method the organization allows
+		return u.ResponseSuccessWithObj(msg.InvitationAccepted, nil)
+	}
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Error issuing tokens: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	resp := v1resp.SigninResponse{RefreshToken: issued.RefreshToken, AccessToken: issued.AccessToken, OrgId: &orgID}
+	return u.ResponseSuccessWithObj(msg.InvitationAccepted, resp)
+}
+
+// invitationFromToken loads the invitation of an acceptance link and checks it is the latest one mailed
+func (is *InvitationService) invitationFromToken(conn database.IConnection, token string) (*model.OrganizationInvitation, map[string]interface{}) {
+	invitationID, jti, _, err := middleware.ValidateActionToken(token, invitationPurpose)
+	if err != nil {
+		log.GetLog().Info("WARN : ", "Invalid invitation token: %s", err.Error())
+		return nil, u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidInvitation)
+	}
+	invitation, err := is.InvitationRepo.GetInvitationById(conn, invitationID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if invitation == nil || invitation.TokenID != jti || !invitation.IsPending() {
+		return nil, u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidInvitation)
+	}
+	return invitation, nil
+}
+
+// managedInvitation loads a pending invitation of the active organization the caller may manage
+func (is *InvitationService) managedInvitation(conn database.IConnection, userData middleware.UserTokenData, invitationID uuid.UUID) (*model.OrganizationInvitation, database.IConnection, map[string]interface{}) {
+	if userData.OrgId == nil {
+		return nil, nil, u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+	tenant := conn.WithTenant(*userData.OrgId)
+
+	invitation, err := is.InvitationRepo.GetInvitationById(tenant, invitationID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if invitation == nil || invitation.Status != model.InvitationPending {
+		return nil, nil, u.ResponseErrorWithCode(http.StatusNotFound, msg.InvitationNotFound)
+	}
+	if resp := is.checkInviter(tenant, userData, invitation.Role); resp != nil {
+		return nil, nil, resp
+	}
+	return invitation, tenant, nil
+}
+
+// checkInviter makes sure the caller still manages the organization, only owners invite owners
+func (is *InvitationService) checkInviter(tenant database.IConnection, userData middleware.UserTokenData, role string) map[string]interface{} {
+	caller, err := is.OrgRepo.GetMember(tenant, userData.Id)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if caller == nil {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.NotOrgMember)
+	}
+	if caller.Role != model.OrgRoleOwner && (caller.Role != model.OrgRoleAdmin || role == model.OrgRoleOwner) {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.Forbidden)
+	}
+	return nil
+}
+
+// renewToken creates a new acceptance token and restarts the expiry of the invitation
+func (is *InvitationService) renewToken(invitation *model.OrganizationInvitation) (string, error) {
+	ttl := time.Duration(is.Config.App().InvitationTTL) * time.Hour
+	token, jti, err := middleware.GenerateActionToken(invitationPurpose, invitation.ID, ttl)
+	if err != nil {
+		return "", err
+	}
+	invitation.TokenID = jti
+	invitation.ExpiresAt = time.Now().Add(ttl)
+	invitation.UpdatedAt = time.Now()
+	return token, nil
+}
+
+func (is *InvitationService) sendInvitation(conn database.IConnection, userData middleware.UserTokenData, invitation *model.OrganizationInvitation, token string) error {
+	org, err := is.OrgRepo.GetOrganizationById(conn, invitation.OrgID)
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return fmt.Errorf("organization %s not found", invitation.OrgID)
+	}
+
+	inviterName := userData.Email
+	if inviter, err := is.UserRepo.GetUserById(conn, userData.Id); err == nil && inviter != nil {
+		inviterName = strings.TrimSpace(inviter.FirstName + " " + inviter.LastName)
+	}
+
+	data := map[string]interface{}{
+		"OrgName":     org.Name,
+		"InviterName": inviterName,
+		"Role":        invitation.Role,
+		"ExpiresAt":   invitation.ExpiresAt.UTC().Format(time.RFC1123),
+		"Link":        fmt.Sprintf("%s/accept-invitation?token=%s", is.Config.App().PublicURL, token),
+	}
+	return is.Mailer.SendTemplate(invitation.Email, mail.TemplateOrgInvitation, data)
+}
+
+func (is *InvitationService) audit(eventType, actorID, targetID, outcome string, info u.RequestInfo, orgID uuid.UUID, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if orgID != uuid.Nil {
+		metadata["org_id"] = orgID.String()
+	}
+	is.Audit.Record(AuditEntry{
+		EventType: eventType,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		Outcome:   outcome,
+		Metadata:  metadata,
+		Info:      info,
+	})
+}
+
+func invitationResponse(invitation *model.OrganizationInvitation) v1resp.InvitationResponse {
+	return v1resp.InvitationResponse{
+		Id:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy,
+		Expired:   !invitation.IsPending(),
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,245 @@
+package v1Service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/denylist"
+	"test-task/shared/log"
+	"test-task/shared/scheduler"
+	"test-task/shared/utils"
+	_const "test-task/shared/utils/const"
+	msg "test-task/shared/utils/message"
+	"time"
+)
+
+const tokenPurgeBatchSize = 1000
+
+// Scheduled jobs
+const (
+	JobRefreshTokenPurge = "refresh-token-purge"
+	JobDenylistCleanup   = "denylist-cleanup"
+	JobDeletedUserPurge  = "deleted-user-purge"
+	JobOutboxCleanup     = "outbox-cleanup"
+	JobHistoryCleanup    = "job-history-cleanup"
+)
+
+type IJobService interface {
+	RegisterJobs(purge IUserPurgeService, denied denylist.IDenylist, outbox IOutboxRelay) error
+	GetJobs(ctx context.Context) map[string]interface{}
+	GetRuns(job string, req v1req.JobRunsRequest) map[string]interface{}
+}
+
+// JobService registers the periodic jobs with the scheduler and shows their run history to admins
+type JobService struct {
+	Config     config.IConfig
+	Scheduler  scheduler.IScheduler
+	JobRunRepo v1repo.IJobRunRepository
+	TokenRepo  v1repo.ITokenRepository
+}
+
+func NewJobService(cf config.IConfig, sched scheduler.IScheduler) IJobService {
+	return &JobService{
+		Config:     cf,
+		Scheduler:  sched,
+		JobRunRepo: v1repo.NewJobRunWriter(),
+		TokenRepo:  v1repo.NewTokenWriter(),
+	}
+}
+
+// RegisterJobs schedules the housekeeping of the service
+func (js *JobService) RegisterJobs(purge IUserPurgeService, denied denylist.IDenylist, outbox IOutboxRelay) error {
+	cf := js.Config.Scheduler()
+	jobs := []scheduler.Job{
+		{
+			Name:     JobRefreshTokenPurge,
+			Schedule: cf.RefreshTokenPurge,
+			Run: func(ctx context.Context) (string, error) {
+				removed, err := js.purgeExpiredTokens(ctx)
+				return fmt.Sprintf("removed %d expired refresh tokens", removed), err
+			},
+		},
+		{
+			Name:     JobDenylistCleanup,
+			Schedule: cf.DenylistCleanup,
+			Run: func(ctx context.Context) (string, error) {
+				removed, err := denied.Cleanup(ctx)
+				return fmt.Sprintf("removed %d expired denylist entries", removed), err
+			},
+		},
+		{
+			Name:     JobDeletedUserPurge,
+			Schedule: fmt.Sprintf("@every %dm", js.Config.Account().PurgeInterval),
+			Run: func(ctx context.Context) (string, error) {
+				purged, err := purge.PurgeExpired(ctx)
+				return fmt.Sprintf("purged %d deleted users", purged), err
+			},
+		},
+		{
+			Name:     JobOutboxCleanup,
+			Schedule: cf.OutboxCleanup,
+			Run: func(ctx context.Context) (string, error) {
+				removed, err := outbox.Cleanup()
+				return fmt.Sprintf("removed %d dispatched outbox messages", removed), err
+			},
+		},
+		{
+			Name:     JobHistoryCleanup,
+			Schedule: cf.HistoryCleanup,
+			Run: func(ctx context.Context) (string, error) {
+				before := time.Now().AddDate(0, 0, -cf.HistoryRetention)
+				removed, err := js.JobRunRepo.DeleteRunsBefore(database.NewConnection(), before)
+				return fmt.Sprintf("removed %d job runs", removed), err
+			},
+		},
+	}
+	for _, job := range jobs {
+		if err := js.Scheduler.Register(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeExpiredTokens removes the expired refresh tokens in batches, stopping early when ctx is done
+func (js *JobService) purgeExpiredTokens(ctx context.Context) (int64, error) {
+	now := time.Now()
+	var total int64
+	for ctx.Err() == nil {
+		removed, err := js.TokenRepo.DeleteExpiredTokens(database.NewConnection(), now, tokenPurgeBatchSize)
+		total += removed
+		if err != nil || removed < tokenPurgeBatchSize {
+			return total, err
+		}
+	}
+	return total, ctx.Err()
+}
+
+// GetJobs is made for listing the scheduled jobs with their next and last run
+func (js *JobService) GetJobs(ctx context.Context) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Job Service Called(GetJobs).")
+	lastRuns, err := js.JobRunRepo.GetLastRuns(database.NewSlaveConnection())
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	last := map[string]*model.JobRun{}
+	for i := range lastRuns {
+		last[lastRuns[i].Job] = &lastRuns[i]
+	}
+
+	leader, err := js.Scheduler.Leader(ctx)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Scheduler leader not loaded: %s", err.Error())
+	}
+	resp := v1resp.JobListResponse{Leader: leader, Instance: js.Scheduler.Instance(), Jobs: []v1resp.JobResponse{}}
+	for _, job := range js.Scheduler.Jobs() {
+		item := v1resp.JobResponse{Name: job.Name, Schedule: job.Schedule, NextRunAt: job.NextRunAt}
+		if run, ok := last[job.Name]; ok {
+			lastRun := jobRunResponse(run)
+			item.LastRun = &lastRun
+		}
+		resp.Jobs = append(resp.Jobs, item)
+	}
+	return u.ResponseSuccessWithObj(msg.JobsFetched, resp)
+}
+
+// GetRuns is made for the run history of a job, newest first
+func (js *JobService) GetRuns(job string, req v1req.JobRunsRequest) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Job Service Called(GetRuns).")
+	known := false
+	for _, info := range js.Scheduler.Jobs() {
+		known = known || info.Name == job
+	}
+	if !known {
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.JobNotFound)
+	}
+
+	page, size := req.Page, req.Size
+	if page < 1 {
+		page = _const.PageNo
+	}
+	if size < 1 {
+		size = _const.PerPageLimit
+	}
+	runs, total, err := js.JobRunRepo.GetRuns(database.NewSlaveConnection(), job, page, size)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	resp := v1resp.JobRunListResponse{
+		Runs: make([]v1resp.JobRunResponse, 0, len(runs)),
+		Pagination: utils.PageAttr{
+			Page:     page,
+			Size:     size,
+			Total:    total,
+			LastPage: (total + size - 1) / size,
+		},
+	}
+	for i := range runs {
+		resp.Runs = append(resp.Runs, jobRunResponse(&runs[i]))
+	}
+	return u.ResponseSuccessWithObj(msg.JobRunsFetched, resp)
+}
+
+func jobRunResponse(run *model.JobRun) v1resp.JobRunResponse {
+	resp := v1resp.JobRunResponse{
+		Id:          run.ID,
+		Job:         run.Job,
+		Instance:    run.Instance,
+		Status:      run.Status,
+		Result:      run.Result,
+		Error:       run.Error,
+		ScheduledAt: run.ScheduledAt,
+		StartedAt:   run.StartedAt,
+		FinishedAt:  run.FinishedAt,
+	}
+	if run.FinishedAt != nil {
+		duration := run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+		resp.DurationMs = &duration
+	}
+	return resp
+}
+
+// JobRunRecorder keeps the history of the scheduler in the job_runs table
+type JobRunRecorder struct {
+	JobRunRepo v1repo.IJobRunRepository
+}
+
+func NewJobRunRecorder() scheduler.IRecorder {
+	return &JobRunRecorder{JobRunRepo: v1repo.NewJobRunWriter()}
+}
+
+func (jr *JobRunRecorder) Started(run *scheduler.Run) {
+	if err := jr.JobRunRepo.CreateRun(database.NewConnection(), jobRun(run)); err != nil {
+		log.GetLog().Error("ERROR(from repo) : ", "Run of job %s not recorded: %s", run.Job, err.Error())
+	}
+}
+
+func (jr *JobRunRecorder) Finished(run *scheduler.Run) {
+	if err := jr.JobRunRepo.UpdateRun(database.NewConnection(), jobRun(run)); err != nil {
+		log.GetLog().Error("ERROR(from repo) : ", "Run of job %s not recorded: %s", run.Job, err.Error())
+	}
+}
+
+func jobRun(run *scheduler.Run) *model.JobRun {
+	return &model.JobRun{
+		ID:          run.ID,
+		Job:         run.Job,
+		Instance:    run.Instance,
+		ScheduledAt: run.ScheduledAt,
+		StartedAt:   run.StartedAt,
+		FinishedAt:  run.FinishedAt,
+		Status:      run.Status,
+		Result:      truncate(run.Result, 500),
+		Error:       truncate(run.Error, 500),
+	}
+}
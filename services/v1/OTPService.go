@@ -0,0 +1,176 @@
+package v1Service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"test-task/shared/cache"
+	"test-task/shared/config"
+	"test-task/shared/sms"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// One-time passcode purposes, a code sent for one can not be used for another
+const (
+	otpPurposeSignIn      = "sign_in"
+	otpPurposePhoneVerify = "phone_verify"
+)
+
+const otpKeyPrefix = "otp_"
+
+var (
+	// ErrOTPTooSoon is returned when a passcode was sent to the number less than SMS.OTPResendInterval ago
+	ErrOTPTooSoon = errors.New("a passcode was sent recently")
+	// ErrOTPInvalid is returned for a wrong, expired or unknown passcode
+	ErrOTPInvalid = errors.New("invalid passcode")
+	// ErrOTPAttempts is returned once SMS.OTPMaxAttempts codes were tried for the number within
+	// SMS.OTPAttemptWindow minutes, the passcode is gone then and no new one is sent before the window ends
+	ErrOTPAttempts = errors.New("too many attempts")
+)
+
+// IOTPService sends one-time passcodes by SMS and checks them. Only an HMAC of the code is kept in Redis,
+// together with the user it was sent for. The attempts are counted per number, a resend does not reset them.
+type IOTPService interface {
+	Send(ctx context.Context, purpose, phone string, userID uuid.UUID) error
+	Verify(ctx context.Context, purpose, phone, code string) (uuid.UUID, error)
+}
+
+type OTPService struct {
+	Config config.IConfig
+	Sender sms.ISender
+}
+
+func NewOTPService(cf config.IConfig) IOTPService {
+	return &OTPService{
+		Config: cf,
+		Sender: sms.NewSender(cf),
+	}
+}
+
+// Send is made for texting a new passcode to the phone, it replaces the previous one of the same purpose
+func (ots *OTPService) Send(ctx context.Context, purpose, phone string, userID uuid.UUID) error {
+	cf := ots.Config.SMS()
+	key := otpKey(purpose, phone)
+
+	attempts, err := ots.attempts(ctx, phone)
+	if err != nil {
+		return err
+	}
+	if attempts >= int64(cf.OTPMaxAttempts) {
+		return ErrOTPAttempts
+	}
+
+	if cf.OTPResendInterval > 0 {
+		sent, err := cache.IncrementValue(ctx, key+"_sent", time.Duration(cf.OTPResendInterval)*time.Second)
+		if err != nil {
+			return err
+		}
+		if sent > 1 {
+			return ErrOTPTooSoon
+		}
+	}
+
+	code, err := otpCode(cf.OTPLength)
+	if err != nil {
+		return err
+	}
+	ttl := time.Duration(cf.OTPTTL) * time.Second
+	if err = cache.SetValue(ctx, key, userID.String()+":"+ots.digest(phone, code), ttl); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("%s is your verification code. It expires in %d minutes. Do not share it with anyone.", code, (cf.OTPTTL+59)/60)
+	return ots.Sender.Send(phone, body)
+}
+
+// Verify is made for checking a passcode, it returns the user the code was sent for. A correct code
+// can be used once.
+func (ots *OTPService) Verify(ctx context.Context, purpose, phone, code string) (uuid.UUID, error) {
+	cf := ots.Config.SMS()
+	key := otpKey(purpose, phone)
+
+	value, err := cache.GetValue(ctx, key)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return uuid.Nil, ErrOTPInvalid
+	}
+
+	attempts, err := cache.IncrementValue(ctx, otpAttemptsKey(phone), time.Duration(cf.OTPAttemptWindow)*time.Minute)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if attempts > int64(cf.OTPMaxAttempts) {
+		if err = cache.DeleteValue(ctx, key); err != nil {
+			return uuid.Nil, err
+		}
+		return uuid.Nil, ErrOTPAttempts
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(ots.digest(phone, code))) {
+		return uuid.Nil, ErrOTPInvalid
+	}
+	// the code is gone once read, two requests racing with the right code can not both use it
+	consumed, err := cache.DeleteValueIfEqual(ctx, key, value)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !consumed {
+		return uuid.Nil, ErrOTPInvalid
+	}
+	if err = cache.DeleteValue(ctx, otpAttemptsKey(phone)); err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.FromString(parts[0])
+}
+
+// attempts returns how many codes were tried for the number in the current SMS.OTPAttemptWindow
+func (ots *OTPService) attempts(ctx context.Context, phone string) (int64, error) {
+	value, err := cache.GetValue(ctx, otpAttemptsKey(phone))
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// digest keys the hash with a server secret, a plain hash of a 6 digit code is reversed in no time
+func (ots *OTPService) digest(phone, code string) string {
+	mac := hmac.New(sha256.New, []byte(ots.Config.App().RefreshTokenKey))
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func otpCode(length int) (string, error) {
+	var code strings.Builder
+	for i := 0; i < length; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code.WriteString(digit.String())
+	}
+	return code.String(), nil
+}
+
+// otpKey hashes the phone so Redis does not hold phone numbers
+func otpKey(purpose, phone string) string {
+	sum := sha256.Sum256([]byte(phone))
+	return otpKeyPrefix + purpose + "_" + hex.EncodeToString(sum[:])
+}
+
+// otpAttemptsKey counts the codes tried for the number, whatever they were sent for
+func otpAttemptsKey(phone string) string {
+	sum := sha256.Sum256([]byte(phone))
+	return otpKeyPrefix + "attempts_" + hex.EncodeToString(sum[:])
+}
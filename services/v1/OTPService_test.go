@@ -0,0 +1,287 @@
+package v1Service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"test-task/shared/config"
+	"test-task/shared/sms"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const testPhone = "+919876543210"
+
+// newTestOTPService sends with the "file" driver, the stand-in provider tests read the passcodes from
+func newTestOTPService(t *testing.T) (*OTPService, string) {
+	t.Helper()
+	outbox := filepath.Join(t.TempDir(), "sms.jsonl")
+	cf := &testConfig{
+		app: config.App{RefreshTokenKey: "otp-test-secret"},
+		sms: config.SMS{
+			Driver:            "file",
+			FilePath:          outbox,
+			From:              "AUTH",
+			OTPLength:         6,
+			OTPTTL:            300,
+			OTPMaxAttempts:    3,
+			OTPAttemptWindow:  60,
+			OTPResendInterval: 60,
+		},
+	}
+	return &OTPService{Config: cf, Sender: sms.NewSender(cf)}, outbox
+}
+
+// lastCode reads the passcode of the last message texted to the phone
+func lastCode(t *testing.T, outbox, phone string) string {
+	t.Helper()
+	file, err := os.Open(outbox)
+	if err != nil {
+		t.Fatalf("no message was sent: %v", err)
+	}
+	defer file.Close()
+
+	var code string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var message sms.Message
+		if err = json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			t.Fatalf("unreadable message %q: %v", scanner.Text(), err)
+		}
+		if message.To == phone {
+			code = regexp.MustCompile(`^\d+`).FindString(message.Body)
+		}
+	}
+	if code == "" {
+		t.Fatalf("no passcode was texted to %s", phone)
+	}
+	return code
+}
+
+func TestOTPSendAndVerify(t *testing.T) {
+	newTestRedis(t)
+	ots, outbox := newTestOTPService(t)
+	ctx := context.Background()
+	userID := uuid.NewV4()
+
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	code := lastCode(t, outbox, testPhone)
+	if len(code) != 6 {
+		t.Fatalf("passcode %q does not have SMS.OTPLength digits", code)
+	}
+
+	got, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, code)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got != userID {
+		t.Fatalf("Verify returned user %s, want %s", got, userID)
+	}
+
+	// a passcode works once
+	if _, err = ots.Verify(ctx, otpPurposeSignIn, testPhone, code); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("second Verify: got %v, want ErrOTPInvalid", err)
+	}
+}
+
+func TestOTPVerifyRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose string
+		phone   string
+		code    func(code string) string
+	}{
+		{"wrong code", otpPurposeSignIn, testPhone, func(code string) string { return otherCode(code) }},
+		{"other purpose", otpPurposePhoneVerify, testPhone, func(code string) string { return code }},
+		{"other phone", otpPurposeSignIn, "+919876543211", func(code string) string { return code }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			ots, outbox := newTestOTPService(t)
+			ctx := context.Background()
+
+			if err := ots.Send(ctx, otpPurposeSignIn, testPhone, uuid.NewV4()); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			code := tt.code(lastCode(t, outbox, testPhone))
+			if _, err := ots.Verify(ctx, tt.purpose, tt.phone, code); !errors.Is(err, ErrOTPInvalid) {
+				t.Fatalf("Verify: got %v, want ErrOTPInvalid", err)
+			}
+		})
+	}
+}
+
+func TestOTPExpiry(t *testing.T) {
+	redis := newTestRedis(t)
+	ots, outbox := newTestOTPService(t)
+	ctx := context.Background()
+
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, uuid.NewV4()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	code := lastCode(t, outbox, testPhone)
+
+	redis.FastForward(time.Duration(ots.Config.SMS().OTPTTL+1) * time.Second)
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, code); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("Verify of an expired passcode: got %v, want ErrOTPInvalid", err)
+	}
+}
+
+func TestOTPAttemptLimit(t *testing.T) {
+	newTestRedis(t)
+	ots, outbox := newTestOTPService(t)
+	ctx := context.Background()
+
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, uuid.NewV4()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	code := lastCode(t, outbox, testPhone)
+
+	for i := 0; i < ots.Config.SMS().OTPMaxAttempts; i++ {
+		if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, otherCode(code)); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("attempt %d: got %v, want ErrOTPInvalid", i+1, err)
+		}
+	}
+	// the attempt over the limit fails even with the right code and discards the passcode
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, code); !errors.Is(err, ErrOTPAttempts) {
+		t.Fatalf("attempt over the limit: got %v, want ErrOTPAttempts", err)
+	}
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, code); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("after the limit: got %v, want ErrOTPInvalid", err)
+	}
+}
+
+func TestOTPAttemptsSurviveResend(t *testing.T) {
+	redis := newTestRedis(t)
+	ots, outbox := newTestOTPService(t)
+	ctx := context.Background()
+	userID := uuid.NewV4()
+	cf := ots.Config.SMS()
+
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	code := lastCode(t, outbox, testPhone)
+	for i := 0; i < cf.OTPMaxAttempts-1; i++ {
+		if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, otherCode(code)); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("attempt %d: got %v, want ErrOTPInvalid", i+1, err)
+		}
+	}
+
+	// a new passcode keeps the count, the last attempt of the budget is all it gets
+	redis.FastForward(time.Duration(cf.OTPResendInterval) * time.Second)
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); err != nil {
+		t.Fatalf("Send after SMS.OTPResendInterval: %v", err)
+	}
+	code = lastCode(t, outbox, testPhone)
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, otherCode(code)); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("last attempt: got %v, want ErrOTPInvalid", err)
+	}
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, code); !errors.Is(err, ErrOTPAttempts) {
+		t.Fatalf("attempt over the budget: got %v, want ErrOTPAttempts", err)
+	}
+
+	// no passcode is sent for the number, whatever the purpose, until the window ends
+	redis.FastForward(time.Duration(cf.OTPResendInterval) * time.Second)
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); !errors.Is(err, ErrOTPAttempts) {
+		t.Fatalf("Send with the budget spent: got %v, want ErrOTPAttempts", err)
+	}
+	if err := ots.Send(ctx, otpPurposePhoneVerify, testPhone, userID); !errors.Is(err, ErrOTPAttempts) {
+		t.Fatalf("Send for another purpose with the budget spent: got %v, want ErrOTPAttempts", err)
+	}
+
+	redis.FastForward(time.Duration(cf.OTPAttemptWindow) * time.Minute)
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); err != nil {
+		t.Fatalf("Send after SMS.OTPAttemptWindow: %v", err)
+	}
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, lastCode(t, outbox, testPhone)); err != nil {
+		t.Fatalf("Verify after SMS.OTPAttemptWindow: %v", err)
+	}
+}
+
+func TestOTPVerifyConcurrent(t *testing.T) {
+	newTestRedis(t)
+	ots, outbox := newTestOTPService(t)
+	ctx := context.Background()
+
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, uuid.NewV4()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	code := lastCode(t, outbox, testPhone)
+
+	// requests racing with the right code, only one of them may sign in
+	const racers = 3
+	var wg sync.WaitGroup
+	errs := make(chan error, racers)
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, code)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	accepted := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, ErrOTPInvalid):
+			t.Errorf("Verify: got %v, want ErrOTPInvalid for the losers", err)
+		}
+	}
+	if accepted != 1 {
+		t.Fatalf("the passcode was accepted %d times, want once", accepted)
+	}
+}
+
+func TestOTPResendInterval(t *testing.T) {
+	redis := newTestRedis(t)
+	ots, outbox := newTestOTPService(t)
+	ctx := context.Background()
+	userID := uuid.NewV4()
+
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	first := lastCode(t, outbox, testPhone)
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); !errors.Is(err, ErrOTPTooSoon) {
+		t.Fatalf("Send within SMS.OTPResendInterval: got %v, want ErrOTPTooSoon", err)
+	}
+
+	// a passcode sent after the interval replaces the previous one
+	redis.FastForward(time.Duration(ots.Config.SMS().OTPResendInterval) * time.Second)
+	if err := ots.Send(ctx, otpPurposeSignIn, testPhone, userID); err != nil {
+		t.Fatalf("Send after SMS.OTPResendInterval: %v", err)
+	}
+	second := lastCode(t, outbox, testPhone)
+	if first != second {
+		if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, first); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("Verify of the replaced passcode: got %v, want ErrOTPInvalid", err)
+		}
+	}
+	if _, err := ots.Verify(ctx, otpPurposeSignIn, testPhone, second); err != nil {
+		t.Fatalf("Verify of the new passcode: %v", err)
+	}
+}
+
+// otherCode returns a passcode of the same length that differs from code
+func otherCode(code string) string {
+	other := []byte(code)
+	other[0] = '0' + (other[0]-'0'+1)%10
+	return string(other)
+}
@@ -0,0 +1,424 @@
+package v1Service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type IOrganizationService interface {
+	CreateOrganization(userData middleware.UserTokenData, req v1req.CreateOrganizationRequest, info u.RequestInfo) map[string]interface{}
+	GetOrganizations(userData middleware.UserTokenData) map[string]interface{}
+	SwitchOrganization(userData middleware.UserTokenData, req v1req.SwitchOrganizationRequest, info u.RequestInfo) map[string]interface{}
+	GetCurrentOrganization(userData middleware.UserTokenData) map[string]interface{}
+	UpdateSettings(userData middleware.UserTokenData, req v1req.OrganizationSettingsRequest, info u.RequestInfo) map[string]interface{}
+	GetMembers(userData middleware.UserTokenData) map[string]interface{}
+	UpdateMemberRole(userData middleware.UserTokenData, memberID uuid.UUID, req v1req.OrganizationMemberRequest, info u.RequestInfo) map[string]interface{}
+	RemoveMember(userData middleware.UserTokenData, memberID uuid.UUID, info u.RequestInfo) map[string]interface{}
+}
+
+type OrganizationService struct {
+	Config      config.IConfig
+	OrgRepo     v1repo.IOrganizationRepository
+	UserRepo    v1repo.IUserRepository
+	TokenIssuer ITokenIssuer
+	Audit       IAuditService
+}
+
+func NewOrganizationService(cf config.IConfig, auditService IAuditService) IOrganizationService {
+	return &OrganizationService{
+		Config:      cf,
+		OrgRepo:     v1repo.NewOrganizationWriter(),
+		UserRepo:    v1repo.NewUserWriter(),
+		TokenIssuer: NewTokenIssuer(cf),
+		Audit:       auditService,
+	}
+}
+
+// CreateOrganization is made for creating an organization owned by the signed in user
+func (ors *OrganizationService) CreateOrganization(userData middleware.UserTokenData, req v1req.CreateOrganizationRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(CreateOrganization).")
+
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !slugPattern.MatchString(slug) {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.OrgSlugInvalid)
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	existing, err := ors.OrgRepo.GetOrganizationBySlug(conn, slug)
+	if err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if existing != nil {
+		conn.RollbackTransaction()
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.OrgSlugInUse)
+	}
+
+	now := time.Now()
+	org := model.Organization{
+		ID:        uuid.NewV4(),
+		Name:      strings.TrimSpace(req.Name),
+		Slug:      slug,
+		CreatedBy: userData.Id,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = ors.OrgRepo.CreateOrganization(conn, &org); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	owner := model.OrganizationMember{
+		ID:        uuid.NewV4(),
+		UserID:    userData.Id,
+		Role:      model.OrgRoleOwner,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = ors.OrgRepo.AddMember(conn.WithTenant(org.ID), &owner); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	conn.CommitTransaction()
+
+	ors.audit(model.AuditOrgCreate, userData, org.ID.String(), model.AuditSuccess, info, map[string]interface{}{"slug": org.Slug})
+
+	return u.ResponseSuccessWithObj(msg.OrgCreated, ors.organizationResponse(&org, model.OrgRoleOwner))
+}
+
+// GetOrganizations is made for listing the organizations of the signed in user
+func (ors *OrganizationService) GetOrganizations(userData middleware.UserTokenData) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(GetOrganizations).")
+	conn := database.NewSlaveConnection()
+
+	memberships, err := ors.OrgRepo.GetUserMemberships(conn, userData.Id)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	orgs := make([]v1resp.OrganizationResponse, 0, len(memberships))
+	for _, member := range memberships {
+		if member.Organization == nil {
+			continue
+		}
+		orgs = append(orgs, ors.organizationResponse(member.Organization, member.Role))
+	}
+	return u.ResponseSuccessWithObj(msg.OrgsFetched, orgs)
+}
+
+// SwitchOrganization is made for re-issuing the tokens of the user for another organization
+func (ors *OrganizationService) SwitchOrganization(userData middleware.UserTokenData, req v1req.SwitchOrganizationRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(SwitchOrganization).")
+	conn := database.NewConnection()
+
+	orgID := uuid.FromStringOrNil(req.OrgID)
+	user, err := ors.UserRepo.GetUserById(conn, userData.Id)
+	if err != nil || user == nil {
+		log.GetLog().Info("WARN : ", "User not found.")
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+	}
+
+	method := userData.AuthMethod
+	if method == "" {
+		method = config.SignInPassword
+	}
+	issued, err := ors.TokenIssuer.Issue(conn, user, &orgID, method, info.DPoPThumbprint)
+	if err != nil {
+		reason := msg.InternalServer
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrNotOrgMember):
+			reason, code = msg.NotOrgMember, http.StatusForbidden
+		case errors.Is(err, ErrSignInMethodNotAllowed):
+			reason, code = msg.SignInMethodDenied, http.StatusForbidden
+		default:
+			log.GetLog().Error("ERROR : ", "Error issuing tokens: %s", err.Error())
+		}
+		ors.audit(model.AuditOrgSwitch, userData, orgID.String(), model.AuditFailure, info, map[string]interface{}{"reason": reason})
+		return u.ResponseErrorWithCode(code, reason)
+	}
+
+	ors.audit(model.AuditOrgSwitch, userData, orgID.String(), model.AuditSuccess, info, nil)
+
+	resp := v1resp.SigninResponse{RefreshToken: issued.RefreshToken, AccessToken: issued.AccessToken, OrgId: &issued.Organization.ID}
+	return u.ResponseSuccessWithObj(msg.OrgSwitched, resp)
+}
+
+// GetCurrentOrganization is made for fetching the active organization of the token
+func (ors *OrganizationService) GetCurrentOrganization(userData middleware.UserTokenData) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(GetCurrentOrganization).")
+	conn := database.NewSlaveConnection()
+
+	org, member, resp := ors.currentMembership(conn, userData)
+	if resp != nil {
+		return resp
+	}
+	return u.ResponseSuccessWithObj(msg.OrgFetched, ors.organizationResponse(org, member.Role))
+}
+
+// UpdateSettings is made for changing the name, sign-in methods and token lifetimes of the active organization
+func (ors *OrganizationService) UpdateSettings(userData middleware.UserTokenData, req v1req.OrganizationSettingsRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(UpdateSettings).")
+	conn := database.NewConnection()
+
+	org, member, resp := ors.currentMembership(conn, userData)
+	if resp != nil {
+		return resp
+	}
+
+	changes := map[string]interface{}{}
+	if req.Name != nil {
+		org.Name = strings.TrimSpace(*req.Name)
+		changes["name"] = org.Name
+	}
+	if req.AllowedSignInMethods != nil {
+		for _, method := range *req.AllowedSignInMethods {
+			if !config.IsSignInMethod(method) {
+				return u.ResponseErrorWithCode(http.StatusBadRequest, fmt.Sprintf(msg.UnknownSignInMethod, method))
+			}
+		}
+		org.AllowedSignInMethods = strings.Join(*req.AllowedSignInMethods, ",")
+		changes["allowed_sign_in_methods"] = *req.AllowedSignInMethods
+	}
+	if req.AccessTokenTTL != nil {
+		org.AccessTokenTTL = *req.AccessTokenTTL
+		changes["access_token_ttl"] = org.AccessTokenTTL
+	}
+	if req.RefreshTokenTTL != nil {
+		org.RefreshTokenTTL = *req.RefreshTokenTTL
+		changes["refresh_token_ttl"] = org.RefreshTokenTTL
+	}
+	org.UpdatedAt = time.Now()
+
+	if err := ors.OrgRepo.UpdateOrganization(conn, org); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	ors.audit(model.AuditOrgSettings, userData, org.ID.String(), model.AuditSuccess, info, changes)
+
+	return u.ResponseSuccessWithObj(msg.OrgSettingsUpdated, ors.organizationResponse(org, member.Role))
+}
+
+// GetMembers is made for listing the members of the active organization
+func (ors *OrganizationService) GetMembers(userData middleware.UserTokenData) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(GetMembers).")
+	conn := database.NewSlaveConnection()
+
+	if userData.OrgId == nil {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+	members, err := ors.OrgRepo.GetMembers(conn.WithTenant(*userData.OrgId))
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	list := make([]v1resp.OrganizationMemberResponse, 0, len(members))
+	for _, member := range members {
+		item := v1resp.OrganizationMemberResponse{
+			UserId:   member.UserID,
+			Role:     member.Role,
+			JoinedAt: member.CreatedAt,
+		}
+		if member.User != nil {
+			item.Email = member.User.Email
+			item.FirstName = member.User.FirstName
+			item.LastName = member.User.LastName
+		}
+		list = append(list, item)
+	}
+	return u.ResponseSuccessWithObj(msg.OrgMembersFetched, list)
+}
+
+// UpdateMemberRole is made for changing the role of a member in the active organization
+func (ors *OrganizationService) UpdateMemberRole(userData middleware.UserTokenData, memberID uuid.UUID, req v1req.OrganizationMemberRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(UpdateMemberRole).")
+	if userData.OrgId == nil {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+	tenant := conn.WithTenant(*userData.OrgId)
+
+	member, resp := ors.manageableMember(tenant, userData, memberID, req.Role)
+	if resp != nil {
+		conn.RollbackTransaction()
+		return resp
+	}
+	if member.Role == model.OrgRoleOwner && req.Role != model.OrgRoleOwner {
+		if resp = ors.keepOwner(tenant); resp != nil {
+			conn.RollbackTransaction()
+			return resp
+		}
+	}
+
+	if err := ors.OrgRepo.UpdateMemberRole(tenant, memberID, req.Role); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	conn.CommitTransaction()
+
+	ors.audit(model.AuditOrgMemberRole, userData, memberID.String(), model.AuditSuccess, info, map[string]interface{}{"from": member.Role, "to": req.Role})
+
+	return u.ResponseSuccessWithObj(msg.OrgMemberUpdated, nil)
+}
+
+// RemoveMember is made for removing a member from the active organization
+func (ors *OrganizationService) RemoveMember(userData middleware.UserTokenData, memberID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Organization Service Called(RemoveMember).")
+	if userData.OrgId == nil {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+	tenant := conn.WithTenant(*userData.OrgId)
+
+	member, resp := ors.manageableMember(tenant, userData, memberID, "")
+	if resp != nil {
+		conn.RollbackTransaction()
+		return resp
+	}
+	if member.Role == model.OrgRoleOwner {
+		if resp = ors.keepOwner(tenant); resp != nil {
+			conn.RollbackTransaction()
+			return resp
+		}
+	}
+
+	if err := ors.OrgRepo.RemoveMember(tenant, memberID); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	conn.CommitTransaction()
+
+	ors.audit(model.AuditOrgMemberRemove, userData, memberID.String(), model.AuditSuccess, info, map[string]interface{}{"role": member.Role})
+
+	return u.ResponseSuccessWithObj(msg.OrgMemberRemoved, nil)
+}
+
+// currentMembership loads the active organization of the token and checks the user still belongs to it
+func (ors *OrganizationService) currentMembership(conn database.IConnection, userData middleware.UserTokenData) (*model.Organization, *model.OrganizationMember, map[string]interface{}) {
+	if userData.OrgId == nil {
+		return nil, nil, u.ResponseErrorWithCode(http.StatusForbidden, msg.OrgNotSelected)
+	}
+	member, err := ors.OrgRepo.GetMember(conn.WithTenant(*userData.OrgId), userData.Id)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if member == nil {
+		return nil, nil, u.ResponseErrorWithCode(http.StatusForbidden, msg.NotOrgMember)
+	}
+	org, err := ors.OrgRepo.GetOrganizationById(conn, *userData.OrgId)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if org == nil {
+		return nil, nil, u.ResponseErrorWithCode(http.StatusNotFound, msg.OrgNotFound)
+	}
+	return org, member, nil
+}
+
+// manageableMember loads the member and checks the caller may manage them.
+// Only owners manage owners or grant the owner role.
+func (ors *OrganizationService) manageableMember(tenant database.IConnection, userData middleware.UserTokenData, memberID uuid.UUID, newRole string) (*model.OrganizationMember, map[string]interface{}) {
+	caller, err := ors.OrgRepo.GetMember(tenant, userData.Id)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if caller == nil {
+		return nil, u.ResponseErrorWithCode(http.StatusForbidden, msg.NotOrgMember)
+	}
+
+	member, err := ors.OrgRepo.GetMember(tenant, memberID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if member == nil {
+		return nil, u.ResponseErrorWithCode(http.StatusNotFound, msg.OrgMemberNotFound)
+	}
+
+	if caller.Role != model.OrgRoleOwner && (member.Role == model.OrgRoleOwner || newRole == model.OrgRoleOwner) {
+		return nil, u.ResponseErrorWithCode(http.StatusForbidden, msg.Forbidden)
+	}
+	return member, nil
+}
+
+// keepOwner refuses a change that would leave the organization without an owner
+func (ors *OrganizationService) keepOwner(tenant database.IConnection) map[string]interface{} {
+	owners, err := ors.OrgRepo.CountMembersWithRole(tenant, model.OrgRoleOwner)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if owners <= 1 {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.OrgLastOwner)
+	}
+	return nil
+}
+
+func (ors *OrganizationService) organizationResponse(org *model.Organization, role string) v1resp.OrganizationResponse {
+	methods := org.SignInMethods()
+	if methods == nil {
+		methods = []string{}
+	}
+	return v1resp.OrganizationResponse{
+		Id:                   org.ID,
+		Name:                 org.Name,
+		Slug:                 org.Slug,
+		Role:                 role,
+		AllowedSignInMethods: methods,
+		AccessTokenTTL:       org.AccessTokenTTL,
+		RefreshTokenTTL:      org.RefreshTokenTTL,
+		CreatedAt:            org.CreatedAt,
+	}
+}
+
+func (ors *OrganizationService) audit(eventType string, userData middleware.UserTokenData, targetID, outcome string, info u.RequestInfo, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if userData.OrgId != nil {
+		metadata["org_id"] = userData.OrgId.String()
+	}
+	ors.Audit.Record(AuditEntry{
+		EventType: eventType,
+		ActorID:   userData.Id.String(),
+		TargetID:  targetID,
+		Outcome:   outcome,
+		Metadata:  metadata,
+		Info:      info,
+	})
+}
@@ -0,0 +1,214 @@
+package v1Service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/eventbus"
+	"test-task/shared/log"
+	"time"
+)
+
+const outboxErrorLimit = 500
+
+// IOutboxSink receives the messages the relay takes from the outbox. A message is published again when the
+// relay could not record it as dispatched, so a sink must tolerate duplicates, the message ID tells them apart.
+type IOutboxSink interface {
+	Publish(ctx context.Context, message *model.OutboxMessage) error
+}
+
+type IOutboxRelay interface {
+	Run(ctx context.Context)
+	RelayDue(ctx context.Context) int
+	Cleanup() (int64, error)
+}
+
+// OutboxRelay publishes the committed outbox messages to the sink, the messages of one aggregate in the
+// order they were written. A message stays due until the sink accepted it, so none is lost.
+type OutboxRelay struct {
+	Config     config.IConfig
+	OutboxRepo v1repo.IOutboxRepository
+	Sink       IOutboxSink
+}
+
+func NewOutboxRelay(cf config.IConfig, sink IOutboxSink) IOutboxRelay {
+	return &OutboxRelay{
+		Config:     cf,
+		OutboxRepo: v1repo.NewOutboxWriter(),
+		Sink:       sink,
+	}
+}
+
+// NewOutboxSink returns the sinks Outbox.Sinks names, webhooks publishes to the webhook endpoints and bus to the event bus
+func NewOutboxSink(cf config.IConfig, webhooks IOutboxSink, bus eventbus.IPublisher) IOutboxSink {
+	var sinks multiSink
+	for _, name := range cf.Outbox().Sinks {
+		switch name {
+		case config.OutboxSinkWebhook:
+			sinks = append(sinks, webhooks)
+		case config.OutboxSinkEventBus:
+			sinks = append(sinks, busSink{bus: bus})
+		case config.OutboxSinkLog:
+			sinks = append(sinks, logSink{})
+		}
+	}
+	if len(sinks) == 1 {
+		return sinks[0]
+	}
+	return sinks
+}
+
+// Run relays the due messages every Outbox.PollInterval milliseconds until ctx is done
+func (or *OutboxRelay) Run(ctx context.Context) {
+	for {
+		// a full batch means more are probably waiting
+		for or.RelayDue(ctx) == or.Config.Outbox().BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(or.Config.Outbox().PollInterval) * time.Millisecond):
+		}
+	}
+}
+
+// RelayDue publishes one batch of due messages and returns how many were taken. The messages are leased for
+// Outbox.Lease seconds in a short transaction, so other instances skip them and the later messages of their
+// aggregates while they are published, without a transaction staying open during the publishing.
+func (or *OutboxRelay) RelayDue(ctx context.Context) int {
+	cf := or.Config.Outbox()
+	lockedUntil := time.Now().Add(time.Duration(cf.Lease) * time.Second)
+
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	messages, err := or.OutboxRepo.ClaimDue(tx, lockedUntil, cf.BatchSize)
+	if err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Error("ERROR(from repo) : ", "Due outbox messages not loaded: %s", err.Error())
+		return 0
+	}
+	tx.CommitTransaction()
+
+	// publishing stops with the lease, a message published after it could be taken by another instance meanwhile
+	ctx, cancel := context.WithDeadline(ctx, lockedUntil)
+	defer cancel()
+	for i := range messages {
+		message := &messages[i]
+		if ctx.Err() != nil {
+			// the rest is taken again once the lease ran out
+			break
+		}
+
+		if err = or.Sink.Publish(ctx, message); err != nil {
+			message.Attempts++
+			message.LastError = truncate(err.Error(), outboxErrorLimit)
+			message.NextAttemptAt = time.Now().Add(or.backoff(message.Attempts))
+			log.GetLog().Info("WARN : ", "Outbox message %s (%s of %s %s) not published (attempt %d): %s",
+				message.ID, message.EventType, message.AggregateType, message.AggregateID, message.Attempts, message.LastError)
+			err = or.OutboxRepo.MarkFailed(database.NewConnection(), message)
+		} else {
+			err = or.OutboxRepo.MarkDispatched(database.NewConnection(), message.ID, time.Now())
+		}
+		if err != nil {
+			// the message is published again once the lease ran out, which the sink tolerates
+			log.GetLog().Error("ERROR(from repo) : ", "Outbox message %s not updated: %s", message.ID, err.Error())
+		}
+	}
+	return len(messages)
+}
+
+// Cleanup removes the messages dispatched more than Outbox.Retention hours ago and returns how many it removed,
+// it is run by the outbox-cleanup job
+func (or *OutboxRelay) Cleanup() (int64, error) {
+	cf := or.Config.Outbox()
+	before := time.Now().Add(-time.Duration(cf.Retention) * time.Hour)
+	var total int64
+	for {
+		removed, err := or.OutboxRepo.DeleteDispatched(database.NewConnection(), before, cf.BatchSize)
+		total += removed
+		if err != nil || removed < int64(cf.BatchSize) {
+			return total, err
+		}
+	}
+}
+
+// backoff is the wait after the given number of failed attempts, a second doubled each time up to Outbox.RetryMaxDelay
+func (or *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := time.Duration(or.Config.Outbox().RetryMaxDelay) * time.Second
+	if attempts < 30 {
+		if d := time.Second << (attempts - 1); d < delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// multiSink publishes to every sink. A failing sink makes the message be published again to all of them,
+// which is fine as sinks tolerate duplicates.
+type multiSink []IOutboxSink
+
+func (sinks multiSink) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// busSink appends the messages to the event bus, the message ID is the event ID
+type busSink struct {
+	bus eventbus.IPublisher
+}
+
+func (s busSink) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	return s.bus.Publish(ctx, eventbus.Event{
+		ID:          message.ID.String(),
+		Type:        message.EventType,
+		Version:     message.Version,
+		AggregateID: message.AggregateID,
+		OccurredAt:  message.CreatedAt.UTC(),
+		Data:        json.RawMessage(message.Payload),
+	})
+}
+
+// logSink only logs the messages, for environments without a consumer
+type logSink struct{}
+
+func (logSink) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	log.GetLog().Info("INFO : ", "Outbox message %s: %s of %s %s: %s",
+		message.ID, message.EventType, message.AggregateType, message.AggregateID, message.Payload)
+	return nil
+}
+
+// appendUserEvent writes a user event to the outbox. tx must be the transaction that changed the user and
+// the event is appended after the change, so the row lock it holds keeps the events of a user in order.
+func appendUserEvent(tx database.IConnection, outbox v1repo.IOutboxRepository, eventType string, user *model.User, changes ...string) error {
+	data := eventbus.UserEventV1{
+		User: eventbus.UserV1{
+			Id:              user.ID,
+			Email:           user.Email,
+			FirstName:       user.FirstName,
+			LastName:        user.LastName,
+			Phone:           user.Phone,
+			Status:          user.Status,
+			EmailVerifiedAt: user.EmailVerifiedAt,
+			CreatedAt:       user.CreatedAt,
+			UpdatedAt:       user.UpdatedAt,
+		},
+		Changes: changes,
+	}
+	message, err := model.NewOutboxMessage(model.AggregateUser, user.ID.String(), eventType, eventbus.Versions[eventType], data)
+	if err != nil {
+		return fmt.Errorf("outbox message of %s not encoded: %w", eventType, err)
+	}
+	return outbox.Append(tx, message)
+}
@@ -0,0 +1,214 @@
+package v1Service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"test-task/model"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	"test-task/shared/cache"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"test-task/shared/mail"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const passwordResetKeyPrefix = "password_reset_"
+
+// ChangePassword is made for changing the password of a signed in user. Every other session of the user
+// ends, the caller gets new tokens in the answer.
+func (as *AuthService) ChangePassword(ctx context.Context, userData middleware.UserTokenData, req v1req.ChangePasswordRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Auth Service Called(ChangePassword).")
+	conn := database.NewConnection()
+	userID := userData.Id
+
+	user, err := as.UserRepo.GetUserById(conn, userID)
+	if err != nil || user == nil || user.ID == uuid.Nil {
+		log.GetLog().Info("ERROR : ", "User not found")
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+	}
+
+	match, _, err := as.PasswordHasher.Verify(req.CurrentPassword, user.Password)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password verification failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if !match {
+		log.GetLog().Info("WARN : ", "Current password mismatch")
+		as.audit(model.AuditPasswordChange, userID.String(), userID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.InvalidPassword})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidPassword)
+	}
+
+	if req.CurrentPassword == req.NewPassword {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.SamePassword)
+	}
+
+	if err = as.PasswordPolicy.Validate(req.NewPassword, user.FirstName, user.LastName, user.Email); err != nil {
+		log.GetLog().Info("WARN : ", "Password policy failed: %s", err.Error())
+		as.audit(model.AuditPasswordChange, user.ID.String(), user.ID.String(), model.AuditFailure, info, map[string]interface{}{"reason": err.Error()})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, err.Error())
+	}
+
+	hashedPassword, err := as.PasswordHasher.Hash(req.NewPassword)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password hashing failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	if err = as.UserRepo.UpdatePassword(conn, user.ID, hashedPassword); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	// the tokens of the caller are issued after the cutoff second, the ones of the other sessions before it
+	if err = endSessions(ctx, conn, as.TokenRepo, as.Denylist, user.ID, time.Now().Add(-time.Second)); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	as.audit(model.AuditPasswordChange, user.ID.String(), user.ID.String(), model.AuditSuccess, info, nil)
+
+	method := userData.AuthMethod
+	if method == "" {
+		method = config.SignInPassword
+	}
+	issued, err := as.TokenIssuer.Issue(conn, user, userData.OrgId, method, info.DPoPThumbprint)
+	if err != nil {
+		// the password is changed either way, the user signs in again
+		log.GetLog().Info("WARN : ", "Tokens not issued after the password change: %s", err.Error())
+		return u.ResponseSuccessWithObj(msg.PasswordChanged, nil)
+	}
+	resp := v1resp.SigninResponse{RefreshToken: issued.RefreshToken, AccessToken: issued.AccessToken}
+	if issued.Organization != nil {
+		resp.OrgId = &issued.Organization.ID
+	}
+	return u.ResponseSuccessWithObj(msg.PasswordChanged, resp)
+}
+
+// ForgotPassword is made for mailing a single use password reset link.
+// The response is the same whether the email is registered or not.
+func (as *AuthService) ForgotPassword(ctx context.Context, req v1req.ForgotPasswordRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Auth Service Called(ForgotPassword).")
+	conn := database.NewConnection()
+
+	user, err := as.UserRepo.GetUserByEmail(conn, req.Email)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if user == nil || user.ID == uuid.Nil {
+		log.GetLog().Info("WARN : ", "Password reset requested for unknown email")
+		as.audit(model.AuditPasswordForgot, "", "", model.AuditFailure, info, map[string]interface{}{"email_sha256": auditEmail(req.Email), "reason": msg.EmailNotRegistered})
+		return u.ResponseSuccessWithObj(msg.PasswordResetSent, nil)
+	}
+
+	// from here on a failure is only logged, answering with an error for registered emails alone would
+	// tell which ones are
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseSuccessWithObj(msg.PasswordResetSent, nil)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	ttl := time.Duration(as.Config.Password().ResetTokenTTL) * time.Minute
+	if err = cache.SetValue(ctx, passwordResetKey(token), user.ID.String(), ttl); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseSuccessWithObj(msg.PasswordResetSent, nil)
+	}
+
+	data := map[string]interface{}{
+		"FirstName":        user.FirstName,
+		"ExpiresInMinutes": as.Config.Password().ResetTokenTTL,
+		"Link":             fmt.Sprintf("%s/reset-password?token=%s", as.Config.App().PublicURL, token),
+	}
+	if err = as.Mailer.SendTemplate(user.Email, mail.TemplatePasswordReset, data); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseSuccessWithObj(msg.PasswordResetSent, nil)
+	}
+
+	as.audit(model.AuditPasswordForgot, "", user.ID.String(), model.AuditSuccess, info, nil)
+
+	return u.ResponseSuccessWithObj(msg.PasswordResetSent, nil)
+}
+
+// ResetPassword is made for setting a new password with a token from ForgotPassword
+func (as *AuthService) ResetPassword(ctx context.Context, req v1req.ResetPasswordRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Auth Service Called(ResetPassword).")
+	conn := database.NewConnection()
+
+	key := passwordResetKey(req.Token)
+	value, err := cache.GetValue(ctx, key)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	userID, err := uuid.FromString(value)
+	if err != nil {
+		log.GetLog().Info("WARN : ", "Unknown password reset token")
+		as.audit(model.AuditPasswordReset, "", "", model.AuditFailure, info, map[string]interface{}{"reason": msg.InvalidResetToken})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidResetToken)
+	}
+
+	user, err := as.UserRepo.GetUserById(conn, userID)
+	if err != nil || user == nil || user.ID == uuid.Nil {
+		log.GetLog().Info("ERROR : ", "User not found")
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidResetToken)
+	}
+
+	if err = as.PasswordPolicy.Validate(req.NewPassword, user.FirstName, user.LastName, user.Email); err != nil {
+		log.GetLog().Info("WARN : ", "Password policy failed: %s", err.Error())
+		as.audit(model.AuditPasswordReset, user.ID.String(), user.ID.String(), model.AuditFailure, info, map[string]interface{}{"reason": err.Error()})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, err.Error())
+	}
+
+	hashedPassword, err := as.PasswordHasher.Hash(req.NewPassword)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Password hashing failed: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	// the token is single use, of concurrent requests with it only the one removing it goes on
+	consumed, err := cache.DeleteValueIfEqual(ctx, key, value)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if !consumed {
+		log.GetLog().Info("WARN : ", "Password reset token already used")
+		as.audit(model.AuditPasswordReset, "", user.ID.String(), model.AuditFailure, info, map[string]interface{}{"reason": msg.InvalidResetToken})
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidResetToken)
+	}
+
+	if err = as.UserRepo.UpdatePassword(conn, user.ID, hashedPassword); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	// every existing session ends with the reset
+	if err = endSessions(ctx, conn, as.TokenRepo, as.Denylist, user.ID, time.Now()); err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+	}
+
+	as.audit(model.AuditPasswordReset, user.ID.String(), user.ID.String(), model.AuditSuccess, info, nil)
+
+	return u.ResponseSuccessWithObj(msg.PasswordReset, nil)
+}
+
+// passwordResetKey stores only a digest of the token so a Redis dump does not leak usable links
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return passwordResetKeyPrefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package v1Service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1resp "test-task/resources/response/v1"
+	"test-task/shared/cache"
+	u "test-task/shared/common"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const effectivePermissionsKeyPrefix = "effective_permissions_"
+
+// effectivePermissionsTTL bounds how long a missed invalidation can keep stale permissions
+const effectivePermissionsTTL = 10 * time.Minute
+
+type IPermissionService interface {
+	middleware.IPermissionResolver
+	Invalidate(ctx context.Context, userIDs ...uuid.UUID)
+	GetPermissions(ctx context.Context, userID uuid.UUID) map[string]interface{}
+}
+
+type PermissionService struct {
+	UserRepo  v1repo.IUserRepository
+	GroupRepo v1repo.IGroupRepository
+}
+
+func NewPermissionService() IPermissionService {
+	return &PermissionService{
+		UserRepo:  v1repo.NewUserWriter(),
+		GroupRepo: v1repo.NewGroupWriter(),
+	}
+}
+
+// EffectivePermissions is made for resolving the user role plus the roles of the user's groups.
+// The result is cached in Redis until a membership or role change invalidates it.
+func (ps *PermissionService) EffectivePermissions(ctx context.Context, userID uuid.UUID) (*middleware.EffectivePermissions, error) {
+	key := effectivePermissionsKey(userID)
+	if cached, err := cache.GetValue(ctx, key); err == nil && cached != "" {
+		var effective middleware.EffectivePermissions
+		if err = json.Unmarshal([]byte(cached), &effective); err == nil {
+			return &effective, nil
+		}
+	}
+
+	// the master, a lagging replica right after an invalidation would put the old roles back in the cache
+	conn := database.NewConnection()
+	roles := []string{}
+	user, err := ps.UserRepo.GetUserById(conn, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && user.Role != "" {
+		roles = append(roles, user.Role)
+	}
+	groupRoles, err := ps.GroupRepo.GetUserGroupRoles(conn, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range groupRoles {
+		if !contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	effective := &middleware.EffectivePermissions{Roles: roles, Permissions: model.PermissionsOf(roles...)}
+	if encoded, err := json.Marshal(effective); err == nil {
+		if err = cache.SetValue(ctx, key, string(encoded), effectivePermissionsTTL); err != nil {
+			log.GetLog().Info("WARN : ", "Effective permissions not cached: %s", err.Error())
+		}
+	}
+	return effective, nil
+}
+
+// Invalidate is made for dropping the cached permissions after a membership or role change
+func (ps *PermissionService) Invalidate(ctx context.Context, userIDs ...uuid.UUID) {
+	if len(userIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, effectivePermissionsKey(id))
+	}
+	if err := cache.DeleteValue(ctx, keys...); err != nil {
+		log.GetLog().Error("ERROR : ", "Effective permissions not invalidated: %s", err.Error())
+	}
+}
+
+// GetPermissions is made for showing the signed in user what they are allowed to do
+func (ps *PermissionService) GetPermissions(ctx context.Context, userID uuid.UUID) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Permission Service Called(GetPermissions).")
+
+	effective, err := ps.EffectivePermissions(ctx, userID)
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	return u.ResponseSuccessWithObj(msg.PermissionsFetched, v1resp.PermissionsResponse{Roles: effective.Roles, Permissions: effective.Permissions})
+}
+
+func effectivePermissionsKey(userID uuid.UUID) string {
+	return effectivePermissionsKeyPrefix + userID.String()
+}
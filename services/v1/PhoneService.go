@@ -0,0 +1,194 @@
+package v1Service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"test-task/model"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// AddPhone is made for texting a verification code to a new phone number of the signed in user,
+// the number is stored once the code is entered
+func (as *AccountService) AddPhone(ctx context.Context, userID uuid.UUID, req v1req.PhoneRequest) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Account Service Called(AddPhone).")
+	conn := database.NewConnection()
+
+	owner, err := as.UserRepo.GetUserByPhone(conn, req.Phone)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if owner != nil && owner.ID != userID {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.PhoneInUse)
+	}
+
+	if err = as.OTP.Send(ctx, otpPurposePhoneVerify, req.Phone, userID); err != nil {
+		return otpSendError(err)
+	}
+	return u.ResponseSuccessWithObj(msg.PhoneCodeSent, nil)
+}
+
+// SendPhoneVerification is made for texting the code that verifies the phone given at sign-up
+func (as *AccountService) SendPhoneVerification(ctx context.Context, userID uuid.UUID, phone string) error {
+	return as.OTP.Send(ctx, otpPurposePhoneVerify, phone, userID)
+}
+
+// VerifyPhone is made for storing the phone of the user the code was sent for, no session is needed
+// so a phone given at sign-up can be verified before the first sign-in
+func (as *AccountService) VerifyPhone(ctx context.Context, req v1req.VerifyPhoneRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Account Service Called(VerifyPhone).")
+	conn := database.NewConnection()
+
+	userID, err := as.OTP.Verify(ctx, otpPurposePhoneVerify, req.Phone, req.Code)
+	if err != nil {
+		as.Audit.Record(AuditEntry{
+			EventType: model.AuditPhoneVerified,
+			Outcome:   model.AuditFailure,
+			Metadata:  map[string]interface{}{"reason": err.Error()},
+			Info:      info,
+		})
+		return otpVerifyError(err)
+	}
+
+	user, err := as.UserRepo.GetUserById(conn, userID)
+	if err != nil || user == nil {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidOTP)
+	}
+	owner, err := as.UserRepo.GetUserByPhone(conn, req.Phone)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	// someone else may have verified the number since the code was sent
+	if owner != nil && owner.ID != userID {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.PhoneInUse)
+	}
+
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	if err = as.UserRepo.SetVerifiedPhone(tx, userID, req.Phone); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	phone := req.Phone
+	user.Phone, user.UpdatedAt = &phone, time.Now()
+	if err = appendUserEvent(tx, as.OutboxRepo, model.WebhookUserUpdated, user, "phone"); err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	tx.CommitTransaction()
+
+	as.Audit.Record(AuditEntry{
+		EventType: model.AuditPhoneVerified,
+		ActorID:   userID.String(),
+		TargetID:  userID.String(),
+		Outcome:   model.AuditSuccess,
+		Info:      info,
+	})
+	return u.ResponseSuccessWithObj(msg.PhoneVerified, nil)
+}
+
+// SendSignInOTP is made for texting a sign-in code to a verified phone. The response is the same
+// whether the number is registered or not.
+func (as *AuthService) SendSignInOTP(ctx context.Context, req v1req.OTPRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Auth Service Called(SendSignInOTP).")
+	conn := database.NewConnection()
+
+	user, err := as.UserRepo.GetUserByPhone(conn, req.Phone)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if user == nil || user.Status != model.UserStatusActive {
+		as.audit(model.AuditOTPSent, "", "", model.AuditFailure, info, map[string]interface{}{"reason": msg.EmailNotRegistered})
+		return u.ResponseSuccessWithObj(msg.OTPSent, nil)
+	}
+
+	err = as.OTP.Send(ctx, otpPurposeSignIn, req.Phone, user.ID)
+	if errors.Is(err, ErrOTPTooSoon) || errors.Is(err, ErrOTPAttempts) {
+		// answering differently would tell which numbers are registered
+		return u.ResponseSuccessWithObj(msg.OTPSent, nil)
+	}
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Sign-in code not sent: %s", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	as.audit(model.AuditOTPSent, "", user.ID.String(), model.AuditSuccess, info, nil)
+	return u.ResponseSuccessWithObj(msg.OTPSent, nil)
+}
+
+// SignInWithOTP is made for signing in with a code from SendSignInOTP
+func (as *AuthService) SignInWithOTP(ctx context.Context, req v1req.OTPSignInRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Auth Service Called(SignInWithOTP).")
+	conn := database.NewConnection()
+
+	userID, err := as.OTP.Verify(ctx, otpPurposeSignIn, req.Phone, req.Code)
+	if err != nil {
+		as.audit(model.AuditSignIn, "", "", model.AuditFailure, info, map[string]interface{}{"method": config.SignInOTP, "reason": err.Error()})
+		return otpVerifyError(err)
+	}
+
+	user, err := as.UserRepo.GetUserById(conn, userID)
+	if err != nil || user == nil || user.Phone == nil || *user.Phone != req.Phone {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidOTP)
+	}
+	if user.Status != model.UserStatusActive {
+		as.audit(model.AuditSignIn, "", user.ID.String(), model.AuditFailure, info, map[string]interface{}{"method": config.SignInOTP, "reason": "status " + user.Status})
+		return statusError(user.Status)
+	}
+
+	var orgID *uuid.UUID
+	if req.OrgID != "" {
+		id := uuid.FromStringOrNil(req.OrgID)
+		orgID = &id
+	}
+	issued, err := as.TokenIssuer.Issue(conn, user, orgID, config.SignInOTP, info.DPoPThumbprint)
+	if err != nil {
+		return as.tokenIssueError(model.AuditSignIn, user.ID, info, err)
+	}
+
+	as.audit(model.AuditSignIn, user.ID.String(), user.ID.String(), model.AuditSuccess, info, map[string]interface{}{"method": config.SignInOTP})
+	as.Account.RecordSignIn(user, info)
+
+	resp := v1resp.SigninResponse{RefreshToken: issued.RefreshToken, AccessToken: issued.AccessToken}
+	if issued.Organization != nil {
+		resp.OrgId = &issued.Organization.ID
+	}
+	return u.ResponseSuccessWithObj(msg.SignInSuccess, resp)
+}
+
+func otpSendError(err error) map[string]interface{} {
+	if errors.Is(err, ErrOTPTooSoon) {
+		return u.ResponseErrorWithCode(http.StatusTooManyRequests, msg.OTPTooSoon)
+	}
+	if errors.Is(err, ErrOTPAttempts) {
+		return u.ResponseErrorWithCode(http.StatusTooManyRequests, msg.OTPAttemptsExceeded)
+	}
+	log.GetLog().Error("ERROR : ", "Code not sent: %s", err.Error())
+	return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+}
+
+func otpVerifyError(err error) map[string]interface{} {
+	switch {
+	case errors.Is(err, ErrOTPInvalid):
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidOTP)
+	case errors.Is(err, ErrOTPAttempts):
+		return u.ResponseErrorWithCode(http.StatusTooManyRequests, msg.OTPAttemptsExceeded)
+	}
+	log.GetLog().Error("ERROR : ", "Code not verified: %s", err.Error())
+	return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+}
@@ -0,0 +1,162 @@
+package v1Service
+
+import (
+	"errors"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	// ErrNotOrgMember is returned when the requested organization is not one of the user
+	ErrNotOrgMember = errors.New("user is not a member of the organization")
+	// ErrSignInMethodNotAllowed is returned when the organization does not allow the sign-in method
+	ErrSignInMethodNotAllowed = errors.New("sign-in method is not allowed")
+)
+
+// IssuedTokens is the pair handed to the client after a sign in, refresh or organization switch
+type IssuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+	Organization *model.Organization
+	OrgRole      string
+}
+
+// ITokenIssuer resolves the active organization of a session and issues its tokens
+// with the organization's settings applied over the global App config. A non empty jkt
+// binds the tokens to the DPoP key with that thumbprint.
+type ITokenIssuer interface {
+	Issue(conn database.IConnection, user *model.User, orgID *uuid.UUID, method, jkt string) (*IssuedTokens, error)
+	AccessToken(conn database.IConnection, user *model.User, orgID *uuid.UUID, method, jkt string) (*IssuedTokens, error)
+}
+
+type TokenIssuer struct {
+	Config    config.IConfig
+	OrgRepo   v1repo.IOrganizationRepository
+	TokenRepo v1repo.ITokenRepository
+}
+
+func NewTokenIssuer(cf config.IConfig) ITokenIssuer {
+	return &TokenIssuer{
+		Config:    cf,
+		OrgRepo:   v1repo.NewOrganizationWriter(),
+		TokenRepo: v1repo.NewTokenWriter(),
+	}
+}
+
+// Issue creates an access and a refresh token and stores the refresh token with
+// the session's organization. A nil orgID selects the user's first organization.
+func (ti *TokenIssuer) Issue(conn database.IConnection, user *model.User, orgID *uuid.UUID, method, jkt string) (*IssuedTokens, error) {
+	issued, err := ti.AccessToken(conn, user, orgID, method, jkt)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL := middleware.RefreshTokenTTL
+	if issued.Organization != nil && issued.Organization.RefreshTokenTTL > 0 {
+		refreshTTL = time.Duration(issued.Organization.RefreshTokenTTL) * time.Hour
+	}
+	issued.RefreshToken, err = middleware.GenerateBoundRefreshToken(user.ID, refreshTTL, jkt)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenData := &model.UserRefreshToken{
+		UserID:       user.ID,
+		RefreshToken: issued.RefreshToken,
+		ExpiresAt:    time.Now().Add(refreshTTL),
+		AuthMethod:   method,
+		DPoPJkt:      jkt,
+	}
+	if issued.Organization != nil {
+		refreshTokenData.ActiveOrgID = issued.Organization.ID
+	}
+	if err = ti.TokenRepo.SaveRefreshToken(conn, refreshTokenData); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// AccessToken creates only the access token, used when the refresh token is kept
+func (ti *TokenIssuer) AccessToken(conn database.IConnection, user *model.User, orgID *uuid.UUID, method, jkt string) (*IssuedTokens, error) {
+	org, member, err := ti.resolveOrganization(conn, user.ID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := ti.Config.App().SignInMethods
+	if org != nil && len(org.SignInMethods()) > 0 {
+		methods = org.SignInMethods()
+	}
+	if !contains(methods, method) {
+		return nil, ErrSignInMethodNotAllowed
+	}
+
+	tokenData := middleware.UserTokenData{
+		Id:         user.ID,
+		Email:      user.Email,
+		Role:       user.Role,
+		CreatedAt:  user.CreatedAt,
+		AuthMethod: method,
+	}
+	accessTTL := middleware.AccessTokenTTL
+	issued := &IssuedTokens{}
+	if org != nil {
+		tokenData.OrgId = &org.ID
+		tokenData.OrgRole = member.Role
+		issued.Organization = org
+		issued.OrgRole = member.Role
+		if org.AccessTokenTTL > 0 {
+			accessTTL = time.Duration(org.AccessTokenTTL) * time.Minute
+		}
+	}
+
+	issued.AccessToken, err = middleware.GenerateBoundToken(tokenData, accessTTL, jkt)
+	if err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+func (ti *TokenIssuer) resolveOrganization(conn database.IConnection, userID uuid.UUID, orgID *uuid.UUID) (*model.Organization, *model.OrganizationMember, error) {
+	if orgID == nil || *orgID == uuid.Nil {
+		memberships, err := ti.OrgRepo.GetUserMemberships(conn, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(memberships) == 0 || memberships[0].Organization == nil {
+			return nil, nil, nil
+		}
+		return memberships[0].Organization, &memberships[0], nil
+	}
+
+	member, err := ti.OrgRepo.GetMember(conn.WithTenant(*orgID), userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil {
+		return nil, nil, ErrNotOrgMember
+	}
+	org, err := ti.OrgRepo.GetOrganizationById(conn, *orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if org == nil {
+		return nil, nil, ErrNotOrgMember
+	}
+	return org, member, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package v1Service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1resp "test-task/resources/response/v1"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/eventbus"
+	"test-task/shared/log"
+	"time"
+)
+
+const userPurgeBatchSize = 100
+
+type IUserPurgeService interface {
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// UserPurgeService removes the personal data of users deleted longer than Account.RestoreWindow ago
+type UserPurgeService struct {
+	Config       config.IConfig
+	UserRepo     v1repo.IUserRepository
+	TokenRepo    v1repo.ITokenRepository
+	ActivityRepo v1repo.ILoginActivityRepository
+	OrgRepo      v1repo.IOrganizationRepository
+	GroupRepo    v1repo.IGroupRepository
+	InviteRepo   v1repo.IInvitationRepository
+	OutboxRepo   v1repo.IOutboxRepository
+	WebhookRepo  v1repo.IWebhookRepository
+	Audit        IAuditService
+}
+
+func NewUserPurgeService(cf config.IConfig, auditService IAuditService) IUserPurgeService {
+	return &UserPurgeService{
+		Config:       cf,
+		UserRepo:     v1repo.NewUserWriter(),
+		TokenRepo:    v1repo.NewTokenWriter(),
+		ActivityRepo: v1repo.NewLoginActivityWriter(),
+		OrgRepo:      v1repo.NewOrganizationWriter(),
+		GroupRepo:    v1repo.NewGroupWriter(),
+		InviteRepo:   v1repo.NewInvitationWriter(),
+		OutboxRepo:   v1repo.NewOutboxWriter(),
+		WebhookRepo:  v1repo.NewWebhookWriter(),
+		Audit:        auditService,
+	}
+}
+
+// PurgeExpired purges every deleted user past the restore window and returns how many were purged.
+// A user that fails is logged and retried on the next run, the run then reports how many failed.
+// It stops between two users once ctx is done.
+func (ps *UserPurgeService) PurgeExpired(ctx context.Context) (int, error) {
+	deletedBefore := time.Now().Add(-time.Duration(ps.Config.Account().RestoreWindow) * 24 * time.Hour)
+	purged, failed := 0, 0
+	var lastErr error
+	for {
+		users, err := ps.UserRepo.GetPurgeableUsers(database.NewConnection(), deletedBefore, userPurgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("purgeable users not loaded: %w", err)
+		}
+
+		done := 0
+		for i := range users {
+			if err = ctx.Err(); err != nil {
+				return purged + done, err
+			}
+			if err = ps.purgeUser(&users[i]); err != nil {
+				log.GetLog().Error("ERROR : ", "User %s not purged: %s", users[i].ID, err.Error())
+				failed, lastErr = failed+1, err
+				continue
+			}
+			done++
+		}
+		purged += done
+
+		// a short batch was the last one, a batch without any success would only repeat itself
+		if len(users) < userPurgeBatchSize || done == 0 {
+			if failed > 0 {
+				return purged, fmt.Errorf("%d users not purged, the last one: %w", failed, lastErr)
+			}
+			return purged, nil
+		}
+	}
+}
+
+// purgeUser scrubs the user row and removes the rows holding their personal data in one transaction
+func (ps *UserPurgeService) purgeUser(user *model.User) error {
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	steps := []func() error{
+		func() error { return ps.TokenRepo.DeleteUserTokens(conn, user.ID) },
+		func() error { return ps.ActivityRepo.DeleteActivities(conn, user.ID) },
+		func() error { return ps.OrgRepo.RemoveMember(conn, user.ID) },
+		func() error { return ps.GroupRepo.RemoveUserFromGroups(conn, user.ID) },
+		func() error { return ps.InviteRepo.DeleteInvitationsByEmail(conn, user.Email) },
+		func() error { return ps.redactEvents(conn, user) },
+		func() error { return ps.UserRepo.PurgeUser(conn, user.ID) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			conn.RollbackTransaction()
+			return err
+		}
+	}
+	conn.CommitTransaction()
+
+	ps.Audit.Record(AuditEntry{
+		EventType: model.AuditUserPurge,
+		TargetID:  user.ID.String(),
+		Outcome:   model.AuditSuccess,
+		Metadata:  map[string]interface{}{"deleted_at": user.DeletedAt},
+	})
+	return nil
+}
+
+// redactEvents replaces the user in the outbox messages and webhook deliveries of their events with the id
+// and status alone. The events stay, so the history of deliveries still adds up.
+func (ps *UserPurgeService) redactEvents(conn database.IConnection, user *model.User) error {
+	data, err := json.Marshal(eventbus.UserEventV1{User: eventbus.UserV1{Id: user.ID, Status: user.Status, CreatedAt: user.CreatedAt}})
+	if err != nil {
+		return err
+	}
+	if err = ps.OutboxRepo.ReplacePayloads(conn, model.AggregateUser, user.ID.String(), string(data)); err != nil {
+		return err
+	}
+
+	deliveries, err := ps.WebhookRepo.GetAggregateDeliveries(conn, user.ID.String())
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		var event v1resp.WebhookPayload
+		if err = json.Unmarshal([]byte(deliveries[i].Payload), &event); err != nil {
+			return fmt.Errorf("delivery %s not decoded: %w", deliveries[i].ID, err)
+		}
+		event.Data = data
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err = ps.WebhookRepo.UpdateDeliveryPayload(conn, deliveries[i].ID, string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
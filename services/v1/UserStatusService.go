@@ -0,0 +1,267 @@
+package v1Service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/denylist"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// userStatusMissing is cached for users that do not exist, so unknown ids do not hit the database either
+const userStatusMissing = "missing"
+
+// maxCachedStatuses bounds the local status cache, the expired entries are dropped when it is reached
+const maxCachedStatuses = 100000
+
+type cachedStatus struct {
+	status string
+	until  time.Time
+}
+
+type IUserStatusService interface {
+	middleware.IUserStatusResolver
+	ChangeStatus(ctx context.Context, admin middleware.UserTokenData, userID uuid.UUID, req v1req.UserStatusRequest, info u.RequestInfo) map[string]interface{}
+	RestoreUser(ctx context.Context, admin middleware.UserTokenData, userID uuid.UUID, req v1req.RestoreUserRequest, info u.RequestInfo) map[string]interface{}
+}
+
+type UserStatusService struct {
+	Config     config.IConfig
+	UserRepo   v1repo.IUserRepository
+	TokenRepo  v1repo.ITokenRepository
+	OutboxRepo v1repo.IOutboxRepository
+	Audit      IAuditService
+	Denylist   denylist.IDenylist
+
+	mu       sync.Mutex
+	statuses map[uuid.UUID]cachedStatus
+}
+
+func NewUserStatusService(cf config.IConfig, auditService IAuditService, denied denylist.IDenylist) IUserStatusService {
+	ss := &UserStatusService{
+		Config:     cf,
+		UserRepo:   v1repo.NewUserWriter(),
+		TokenRepo:  v1repo.NewTokenWriter(),
+		OutboxRepo: v1repo.NewOutboxWriter(),
+		Audit:      auditService,
+		Denylist:   denied,
+		statuses:   map[uuid.UUID]cachedStatus{},
+	}
+	denied.OnUserChanged(ss.forget)
+	return ss
+}
+
+// UserStatus is made for the status check of AuthHandler, the status is kept in memory for
+// Account.StatusCacheTTL seconds. A status change on any instance drops it through the denylist channel.
+func (ss *UserStatusService) UserStatus(ctx context.Context, userID uuid.UUID) (string, error) {
+	now := time.Now()
+	ss.mu.Lock()
+	cached, ok := ss.statuses[userID]
+	ss.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		if cached.status == userStatusMissing {
+			return "", nil
+		}
+		return cached.status, nil
+	}
+
+	user, err := ss.UserRepo.GetUserById(database.NewSlaveConnection(), userID)
+	if err != nil {
+		return "", err
+	}
+	status := userStatusMissing
+	if user != nil {
+		status = user.Status
+	}
+	ss.store(userID, cachedStatus{status: status, until: now.Add(time.Duration(ss.Config.Account().StatusCacheTTL) * time.Second)}, now)
+
+	if status == userStatusMissing {
+		return "", nil
+	}
+	return status, nil
+}
+
+func (ss *UserStatusService) store(userID uuid.UUID, entry cachedStatus, now time.Time) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if len(ss.statuses) >= maxCachedStatuses {
+		for id, cached := range ss.statuses {
+			if !now.Before(cached.until) {
+				delete(ss.statuses, id)
+			}
+		}
+		if len(ss.statuses) >= maxCachedStatuses {
+			ss.statuses = map[uuid.UUID]cachedStatus{}
+		}
+	}
+	ss.statuses[userID] = entry
+}
+
+// forget drops the cached status of the user, of every user for an empty id
+func (ss *UserStatusService) forget(userID string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if userID == "" {
+		ss.statuses = map[uuid.UUID]cachedStatus{}
+		return
+	}
+	delete(ss.statuses, uuid.FromStringOrNil(userID))
+}
+
+// ChangeStatus is made for moving a user to another status with a reason. Leaving the active
+// status signs the user out everywhere.
+func (ss *UserStatusService) ChangeStatus(ctx context.Context, admin middleware.UserTokenData, userID uuid.UUID, req v1req.UserStatusRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "User Status Service Called(ChangeStatus).")
+	conn := database.NewConnection()
+
+	entry := AuditEntry{
+		EventType: model.AuditUserStatus,
+		ActorID:   admin.Id.String(),
+		TargetID:  userID.String(),
+		Info:      info,
+		Metadata:  map[string]interface{}{"to": req.Status, "reason": req.Reason},
+	}
+
+	user, err := ss.UserRepo.GetUserById(conn, userID)
+	if err != nil || user == nil {
+		log.GetLog().Info("WARN : ", "User not found.")
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+	}
+	entry.Metadata["from"] = user.Status
+
+	if !model.CanTransition(user.Status, req.Status) {
+		entry.Outcome = model.AuditFailure
+		ss.Audit.Record(entry)
+		return u.ResponseErrorWithCode(http.StatusBadRequest, fmt.Sprintf(msg.InvalidTransition, user.Status, req.Status))
+	}
+	if req.Status != model.UserStatusActive && strings.TrimSpace(req.Reason) == "" {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.StatusReasonRequired)
+	}
+	// an admin can not lock themselves out
+	if userID == admin.Id {
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.Forbidden)
+	}
+
+	// deleting is a soft delete, the user can be restored until Account.RestoreWindow ends
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	if req.Status == model.UserStatusDeleted {
+		err = ss.UserRepo.SoftDeleteUser(tx, userID, strings.TrimSpace(req.Reason))
+		if err == nil {
+			user.Status, user.UpdatedAt = req.Status, time.Now()
+			err = appendUserEvent(tx, ss.OutboxRepo, model.WebhookUserDeleted, user)
+		}
+	} else {
+		err = ss.UserRepo.UpdateStatus(tx, userID, req.Status, strings.TrimSpace(req.Reason))
+		if err == nil {
+			user.Status, user.UpdatedAt = req.Status, time.Now()
+			err = appendUserEvent(tx, ss.OutboxRepo, model.WebhookUserUpdated, user, "status")
+		}
+	}
+	if err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	tx.CommitTransaction()
+	clearUserStatus(ctx, ss.Denylist, userID)
+
+	if req.Status != model.UserStatusActive {
+		if err = ss.TokenRepo.DeleteUserTokens(conn, userID); err != nil {
+			log.GetLog().Error("ERROR(from repo) : ", "Refresh tokens not revoked: %s", err.Error())
+		}
+	}
+
+	entry.Outcome = model.AuditSuccess
+	ss.Audit.Record(entry)
+
+	return u.ResponseSuccessWithObj(msg.UserStatusChanged, v1resp.UserStatusResponse{Id: userID, Status: req.Status})
+}
+
+// RestoreUser is made for bringing back a deleted user before the restore window ends, the user comes back active
+func (ss *UserStatusService) RestoreUser(ctx context.Context, admin middleware.UserTokenData, userID uuid.UUID, req v1req.RestoreUserRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "User Status Service Called(RestoreUser).")
+	conn := database.NewConnection()
+
+	user, err := ss.UserRepo.GetDeletedUser(conn, userID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if user == nil {
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.UserNotFound)
+	}
+
+	entry := AuditEntry{
+		EventType: model.AuditUserRestore,
+		ActorID:   admin.Id.String(),
+		TargetID:  userID.String(),
+		Info:      info,
+		Metadata:  map[string]interface{}{"reason": req.Reason, "deleted_at": user.DeletedAt},
+	}
+
+	window := time.Duration(ss.Config.Account().RestoreWindow) * 24 * time.Hour
+	if time.Since(*user.DeletedAt) > window {
+		entry.Outcome = model.AuditFailure
+		ss.Audit.Record(entry)
+		return u.ResponseErrorWithCode(http.StatusGone, msg.RestoreWindowEnded)
+	}
+
+	// subscribers saw the user.deleted event, the restored user comes back as a status change
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	err = ss.UserRepo.RestoreUser(tx, userID, strings.TrimSpace(req.Reason))
+	if err == nil {
+		user.Status, user.DeletedAt, user.UpdatedAt = model.UserStatusActive, nil, time.Now()
+		err = appendUserEvent(tx, ss.OutboxRepo, model.WebhookUserUpdated, user, "status")
+	}
+	if err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	tx.CommitTransaction()
+	clearUserStatus(ctx, ss.Denylist, userID)
+
+	entry.Outcome = model.AuditSuccess
+	ss.Audit.Record(entry)
+
+	return u.ResponseSuccessWithObj(msg.UserRestored, v1resp.UserStatusResponse{Id: userID, Status: model.UserStatusActive})
+}
+
+// clearUserStatus drops the cached status on every instance so the next request reads the new one
+func clearUserStatus(ctx context.Context, denied denylist.IDenylist, userID uuid.UUID) {
+	if err := denied.UserChanged(ctx, userID.String()); err != nil {
+		log.GetLog().Error("ERROR : ", "User status change not published: %s", err.Error())
+	}
+}
+
+// statusError is the response for a user whose status does not allow signing in
+func statusError(status string) map[string]interface{} {
+	switch status {
+	case model.UserStatusPendingVerification:
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.AccountPending)
+	case model.UserStatusSuspended:
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.AccountSuspended)
+	case model.UserStatusLocked:
+		return u.ResponseErrorWithCode(http.StatusForbidden, msg.AccountLocked)
+	case model.UserStatusDeleted:
+		// deleted accounts look like unknown ones
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.EmailNotRegistered)
+	}
+	return u.ResponseErrorWithCode(http.StatusForbidden, msg.AccountInactive)
+}
@@ -0,0 +1,121 @@
+package v1Service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/denylist"
+	"test-task/shared/eventbus"
+	"test-task/shared/utils/middleware"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// testDenylist drops the status change broadcasts, the tests run a single instance
+type testDenylist struct {
+	denylist.IDenylist
+}
+
+func (d *testDenylist) UserChanged(ctx context.Context, userID string) error { return nil }
+
+func newTestUserStatusService(t *testing.T) (*UserStatusService, *model.User) {
+	t.Helper()
+	conn := database.NewConnection()
+	conn.GetDB().Delete(&model.OutboxMessage{})
+	conn.GetDB().Unscoped().Delete(&model.User{})
+
+	ss := &UserStatusService{
+		Config:     &testConfig{account: config.Account{RestoreWindow: 30}},
+		UserRepo:   v1repo.NewUserWriter(),
+		TokenRepo:  v1repo.NewTokenWriter(),
+		OutboxRepo: v1repo.NewOutboxWriter(),
+		Audit:      &testAudit{},
+		Denylist:   &testDenylist{},
+		statuses:   map[uuid.UUID]cachedStatus{},
+	}
+	user := &model.User{ID: uuid.NewV4(), FirstName: "Test", LastName: "User", Email: "status@example.com", Password: "-", Role: model.RoleUser, Status: model.UserStatusActive}
+	if err := ss.UserRepo.CreateUser(conn, user); err != nil {
+		t.Fatal(err)
+	}
+	return ss, user
+}
+
+// userEvents returns the outbox messages written for the user, oldest first
+func userEvents(t *testing.T, userID uuid.UUID) []model.OutboxMessage {
+	t.Helper()
+	var messages []model.OutboxMessage
+	err := database.NewConnection().GetDB().
+		Where("aggregate_type = ? AND aggregate_id = ?", model.AggregateUser, userID.String()).
+		Order("sequence asc").Find(&messages).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+func checkStatusEvent(t *testing.T, message model.OutboxMessage, eventType, status string, changes []string) {
+	t.Helper()
+	var data eventbus.UserEventV1
+	if err := json.Unmarshal([]byte(message.Payload), &data); err != nil {
+		t.Fatal(err)
+	}
+	if message.EventType != eventType || data.User.Status != status || !reflect.DeepEqual(data.Changes, changes) {
+		t.Fatalf("event %s with status %q and changes %v, want %s with status %q and changes %v",
+			message.EventType, data.User.Status, data.Changes, eventType, status, changes)
+	}
+}
+
+func TestUserStatusEvents(t *testing.T) {
+	ss, user := newTestUserStatusService(t)
+	ctx := context.Background()
+	admin := middleware.UserTokenData{Id: uuid.NewV4(), Role: model.RoleAdmin}
+
+	change := func(status string) {
+		t.Helper()
+		resp := ss.ChangeStatus(ctx, admin, user.ID, v1req.UserStatusRequest{Status: status, Reason: "support ticket"}, u.RequestInfo{})
+		if _, failed := resp["res_code"]; failed {
+			t.Fatalf("ChangeStatus to %s: %v", status, resp)
+		}
+	}
+
+	change(model.UserStatusSuspended)
+	change(model.UserStatusActive)
+	change(model.UserStatusDeleted)
+	resp := ss.RestoreUser(ctx, admin, user.ID, v1req.RestoreUserRequest{Reason: "deleted by mistake"}, u.RequestInfo{})
+	if _, failed := resp["res_code"]; failed {
+		t.Fatalf("RestoreUser: %v", resp)
+	}
+
+	events := userEvents(t, user.ID)
+	if len(events) != 4 {
+		t.Fatalf("%d events written, want 4", len(events))
+	}
+	checkStatusEvent(t, events[0], model.WebhookUserUpdated, model.UserStatusSuspended, []string{"status"})
+	checkStatusEvent(t, events[1], model.WebhookUserUpdated, model.UserStatusActive, []string{"status"})
+	checkStatusEvent(t, events[2], model.WebhookUserDeleted, model.UserStatusDeleted, nil)
+	checkStatusEvent(t, events[3], model.WebhookUserUpdated, model.UserStatusActive, []string{"status"})
+}
+
+func TestUserStatusRefusedWithoutEvent(t *testing.T) {
+	ss, user := newTestUserStatusService(t)
+	ctx := context.Background()
+
+	// an admin can not change their own status, nothing is written then
+	self := middleware.UserTokenData{Id: user.ID, Role: model.RoleAdmin}
+	resp := ss.ChangeStatus(ctx, self, user.ID, v1req.UserStatusRequest{Status: model.UserStatusSuspended, Reason: "testing"}, u.RequestInfo{})
+	if code, _ := resp["res_code"].(int); code != http.StatusForbidden {
+		t.Fatalf("own status change answered %v, want %d", resp, http.StatusForbidden)
+	}
+	if events := userEvents(t, user.ID); len(events) != 0 {
+		t.Fatalf("%d events written for a refused change, want none", len(events))
+	}
+}
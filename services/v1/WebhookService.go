@@ -0,0 +1,685 @@
+package v1Service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	v1req "test-task/resources/request/v1"
+	v1resp "test-task/resources/response/v1"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+	"test-task/shared/utils"
+	_const "test-task/shared/utils/const"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/utils/middleware"
+	"test-task/shared/webhook"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	webhookSecretPrefix   = "whsec_"
+	webhookResponseLimit  = 1024
+	webhookErrorLimit     = 500
+	webhookUserAgent      = "test-task-webhooks/1"
+	webhookDisabledReason = "disabled after %d failed attempts in a row"
+)
+
+type IWebhookService interface {
+	IOutboxSink
+	Run(ctx context.Context)
+	DeliverDue(ctx context.Context) int
+	CreateEndpoint(admin middleware.UserTokenData, req v1req.CreateWebhookRequest, info u.RequestInfo) map[string]interface{}
+	GetEndpoints() map[string]interface{}
+	GetEndpoint(endpointID uuid.UUID) map[string]interface{}
+	UpdateEndpoint(admin middleware.UserTokenData, endpointID uuid.UUID, req v1req.UpdateWebhookRequest, info u.RequestInfo) map[string]interface{}
+	DeleteEndpoint(admin middleware.UserTokenData, endpointID uuid.UUID, info u.RequestInfo) map[string]interface{}
+	GetDeliveries(endpointID uuid.UUID, req v1req.WebhookDeliveriesRequest) map[string]interface{}
+	Redeliver(admin middleware.UserTokenData, endpointID, deliveryID uuid.UUID, info u.RequestInfo) map[string]interface{}
+}
+
+// WebhookService stores the deliveries of every event the outbox relay publishes and sends them in the
+// background, retrying failed ones with exponential backoff
+type WebhookService struct {
+	Config      config.IConfig
+	WebhookRepo v1repo.IWebhookRepository
+	Audit       IAuditService
+	Client      *http.Client
+
+	// wake cuts the wait of Run short when new deliveries were queued
+	wake chan struct{}
+}
+
+func NewWebhookService(cf config.IConfig, auditService IAuditService) IWebhookService {
+	return &WebhookService{
+		Config:      cf,
+		WebhookRepo: v1repo.NewWebhookWriter(),
+		Audit:       auditService,
+		Client:      newWebhookClient(cf.Webhook()),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// errWebhookAddress is the error of a delivery to an address Webhook.AllowPrivateNetworks does not allow
+var errWebhookAddress = errors.New("webhook address is not public")
+
+// newWebhookClient does not follow redirects and, unless Webhook.AllowPrivateNetworks is set, only connects to
+// public addresses. The address is checked when the connection is made, after the host name was resolved, so a
+// name pointing into the internal network is refused as well. Environment proxies are not used, the check would
+// only see the proxy.
+func newWebhookClient(cf *config.Webhook) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !cf.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", errWebhookAddress, host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   time.Duration(cf.Timeout) * time.Second,
+		Transport: transport,
+		// a redirect is an answer of the endpoint like any other, it is not followed
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// sharedAddressSpace is 100.64.0.0/10, used by carrier-grade NAT and some cloud networks
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP reports whether the address can be reached from the internet, loopback, private, link-local
+// (cloud metadata services), multicast and unspecified addresses can not
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// Publish is made for the outbox relay, it queues a delivery of the message to every enabled endpoint
+// subscribed to its event. The message ID is the event ID, so receivers can drop the duplicates a message
+// published again causes. An error leaves the message in the outbox to be published again.
+func (ws *WebhookService) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	if !model.IsWebhookEvent(message.EventType) {
+		return nil
+	}
+
+	event := v1resp.WebhookPayload{
+		Id:        message.ID,
+		Type:      message.EventType,
+		Version:   message.Version,
+		CreatedAt: message.CreatedAt.UTC(),
+		Data:      json.RawMessage(message.Payload),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+	endpoints, err := ws.WebhookRepo.GetEnabledEndpoints(conn)
+	if err != nil {
+		conn.RollbackTransaction()
+		return err
+	}
+	queued := 0
+	for i := range endpoints {
+		if !endpoints[i].Subscribed(message.EventType) {
+			continue
+		}
+		delivery := newDelivery(endpoints[i].ID, event.Id, message.EventType, message.AggregateID, string(payload))
+		if err = ws.WebhookRepo.CreateDelivery(conn, &delivery); err != nil {
+			conn.RollbackTransaction()
+			return err
+		}
+		queued++
+	}
+	conn.CommitTransaction()
+
+	if queued > 0 {
+		ws.notify()
+	}
+	return nil
+}
+
+// Run sends the due deliveries every Webhook.PollInterval seconds, or as soon as new ones are queued, until ctx is done
+func (ws *WebhookService) Run(ctx context.Context) {
+	for {
+		// a full batch means more are probably waiting
+		for ws.DeliverDue(ctx) == ws.Config.Webhook().BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ws.wake:
+		case <-time.After(time.Duration(ws.Config.Webhook().PollInterval) * time.Second):
+		}
+	}
+}
+
+// DeliverDue sends one batch of due deliveries, Webhook.Workers at a time, and returns how many were taken
+func (ws *WebhookService) DeliverDue(ctx context.Context) int {
+	cf := ws.Config.Webhook()
+	// a delivery is held long enough for its attempt to end, a crashed instance releases it after that
+	heldUntil := time.Now().Add(2 * time.Duration(cf.Timeout) * time.Second)
+
+	tx := database.NewTransaction()
+	defer tx.RollbackOnException()
+	deliveries, err := ws.WebhookRepo.ClaimDueDeliveries(tx, heldUntil, cf.BatchSize)
+	if err != nil {
+		tx.RollbackTransaction()
+		log.GetLog().Error("ERROR(from repo) : ", "Due webhook deliveries not loaded: %s", err.Error())
+		return 0
+	}
+	tx.CommitTransaction()
+
+	endpoints := map[uuid.UUID]*model.WebhookEndpoint{}
+	workers := make(chan struct{}, cf.Workers)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			if endpoint, err = ws.WebhookRepo.GetEndpointById(database.NewConnection(), delivery.EndpointID); err != nil {
+				log.GetLog().Error("ERROR(from repo) : ", "Webhook endpoint %s not loaded: %s", delivery.EndpointID, err.Error())
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+		if endpoint == nil {
+			continue
+		}
+
+		workers <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-workers; wg.Done() }()
+			ws.deliver(ctx, endpoint, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+// deliver makes one attempt of the delivery and records its outcome
+func (ws *WebhookService) deliver(ctx context.Context, endpoint *model.WebhookEndpoint, delivery *model.WebhookDelivery) {
+	cf := ws.Config.Webhook()
+	attempt := model.WebhookAttempt{
+		ID:         uuid.NewV4(),
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+	}
+
+	started := time.Now()
+	status, body, err := ws.send(ctx, endpoint, delivery)
+	if ctx.Err() != nil {
+		// shutting down is not the fault of the endpoint, the delivery is picked up again once it is released
+		return
+	}
+	attempt.CreatedAt = time.Now()
+	attempt.DurationMs = time.Since(started).Milliseconds()
+	attempt.StatusCode = status
+	attempt.ResponseBody = body
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("unexpected status %d", status)
+	}
+
+	delivery.Attempts = attempt.Attempt
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &attempt.CreatedAt
+	} else {
+		attempt.Error = truncate(err.Error(), webhookErrorLimit)
+		if delivery.Attempts >= cf.MaxAttempts {
+			delivery.Status = model.WebhookDeliveryFailed
+		} else {
+			delivery.NextAttemptAt = attempt.CreatedAt.Add(ws.backoff(delivery.Attempts))
+		}
+	}
+
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+	if err := ws.WebhookRepo.CreateAttempt(conn, &attempt); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Error("ERROR(from repo) : ", "Webhook attempt of %s not recorded: %s", delivery.ID, err.Error())
+		return
+	}
+	if err := ws.WebhookRepo.UpdateDelivery(conn, delivery); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Error("ERROR(from repo) : ", "Webhook delivery %s not updated: %s", delivery.ID, err.Error())
+		return
+	}
+	conn.CommitTransaction()
+
+	if err == nil {
+		if err = ws.WebhookRepo.RecordEndpointSuccess(database.NewConnection(), endpoint.ID); err != nil {
+			log.GetLog().Error("ERROR(from repo) : ", "Webhook endpoint %s not updated: %s", endpoint.ID, err.Error())
+		}
+		return
+	}
+
+	log.GetLog().Info("WARN : ", "Webhook delivery %s to %s failed (attempt %d): %s", delivery.ID, endpoint.URL, attempt.Attempt, attempt.Error)
+	reason := fmt.Sprintf(webhookDisabledReason, cf.DisableAfter)
+	disabled, err := ws.WebhookRepo.RecordEndpointFailure(database.NewConnection(), endpoint.ID, cf.DisableAfter, reason)
+	if err != nil {
+		log.GetLog().Error("ERROR(from repo) : ", "Webhook endpoint %s not updated: %s", endpoint.ID, err.Error())
+		return
+	}
+	if disabled {
+		log.GetLog().Info("WARN : ", "Webhook endpoint %s (%s) %s", endpoint.ID, endpoint.URL, reason)
+		ws.Audit.Record(AuditEntry{
+			EventType: model.AuditWebhookDisabled,
+			TargetID:  endpoint.ID.String(),
+			Outcome:   model.AuditSuccess,
+			Metadata:  map[string]interface{}{"url": endpoint.URL, "reason": reason},
+		})
+	}
+}
+
+// send POSTs the signed payload and returns the status and the start of the body of the answer
+func (ws *WebhookService) send(ctx context.Context, endpoint *model.WebhookEndpoint, delivery *model.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(webhook.IDHeader, delivery.ID.String())
+	req.Header.Set(webhook.EventIDHeader, delivery.EventID.String())
+	req.Header.Set(webhook.EventHeader, delivery.EventType)
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(endpoint.Secret, timestamp, body))
+
+	resp, err := ws.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	return resp.StatusCode, string(answer), nil
+}
+
+// backoff is the wait after the given number of failed attempts, doubled each time up to Webhook.RetryMaxDelay.
+// Up to a tenth is added at random so endpoints coming back are not hit by every retry at once.
+func (ws *WebhookService) backoff(attempts int) time.Duration {
+	cf := ws.Config.Webhook()
+	delay := time.Duration(cf.RetryMaxDelay) * time.Second
+	if attempts < 30 {
+		if d := time.Duration(cf.RetryBaseDelay) * time.Second << (attempts - 1); d < delay {
+			delay = d
+		}
+	}
+	return delay + time.Duration(mrand.Int63n(int64(delay)/10+1))
+}
+
+func (ws *WebhookService) notify() {
+	select {
+	case ws.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CreateEndpoint is made for subscribing a URL to events, the signing secret is only returned here
+func (ws *WebhookService) CreateEndpoint(admin middleware.UserTokenData, req v1req.CreateWebhookRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(CreateEndpoint).")
+	if resp := ws.checkEndpoint(req.URL, req.Events); resp != nil {
+		return resp
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		log.GetLog().Info("ERROR : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	now := time.Now()
+	endpoint := model.WebhookEndpoint{
+		ID:          uuid.NewV4(),
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      strings.Join(uniqueStrings(req.Events), ","),
+		Secret:      secret,
+		Enabled:     true,
+		CreatedBy:   admin.Id,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err = ws.WebhookRepo.CreateEndpoint(database.NewConnection(), &endpoint); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	ws.audit(model.AuditWebhookCreate, admin, endpoint.ID.String(), info, map[string]interface{}{"url": endpoint.URL, "events": endpoint.EventList()})
+
+	resp := webhookResponse(&endpoint)
+	resp.Secret = endpoint.Secret
+	return u.ResponseSuccessWithObj(msg.WebhookCreated, resp)
+}
+
+// GetEndpoints is made for listing every webhook endpoint
+func (ws *WebhookService) GetEndpoints() map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(GetEndpoints).")
+	endpoints, err := ws.WebhookRepo.GetEndpoints(database.NewSlaveConnection())
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	list := make([]v1resp.WebhookResponse, 0, len(endpoints))
+	for i := range endpoints {
+		list = append(list, webhookResponse(&endpoints[i]))
+	}
+	return u.ResponseSuccessWithObj(msg.WebhooksFetched, list)
+}
+
+// GetEndpoint is made for fetching one webhook endpoint
+func (ws *WebhookService) GetEndpoint(endpointID uuid.UUID) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(GetEndpoint).")
+	endpoint, resp := ws.loadEndpoint(database.NewSlaveConnection(), endpointID)
+	if resp != nil {
+		return resp
+	}
+	return u.ResponseSuccessWithObj(msg.WebhookFetched, webhookResponse(endpoint))
+}
+
+// UpdateEndpoint is made for changing an endpoint. Enabling it again resets its failure count and
+// rotating the secret returns the new one.
+func (ws *WebhookService) UpdateEndpoint(admin middleware.UserTokenData, endpointID uuid.UUID, req v1req.UpdateWebhookRequest, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(UpdateEndpoint).")
+	conn := database.NewConnection()
+	endpoint, resp := ws.loadEndpoint(conn, endpointID)
+	if resp != nil {
+		return resp
+	}
+
+	changes := map[string]interface{}{}
+	if req.URL != nil {
+		endpoint.URL = *req.URL
+		changes["url"] = endpoint.URL
+	}
+	if req.Events != nil {
+		endpoint.Events = strings.Join(uniqueStrings(req.Events), ",")
+		changes["events"] = endpoint.EventList()
+	}
+	if resp = ws.checkEndpoint(endpoint.URL, endpoint.EventList()); resp != nil {
+		return resp
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.Enabled != nil && *req.Enabled != endpoint.Enabled {
+		endpoint.Enabled = *req.Enabled
+		changes["enabled"] = endpoint.Enabled
+		if endpoint.Enabled {
+			endpoint.ConsecutiveFailures = 0
+			endpoint.DisabledAt = nil
+			endpoint.DisabledReason = ""
+		} else {
+			now := time.Now()
+			endpoint.DisabledAt = &now
+			endpoint.DisabledReason = "disabled by " + admin.Email
+		}
+	}
+	if req.RotateSecret {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			log.GetLog().Info("ERROR : ", err.Error())
+			return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+		}
+		endpoint.Secret = secret
+		changes["secret_rotated"] = true
+	}
+	endpoint.UpdatedAt = time.Now()
+
+	if err := ws.WebhookRepo.UpdateEndpoint(conn, endpoint); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	ws.audit(model.AuditWebhookUpdate, admin, endpoint.ID.String(), info, changes)
+	if endpoint.Enabled {
+		ws.notify()
+	}
+
+	result := webhookResponse(endpoint)
+	if req.RotateSecret {
+		result.Secret = endpoint.Secret
+	}
+	return u.ResponseSuccessWithObj(msg.WebhookUpdated, result)
+}
+
+// DeleteEndpoint is made for removing an endpoint with its delivery logs
+func (ws *WebhookService) DeleteEndpoint(admin middleware.UserTokenData, endpointID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(DeleteEndpoint).")
+	conn := database.NewTransaction()
+	defer conn.RollbackOnException()
+
+	endpoint, resp := ws.loadEndpoint(conn, endpointID)
+	if resp != nil {
+		conn.RollbackTransaction()
+		return resp
+	}
+	if err := ws.WebhookRepo.DeleteEndpoint(conn, endpointID); err != nil {
+		conn.RollbackTransaction()
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	conn.CommitTransaction()
+
+	ws.audit(model.AuditWebhookDelete, admin, endpointID.String(), info, map[string]interface{}{"url": endpoint.URL})
+	return u.ResponseSuccessWithObj(msg.WebhookDeleted, nil)
+}
+
+// GetDeliveries is made for the delivery log of an endpoint, every attempt included
+func (ws *WebhookService) GetDeliveries(endpointID uuid.UUID, req v1req.WebhookDeliveriesRequest) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(GetDeliveries).")
+	conn := database.NewSlaveConnection()
+	if _, resp := ws.loadEndpoint(conn, endpointID); resp != nil {
+		return resp
+	}
+
+	page, size := req.Page, req.Size
+	if page < 1 {
+		page = _const.PageNo
+	}
+	if size < 1 {
+		size = _const.PerPageLimit
+	}
+	deliveries, total, err := ws.WebhookRepo.GetDeliveries(conn, endpointID, page, size)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+
+	resp := v1resp.WebhookDeliveryListResponse{
+		Deliveries: make([]v1resp.WebhookDeliveryResponse, 0, len(deliveries)),
+		Pagination: utils.PageAttr{
+			Page:     page,
+			Size:     size,
+			Total:    total,
+			LastPage: (total + size - 1) / size,
+		},
+	}
+	for i := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, deliveryResponse(&deliveries[i]))
+	}
+	return u.ResponseSuccessWithObj(msg.DeliveriesFetched, resp)
+}
+
+// Redeliver is made for sending the payload of a past delivery again. It becomes a new delivery,
+// so the log of the original one stays as it was.
+func (ws *WebhookService) Redeliver(admin middleware.UserTokenData, endpointID, deliveryID uuid.UUID, info u.RequestInfo) map[string]interface{} {
+	log.GetLog().Info("INFO : ", "Webhook Service Called(Redeliver).")
+	conn := database.NewConnection()
+	if _, resp := ws.loadEndpoint(conn, endpointID); resp != nil {
+		return resp
+	}
+	original, err := ws.WebhookRepo.GetDeliveryById(conn, endpointID, deliveryID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if original == nil {
+		return u.ResponseErrorWithCode(http.StatusNotFound, msg.DeliveryNotFound)
+	}
+
+	delivery := newDelivery(endpointID, original.EventID, original.EventType, original.AggregateID, original.Payload)
+	delivery.RedeliveryOf = &original.ID
+	if err = ws.WebhookRepo.CreateDelivery(conn, &delivery); err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	ws.notify()
+
+	ws.audit(model.AuditWebhookResend, admin, endpointID.String(), info, map[string]interface{}{"delivery_id": original.ID.String(), "redelivery_id": delivery.ID.String()})
+	return u.ResponseSuccessWithObj(msg.WebhookRedelivered, deliveryResponse(&delivery))
+}
+
+// checkEndpoint validates the URL and the event filter of an endpoint
+func (ws *WebhookService) checkEndpoint(rawURL string, events []string) map[string]interface{} {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.InvalidWebhookURL)
+	}
+	if ws.Config.Webhook().RequireHTTPS && parsed.Scheme != "https" {
+		return u.ResponseErrorWithCode(http.StatusBadRequest, msg.WebhookHTTPSRequired)
+	}
+	// names are only resolved when a delivery is sent, addresses and localhost can be refused right away
+	if !ws.Config.Webhook().AllowPrivateNetworks {
+		host := parsed.Hostname()
+		if ip := net.ParseIP(host); strings.EqualFold(host, "localhost") || (ip != nil && !isPublicIP(ip)) {
+			return u.ResponseErrorWithCode(http.StatusBadRequest, msg.WebhookPrivateHost)
+		}
+	}
+	for _, event := range events {
+		if !model.IsWebhookEvent(event) {
+			return u.ResponseErrorWithCode(http.StatusBadRequest, fmt.Sprintf(msg.UnknownWebhookEvent, event))
+		}
+	}
+	return nil
+}
+
+func (ws *WebhookService) loadEndpoint(conn database.IConnection, endpointID uuid.UUID) (*model.WebhookEndpoint, map[string]interface{}) {
+	endpoint, err := ws.WebhookRepo.GetEndpointById(conn, endpointID)
+	if err != nil {
+		log.GetLog().Info("ERROR(from repo) : ", err.Error())
+		return nil, u.ResponseErrorWithCode(http.StatusInternalServerError, msg.InternalServer)
+	}
+	if endpoint == nil {
+		return nil, u.ResponseErrorWithCode(http.StatusNotFound, msg.WebhookNotFound)
+	}
+	return endpoint, nil
+}
+
+func (ws *WebhookService) audit(eventType string, admin middleware.UserTokenData, targetID string, info u.RequestInfo, metadata map[string]interface{}) {
+	ws.Audit.Record(AuditEntry{
+		EventType: eventType,
+		ActorID:   admin.Id.String(),
+		TargetID:  targetID,
+		Outcome:   model.AuditSuccess,
+		Metadata:  metadata,
+		Info:      info,
+	})
+}
+
+func newDelivery(endpointID, eventID uuid.UUID, eventType, aggregateID, payload string) model.WebhookDelivery {
+	now := time.Now()
+	return model.WebhookDelivery{
+		ID:            uuid.NewV4(),
+		EndpointID:    endpointID,
+		EventID:       eventID,
+		EventType:     eventType,
+		AggregateID:   aggregateID,
+		Payload:       payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func webhookResponse(endpoint *model.WebhookEndpoint) v1resp.WebhookResponse {
+	return v1resp.WebhookResponse{
+		Id:                  endpoint.ID,
+		URL:                 endpoint.URL,
+		Description:         endpoint.Description,
+		Events:              endpoint.EventList(),
+		Enabled:             endpoint.Enabled,
+		ConsecutiveFailures: endpoint.ConsecutiveFailures,
+		DisabledAt:          endpoint.DisabledAt,
+		DisabledReason:      endpoint.DisabledReason,
+		CreatedBy:           endpoint.CreatedBy,
+		CreatedAt:           endpoint.CreatedAt,
+	}
+}
+
+func deliveryResponse(delivery *model.WebhookDelivery) v1resp.WebhookDeliveryResponse {
+	resp := v1resp.WebhookDeliveryResponse{
+		Id:           delivery.ID,
+		EventId:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		DeliveredAt:  delivery.DeliveredAt,
+		RedeliveryOf: delivery.RedeliveryOf,
+		CreatedAt:    delivery.CreatedAt,
+		Log:          make([]v1resp.WebhookAttemptResponse, 0, len(delivery.Log)),
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	for _, attempt := range delivery.Log {
+		resp.Log = append(resp.Log, v1resp.WebhookAttemptResponse{
+			Attempt:      attempt.Attempt,
+			StatusCode:   attempt.StatusCode,
+			Error:        attempt.Error,
+			ResponseBody: attempt.ResponseBody,
+			DurationMs:   attempt.DurationMs,
+			CreatedAt:    attempt.CreatedAt,
+		})
+	}
+	return resp
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit]
+}
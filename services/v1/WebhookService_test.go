@@ -0,0 +1,402 @@
+package v1Service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"test-task/model"
+	v1repo "test-task/repository/v1"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	msg "test-task/shared/utils/message"
+	"test-task/shared/webhook"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// testWebhookRepo is the webhook repository with the claim query SQLite runs, it has no FOR UPDATE SKIP LOCKED
+type testWebhookRepo struct {
+	v1repo.IWebhookRepository
+}
+
+func (r *testWebhookRepo) ClaimDueDeliveries(conn database.IConnection, heldUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := conn.GetDB().
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, time.Now()).
+		Where("endpoint_id IN (SELECT id FROM webhook_endpoints WHERE enabled = ?)", true).
+		Order("next_attempt_at asc").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = heldUntil
+		if err = conn.GetDB().Model(&deliveries[i]).UpdateColumn("next_attempt_at", heldUntil).Error; err != nil {
+			return nil, err
+		}
+	}
+	return deliveries, nil
+}
+
+// receivedWebhook is a request the test receiver got
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// testReceiver answers the deliveries with the statuses in turn, the last one repeats
+type testReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func newTestReceiver(t *testing.T, statuses ...int) *testReceiver {
+	t.Helper()
+	rc := &testReceiver{statuses: statuses}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		rc.received = append(rc.received, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := rc.statuses[0]
+		if len(rc.statuses) > 1 {
+			rc.statuses = rc.statuses[1:]
+		}
+		rc.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *testReceiver) requests() []receivedWebhook {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]receivedWebhook(nil), rc.received...)
+}
+
+func newTestWebhookService(t *testing.T) (*WebhookService, *testAudit) {
+	t.Helper()
+	conn := database.NewConnection().GetDB()
+	conn.Delete(&model.WebhookAttempt{})
+	conn.Delete(&model.WebhookDelivery{})
+	conn.Delete(&model.WebhookEndpoint{})
+
+	cf := &testConfig{webhook: config.Webhook{
+		Timeout:        5,
+		MaxAttempts:    3,
+		RetryBaseDelay: 30,
+		RetryMaxDelay:  3600,
+		DisableAfter:   20,
+		PollInterval:   5,
+		BatchSize:      10,
+		// SQLite allows one writer at a time
+		Workers: 1,
+		// the test receivers listen on 127.0.0.1
+		AllowPrivateNetworks: true,
+	}}
+	audit := &testAudit{}
+	ws := NewWebhookService(cf, audit).(*WebhookService)
+	ws.WebhookRepo = &testWebhookRepo{IWebhookRepository: ws.WebhookRepo}
+	return ws, audit
+}
+
+func createTestEndpoint(t *testing.T, url string, enabled bool) *model.WebhookEndpoint {
+	t.Helper()
+	endpoint := &model.WebhookEndpoint{
+		ID:        uuid.NewV4(),
+		URL:       url,
+		Events:    "*",
+		Secret:    testWebhookSecret,
+		Enabled:   true,
+		CreatedBy: uuid.NewV4(),
+	}
+	conn := database.NewConnection()
+	if err := conn.CreateNew(endpoint); err != nil {
+		t.Fatalf("endpoint not created: %v", err)
+	}
+	// the column default turns a false Enabled into true on insert
+	if !enabled {
+		endpoint.Enabled = false
+		if err := conn.GetDB().Model(endpoint).UpdateColumn("enabled", false).Error; err != nil {
+			t.Fatalf("endpoint not disabled: %v", err)
+		}
+	}
+	return endpoint
+}
+
+func publishTestEvent(t *testing.T, ws *WebhookService) *model.OutboxMessage {
+	t.Helper()
+	message := &model.OutboxMessage{
+		ID:          uuid.NewV4(),
+		EventType:   model.WebhookUserSignedUp,
+		Version:     1,
+		AggregateID: uuid.NewV4().String(),
+		Payload:     `{"user":{"id":"1"}}`,
+		CreatedAt:   time.Now(),
+	}
+	if err := ws.Publish(context.Background(), message); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	return message
+}
+
+func endpointDeliveries(t *testing.T, endpointID uuid.UUID) []model.WebhookDelivery {
+	t.Helper()
+	var deliveries []model.WebhookDelivery
+	if err := database.NewConnection().GetDB().Where("endpoint_id = ?", endpointID).Find(&deliveries).Error; err != nil {
+		t.Fatalf("deliveries not loaded: %v", err)
+	}
+	return deliveries
+}
+
+// makeDue moves the next attempts of the endpoint's deliveries into the past, as if the backoff had passed
+func makeDue(t *testing.T, endpointID uuid.UUID) {
+	t.Helper()
+	err := database.NewConnection().GetDB().Model(&model.WebhookDelivery{}).Where("endpoint_id = ?", endpointID).
+		UpdateColumn("next_attempt_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatalf("deliveries not made due: %v", err)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	ws, _ := newTestWebhookService(t)
+	receiver := newTestReceiver(t, http.StatusNoContent)
+	endpoint := createTestEndpoint(t, receiver.URL, true)
+	message := publishTestEvent(t, ws)
+
+	if n := ws.DeliverDue(context.Background()); n != 1 {
+		t.Fatalf("DeliverDue took %d deliveries, want 1", n)
+	}
+	requests := receiver.requests()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	got := requests[0]
+
+	err := webhook.Verify(testWebhookSecret, got.header.Get(webhook.SignatureHeader), got.header.Get(webhook.TimestampHeader), got.body, time.Minute)
+	if err != nil {
+		t.Fatalf("signature does not verify: %v", err)
+	}
+	if err = webhook.Verify("whsec_other", got.header.Get(webhook.SignatureHeader), got.header.Get(webhook.TimestampHeader), got.body, time.Minute); err == nil {
+		t.Fatal("signature verifies with another secret")
+	}
+	if id := got.header.Get(webhook.EventIDHeader); id != message.ID.String() {
+		t.Fatalf("%s is %q, want the message ID %s", webhook.EventIDHeader, id, message.ID)
+	}
+	if event := got.header.Get(webhook.EventHeader); event != message.EventType {
+		t.Fatalf("%s is %q, want %q", webhook.EventHeader, event, message.EventType)
+	}
+
+	deliveries := endpointDeliveries(t, endpoint.ID)
+	if len(deliveries) != 1 || deliveries[0].Status != model.WebhookDeliverySucceeded || deliveries[0].Attempts != 1 {
+		t.Fatalf("delivery is %+v, want succeeded after 1 attempt", deliveries)
+	}
+	if id := got.header.Get(webhook.IDHeader); id != deliveries[0].ID.String() {
+		t.Fatalf("%s is %q, want the delivery ID %s", webhook.IDHeader, id, deliveries[0].ID)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	ws, _ := newTestWebhookService(t)
+	receiver := newTestReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	endpoint := createTestEndpoint(t, receiver.URL, true)
+	publishTestEvent(t, ws)
+	ctx := context.Background()
+
+	// each failure pushes the next attempt out by Webhook.RetryBaseDelay, doubled per failure, plus up to a tenth
+	for attempt, wait := range []time.Duration{30 * time.Second, 60 * time.Second} {
+		before := time.Now()
+		if n := ws.DeliverDue(ctx); n != 1 {
+			t.Fatalf("attempt %d: DeliverDue took %d deliveries, want 1", attempt+1, n)
+		}
+		delivery := endpointDeliveries(t, endpoint.ID)[0]
+		if delivery.Status != model.WebhookDeliveryPending || delivery.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: delivery is %s after %d attempts, want pending", attempt+1, delivery.Status, delivery.Attempts)
+		}
+		if next := delivery.NextAttemptAt.Sub(before); next < wait || next > wait+wait/10+time.Second {
+			t.Fatalf("attempt %d: next attempt in %s, want %s to %s", attempt+1, next, wait, wait+wait/10)
+		}
+
+		// not due before the backoff passed
+		if n := ws.DeliverDue(ctx); n != 0 {
+			t.Fatalf("attempt %d: DeliverDue took %d deliveries during the backoff, want 0", attempt+1, n)
+		}
+		makeDue(t, endpoint.ID)
+	}
+
+	if n := ws.DeliverDue(ctx); n != 1 {
+		t.Fatalf("last attempt: DeliverDue took %d deliveries, want 1", n)
+	}
+	delivery := endpointDeliveries(t, endpoint.ID)[0]
+	if delivery.Status != model.WebhookDeliverySucceeded || delivery.Attempts != 3 {
+		t.Fatalf("delivery is %s after %d attempts, want succeeded after 3", delivery.Status, delivery.Attempts)
+	}
+	if got := len(receiver.requests()); got != 3 {
+		t.Fatalf("receiver got %d requests, want 3", got)
+	}
+}
+
+func TestWebhookMaxAttempts(t *testing.T) {
+	ws, _ := newTestWebhookService(t)
+	receiver := newTestReceiver(t, http.StatusServiceUnavailable)
+	endpoint := createTestEndpoint(t, receiver.URL, true)
+	publishTestEvent(t, ws)
+
+	for i := 0; i < ws.Config.Webhook().MaxAttempts; i++ {
+		ws.DeliverDue(context.Background())
+		makeDue(t, endpoint.ID)
+	}
+	delivery := endpointDeliveries(t, endpoint.ID)[0]
+	if delivery.Status != model.WebhookDeliveryFailed || delivery.Attempts != ws.Config.Webhook().MaxAttempts {
+		t.Fatalf("delivery is %s after %d attempts, want failed after Webhook.MaxAttempts", delivery.Status, delivery.Attempts)
+	}
+	if n := ws.DeliverDue(context.Background()); n != 0 {
+		t.Fatalf("DeliverDue took %d failed deliveries, want 0", n)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	ws, _ := newTestWebhookService(t)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{40, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := ws.backoff(tt.attempts); got < tt.want || got > tt.want+tt.want/10 {
+			t.Errorf("backoff(%d) = %s, want %s to %s", tt.attempts, got, tt.want, tt.want+tt.want/10)
+		}
+	}
+}
+
+func TestWebhookDisabledEndpoint(t *testing.T) {
+	ws, _ := newTestWebhookService(t)
+	receiver := newTestReceiver(t, http.StatusOK)
+	disabled := createTestEndpoint(t, receiver.URL, false)
+	enabled := createTestEndpoint(t, receiver.URL, true)
+	publishTestEvent(t, ws)
+
+	if got := len(endpointDeliveries(t, disabled.ID)); got != 0 {
+		t.Fatalf("%d deliveries queued for the disabled endpoint, want 0", got)
+	}
+	if got := len(endpointDeliveries(t, enabled.ID)); got != 1 {
+		t.Fatalf("%d deliveries queued for the enabled endpoint, want 1", got)
+	}
+
+	// deliveries queued before the endpoint was disabled are left alone
+	if err := database.NewConnection().GetDB().Model(enabled).UpdateColumn("enabled", false).Error; err != nil {
+		t.Fatalf("endpoint not disabled: %v", err)
+	}
+	if n := ws.DeliverDue(context.Background()); n != 0 {
+		t.Fatalf("DeliverDue took %d deliveries of a disabled endpoint, want 0", n)
+	}
+	if got := len(receiver.requests()); got != 0 {
+		t.Fatalf("receiver got %d requests, want 0", got)
+	}
+}
+
+func TestWebhookDisableAfterFailures(t *testing.T) {
+	ws, audit := newTestWebhookService(t)
+	ws.Config.Webhook().DisableAfter = 2
+	receiver := newTestReceiver(t, http.StatusInternalServerError)
+	endpoint := createTestEndpoint(t, receiver.URL, true)
+	publishTestEvent(t, ws)
+
+	for i := 0; i < 2; i++ {
+		ws.DeliverDue(context.Background())
+		makeDue(t, endpoint.ID)
+	}
+
+	var stored model.WebhookEndpoint
+	if err := database.NewConnection().GetDB().Where("id = ?", endpoint.ID).First(&stored).Error; err != nil {
+		t.Fatalf("endpoint not loaded: %v", err)
+	}
+	if stored.Enabled || stored.DisabledAt == nil {
+		t.Fatalf("endpoint still enabled after Webhook.DisableAfter failures in a row")
+	}
+	if len(audit.entries) != 1 || audit.entries[0].EventType != model.AuditWebhookDisabled {
+		t.Fatalf("audit entries are %+v, want one %s", audit.entries, model.AuditWebhookDisabled)
+	}
+
+	// the delivery left pending is not sent any more
+	if n := ws.DeliverDue(context.Background()); n != 0 {
+		t.Fatalf("DeliverDue took %d deliveries of the disabled endpoint, want 0", n)
+	}
+	if got := len(receiver.requests()); got != 2 {
+		t.Fatalf("receiver got %d requests, want 2", got)
+	}
+}
+
+func TestWebhookPrivateAddressRefused(t *testing.T) {
+	ws, _ := newTestWebhookService(t)
+	ws.Config.Webhook().AllowPrivateNetworks = false
+	ws.Client = newWebhookClient(ws.Config.Webhook())
+	receiver := newTestReceiver(t, http.StatusNoContent)
+	// stored directly, as if a public name had been changed to resolve to the receiver after the check
+	endpoint := createTestEndpoint(t, receiver.URL, true)
+	publishTestEvent(t, ws)
+
+	ws.DeliverDue(context.Background())
+	if got := len(receiver.requests()); got != 0 {
+		t.Fatalf("receiver on a loopback address got %d requests, want 0", got)
+	}
+
+	deliveries := endpointDeliveries(t, endpoint.ID)
+	if len(deliveries) != 1 || deliveries[0].Status != model.WebhookDeliveryPending || deliveries[0].Attempts != 1 {
+		t.Fatalf("delivery is %+v, want pending after 1 failed attempt", deliveries)
+	}
+	var attempt model.WebhookAttempt
+	if err := database.NewConnection().GetDB().Where("delivery_id = ?", deliveries[0].ID).First(&attempt).Error; err != nil {
+		t.Fatalf("attempt not loaded: %v", err)
+	}
+	if !strings.Contains(attempt.Error, errWebhookAddress.Error()) {
+		t.Fatalf("attempt error is %q, want it to name the refused address", attempt.Error)
+	}
+}
+
+func TestWebhookEndpointURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		want         string
+	}{
+		{"https://hooks.example.com/users", false, ""},
+		{"https://93.184.216.34/users", false, ""},
+		{"ftp://hooks.example.com/users", false, msg.InvalidWebhookURL},
+		{"http://127.0.0.1:8080/users", false, msg.WebhookPrivateHost},
+		{"http://localhost:8080/users", false, msg.WebhookPrivateHost},
+		{"http://[::1]/users", false, msg.WebhookPrivateHost},
+		{"http://10.1.2.3/users", false, msg.WebhookPrivateHost},
+		{"http://192.168.0.10/users", false, msg.WebhookPrivateHost},
+		{"http://169.254.169.254/latest/meta-data", false, msg.WebhookPrivateHost},
+		{"http://100.64.0.1/users", false, msg.WebhookPrivateHost},
+		{"http://127.0.0.1:8080/users", true, ""},
+		{"http://localhost:8080/users", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ws := &WebhookService{Config: &testConfig{webhook: config.Webhook{AllowPrivateNetworks: tt.allowPrivate}}}
+			resp := ws.checkEndpoint(tt.url, []string{"*"})
+			got, _ := resp["message"].(string)
+			if got != tt.want {
+				t.Fatalf("checkEndpoint answered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
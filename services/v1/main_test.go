@@ -0,0 +1,97 @@
+package v1Service
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	_ "github.com/jinzhu/gorm/dialects/sqlite" // Import SQLite dialect for the test database
+	"github.com/sirupsen/logrus"
+
+	"test-task/model"
+	"test-task/shared/cache"
+	"test-task/shared/config"
+	"test-task/shared/database"
+	"test-task/shared/log"
+)
+
+// testRedis backs the cache package for every test of the package, newTestRedis empties it
+var testRedis *miniredis.Miniredis
+
+func TestMain(m *testing.M) {
+	logs, err := os.MkdirTemp("", "v1service-logs")
+	if err != nil {
+		panic(err)
+	}
+	log.Init("test", "", logs, logrus.WarnLevel, time.Hour)
+
+	testRedis, err = miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	if _, err = cache.CreateConnection(&testConfig{redis: config.Redis{Host: testRedis.Addr()}}); err != nil {
+		panic(err)
+	}
+
+	// a file rather than :memory:, the master and slave connections have to see the same tables
+	dir, err := os.MkdirTemp("", "v1service-db")
+	if err != nil {
+		panic(err)
+	}
+	database.Init(&testConfig{database: config.Database{Dialect: "sqlite3", ConnectionString: filepath.Join(dir, "test.db")}})
+	model.AutoMigrate()
+
+	code := m.Run()
+	testRedis.Close()
+	database.Close()
+	os.RemoveAll(dir)
+	os.RemoveAll(logs)
+	os.Exit(code)
+}
+
+// newTestRedis empties the shared Redis, the test gets it to move its clock
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	testRedis.FlushAll()
+	return testRedis
+}
+
+// testAudit keeps the recorded entries
+type testAudit struct {
+	IAuditService
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (a *testAudit) Record(entry AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+// testConfig answers the config sections the tests set, the other sections are not used by them
+type testConfig struct {
+	config.IConfig
+	app      config.App
+	database config.Database
+	redis    config.Redis
+	sms      config.SMS
+	password config.Password
+	signing  config.Signing
+	dpop     config.DPoP
+	account  config.Account
+	webhook  config.Webhook
+}
+
+func (c *testConfig) App() *config.App           { return &c.app }
+func (c *testConfig) Database() *config.Database { return &c.database }
+func (c *testConfig) Redis() *config.Redis       { return &c.redis }
+func (c *testConfig) SMS() *config.SMS           { return &c.sms }
+func (c *testConfig) Password() *config.Password { return &c.password }
+func (c *testConfig) Signing() *config.Signing   { return &c.signing }
+func (c *testConfig) DPoP() *config.DPoP         { return &c.dpop }
+func (c *testConfig) Account() *config.Account   { return &c.account }
+func (c *testConfig) Webhook() *config.Webhook   { return &c.webhook }
@@ -0,0 +1,307 @@
+package apispec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// the patterns of the validator.v9 tags that have no OpenAPI format
+const (
+	patternAlpha   = "^[a-zA-Z]+$"
+	patternNumeric = "^[-+]?[0-9]+(?:\\.[0-9]+)?$"
+	patternE164    = "^\\+[1-9]?[0-9]{7,14}$"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1. Type is a string, or a list of them for nullable values.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Ref points to a schema of the components
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// known are the types encoded as something else than their Go shape
+var known = map[reflect.Type]func() *Schema{
+	reflect.TypeOf(time.Time{}):       func() *Schema { return &Schema{Type: "string", Format: "date-time"} },
+	reflect.TypeOf(uuid.UUID{}):       func() *Schema { return &Schema{Type: "string", Format: "uuid"} },
+	reflect.TypeOf(json.RawMessage{}): func() *Schema { return &Schema{} },
+}
+
+// Schemas turns Go types into schemas, registering the named structs in the components of the document.
+// Properties are named by the json tags and embedded structs are flattened, like encoding/json does.
+//
+// Request structs are described by their validate tags: the required fields, the formats and the limits.
+// In response structs every field without omitempty is required, it is always part of the JSON.
+type Schemas struct {
+	doc   *Document
+	types map[string]reflect.Type
+}
+
+func NewSchemas(doc *Document) *Schemas {
+	return &Schemas{doc: doc, types: map[string]reflect.Type{}}
+}
+
+// Request is the schema of a JSON request body
+func (s *Schemas) Request(v interface{}) *Schema {
+	return s.schema(reflect.TypeOf(v), false)
+}
+
+// Response is the schema of response data
+func (s *Schemas) Response(v interface{}) *Schema {
+	return s.schema(reflect.TypeOf(v), true)
+}
+
+// Query lists the fields of a struct bound from the query string by their form tags
+func (s *Schemas) Query(v interface{}) []Parameter {
+	params := []Parameter{}
+	for _, field := range fields(reflect.TypeOf(v)) {
+		name := tagName(field.Tag.Get("form"))
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := s.schema(field.Type, false)
+		required := applyRules(schema, field)
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+func (s *Schemas) schema(t reflect.Type, response bool) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if build, ok := known[t]; ok {
+		return build()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(s.schema(t.Elem(), response))
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes bytes as base64
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem(), response)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem(), response)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t, response)
+		}
+		return s.named(t, response)
+	}
+	return &Schema{}
+}
+
+// named registers the struct in the components once and refers to it
+func (s *Schemas) named(t reflect.Type, response bool) *Schema {
+	name := t.Name()
+	if other, ok := s.types[name]; ok && other != t {
+		// two packages use the same name, the package tells them apart
+		name = t.String()
+	}
+	if _, ok := s.types[name]; !ok {
+		s.types[name] = t
+		// registered before the fields are walked, so recursive types end in a reference
+		s.doc.Components.Schemas[name] = &Schema{}
+		*s.doc.Components.Schemas[name] = *s.object(t, response)
+	}
+	return Ref(name)
+}
+
+func (s *Schemas) object(t reflect.Type, response bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range fields(t) {
+		name, omitempty := jsonName(field)
+		if name == "" {
+			continue
+		}
+		property := s.schema(field.Type, response)
+		required := applyRules(property, field)
+		if response {
+			required = !omitempty
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// fields lists the exported fields with the fields of embedded structs in their place
+func fields(t reflect.Type) []reflect.StructField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	list := []reflect.StructField{}
+	if t.Kind() != reflect.Struct {
+		return list
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			list = append(list, fields(field.Type)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		list = append(list, field)
+	}
+	return list
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := tagName(tag)
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(tag, ",omitempty")
+}
+
+func tagName(tag string) string {
+	return strings.Split(tag, ",")[0]
+}
+
+// nullable lets the schema also be null, the way encoding/json writes nil pointers
+func nullable(schema *Schema) *Schema {
+	switch kind := schema.Type.(type) {
+	case string:
+		schema.Type = []string{kind, "null"}
+		return schema
+	case nil:
+		if schema.Ref == "" {
+			// anything is already allowed
+			return schema
+		}
+	}
+	return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+}
+
+// applyRules describes the validate tag of the field on its schema and tells whether it is required.
+// The rules after dive apply to the items of a list.
+func applyRules(schema *Schema, field reflect.StructField) bool {
+	required := strings.Contains(field.Tag.Get("binding"), "required")
+	target, kind := schema, baseKind(field.Type)
+	top := true
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+
+		switch name {
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target, kind, top = target.Items, baseKind(elem(field.Type)), false
+		case "required":
+			required = required || top
+		case "email":
+			target.Format = "email"
+		case "uuid":
+			target.Format = "uuid"
+		case "url":
+			target.Format = "uri"
+		case "alpha":
+			target.Pattern = patternAlpha
+		case "numeric":
+			target.Pattern = patternNumeric
+		case "e164":
+			target.Pattern = patternE164
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "min", "max":
+			limit(target, kind, name, param)
+		}
+	}
+	return required
+}
+
+// limit sets min or max by what it means for the kind, a length, a value or a number of items
+func limit(schema *Schema, kind reflect.Kind, name, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	value := float64(n)
+	switch kind {
+	case reflect.String:
+		if name == "min" {
+			schema.MinLength = &n
+		} else {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if name == "min" {
+			schema.MinItems = &n
+		} else {
+			schema.MaxItems = &n
+		}
+	default:
+		if name == "min" {
+			schema.Minimum = &value
+		} else {
+			schema.Maximum = &value
+		}
+	}
+}
+
+func baseKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+func elem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		return t.Elem()
+	}
+	return t
+}
@@ -91,3 +91,98 @@ func GetToken(ctx context.Context, id string) (string, error) {
 
 	return redisConn.Get(ctx, id).Val(), nil
 }
+
+// SetValue is a function used for storing a plain value under the given key
+func SetValue(ctx context.Context, key, value string, ttl time.Duration) error {
+	redisConn, err := GetConnection()
+	if err != nil {
+		return err
+	}
+	if err = redisConn.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set value in Redis: %w", err)
+	}
+	return nil
+}
+
+// GetValue is a function used for retrieving a value by key, an empty string is returned for missing keys
+func GetValue(ctx context.Context, key string) (string, error) {
+	redisConn, err := GetConnection()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := redisConn.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get value from Redis: %w", err)
+	}
+	return value, nil
+}
+
+// DeleteValue is a function used for removing keys
+func DeleteValue(ctx context.Context, keys ...string) error {
+	redisConn, err := GetConnection()
+	if err != nil {
+		return err
+	}
+	if err = redisConn.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete value from Redis: %w", err)
+	}
+	return nil
+}
+
+// IncrementValue is a function used for counting under a key, the ttl is set when the key is created
+func IncrementValue(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	redisConn, err := GetConnection()
+	if err != nil {
+		return 0, err
+	}
+	count, err := redisConn.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment value in Redis: %w", err)
+	}
+	if count == 1 {
+		if err = redisConn.Expire(ctx, key, ttl).Err(); err != nil {
+			return 0, fmt.Errorf("failed to set expiry in Redis: %w", err)
+		}
+	}
+	return count, nil
+}
+
+// SetValueIfAbsent is a function used for storing a value only when the key does not exist yet,
+// it reports whether the value was stored
+func SetValueIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	redisConn, err := GetConnection()
+	if err != nil {
+		return false, err
+	}
+	stored, err := redisConn.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set value in Redis: %w", err)
+	}
+	return stored, nil
+}
+
+// deleteIfEqualScript removes the key only while it still holds the value, in one step so two callers can
+// not both see the value before it is gone
+var deleteIfEqualScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// DeleteValueIfEqual is a function used for consuming a single use value, it reports whether the key held
+// the value and was removed by this call
+func DeleteValueIfEqual(ctx context.Context, key, value string) (bool, error) {
+	redisConn, err := GetConnection()
+	if err != nil {
+		return false, err
+	}
+	deleted, err := deleteIfEqualScript.Run(ctx, redisConn, []string{key}, value).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete value from Redis: %w", err)
+	}
+	return deleted == 1, nil
+}
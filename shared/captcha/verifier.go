@@ -0,0 +1,96 @@
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"test-task/shared/config"
+)
+
+// siteverify endpoints, hCaptcha, reCAPTCHA and Turnstile share the same request and response shape
+var verifyURLs = map[string]string{
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// IVerifier checks the token a client got from solving a challenge
+type IVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+type siteVerifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+type stubVerifier struct {
+	token string
+}
+
+// NewVerifier returns the verifier selected by Captcha.Provider, nil when the check is off
+func NewVerifier(cf config.IConfig) IVerifier {
+	c := cf.Captcha()
+	switch c.Provider {
+	case "":
+		return nil
+	case "stub":
+		return &stubVerifier{token: c.StubToken}
+	}
+
+	verifyURL := c.VerifyURL
+	if verifyURL == "" {
+		verifyURL = verifyURLs[c.Provider]
+	}
+	return &siteVerifier{
+		url:    verifyURL,
+		secret: c.Secret,
+		client: &http.Client{Timeout: time.Duration(c.Timeout) * time.Second},
+	}
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify is made for asking the provider whether the token is a solved challenge
+func (v *siteVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("captcha verification failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha verification failed: status %d", resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("captcha verification failed: %w", err)
+	}
+	return result.Success, nil
+}
+
+// Verify is made for tests and local runs, only the configured token passes
+func (v *stubVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(v.token)) == 1, nil
+}
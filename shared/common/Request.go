@@ -7,3 +7,16 @@ type QueryParam string
 const (
 	QPage QueryParam = "page"
 )
+
+// RequestInfo is the caller information recorded with security events
+type RequestInfo struct {
+	IPAddress string
+	UserAgent string
+	RequestID string
+
+	// ImpersonatorID is the admin acting on behalf of the signed in user, if any
+	ImpersonatorID string
+
+	// DPoPThumbprint is the key the caller proved to hold, tokens issued to it are bound to that key
+	DPoPThumbprint string
+}
@@ -85,6 +85,8 @@ func GetError(field, tag string) string {
 		return field + " is " + tag
 	} else if tag == "email" {
 		return field + " not valid"
+	} else if tag == "e164" {
+		return field + " must be in E.164 format, e.g. +919876543210"
 	}
 	return ""
 }
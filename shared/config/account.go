@@ -0,0 +1,58 @@
+package config
+
+import "github.com/spf13/viper"
+
+type Account struct {
+	NewDeviceAlert  bool   // Account.NewDeviceAlert, mail the user on sign-in from an unseen device or network
+	RevokeLinkTTL   int    // Account.RevokeLinkTTL in hours, lifetime of the "this wasn't me" link
+	GeoIPDatabase   string // Account.GeoIPDatabase, path of a MaxMind GeoLite2/GeoIP2 City database
+	ActivityHistory int    // Account.ActivityHistory, number of sign-ins kept per user
+
+	RequireEmailVerification bool // Account.RequireEmailVerification, new users stay pending_verification until they follow the mailed link
+	VerificationLinkTTL      int  // Account.VerificationLinkTTL in hours, lifetime of the email verification link
+	StatusCacheTTL           int  // Account.StatusCacheTTL in seconds, how long AuthHandler trusts a cached user status
+
+	RestoreWindow int // Account.RestoreWindow in days, a deleted user can be restored until the window ends and is purged after
+	PurgeInterval int // Account.PurgeInterval in minutes, how often deleted users past the window are purged
+}
+
+func (r *RealtimeConfig) reloadAccount() {
+	viper.SetDefault("Account.NewDeviceAlert", true)
+	viper.SetDefault("Account.RevokeLinkTTL", 72)
+	viper.SetDefault("Account.ActivityHistory", 100)
+	viper.SetDefault("Account.RequireEmailVerification", true)
+	viper.SetDefault("Account.VerificationLinkTTL", 48)
+	viper.SetDefault("Account.StatusCacheTTL", 300)
+	viper.SetDefault("Account.RestoreWindow", 30)
+	viper.SetDefault("Account.PurgeInterval", 60)
+
+	r.account.NewDeviceAlert = viper.GetBool("Account.NewDeviceAlert")
+	r.account.RevokeLinkTTL = viper.GetInt("Account.RevokeLinkTTL")
+	r.account.GeoIPDatabase = viper.GetString("Account.GeoIPDatabase")
+	r.account.ActivityHistory = viper.GetInt("Account.ActivityHistory")
+	r.account.RequireEmailVerification = viper.GetBool("Account.RequireEmailVerification")
+	r.account.VerificationLinkTTL = viper.GetInt("Account.VerificationLinkTTL")
+	r.account.StatusCacheTTL = viper.GetInt("Account.StatusCacheTTL")
+	r.account.RestoreWindow = viper.GetInt("Account.RestoreWindow")
+	r.account.PurgeInterval = viper.GetInt("Account.PurgeInterval")
+
+	r.testAccount()
+}
+
+func (r *RealtimeConfig) testAccount() {
+	if r.account.RevokeLinkTTL < 1 {
+		panic("Config - Account.RevokeLinkTTL must be greater than 0")
+	}
+	if r.account.VerificationLinkTTL < 1 {
+		panic("Config - Account.VerificationLinkTTL must be greater than 0")
+	}
+	if r.account.StatusCacheTTL < 1 {
+		panic("Config - Account.StatusCacheTTL must be greater than 0")
+	}
+	if r.account.RestoreWindow < 1 {
+		panic("Config - Account.RestoreWindow must be greater than 0")
+	}
+	if r.account.PurgeInterval < 1 {
+		panic("Config - Account.PurgeInterval must be greater than 0")
+	}
+}
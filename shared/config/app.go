@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"net"
+
 	"github.com/spf13/viper"
 )
 
@@ -8,15 +11,76 @@ type App struct {
 	Port            string
 	AccessTokenKey  string
 	RefreshTokenKey string
+	PublicURL       string // App.PublicURL, base URL used in links sent to users
+
+	ImpersonationTTL int      // App.ImpersonationTTL in minutes, lifetime of admin impersonation tokens
+	InvitationTTL    int      // App.InvitationTTL in hours, lifetime of organization invitations
+	AccessTokenTTL   int      // App.AccessTokenTTL in minutes
+	RefreshTokenTTL  int      // App.RefreshTokenTTL in hours
+	SignInMethods    []string // App.SignInMethods, methods allowed unless an organization narrows them down
+	TrustedProxies   []string // App.TrustedProxies, IPs or CIDRs of the proxies whose X-Forwarded-For is believed, none by default
 }
 
+// Sign-in methods
+const (
+	SignInPassword = "password"
+	SignInOTP      = "otp"
+)
+
 func (r *RealtimeConfig) reloadApp() {
 	r.app.Port = viper.GetString("App.Port")
 	r.app.AccessTokenKey = viper.GetString("App.AccessTokenKey")
 	r.app.RefreshTokenKey = viper.GetString("App.RefreshTokenKey")
+	r.app.PublicURL = viper.GetString("App.PublicURL")
+
+	viper.SetDefault("App.ImpersonationTTL", 10)
+	viper.SetDefault("App.InvitationTTL", 72)
+	viper.SetDefault("App.AccessTokenTTL", 15)
+	viper.SetDefault("App.RefreshTokenTTL", 24*7)
+	viper.SetDefault("App.SignInMethods", []string{SignInPassword, SignInOTP})
+	r.app.ImpersonationTTL = viper.GetInt("App.ImpersonationTTL")
+	r.app.InvitationTTL = viper.GetInt("App.InvitationTTL")
+	r.app.AccessTokenTTL = viper.GetInt("App.AccessTokenTTL")
+	r.app.RefreshTokenTTL = viper.GetInt("App.RefreshTokenTTL")
+	r.app.SignInMethods = viper.GetStringSlice("App.SignInMethods")
+	r.app.TrustedProxies = viper.GetStringSlice("App.TrustedProxies")
+
+	if len(r.app.PublicURL) == 0 {
+		r.app.PublicURL = "http://localhost:" + r.app.Port
+	}
 	r.testApp()
 }
 
 func (r *RealtimeConfig) testApp() {
 	testEmptyString(r.app, "Port")
+	if r.app.AccessTokenTTL < 1 || r.app.AccessTokenTTL > 24*60 {
+		panic("Config - App.AccessTokenTTL must be between 1 and 1440 minutes")
+	}
+	if r.app.RefreshTokenTTL < 1 {
+		panic("Config - App.RefreshTokenTTL must be greater than 0")
+	}
+	if r.app.InvitationTTL < 1 {
+		panic("Config - App.InvitationTTL must be greater than 0")
+	}
+	for _, method := range r.app.SignInMethods {
+		if !IsSignInMethod(method) {
+			panic(fmt.Sprintf("Config - App.SignInMethods has unknown method %q", method))
+		}
+	}
+	for _, proxy := range r.app.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				panic(fmt.Sprintf("Config - App.TrustedProxies has %q, which is not an IP or a CIDR", proxy))
+			}
+		}
+	}
+}
+
+// IsSignInMethod reports whether the method is one the service supports
+func IsSignInMethod(method string) bool {
+	switch method {
+	case SignInPassword, SignInOTP:
+		return true
+	}
+	return false
 }
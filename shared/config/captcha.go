@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/viper"
+)
+
+// Captcha modes, when the public auth endpoints ask for a challenge
+const (
+	CaptchaAlways        = "always"
+	CaptchaAfterFailures = "after_failures"
+	CaptchaIPRanges      = "ip_ranges"
+)
+
+type Captcha struct {
+	Provider  string // Captcha.Provider, "hcaptcha", "recaptcha", "turnstile" or "stub", empty turns the check off
+	Secret    string // Captcha.Secret, secret key of the provider
+	VerifyURL string // Captcha.VerifyURL, overrides the siteverify endpoint of the provider
+	StubToken string // Captcha.StubToken, the only token the "stub" provider accepts
+	Timeout   int    // Captcha.Timeout in seconds, for the call to the provider
+
+	Mode             string   // Captcha.Mode, "always", "after_failures" or "ip_ranges"
+	FailureThreshold int      // Captcha.FailureThreshold, failed requests from an IP before a challenge is asked for
+	FailureWindow    int      // Captcha.FailureWindow in minutes, how long failures are counted
+	IPRanges         []string // Captcha.IPRanges, CIDRs that get a challenge in "ip_ranges" mode
+
+	Networks []*net.IPNet // parsed IPRanges
+}
+
+func (r *RealtimeConfig) reloadCaptcha() {
+	viper.SetDefault("Captcha.Mode", CaptchaAfterFailures)
+	viper.SetDefault("Captcha.StubToken", "pass")
+	viper.SetDefault("Captcha.Timeout", 5)
+	viper.SetDefault("Captcha.FailureThreshold", 3)
+	viper.SetDefault("Captcha.FailureWindow", 15)
+
+	r.captcha.Provider = viper.GetString("Captcha.Provider")
+	r.captcha.Secret = viper.GetString("Captcha.Secret")
+	r.captcha.VerifyURL = viper.GetString("Captcha.VerifyURL")
+	r.captcha.StubToken = viper.GetString("Captcha.StubToken")
+	r.captcha.Timeout = viper.GetInt("Captcha.Timeout")
+	r.captcha.Mode = viper.GetString("Captcha.Mode")
+	r.captcha.FailureThreshold = viper.GetInt("Captcha.FailureThreshold")
+	r.captcha.FailureWindow = viper.GetInt("Captcha.FailureWindow")
+	r.captcha.IPRanges = viper.GetStringSlice("Captcha.IPRanges")
+
+	r.captcha.Networks = nil
+	for _, cidr := range r.captcha.IPRanges {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("Config - Captcha.IPRanges has invalid range %q", cidr))
+		}
+		r.captcha.Networks = append(r.captcha.Networks, network)
+	}
+
+	r.testCaptcha()
+}
+
+func (r *RealtimeConfig) testCaptcha() {
+	switch r.captcha.Provider {
+	case "":
+		return
+	case "hcaptcha", "recaptcha", "turnstile":
+		testEmptyString(r.captcha, "Secret")
+	case "stub":
+		testEmptyString(r.captcha, "StubToken")
+	default:
+		panic(fmt.Sprintf("Config - Captcha.Provider has unknown provider %q", r.captcha.Provider))
+	}
+
+	switch r.captcha.Mode {
+	case CaptchaAlways:
+	case CaptchaAfterFailures:
+		if r.captcha.FailureThreshold < 1 || r.captcha.FailureWindow < 1 {
+			panic("Config - Captcha.FailureThreshold and Captcha.FailureWindow must be greater than 0")
+		}
+	case CaptchaIPRanges:
+		if len(r.captcha.Networks) == 0 {
+			panic("Config - Captcha.IPRanges can not be empty in ip_ranges mode")
+		}
+	default:
+		panic(fmt.Sprintf("Config - Captcha.Mode has unknown mode %q", r.captcha.Mode))
+	}
+	if r.captcha.Timeout < 1 {
+		panic("Config - Captcha.Timeout must be greater than 0")
+	}
+}
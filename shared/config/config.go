@@ -23,6 +23,25 @@ type IConfig interface {
 
 	Database() *Database
 	Redis() *Redis
+
+	Password() *Password
+	Mail() *Mail
+	SMS() *SMS
+	Account() *Account
+	Denylist() *Denylist
+	Captcha() *Captcha
+	DPoP() *DPoP
+	Session() *Session
+	Security() *Security
+	Policy() *Policy
+	Webhook() *Webhook
+	Outbox() *Outbox
+	EventBus() *EventBus
+	Scheduler() *Scheduler
+	GRPC() *GRPC
+	Docs() *Docs
+	Signing() *Signing
+	Introspection() *Introspection
 }
 
 // RealtimeConfig is
@@ -37,6 +56,25 @@ type RealtimeConfig struct {
 	redis    Redis
 	app      App
 	database Database
+	password Password
+	mail     Mail
+	sms      SMS
+	account  Account
+	denylist Denylist
+	captcha  Captcha
+	dpop     DPoP
+	session  Session
+	security Security
+	policy   Policy
+	webhook  Webhook
+	outbox   Outbox
+	eventBus EventBus
+	schedule Scheduler
+	grpc     GRPC
+	docs     Docs
+	signing  Signing
+
+	introspection Introspection
 }
 
 func testEmptyString(entity interface{}, path string) {
@@ -93,6 +131,24 @@ func (r *RealtimeConfig) reload() {
 	r.reloadApp()
 	r.reloadDatabase()
 	r.reloadRedis()
+	r.reloadPassword()
+	r.reloadMail()
+	r.reloadSMS()
+	r.reloadAccount()
+	r.reloadDenylist()
+	r.reloadCaptcha()
+	r.reloadDPoP()
+	r.reloadSession()
+	r.reloadSecurity()
+	r.reloadPolicy()
+	r.reloadWebhook()
+	r.reloadOutbox()
+	r.reloadEventBus()
+	r.reloadScheduler()
+	r.reloadGRPC()
+	r.reloadDocs()
+	r.reloadSigning()
+	r.reloadIntrospection()
 }
 
 func (r *RealtimeConfig) AppVersion() string {
@@ -126,3 +182,75 @@ func (r *RealtimeConfig) Database() *Database {
 func (r *RealtimeConfig) Redis() *Redis {
 	return &r.redis
 }
+
+func (r *RealtimeConfig) Password() *Password {
+	return &r.password
+}
+
+func (r *RealtimeConfig) Mail() *Mail {
+	return &r.mail
+}
+
+func (r *RealtimeConfig) SMS() *SMS {
+	return &r.sms
+}
+
+func (r *RealtimeConfig) Account() *Account {
+	return &r.account
+}
+
+func (r *RealtimeConfig) Denylist() *Denylist {
+	return &r.denylist
+}
+
+func (r *RealtimeConfig) Captcha() *Captcha {
+	return &r.captcha
+}
+
+func (r *RealtimeConfig) DPoP() *DPoP {
+	return &r.dpop
+}
+
+func (r *RealtimeConfig) Session() *Session {
+	return &r.session
+}
+
+func (r *RealtimeConfig) Security() *Security {
+	return &r.security
+}
+
+func (r *RealtimeConfig) Policy() *Policy {
+	return &r.policy
+}
+
+func (r *RealtimeConfig) Webhook() *Webhook {
+	return &r.webhook
+}
+
+func (r *RealtimeConfig) Outbox() *Outbox {
+	return &r.outbox
+}
+
+func (r *RealtimeConfig) EventBus() *EventBus {
+	return &r.eventBus
+}
+
+func (r *RealtimeConfig) Scheduler() *Scheduler {
+	return &r.schedule
+}
+
+func (r *RealtimeConfig) GRPC() *GRPC {
+	return &r.grpc
+}
+
+func (r *RealtimeConfig) Docs() *Docs {
+	return &r.docs
+}
+
+func (r *RealtimeConfig) Signing() *Signing {
+	return &r.signing
+}
+
+func (r *RealtimeConfig) Introspection() *Introspection {
+	return &r.introspection
+}
@@ -0,0 +1,39 @@
+package config
+
+import "github.com/spf13/viper"
+
+type Denylist struct {
+	ExpectedTokens    int     // Denylist.ExpectedTokens, number of signed out tokens the bloom filter is sized for
+	FalsePositiveRate float64 // Denylist.FalsePositiveRate, share of valid tokens that need a Redis lookup
+	CacheSize         int     // Denylist.CacheSize, number of Redis answers kept in the local LRU
+	ResyncInterval    int     // Denylist.ResyncInterval in minutes, how often the local store is rebuilt from Redis
+}
+
+func (r *RealtimeConfig) reloadDenylist() {
+	viper.SetDefault("Denylist.ExpectedTokens", 100000)
+	viper.SetDefault("Denylist.FalsePositiveRate", 0.001)
+	viper.SetDefault("Denylist.CacheSize", 10000)
+	viper.SetDefault("Denylist.ResyncInterval", 10)
+
+	r.denylist.ExpectedTokens = viper.GetInt("Denylist.ExpectedTokens")
+	r.denylist.FalsePositiveRate = viper.GetFloat64("Denylist.FalsePositiveRate")
+	r.denylist.CacheSize = viper.GetInt("Denylist.CacheSize")
+	r.denylist.ResyncInterval = viper.GetInt("Denylist.ResyncInterval")
+
+	r.testDenylist()
+}
+
+func (r *RealtimeConfig) testDenylist() {
+	if r.denylist.ExpectedTokens < 1 {
+		panic("Config - Denylist.ExpectedTokens must be greater than 0")
+	}
+	if r.denylist.FalsePositiveRate <= 0 || r.denylist.FalsePositiveRate >= 1 {
+		panic("Config - Denylist.FalsePositiveRate must be between 0 and 1")
+	}
+	if r.denylist.CacheSize < 1 {
+		panic("Config - Denylist.CacheSize must be greater than 0")
+	}
+	if r.denylist.ResyncInterval < 1 {
+		panic("Config - Denylist.ResyncInterval must be greater than 0")
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "github.com/spf13/viper"
+
+type Docs struct {
+	Enabled bool   // Docs.Enabled, whether /openapi.json and the /docs page are served
+	Assets  string // Docs.Assets, base URL of the swagger-ui-dist files the /docs page loads
+}
+
+func (r *RealtimeConfig) reloadDocs() {
+	viper.SetDefault("Docs.Enabled", true)
+	viper.SetDefault("Docs.Assets", "https://unpkg.com/swagger-ui-dist@5.17.14")
+
+	r.docs.Enabled = viper.GetBool("Docs.Enabled")
+	r.docs.Assets = viper.GetString("Docs.Assets")
+
+	r.testDocs()
+}
+
+func (r *RealtimeConfig) testDocs() {
+	if r.docs.Enabled {
+		testEmptyString(r.docs, "Assets")
+	}
+}
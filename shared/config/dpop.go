@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+type DPoP struct {
+	AllowBearer  bool     // DPoP.AllowBearer, whether tokens not bound to a key are still issued and accepted
+	Algorithms   []string // DPoP.Algorithms, JWS algorithms accepted for proofs
+	ProofMaxAge  int      // DPoP.ProofMaxAge in seconds, how far the iat of a proof may be from the server clock
+	RequireNonce bool     // DPoP.RequireNonce, whether proofs must carry a nonce handed out by the server
+	NonceTTL     int      // DPoP.NonceTTL in seconds, how long a server nonce is accepted
+}
+
+// DPoPAlgorithms are the proof algorithms the service can verify
+var DPoPAlgorithms = []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "EdDSA"}
+
+func (r *RealtimeConfig) reloadDPoP() {
+	viper.SetDefault("DPoP.AllowBearer", true)
+	viper.SetDefault("DPoP.Algorithms", []string{"ES256", "RS256", "EdDSA"})
+	viper.SetDefault("DPoP.ProofMaxAge", 60)
+	viper.SetDefault("DPoP.RequireNonce", false)
+	viper.SetDefault("DPoP.NonceTTL", 300)
+
+	r.dpop.AllowBearer = viper.GetBool("DPoP.AllowBearer")
+	r.dpop.Algorithms = viper.GetStringSlice("DPoP.Algorithms")
+	r.dpop.ProofMaxAge = viper.GetInt("DPoP.ProofMaxAge")
+	r.dpop.RequireNonce = viper.GetBool("DPoP.RequireNonce")
+	r.dpop.NonceTTL = viper.GetInt("DPoP.NonceTTL")
+
+	r.testDPoP()
+}
+
+func (r *RealtimeConfig) testDPoP() {
+	if len(r.dpop.Algorithms) == 0 {
+		panic("Config - DPoP.Algorithms can not be empty")
+	}
+	for _, alg := range r.dpop.Algorithms {
+		known := false
+		for _, supported := range DPoPAlgorithms {
+			known = known || alg == supported
+		}
+		if !known {
+			panic(fmt.Sprintf("Config - DPoP.Algorithms has unsupported algorithm %q", alg))
+		}
+	}
+	if r.dpop.ProofMaxAge < 1 {
+		panic("Config - DPoP.ProofMaxAge must be greater than 0")
+	}
+	if r.dpop.RequireNonce && r.dpop.NonceTTL < 1 {
+		panic("Config - DPoP.NonceTTL must be greater than 0")
+	}
+}
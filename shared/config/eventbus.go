@@ -0,0 +1,46 @@
+package config
+
+import "github.com/spf13/viper"
+
+type EventBus struct {
+	Stream        string // EventBus.Stream, the Redis stream the events are appended to, dead letters go to "<Stream>:dead"
+	MaxLen        int64  // EventBus.MaxLen, the stream is trimmed to about this many events
+	BatchSize     int64  // EventBus.BatchSize, events read per call
+	Block         int    // EventBus.Block in milliseconds, how long a read waits for new events
+	ClaimIdle     int    // EventBus.ClaimIdle in seconds, how long an event stays pending before another consumer takes it over
+	MaxDeliveries int64  // EventBus.MaxDeliveries, an event handed out this many times is moved to the dead letter stream
+}
+
+func (r *RealtimeConfig) reloadEventBus() {
+	viper.SetDefault("EventBus.Stream", "events")
+	viper.SetDefault("EventBus.MaxLen", 100000)
+	viper.SetDefault("EventBus.BatchSize", 10)
+	viper.SetDefault("EventBus.Block", 2000)
+	viper.SetDefault("EventBus.ClaimIdle", 60)
+	viper.SetDefault("EventBus.MaxDeliveries", 5)
+
+	r.eventBus.Stream = viper.GetString("EventBus.Stream")
+	r.eventBus.MaxLen = viper.GetInt64("EventBus.MaxLen")
+	r.eventBus.BatchSize = viper.GetInt64("EventBus.BatchSize")
+	r.eventBus.Block = viper.GetInt("EventBus.Block")
+	r.eventBus.ClaimIdle = viper.GetInt("EventBus.ClaimIdle")
+	r.eventBus.MaxDeliveries = viper.GetInt64("EventBus.MaxDeliveries")
+
+	r.testEventBus()
+}
+
+func (r *RealtimeConfig) testEventBus() {
+	testEmptyString(r.eventBus, "Stream")
+	if r.eventBus.MaxLen < 1 || r.eventBus.BatchSize < 1 {
+		panic("Config - EventBus.MaxLen and EventBus.BatchSize must be greater than 0")
+	}
+	if r.eventBus.Block < 1 {
+		panic("Config - EventBus.Block must be greater than 0")
+	}
+	if r.eventBus.ClaimIdle < 1 {
+		panic("Config - EventBus.ClaimIdle must be greater than 0")
+	}
+	if r.eventBus.MaxDeliveries < 1 {
+		panic("Config - EventBus.MaxDeliveries must be greater than 0")
+	}
+}
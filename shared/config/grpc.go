@@ -0,0 +1,28 @@
+package config
+
+import "github.com/spf13/viper"
+
+type GRPC struct {
+	Enabled    bool   // GRPC.Enabled, whether the gRPC API is served
+	Port       string // GRPC.Port, the gRPC API listens here, next to App.Port
+	Reflection bool   // GRPC.Reflection, whether clients like grpcurl can list the services
+}
+
+func (r *RealtimeConfig) reloadGRPC() {
+	viper.SetDefault("GRPC.Enabled", false)
+	viper.SetDefault("GRPC.Port", "9090")
+	viper.SetDefault("GRPC.Reflection", false)
+
+	r.grpc.Enabled = viper.GetBool("GRPC.Enabled")
+	r.grpc.Port = viper.GetString("GRPC.Port")
+	r.grpc.Reflection = viper.GetBool("GRPC.Reflection")
+
+	r.testGRPC()
+}
+
+func (r *RealtimeConfig) testGRPC() {
+	testEmptyString(r.grpc, "Port")
+	if r.grpc.Enabled && r.grpc.Port == r.app.Port {
+		panic("Config - GRPC.Port must differ from App.Port")
+	}
+}
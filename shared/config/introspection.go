@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+type Introspection struct {
+	// Introspection.Clients, client id to secret of the services allowed to call /api/v1/introspect and the
+	// ValidateToken RPC. The ids are lower cased by the config reader, nobody is allowed without an entry.
+	Clients map[string]string
+}
+
+// minClientSecretLength keeps the secrets out of reach of guessing
+const minClientSecretLength = 32
+
+func (r *RealtimeConfig) reloadIntrospection() {
+	r.introspection.Clients = viper.GetStringMapString("Introspection.Clients")
+
+	r.testIntrospection()
+}
+
+func (r *RealtimeConfig) testIntrospection() {
+	for id, secret := range r.introspection.Clients {
+		// the id and the secret are sent as HTTP Basic credentials, which split them at the first colon
+		if strings.TrimSpace(id) == "" || strings.Contains(id, ":") {
+			panic(fmt.Sprintf("Config - Introspection.Clients has invalid client id %q", id))
+		}
+		if len(secret) < minClientSecretLength {
+			panic(fmt.Sprintf("Config - Introspection.Clients secret of %q must be at least %d characters", id, minClientSecretLength))
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import "github.com/spf13/viper"
+
+type Mail struct {
+	Driver   string // Mail.Driver, "smtp" or "log"
+	Host     string // Mail.Host
+	Port     int    // Mail.Port
+	Username string // Mail.Username
+	Password string // Mail.Password
+	From     string // Mail.From
+
+	TemplateDir string // Mail.TemplateDir, optional directory with <name>.tmpl files replacing the built-in templates
+}
+
+func (r *RealtimeConfig) reloadMail() {
+	viper.SetDefault("Mail.Driver", "log")
+	viper.SetDefault("Mail.Port", 587)
+
+	r.mail.Driver = viper.GetString("Mail.Driver")
+	r.mail.Host = viper.GetString("Mail.Host")
+	r.mail.Port = viper.GetInt("Mail.Port")
+	r.mail.Username = viper.GetString("Mail.Username")
+	r.mail.Password = viper.GetString("Mail.Password")
+	r.mail.From = viper.GetString("Mail.From")
+	r.mail.TemplateDir = viper.GetString("Mail.TemplateDir")
+
+	r.testMail()
+}
+
+func (r *RealtimeConfig) testMail() {
+	if r.mail.Driver == "smtp" {
+		testEmptyString(r.mail, "Host")
+		testEmptyString(r.mail, "From")
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import "github.com/spf13/viper"
+
+// Outbox sinks
+const (
+	OutboxSinkWebhook  = "webhook"
+	OutboxSinkEventBus = "eventbus"
+	OutboxSinkLog      = "log"
+)
+
+type Outbox struct {
+	Sinks         []string // Outbox.Sinks, where the relay publishes the messages: any of webhook, eventbus and log
+	PollInterval  int      // Outbox.PollInterval in milliseconds, how often due messages are looked up
+	BatchSize     int      // Outbox.BatchSize, messages taken per poll
+	RetryMaxDelay int      // Outbox.RetryMaxDelay in seconds, the longest wait before publishing a failed message again
+	Lease         int      // Outbox.Lease in seconds, how long an instance holds the messages it publishes
+	Retention     int      // Outbox.Retention in hours, how long dispatched messages are kept
+}
+
+func (r *RealtimeConfig) reloadOutbox() {
+	viper.SetDefault("Outbox.Sinks", []string{OutboxSinkWebhook, OutboxSinkEventBus})
+	viper.SetDefault("Outbox.PollInterval", 1000)
+	viper.SetDefault("Outbox.BatchSize", 100)
+	viper.SetDefault("Outbox.RetryMaxDelay", 300)
+	viper.SetDefault("Outbox.Lease", 30)
+	viper.SetDefault("Outbox.Retention", 24)
+
+	r.outbox.Sinks = viper.GetStringSlice("Outbox.Sinks")
+	r.outbox.PollInterval = viper.GetInt("Outbox.PollInterval")
+	r.outbox.BatchSize = viper.GetInt("Outbox.BatchSize")
+	r.outbox.RetryMaxDelay = viper.GetInt("Outbox.RetryMaxDelay")
+	r.outbox.Lease = viper.GetInt("Outbox.Lease")
+	r.outbox.Retention = viper.GetInt("Outbox.Retention")
+
+	r.testOutbox()
+}
+
+func (r *RealtimeConfig) testOutbox() {
+	if len(r.outbox.Sinks) == 0 {
+		panic("Config - Outbox.Sinks can not be empty")
+	}
+	for _, sink := range r.outbox.Sinks {
+		if sink != OutboxSinkWebhook && sink != OutboxSinkEventBus && sink != OutboxSinkLog {
+			panic("Config - Outbox.Sinks must be webhook, eventbus or log")
+		}
+	}
+	if r.outbox.PollInterval < 1 {
+		panic("Config - Outbox.PollInterval must be greater than 0")
+	}
+	if r.outbox.BatchSize < 1 {
+		panic("Config - Outbox.BatchSize must be greater than 0")
+	}
+	if r.outbox.RetryMaxDelay < 1 {
+		panic("Config - Outbox.RetryMaxDelay must be greater than 0")
+	}
+	if r.outbox.Lease < 1 {
+		panic("Config - Outbox.Lease must be greater than 0")
+	}
+	if r.outbox.Retention < 0 {
+		panic("Config - Outbox.Retention can not be negative")
+	}
+}
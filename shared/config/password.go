@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+type Password struct {
+	MinLength        int    // Password.MinLength
+	MaxLength        int    // Password.MaxLength
+	RequireUpper     bool   // Password.RequireUpper
+	RequireLower     bool   // Password.RequireLower
+	RequireDigit     bool   // Password.RequireDigit
+	RequireSymbol    bool   // Password.RequireSymbol
+	MinStrength      int    // Password.MinStrength, score from 0 (weakest) to 4 (strongest)
+	BreachedListPath string // Password.BreachedListPath, one password per line
+	ResetTokenTTL    int    // Password.ResetTokenTTL in minutes
+
+	HashAlgorithm     string // Password.HashAlgorithm, "argon2id" or "bcrypt"
+	Argon2Memory      int    // Password.Argon2Memory in KiB
+	Argon2Iterations  int    // Password.Argon2Iterations
+	Argon2Parallelism int    // Password.Argon2Parallelism
+	Argon2SaltLength  int    // Password.Argon2SaltLength in bytes
+	Argon2KeyLength   int    // Password.Argon2KeyLength in bytes
+	BcryptCost        int    // Password.BcryptCost
+	Pepper            string // Password.Pepper, optional server side secret mixed into every hash
+}
+
+func (r *RealtimeConfig) reloadPassword() {
+	viper.SetDefault("Password.MinLength", 8)
+	viper.SetDefault("Password.MaxLength", 128)
+	viper.SetDefault("Password.MinStrength", 2)
+	viper.SetDefault("Password.ResetTokenTTL", 30)
+	viper.SetDefault("Password.HashAlgorithm", "argon2id")
+	viper.SetDefault("Password.Argon2Memory", 64*1024)
+	viper.SetDefault("Password.Argon2Iterations", 3)
+	viper.SetDefault("Password.Argon2Parallelism", 2)
+	viper.SetDefault("Password.Argon2SaltLength", 16)
+	viper.SetDefault("Password.Argon2KeyLength", 32)
+	viper.SetDefault("Password.BcryptCost", 12)
+
+	r.password.MinLength = viper.GetInt("Password.MinLength")
+	r.password.MaxLength = viper.GetInt("Password.MaxLength")
+	r.password.RequireUpper = viper.GetBool("Password.RequireUpper")
+	r.password.RequireLower = viper.GetBool("Password.RequireLower")
+	r.password.RequireDigit = viper.GetBool("Password.RequireDigit")
+	r.password.RequireSymbol = viper.GetBool("Password.RequireSymbol")
+	r.password.MinStrength = viper.GetInt("Password.MinStrength")
+	r.password.BreachedListPath = viper.GetString("Password.BreachedListPath")
+	r.password.ResetTokenTTL = viper.GetInt("Password.ResetTokenTTL")
+	r.password.HashAlgorithm = viper.GetString("Password.HashAlgorithm")
+	r.password.Argon2Memory = viper.GetInt("Password.Argon2Memory")
+	r.password.Argon2Iterations = viper.GetInt("Password.Argon2Iterations")
+	r.password.Argon2Parallelism = viper.GetInt("Password.Argon2Parallelism")
+	r.password.Argon2SaltLength = viper.GetInt("Password.Argon2SaltLength")
+	r.password.Argon2KeyLength = viper.GetInt("Password.Argon2KeyLength")
+	r.password.BcryptCost = viper.GetInt("Password.BcryptCost")
+	r.password.Pepper = viper.GetString("Password.Pepper")
+
+	r.testPassword()
+}
+
+func (r *RealtimeConfig) testPassword() {
+	if r.password.MinLength < 1 {
+		panic("Config - Password.MinLength must be greater than 0")
+	}
+	if r.password.MaxLength < r.password.MinLength {
+		panic("Config - Password.MaxLength can not be less than Password.MinLength")
+	}
+	if r.password.MinStrength < 0 || r.password.MinStrength > 4 {
+		panic("Config - Password.MinStrength must be between 0 and 4")
+	}
+	switch r.password.HashAlgorithm {
+	case "argon2id":
+		if r.password.Argon2Memory < 8*r.password.Argon2Parallelism || r.password.Argon2Iterations < 1 ||
+			r.password.Argon2Parallelism < 1 || r.password.Argon2Parallelism > 255 ||
+			r.password.Argon2SaltLength < 8 || r.password.Argon2KeyLength < 16 {
+			panic("Config - Password.Argon2* parameters are out of range")
+		}
+	case "bcrypt":
+		if r.password.BcryptCost < 10 || r.password.BcryptCost > 31 {
+			panic("Config - Password.BcryptCost must be between 10 and 31")
+		}
+	default:
+		panic(fmt.Sprintf("Config - Password.HashAlgorithm %q is not supported", r.password.HashAlgorithm))
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "github.com/spf13/viper"
+
+type Policy struct {
+	Path           string // Policy.Path, YAML file with the route authorization policies
+	ReloadInterval int    // Policy.ReloadInterval in seconds, how often the file is checked for changes
+}
+
+func (r *RealtimeConfig) reloadPolicy() {
+	viper.SetDefault("Policy.Path", "resources/policies/routes.yaml")
+	viper.SetDefault("Policy.ReloadInterval", 5)
+
+	r.policy.Path = viper.GetString("Policy.Path")
+	r.policy.ReloadInterval = viper.GetInt("Policy.ReloadInterval")
+
+	r.testPolicy()
+}
+
+func (r *RealtimeConfig) testPolicy() {
+	testEmptyString(r.policy, "Path")
+	if r.policy.ReloadInterval < 1 {
+		panic("Config - Policy.ReloadInterval must be greater than 0")
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+)
+
+type Scheduler struct {
+	Enabled           bool   // Scheduler.Enabled, whether this instance takes part in running the jobs
+	LeaseTTL          int    // Scheduler.LeaseTTL in seconds, how long the leadership lasts without being renewed
+	Jitter            int    // Scheduler.Jitter in seconds, runs start up to this much after their scheduled time
+	Timeout           int    // Scheduler.Timeout in seconds, a run is cancelled after this long
+	HistoryRetention  int    // Scheduler.HistoryRetention in days, how long the job runs are kept
+	RefreshTokenPurge string // Scheduler.RefreshTokenPurge, cron schedule removing expired refresh tokens
+	DenylistCleanup   string // Scheduler.DenylistCleanup, cron schedule removing expired denylist entries
+	OutboxCleanup     string // Scheduler.OutboxCleanup, cron schedule removing dispatched outbox messages
+	HistoryCleanup    string // Scheduler.HistoryCleanup, cron schedule removing old job runs
+}
+
+func (r *RealtimeConfig) reloadScheduler() {
+	viper.SetDefault("Scheduler.Enabled", true)
+	viper.SetDefault("Scheduler.LeaseTTL", 15)
+	viper.SetDefault("Scheduler.Jitter", 30)
+	viper.SetDefault("Scheduler.Timeout", 300)
+	viper.SetDefault("Scheduler.HistoryRetention", 30)
+	viper.SetDefault("Scheduler.RefreshTokenPurge", "@hourly")
+	viper.SetDefault("Scheduler.DenylistCleanup", "*/15 * * * *")
+	viper.SetDefault("Scheduler.OutboxCleanup", "*/10 * * * *")
+	viper.SetDefault("Scheduler.HistoryCleanup", "@daily")
+
+	r.schedule.Enabled = viper.GetBool("Scheduler.Enabled")
+	r.schedule.LeaseTTL = viper.GetInt("Scheduler.LeaseTTL")
+	r.schedule.Jitter = viper.GetInt("Scheduler.Jitter")
+	r.schedule.Timeout = viper.GetInt("Scheduler.Timeout")
+	r.schedule.HistoryRetention = viper.GetInt("Scheduler.HistoryRetention")
+	r.schedule.RefreshTokenPurge = viper.GetString("Scheduler.RefreshTokenPurge")
+	r.schedule.DenylistCleanup = viper.GetString("Scheduler.DenylistCleanup")
+	r.schedule.OutboxCleanup = viper.GetString("Scheduler.OutboxCleanup")
+	r.schedule.HistoryCleanup = viper.GetString("Scheduler.HistoryCleanup")
+
+	r.testScheduler()
+}
+
+func (r *RealtimeConfig) testScheduler() {
+	if r.schedule.LeaseTTL < 3 {
+		panic("Config - Scheduler.LeaseTTL must be at least 3")
+	}
+	if r.schedule.Jitter < 0 {
+		panic("Config - Scheduler.Jitter can not be negative")
+	}
+	if r.schedule.Timeout < 1 {
+		panic("Config - Scheduler.Timeout must be greater than 0")
+	}
+	if r.schedule.HistoryRetention < 1 {
+		panic("Config - Scheduler.HistoryRetention must be greater than 0")
+	}
+	schedules := map[string]string{
+		"RefreshTokenPurge": r.schedule.RefreshTokenPurge,
+		"DenylistCleanup":   r.schedule.DenylistCleanup,
+		"OutboxCleanup":     r.schedule.OutboxCleanup,
+		"HistoryCleanup":    r.schedule.HistoryCleanup,
+	}
+	for name, spec := range schedules {
+		if _, err := cron.ParseStandard(spec); err != nil {
+			panic(fmt.Sprintf("Config - Scheduler.%s is not a valid cron schedule: %s", name, err.Error()))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+type Security struct {
+	AllowedOrigins   []string // Security.AllowedOrigins, exact origins or patterns such as "https://*.example.com" and "http://localhost:*"
+	AllowCredentials bool     // Security.AllowCredentials, whether browsers may send cookies cross-origin
+	ExposedHeaders   []string // Security.ExposedHeaders, response headers scripts may read besides the ones of the service
+	MaxAge           int      // Security.MaxAge in seconds, how long browsers cache a preflight answer
+
+	HSTSMaxAge            int    // Security.HSTSMaxAge in seconds, 0 sends no Strict-Transport-Security header
+	HSTSIncludeSubdomains bool   // Security.HSTSIncludeSubdomains
+	HSTSPreload           bool   // Security.HSTSPreload
+	ContentSecurityPolicy string // Security.ContentSecurityPolicy, empty sends no header
+	ReferrerPolicy        string // Security.ReferrerPolicy, empty sends no header
+	PermissionsPolicy     string // Security.PermissionsPolicy, empty sends no header
+}
+
+func (r *RealtimeConfig) reloadSecurity() {
+	// production only trusts its own origin and asks browsers to stay on HTTPS, local runs are served over HTTP
+	if r.env == "production" {
+		viper.SetDefault("Security.AllowedOrigins", []string{originOf(r.app.PublicURL)})
+		viper.SetDefault("Security.HSTSMaxAge", 31536000)
+		viper.SetDefault("Security.HSTSIncludeSubdomains", true)
+	} else {
+		viper.SetDefault("Security.AllowedOrigins", []string{originOf(r.app.PublicURL), "http://localhost:*", "http://127.0.0.1:*"})
+		viper.SetDefault("Security.HSTSMaxAge", 0)
+		viper.SetDefault("Security.HSTSIncludeSubdomains", false)
+	}
+	viper.SetDefault("Security.AllowCredentials", true)
+	viper.SetDefault("Security.MaxAge", 12*60*60)
+	viper.SetDefault("Security.ContentSecurityPolicy", "default-src 'none'; frame-ancestors 'none'; base-uri 'none'")
+	viper.SetDefault("Security.ReferrerPolicy", "no-referrer")
+	viper.SetDefault("Security.PermissionsPolicy", "accelerometer=(), camera=(), geolocation=(), gyroscope=(), microphone=(), payment=(), usb=()")
+
+	r.security.AllowedOrigins = viper.GetStringSlice("Security.AllowedOrigins")
+	r.security.AllowCredentials = viper.GetBool("Security.AllowCredentials")
+	r.security.ExposedHeaders = viper.GetStringSlice("Security.ExposedHeaders")
+	r.security.MaxAge = viper.GetInt("Security.MaxAge")
+	r.security.HSTSMaxAge = viper.GetInt("Security.HSTSMaxAge")
+	r.security.HSTSIncludeSubdomains = viper.GetBool("Security.HSTSIncludeSubdomains")
+	r.security.HSTSPreload = viper.GetBool("Security.HSTSPreload")
+	r.security.ContentSecurityPolicy = viper.GetString("Security.ContentSecurityPolicy")
+	r.security.ReferrerPolicy = viper.GetString("Security.ReferrerPolicy")
+	r.security.PermissionsPolicy = viper.GetString("Security.PermissionsPolicy")
+
+	r.testSecurity()
+}
+
+func (r *RealtimeConfig) testSecurity() {
+	for _, origin := range r.security.AllowedOrigins {
+		if origin == "*" {
+			if r.security.AllowCredentials {
+				panic("Config - Security.AllowedOrigins can not allow every origin with Security.AllowCredentials")
+			}
+			continue
+		}
+		// the wildcards stand for a subdomain or a port, the rest must be a plain origin
+		parsed, err := url.Parse(strings.Replace(strings.Replace(origin, "://*.", "://wildcard.", 1), ":*", ":1", 1))
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") || strings.Contains(parsed.Host, "*") {
+			panic(fmt.Sprintf("Config - Security.AllowedOrigins has invalid origin %q", origin))
+		}
+	}
+	if r.security.MaxAge < 0 {
+		panic("Config - Security.MaxAge can not be negative")
+	}
+	if r.security.HSTSMaxAge < 0 {
+		panic("Config - Security.HSTSMaxAge can not be negative")
+	}
+	if r.security.HSTSPreload && (r.security.HSTSMaxAge < 31536000 || !r.security.HSTSIncludeSubdomains) {
+		panic("Config - Security.HSTSPreload needs a Security.HSTSMaxAge of a year and Security.HSTSIncludeSubdomains")
+	}
+}
+
+func originOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
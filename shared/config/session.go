@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+type Session struct {
+	Clients        []string // Session.Clients, ids sent in X-Client-ID that always get cookie sessions
+	RefreshCookie  string   // Session.RefreshCookie, name of the HttpOnly cookie holding the refresh token
+	CSRFCookie     string   // Session.CSRFCookie, name of the cookie holding the CSRF token, readable by scripts
+	Domain         string   // Session.Domain, domain of the cookies, the host of the request when empty
+	Path           string   // Session.Path, path of the cookies
+	Secure         bool     // Session.Secure, whether the cookies are only sent over HTTPS
+	SameSite       string   // Session.SameSite, "strict", "lax" or "none"
+	AllowedOrigins []string // Session.AllowedOrigins, origins cookie sessions are accepted from, the origin of App.PublicURL when empty
+
+	SameSiteMode http.SameSite // parsed SameSite
+}
+
+func (r *RealtimeConfig) reloadSession() {
+	viper.SetDefault("Session.RefreshCookie", "refresh_token")
+	viper.SetDefault("Session.CSRFCookie", "csrf_token")
+	viper.SetDefault("Session.Path", "/api/v1")
+	viper.SetDefault("Session.Secure", true)
+	viper.SetDefault("Session.SameSite", "strict")
+
+	r.session.Clients = viper.GetStringSlice("Session.Clients")
+	r.session.RefreshCookie = viper.GetString("Session.RefreshCookie")
+	r.session.CSRFCookie = viper.GetString("Session.CSRFCookie")
+	r.session.Domain = viper.GetString("Session.Domain")
+	r.session.Path = viper.GetString("Session.Path")
+	r.session.Secure = viper.GetBool("Session.Secure")
+	r.session.SameSite = strings.ToLower(viper.GetString("Session.SameSite"))
+	r.session.AllowedOrigins = viper.GetStringSlice("Session.AllowedOrigins")
+
+	if len(r.session.AllowedOrigins) == 0 {
+		r.session.AllowedOrigins = []string{originOf(r.app.PublicURL)}
+	}
+	r.testSession()
+}
+
+func (r *RealtimeConfig) testSession() {
+	testEmptyString(r.session, "RefreshCookie")
+	testEmptyString(r.session, "CSRFCookie")
+	testEmptyString(r.session, "Path")
+
+	switch r.session.SameSite {
+	case "strict":
+		r.session.SameSiteMode = http.SameSiteStrictMode
+	case "lax":
+		r.session.SameSiteMode = http.SameSiteLaxMode
+	case "none":
+		if !r.session.Secure {
+			panic("Config - Session.SameSite none requires Session.Secure")
+		}
+		r.session.SameSiteMode = http.SameSiteNoneMode
+	default:
+		panic(fmt.Sprintf("Config - Session.SameSite has unknown value %q", r.session.SameSite))
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+type Signing struct {
+	Algorithm        string   // Signing.Algorithm of the access tokens, HS256 signs with App.AccessTokenKey
+	KeyFile          string   // Signing.KeyFile, PEM private key for RS256 and ES256
+	PreviousKeyFiles []string // Signing.PreviousKeyFiles, PEM keys of a rotation, still accepted and published
+	AcceptHS256      bool     // Signing.AcceptHS256, whether HS256 tokens are still accepted after a switch to a key pair, off by default
+	Issuer           string   // Signing.Issuer, the iss claim of the access tokens, App.PublicURL when empty
+	Audience         string   // Signing.Audience, the aud claim of the access tokens, left out when empty
+	JWKSMaxAge       int      // Signing.JWKSMaxAge in seconds, how long verifiers may cache the published keys
+}
+
+// SigningAlgorithms are the algorithms access tokens can be signed with
+var SigningAlgorithms = []string{"HS256", "RS256", "ES256"}
+
+func (r *RealtimeConfig) reloadSigning() {
+	viper.SetDefault("Signing.Algorithm", "HS256")
+	viper.SetDefault("Signing.PreviousKeyFiles", []string{})
+	// anyone knowing App.AccessTokenKey could sign tokens the key pair is meant to replace
+	viper.SetDefault("Signing.AcceptHS256", false)
+	viper.SetDefault("Signing.JWKSMaxAge", 900)
+
+	r.signing.Algorithm = viper.GetString("Signing.Algorithm")
+	r.signing.KeyFile = viper.GetString("Signing.KeyFile")
+	r.signing.PreviousKeyFiles = viper.GetStringSlice("Signing.PreviousKeyFiles")
+	r.signing.AcceptHS256 = viper.GetBool("Signing.AcceptHS256")
+	r.signing.Issuer = viper.GetString("Signing.Issuer")
+	r.signing.Audience = viper.GetString("Signing.Audience")
+	r.signing.JWKSMaxAge = viper.GetInt("Signing.JWKSMaxAge")
+
+	if len(r.signing.Issuer) == 0 {
+		r.signing.Issuer = r.app.PublicURL
+	}
+	r.testSigning()
+}
+
+func (r *RealtimeConfig) testSigning() {
+	known := false
+	for _, alg := range SigningAlgorithms {
+		known = known || r.signing.Algorithm == alg
+	}
+	if !known {
+		panic(fmt.Sprintf("Config - Signing.Algorithm has unsupported algorithm %q", r.signing.Algorithm))
+	}
+	if r.signing.Algorithm != "HS256" {
+		testEmptyString(r.signing, "KeyFile")
+	}
+	if r.signing.JWKSMaxAge < 0 {
+		panic("Config - Signing.JWKSMaxAge can not be negative")
+	}
+}
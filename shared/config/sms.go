@@ -0,0 +1,56 @@
+package config
+
+import "github.com/spf13/viper"
+
+type SMS struct {
+	Driver   string // SMS.Driver, "log" or "file", a real provider plugs in behind sms.ISender
+	FilePath string // SMS.FilePath, file the "file" driver appends every message to as a JSON line
+	From     string // SMS.From, sender id shown to the recipient
+
+	OTPLength         int // SMS.OTPLength, digits of a one-time passcode
+	OTPTTL            int // SMS.OTPTTL in seconds, lifetime of a one-time passcode
+	OTPMaxAttempts    int // SMS.OTPMaxAttempts, codes a number may try within OTPAttemptWindow, resends included
+	OTPAttemptWindow  int // SMS.OTPAttemptWindow in minutes, how long the tried codes of a number are counted
+	OTPResendInterval int // SMS.OTPResendInterval in seconds, wait before another passcode is sent to the same number
+}
+
+func (r *RealtimeConfig) reloadSMS() {
+	viper.SetDefault("SMS.Driver", "log")
+	viper.SetDefault("SMS.OTPLength", 6)
+	viper.SetDefault("SMS.OTPTTL", 300)
+	viper.SetDefault("SMS.OTPMaxAttempts", 5)
+	viper.SetDefault("SMS.OTPAttemptWindow", 60)
+	viper.SetDefault("SMS.OTPResendInterval", 60)
+
+	r.sms.Driver = viper.GetString("SMS.Driver")
+	r.sms.FilePath = viper.GetString("SMS.FilePath")
+	r.sms.From = viper.GetString("SMS.From")
+	r.sms.OTPLength = viper.GetInt("SMS.OTPLength")
+	r.sms.OTPTTL = viper.GetInt("SMS.OTPTTL")
+	r.sms.OTPMaxAttempts = viper.GetInt("SMS.OTPMaxAttempts")
+	r.sms.OTPAttemptWindow = viper.GetInt("SMS.OTPAttemptWindow")
+	r.sms.OTPResendInterval = viper.GetInt("SMS.OTPResendInterval")
+
+	r.testSMS()
+}
+
+func (r *RealtimeConfig) testSMS() {
+	if r.sms.Driver == "file" {
+		testEmptyString(r.sms, "FilePath")
+	}
+	if r.sms.OTPLength < 4 || r.sms.OTPLength > 10 {
+		panic("Config - SMS.OTPLength must be between 4 and 10")
+	}
+	if r.sms.OTPTTL < 1 {
+		panic("Config - SMS.OTPTTL must be greater than 0")
+	}
+	if r.sms.OTPMaxAttempts < 1 {
+		panic("Config - SMS.OTPMaxAttempts must be greater than 0")
+	}
+	if r.sms.OTPAttemptWindow < 1 {
+		panic("Config - SMS.OTPAttemptWindow must be greater than 0")
+	}
+	if r.sms.OTPResendInterval < 0 {
+		panic("Config - SMS.OTPResendInterval can not be negative")
+	}
+}
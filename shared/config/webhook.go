@@ -0,0 +1,64 @@
+package config
+
+import "github.com/spf13/viper"
+
+type Webhook struct {
+	Timeout        int  // Webhook.Timeout in seconds, how long an endpoint has to answer
+	MaxAttempts    int  // Webhook.MaxAttempts, a delivery is failed after this many unsuccessful attempts
+	RetryBaseDelay int  // Webhook.RetryBaseDelay in seconds, the wait after the first failure, doubled after each further one
+	RetryMaxDelay  int  // Webhook.RetryMaxDelay in seconds, the longest wait between two attempts
+	DisableAfter   int  // Webhook.DisableAfter, an endpoint is disabled after this many failed attempts in a row
+	PollInterval   int  // Webhook.PollInterval in seconds, how often due deliveries are looked up
+	BatchSize      int  // Webhook.BatchSize, deliveries taken per poll
+	Workers        int  // Webhook.Workers, deliveries sent at the same time
+	RequireHTTPS   bool // Webhook.RequireHTTPS, refuse endpoint URLs that are not https
+
+	AllowPrivateNetworks bool // Webhook.AllowPrivateNetworks, deliver to loopback, private and link-local addresses, off by default
+}
+
+func (r *RealtimeConfig) reloadWebhook() {
+	viper.SetDefault("Webhook.Timeout", 10)
+	viper.SetDefault("Webhook.MaxAttempts", 8)
+	viper.SetDefault("Webhook.RetryBaseDelay", 30)
+	viper.SetDefault("Webhook.RetryMaxDelay", 6*60*60)
+	viper.SetDefault("Webhook.DisableAfter", 20)
+	viper.SetDefault("Webhook.PollInterval", 5)
+	viper.SetDefault("Webhook.BatchSize", 50)
+	viper.SetDefault("Webhook.Workers", 4)
+	// local receivers are usually plain http
+	viper.SetDefault("Webhook.RequireHTTPS", r.env == "production")
+
+	r.webhook.Timeout = viper.GetInt("Webhook.Timeout")
+	r.webhook.MaxAttempts = viper.GetInt("Webhook.MaxAttempts")
+	r.webhook.RetryBaseDelay = viper.GetInt("Webhook.RetryBaseDelay")
+	r.webhook.RetryMaxDelay = viper.GetInt("Webhook.RetryMaxDelay")
+	r.webhook.DisableAfter = viper.GetInt("Webhook.DisableAfter")
+	r.webhook.PollInterval = viper.GetInt("Webhook.PollInterval")
+	r.webhook.BatchSize = viper.GetInt("Webhook.BatchSize")
+	r.webhook.Workers = viper.GetInt("Webhook.Workers")
+	r.webhook.RequireHTTPS = viper.GetBool("Webhook.RequireHTTPS")
+	r.webhook.AllowPrivateNetworks = viper.GetBool("Webhook.AllowPrivateNetworks")
+
+	r.testWebhook()
+}
+
+func (r *RealtimeConfig) testWebhook() {
+	if r.webhook.Timeout < 1 {
+		panic("Config - Webhook.Timeout must be greater than 0")
+	}
+	if r.webhook.MaxAttempts < 1 {
+		panic("Config - Webhook.MaxAttempts must be greater than 0")
+	}
+	if r.webhook.RetryBaseDelay < 1 || r.webhook.RetryMaxDelay < r.webhook.RetryBaseDelay {
+		panic("Config - Webhook.RetryBaseDelay must be greater than 0 and at most Webhook.RetryMaxDelay")
+	}
+	if r.webhook.DisableAfter < 1 {
+		panic("Config - Webhook.DisableAfter must be greater than 0")
+	}
+	if r.webhook.PollInterval < 1 {
+		panic("Config - Webhook.PollInterval must be greater than 0")
+	}
+	if r.webhook.BatchSize < 1 || r.webhook.Workers < 1 {
+		panic("Config - Webhook.BatchSize and Webhook.Workers must be greater than 0")
+	}
+}
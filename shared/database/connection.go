@@ -5,6 +5,7 @@ import (
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres" // Import PostgreSQL dialect
+	uuid "github.com/satori/go.uuid"
 
 	"test-task/shared/config"
 	"test-task/shared/utils"
@@ -20,6 +21,8 @@ type IConnection interface {
 
 	CreateNew(obj interface{}) error   // insert new data
 	SaveChanges(obj interface{}) error // update existing data
+
+	WithTenant(orgID uuid.UUID) IConnection // scope tenant owned models to the organization
 }
 
 // GormDB is
@@ -48,6 +51,7 @@ func Init(config config.IConfig) {
 			panic(err)
 		}
 		db.LogMode(logMode)
+		registerTenantCallbacks(db)
 
 		if len(config.Database().SlaveConnectionString) > 0 {
 			connectionString = config.Database().SlaveConnectionString
@@ -58,6 +62,7 @@ func Init(config config.IConfig) {
 			panic(err)
 		}
 		slaveDB.LogMode(logMode)
+		registerTenantCallbacks(slaveDB)
 	})
 }
 
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// tenantSetting is the gorm setting carrying the organization a connection is scoped to
+const tenantSetting = "tenant:org_id"
+
+// tenantField is the field every tenant owned model declares
+const tenantField = "OrgID"
+
+// WithTenant returns a connection scoped to the organization. Every query, update
+// and delete on a model with an OrgID field is limited to the organization, and
+// created rows get the OrgID filled in.
+func (self *connection) WithTenant(orgID uuid.UUID) IConnection {
+	return &connection{
+		self.db.Set(tenantSetting, orgID),
+		self.readonly,
+		self.isTranscation,
+	}
+}
+
+func registerTenantCallbacks(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("tenant:scope_query", scopeTenant)
+	db.Callback().RowQuery().Register("tenant:scope_row_query", scopeTenant)
+	db.Callback().Update().Before("gorm:update").Register("tenant:scope_update", scopeTenant)
+	db.Callback().Delete().Before("gorm:delete").Register("tenant:scope_delete", scopeTenant)
+	db.Callback().Create().Before("gorm:create").Register("tenant:assign_create", assignTenant)
+}
+
+func tenantOf(scope *gorm.Scope) (uuid.UUID, bool) {
+	value, ok := scope.Get(tenantSetting)
+	if !ok {
+		return uuid.Nil, false
+	}
+	orgID, ok := value.(uuid.UUID)
+	return orgID, ok
+}
+
+func scopeTenant(scope *gorm.Scope) {
+	orgID, ok := tenantOf(scope)
+	if !ok {
+		return
+	}
+	if _, hasField := scope.FieldByName(tenantField); !hasField {
+		return
+	}
+	scope.Search.Where(scope.QuotedTableName()+".org_id = ?", orgID)
+}
+
+func assignTenant(scope *gorm.Scope) {
+	orgID, ok := tenantOf(scope)
+	if !ok {
+		return
+	}
+	field, hasField := scope.FieldByName(tenantField)
+	if !hasField {
+		return
+	}
+	if field.IsBlank {
+		scope.Err(field.Set(orgID))
+		return
+	}
+	// a tenant scoped connection never writes into another tenant
+	if current, ok := field.Field.Interface().(uuid.UUID); ok && current != orgID {
+		scope.Err(gorm.ErrInvalidSQL)
+	}
+}
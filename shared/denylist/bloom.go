@@ -0,0 +1,54 @@
+package denylist
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// bloomFilter answers "definitely not added" or "maybe added" for 32 byte digests.
+// It is not safe for concurrent use, Denylist guards it.
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// newBloomFilter sizes the filter for n entries at the false positive rate p
+func newBloomFilter(n int, p float64) *bloomFilter {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/float64(n)*math.Ln2))
+	size := uint64(m)
+	return &bloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: uint64(k),
+	}
+}
+
+// positions derives the k bit positions from the digest with double hashing, the digest is
+// already a SHA-256 so its halves are independent enough
+func (b *bloomFilter) positions(digest []byte, fn func(pos uint64) bool) {
+	h1 := binary.BigEndian.Uint64(digest[0:8])
+	h2 := binary.BigEndian.Uint64(digest[8:16]) | 1
+	for i := uint64(0); i < b.hashes; i++ {
+		if !fn((h1 + i*h2) % b.size) {
+			return
+		}
+	}
+}
+
+func (b *bloomFilter) add(digest []byte) {
+	b.positions(digest, func(pos uint64) bool {
+		b.bits[pos/64] |= 1 << (pos % 64)
+		return true
+	})
+}
+
+func (b *bloomFilter) mayContain(digest []byte) bool {
+	found := true
+	b.positions(digest, func(pos uint64) bool {
+		found = b.bits[pos/64]&(1<<(pos%64)) != 0
+		return found
+	})
+	return found
+}
@@ -0,0 +1,441 @@
+package denylist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"test-task/shared/cache"
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+const (
+	keyPrefix    = "denylist_"         // one key per revoked token, expires with the token
+	setKey       = "denylist_entries"  // every revoked digest scored by its expiry, read on resync
+	sessionsKey  = "denylist_sessions" // user id -> "<issued until>:<expiry>" of the last "sign out everywhere"
+	channel      = "denylist_revoked"  // "<digest>:<expiry>" is published for every revocation
+	usersChannel = "denylist_users"    // "sessions:<user id>:<issued until>:<expiry>" and "changed:<user id>"
+	cleanTTL     = 1 * time.Minute     // how long a Redis "not revoked" answer is trusted
+	retryWait    = 1 * time.Second     // pause after a failed pub/sub receive before trying again
+)
+
+// IDenylist keeps the ids of signed out access tokens until the tokens expire, and the sessions users ended
+// everywhere until the tokens of those sessions expire
+type IDenylist interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	// RevokeSessions makes the tokens of the user issued up to issuedUntil stop working, expiresAt is
+	// when the last of them expires
+	RevokeSessions(ctx context.Context, userID string, issuedUntil, expiresAt time.Time) error
+	// SessionsRevokedAt returns the issuedUntil of the last RevokeSessions of the user, zero when none applies
+	SessionsRevokedAt(ctx context.Context, userID string) (time.Time, error)
+	// UserChanged tells every instance, this one included, that data cached about the user is stale
+	UserChanged(ctx context.Context, userID string) error
+	// OnUserChanged registers fn for UserChanged, an empty user id stands for every user
+	OnUserChanged(fn func(userID string))
+	Run(ctx context.Context)
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+// sessionsEntry is a "sign out everywhere" of a user
+type sessionsEntry struct {
+	issuedUntil time.Time
+	expiresAt   time.Time
+}
+
+// Denylist answers most lookups from memory. Token ids are hashed into a bloom filter, only a "maybe"
+// of the filter is looked up in a small LRU and then in Redis. Instances learn about revocations through
+// Redis pub/sub and rebuild the filter from Redis on start, after every reconnect and every
+// Denylist.ResyncInterval minutes, which also drops expired tokens from the filter. The sessions users
+// ended everywhere are few, they are kept in memory as a whole and synced the same way.
+type Denylist struct {
+	config config.IConfig
+
+	mu        sync.Mutex
+	bloom     *bloomFilter
+	recent    *lruCache
+	sessions  map[string]sessionsEntry
+	listeners []func(userID string)
+	synced    bool
+}
+
+func NewDenylist(cf config.IConfig) IDenylist {
+	return &Denylist{
+		config:   cf,
+		bloom:    newBloomFilter(cf.Denylist().ExpectedTokens, cf.Denylist().FalsePositiveRate),
+		recent:   newLRUCache(cf.Denylist().CacheSize),
+		sessions: map[string]sessionsEntry{},
+	}
+}
+
+// Revoke stores the token id until expiresAt and tells the other instances about it
+func (d *Denylist) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	key := digestHex(tokenID)
+	d.add(key, expiresAt)
+
+	client, err := cache.GetConnection()
+	if err != nil {
+		return err
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keyPrefix+key, expiresAt.Unix(), ttl)
+		pipe.ZAdd(ctx, setKey, &redis.Z{Score: float64(expiresAt.Unix()), Member: key})
+		pipe.Publish(ctx, channel, fmt.Sprintf("%s:%d", key, expiresAt.Unix()))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke token in Redis: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token id was revoked. Redis is only asked when the bloom filter can not
+// rule the id out and the LRU has no answer, or before the first sync. The error is a failed Redis lookup.
+func (d *Denylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	key := digestHex(tokenID)
+	digest, _ := hex.DecodeString(key)
+
+	d.mu.Lock()
+	if d.synced && !d.bloom.mayContain(digest) {
+		d.mu.Unlock()
+		return false, nil
+	}
+	if entry, ok := d.recent.get(key); ok {
+		d.mu.Unlock()
+		return entry.revoked, nil
+	}
+	d.mu.Unlock()
+
+	client, err := cache.GetConnection()
+	if err != nil {
+		return false, err
+	}
+	value, err := client.Get(ctx, keyPrefix+key).Result()
+	if err == redis.Nil {
+		d.mu.Lock()
+		d.recent.put(lruEntry{key: key, expiresAt: time.Now().Add(cleanTTL)})
+		d.mu.Unlock()
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up token in Redis: %w", err)
+	}
+
+	expiresAt := time.Now().Add(cleanTTL)
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		expiresAt = time.Unix(unix, 0)
+	}
+	d.add(key, expiresAt)
+	return true, nil
+}
+
+// RevokeSessions stores the cutoff until expiresAt and tells the other instances about it
+func (d *Denylist) RevokeSessions(ctx context.Context, userID string, issuedUntil, expiresAt time.Time) error {
+	if !time.Now().Before(expiresAt) {
+		return nil
+	}
+	value := fmt.Sprintf("%d:%d", issuedUntil.Unix(), expiresAt.Unix())
+	d.applySessions(userID, value)
+
+	client, err := cache.GetConnection()
+	if err != nil {
+		return err
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionsKey, userID, value)
+		pipe.Publish(ctx, usersChannel, "sessions:"+userID+":"+value)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions in Redis: %w", err)
+	}
+	return nil
+}
+
+// SessionsRevokedAt answers from memory once synced, Redis is only asked before the first sync. The error
+// is a failed Redis lookup.
+func (d *Denylist) SessionsRevokedAt(ctx context.Context, userID string) (time.Time, error) {
+	d.mu.Lock()
+	entry, synced := d.sessions[userID], d.synced
+	d.mu.Unlock()
+
+	if !synced {
+		client, err := cache.GetConnection()
+		if err != nil {
+			return time.Time{}, err
+		}
+		value, err := client.HGet(ctx, sessionsKey, userID).Result()
+		if err != nil && err != redis.Nil {
+			return time.Time{}, fmt.Errorf("failed to look up revoked sessions in Redis: %w", err)
+		}
+		entry, _ = parseSessions(value)
+	}
+
+	if !time.Now().Before(entry.expiresAt) {
+		return time.Time{}, nil
+	}
+	return entry.issuedUntil, nil
+}
+
+// UserChanged runs the listeners of this instance at once, the other instances run theirs when the message
+// reaches them
+func (d *Denylist) UserChanged(ctx context.Context, userID string) error {
+	d.notify(userID)
+
+	client, err := cache.GetConnection()
+	if err != nil {
+		return err
+	}
+	if err = client.Publish(ctx, usersChannel, "changed:"+userID).Err(); err != nil {
+		return fmt.Errorf("failed to publish user change to Redis: %w", err)
+	}
+	return nil
+}
+
+func (d *Denylist) OnUserChanged(fn func(userID string)) {
+	d.mu.Lock()
+	d.listeners = append(d.listeners, fn)
+	d.mu.Unlock()
+}
+
+// Run follows the revocations of the other instances until ctx is done
+func (d *Denylist) Run(ctx context.Context) {
+	client, err := cache.GetConnection()
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Denylist sync not started: %s", err.Error())
+		return
+	}
+
+	pubsub := client.Subscribe(ctx, channel, usersChannel)
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+
+	interval := time.Duration(d.config.Denylist().ResyncInterval) * time.Minute
+	nextResync := time.Now().Add(interval)
+	for ctx.Err() == nil {
+		msg, err := pubsub.ReceiveTimeout(ctx, time.Until(nextResync))
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			// delivered on the first subscribe and again after every reconnect, messages may have been missed.
+			// Both channels are confirmed each time, one resync covers them.
+			if m.Kind == "subscribe" && m.Channel == channel {
+				d.resync(ctx)
+				nextResync = time.Now().Add(interval)
+			}
+		case *redis.Message:
+			if m.Channel == usersChannel {
+				d.applyUser(m.Payload)
+			} else {
+				d.apply(m.Payload)
+			}
+		}
+
+		if err != nil && !isTimeout(err) && ctx.Err() == nil {
+			log.GetLog().Error("ERROR : ", "Denylist subscription failed: %s", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryWait):
+			}
+		}
+		if !time.Now().Before(nextResync) {
+			d.resync(ctx)
+			nextResync = time.Now().Add(interval)
+		}
+	}
+}
+
+// resync rebuilds the local store from the revocations still valid in Redis
+func (d *Denylist) resync(ctx context.Context) {
+	client, err := cache.GetConnection()
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Denylist not synced: %s", err.Error())
+		return
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	entries, err := client.ZRangeByScoreWithScores(ctx, setKey, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Denylist not synced: %s", err.Error())
+		return
+	}
+
+	cf := d.config.Denylist()
+	size := cf.ExpectedTokens
+	if len(entries) > size {
+		size = len(entries)
+	}
+	bloom := newBloomFilter(size, cf.FalsePositiveRate)
+	for _, entry := range entries {
+		key, _ := entry.Member.(string)
+		if digest, err := hex.DecodeString(key); err == nil && len(digest) == sha256.Size {
+			bloom.add(digest)
+		}
+	}
+
+	values, err := client.HGetAll(ctx, sessionsKey).Result()
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Denylist not synced: %s", err.Error())
+		return
+	}
+	sessions := make(map[string]sessionsEntry, len(values))
+	for userID, value := range values {
+		if entry, ok := parseSessions(value); ok && time.Now().Before(entry.expiresAt) {
+			sessions[userID] = entry
+		}
+	}
+
+	d.mu.Lock()
+	d.bloom = bloom
+	d.recent = newLRUCache(cf.CacheSize)
+	d.sessions = sessions
+	d.synced = true
+	d.mu.Unlock()
+	// changes published while not subscribed were missed, everything cached about users is dropped
+	d.notify("")
+	log.GetLog().Info("INFO : ", "Denylist synced with %d revoked tokens and %d users signed out everywhere", len(entries), len(sessions))
+}
+
+// Cleanup removes the expired revocations from the set and hash read on resync and returns how many it
+// removed. The per token keys expire by themselves.
+func (d *Denylist) Cleanup(ctx context.Context) (int64, error) {
+	client, err := cache.GetConnection()
+	if err != nil {
+		return 0, err
+	}
+	removed, err := client.ZRemRangeByScore(ctx, setKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove expired denylist entries from Redis: %w", err)
+	}
+
+	values, err := client.HGetAll(ctx, sessionsKey).Result()
+	if err != nil {
+		return removed, fmt.Errorf("failed to read revoked sessions from Redis: %w", err)
+	}
+	var expired []string
+	for userID, value := range values {
+		if entry, _ := parseSessions(value); !time.Now().Before(entry.expiresAt) {
+			expired = append(expired, userID)
+		}
+	}
+	if len(expired) > 0 {
+		count, err := client.HDel(ctx, sessionsKey, expired...).Result()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove expired revoked sessions from Redis: %w", err)
+		}
+		removed += count
+	}
+
+	d.mu.Lock()
+	for userID, entry := range d.sessions {
+		if !time.Now().Before(entry.expiresAt) {
+			delete(d.sessions, userID)
+		}
+	}
+	d.mu.Unlock()
+	return removed, nil
+}
+
+// apply adds a revocation published by an instance, this one included
+func (d *Denylist) apply(payload string) {
+	parts := strings.SplitN(payload, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+	d.add(parts[0], time.Unix(unix, 0))
+}
+
+// applyUser takes a message of usersChannel published by an instance, this one included
+func (d *Denylist) applyUser(payload string) {
+	kind, rest, _ := strings.Cut(payload, ":")
+	switch kind {
+	case "sessions":
+		if userID, value, ok := strings.Cut(rest, ":"); ok {
+			d.applySessions(userID, value)
+		}
+	case "changed":
+		if rest != "" {
+			d.notify(rest)
+		}
+	}
+}
+
+func (d *Denylist) applySessions(userID, value string) {
+	entry, ok := parseSessions(value)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	// a late message of an older revocation does not shorten a newer one
+	if current, found := d.sessions[userID]; !found || entry.issuedUntil.After(current.issuedUntil) {
+		d.sessions[userID] = entry
+	}
+	d.mu.Unlock()
+}
+
+func (d *Denylist) notify(userID string) {
+	d.mu.Lock()
+	listeners := d.listeners
+	d.mu.Unlock()
+	for _, fn := range listeners {
+		fn(userID)
+	}
+}
+
+func (d *Denylist) add(key string, expiresAt time.Time) {
+	digest, err := hex.DecodeString(key)
+	if err != nil || len(digest) != sha256.Size {
+		return
+	}
+	d.mu.Lock()
+	d.bloom.add(digest)
+	d.recent.put(lruEntry{key: key, revoked: true, expiresAt: expiresAt})
+	d.mu.Unlock()
+}
+
+// parseSessions reads "<issued until>:<expiry>"
+func parseSessions(value string) (sessionsEntry, bool) {
+	until, expiry, ok := strings.Cut(value, ":")
+	if !ok {
+		return sessionsEntry{}, false
+	}
+	untilUnix, err := strconv.ParseInt(until, 10, 64)
+	if err != nil {
+		return sessionsEntry{}, false
+	}
+	expiryUnix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return sessionsEntry{}, false
+	}
+	return sessionsEntry{issuedUntil: time.Unix(untilUnix, 0), expiresAt: time.Unix(expiryUnix, 0)}, true
+}
+
+// digestHex is the id stored for a token, the token id itself never leaves the process
+func digestHex(tokenID string) string {
+	sum := sha256.Sum256([]byte(tokenID))
+	return hex.EncodeToString(sum[:])
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
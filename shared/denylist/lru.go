@@ -0,0 +1,58 @@
+package denylist
+
+import (
+	"container/list"
+	"time"
+)
+
+// lruEntry is a known answer for a token id, revoked entries are dropped once the token expired
+type lruEntry struct {
+	key       string
+	revoked   bool
+	expiresAt time.Time
+}
+
+// lruCache keeps the latest answers for the ids the bloom filter can not rule out.
+// It is not safe for concurrent use, Denylist guards it.
+type lruCache struct {
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (l *lruCache) get(key string) (lruEntry, bool) {
+	element, ok := l.items[key]
+	if !ok {
+		return lruEntry{}, false
+	}
+	entry := element.Value.(lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		l.order.Remove(element)
+		delete(l.items, key)
+		return lruEntry{}, false
+	}
+	l.order.MoveToFront(element)
+	return entry, true
+}
+
+func (l *lruCache) put(entry lruEntry) {
+	if element, ok := l.items[entry.key]; ok {
+		element.Value = entry
+		l.order.MoveToFront(element)
+		return
+	}
+	l.items[entry.key] = l.order.PushFront(entry)
+	if l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(lruEntry).key)
+	}
+}
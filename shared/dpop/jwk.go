@@ -0,0 +1,153 @@
+package dpop
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// minRSABits is the smallest RSA key accepted for proofs
+const minRSABits = 2048
+
+// JWK is the public key a client puts in the "jwk" header of its proofs (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"` // only set on private keys, which are refused
+}
+
+// jwkFromHeader reads the "jwk" header of a proof
+func jwkFromHeader(value interface{}) (*JWK, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var key JWK
+	if err = json.Unmarshal(raw, &key); err != nil {
+		return nil, errors.New("jwk header is not a key")
+	}
+	if key.D != "" {
+		return nil, errors.New("jwk header holds a private key")
+	}
+	return &key, nil
+}
+
+// PublicKey returns the key in the form the JWT library verifies signatures with
+func (k *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "EC":
+		return k.ecdsaKey()
+	case "RSA":
+		n, err := decodeMember("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeMember("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits || key.E < 3 || key.E%2 == 0 {
+			return nil, errors.New("rsa key is too weak")
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeMember("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func (k *JWK) ecdsaKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var checker ecdh.Curve
+	switch k.Crv {
+	case "P-256":
+		curve, checker = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, checker = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, checker = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := decodeMember("x", k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeMember("y", k.Y)
+	if err != nil {
+		return nil, err
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("invalid ec key")
+	}
+	// the ecdh package refuses points that are not on the curve
+	point := append(append([]byte{4}, x...), y...)
+	if _, err = checker.NewPublicKey(point); err != nil {
+		return nil, errors.New("invalid ec key")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// Thumbprint is the base64url SHA-256 JWK thumbprint of the key (RFC 7638), the "jkt" tokens are bound to
+func (k *JWK) Thumbprint() (string, error) {
+	var members []string
+	switch k.Kty {
+	case "EC":
+		members = []string{"crv", k.Crv, "kty", k.Kty, "x", k.X, "y", k.Y}
+	case "RSA":
+		members = []string{"e", k.E, "kty", k.Kty, "n", k.N}
+	case "OKP":
+		members = []string{"crv", k.Crv, "kty", k.Kty, "x", k.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	// the required members in lexicographic order, without whitespace
+	canonical := []byte{'{'}
+	for i := 0; i < len(members); i += 2 {
+		if i > 0 {
+			canonical = append(canonical, ',')
+		}
+		name, _ := json.Marshal(members[i])
+		value, _ := json.Marshal(members[i+1])
+		canonical = append(append(append(canonical, name...), ':'), value...)
+	}
+	canonical = append(canonical, '}')
+
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+func decodeMember(name, value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("invalid %q member in jwk", name)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,181 @@
+package dpop
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"test-task/shared/cache"
+	"test-task/shared/config"
+)
+
+const (
+	Header      = "DPoP"       // request header carrying the proof
+	NonceHeader = "DPoP-Nonce" // response header carrying a fresh server nonce
+	proofType   = "dpop+jwt"
+	jtiPrefix   = "dpop_jti_" // one key per used proof, kept until the proof is too old anyway
+	maxJTI      = 256
+)
+
+var (
+	// ErrInvalidProof is returned for a malformed, wrongly signed, stale, replayed or mismatching proof
+	ErrInvalidProof = errors.New("invalid DPoP proof")
+	// ErrUseNonce is returned when DPoP.RequireNonce is on and the proof has no valid server nonce,
+	// the client retries with the nonce of the NonceHeader
+	ErrUseNonce = errors.New("a DPoP nonce is required")
+)
+
+// Request is what a proof must have been made for
+type Request struct {
+	Method string
+	URL    string
+	// AccessToken is the token sent along with the proof, empty on the token endpoints
+	AccessToken string
+}
+
+// IVerifier checks DPoP proofs (RFC 9449) and hands out server nonces
+type IVerifier interface {
+	Verify(ctx context.Context, proof string, req Request) (string, error)
+	Nonce() string
+}
+
+type Verifier struct {
+	config config.IConfig
+}
+
+func NewVerifier(cf config.IConfig) IVerifier {
+	return &Verifier{config: cf}
+}
+
+// Verify checks the proof against the request and returns the thumbprint of its key. Every proof can
+// be used once, the errors other than ErrInvalidProof and ErrUseNonce are failed Redis calls.
+func (v *Verifier) Verify(ctx context.Context, proof string, req Request) (string, error) {
+	cf := v.config.DPoP()
+
+	var key *JWK
+	token, err := jwt.Parse(proof, func(t *jwt.Token) (interface{}, error) {
+		if typ, _ := t.Header["typ"].(string); typ != proofType {
+			return nil, fmt.Errorf("typ is not %s", proofType)
+		}
+		var err error
+		if key, err = jwkFromHeader(t.Header["jwk"]); err != nil {
+			return nil, err
+		}
+		return key.PublicKey()
+	}, jwt.WithValidMethods(cf.Algorithms))
+	if err != nil {
+		return "", invalid(err.Error())
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" || len(jti) > maxJTI {
+		return "", invalid("missing jti")
+	}
+	if htm, _ := claims["htm"].(string); htm != req.Method {
+		return "", invalid("htm does not match the request method")
+	}
+	if htu, _ := claims["htu"].(string); !sameURL(htu, req.URL) {
+		return "", invalid("htu does not match the request URL")
+	}
+	iat, ok := claims["iat"].(float64)
+	if !ok || math.Abs(float64(time.Now().Unix())-iat) > float64(cf.ProofMaxAge) {
+		return "", invalid("iat is too far from the server time")
+	}
+	if req.AccessToken != "" {
+		sum := sha256.Sum256([]byte(req.AccessToken))
+		ath, _ := claims["ath"].(string)
+		if subtle.ConstantTimeCompare([]byte(ath), []byte(base64.RawURLEncoding.EncodeToString(sum[:]))) != 1 {
+			return "", invalid("ath does not match the access token")
+		}
+	}
+	if cf.RequireNonce {
+		if nonce, _ := claims["nonce"].(string); !v.validNonce(nonce) {
+			return "", ErrUseNonce
+		}
+	}
+
+	jkt, err := key.Thumbprint()
+	if err != nil {
+		return "", invalid(err.Error())
+	}
+
+	// the proof is fresh for ProofMaxAge on both sides of iat, it only has to be remembered that long
+	sum := sha256.Sum256([]byte(jkt + ":" + jti))
+	fresh, err := cache.SetValueIfAbsent(ctx, jtiPrefix+hex.EncodeToString(sum[:]), "1", 2*time.Duration(cf.ProofMaxAge)*time.Second)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", invalid("the proof was already used")
+	}
+	return jkt, nil
+}
+
+// Nonce returns a server nonce valid for DPoP.NonceTTL seconds. Nonces are signed timestamps,
+// so every instance accepts the nonces of the others.
+func (v *Verifier) Nonce() string {
+	issued := strconv.FormatInt(time.Now().Unix(), 10)
+	return issued + "." + v.nonceMAC(issued)
+}
+
+func (v *Verifier) validNonce(nonce string) bool {
+	parts := strings.SplitN(nonce, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(v.nonceMAC(parts[0]))) {
+		return false
+	}
+	issued, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Now().Unix() - issued
+	return age >= 0 && age <= int64(v.config.DPoP().NonceTTL)
+}
+
+func (v *Verifier) nonceMAC(issued string) string {
+	mac := hmac.New(sha256.New, []byte(v.config.App().AccessTokenKey))
+	mac.Write([]byte("dpop-nonce:" + issued))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sameURL compares htu to the request URL without query and fragment, scheme and host are case insensitive
+// and default ports are ignored
+func sameURL(htu, requestURL string) bool {
+	a, err := url.Parse(htu)
+	if err != nil || a.Host == "" {
+		return false
+	}
+	b, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && hostPort(a) == hostPort(b) && a.EscapedPath() == b.EscapedPath()
+}
+
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if (port == "443" && strings.EqualFold(u.Scheme, "https")) || (port == "80" && strings.EqualFold(u.Scheme, "http")) {
+		port = ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if port == "" {
+		return host
+	}
+	return host + ":" + port
+}
+
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidProof, reason)
+}
@@ -0,0 +1,34 @@
+package eventbus
+
+import "context"
+
+// Handler processes one event. Returning nil acknowledges it, an error leaves it pending to be handed out again.
+type Handler func(ctx context.Context, event Event) error
+
+// IPublisher appends events to the bus
+type IPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// ISubscriber reads the bus as a member of a consumer group. Every group receives every event, the consumers
+// of a group share them. An event that is not acknowledged is handed out again, possibly to another consumer
+// of the group, once it was pending for EventBus.ClaimIdle seconds. After EventBus.MaxDeliveries it is moved
+// to the dead letter stream instead.
+type ISubscriber interface {
+	// Subscribe hands the events to handler until ctx is done, it only fails when the group can not be joined
+	Subscribe(ctx context.Context, group, consumer string, handler Handler) error
+}
+
+type IBus interface {
+	IPublisher
+	ISubscriber
+}
+
+// DeadLetter is an event that was handed out EventBus.MaxDeliveries times without being acknowledged,
+// or could not be read at all
+type DeadLetter struct {
+	Event      Event
+	Group      string
+	Deliveries int64
+	Reason     string
+}
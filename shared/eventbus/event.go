@@ -0,0 +1,98 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Event types
+const (
+	UserSignedUp       = "user.signed_up"
+	UserEmailVerified  = "user.email_verified"
+	UserProfileUpdated = "user.profile_updated"
+	UserDeleted        = "user.deleted"
+)
+
+// Versions is the current schema version of the data of every event type. A version is raised when the
+// data changes in a way consumers of the old one can not read, adding a field does not need a new one.
+var Versions = map[string]int{
+	UserSignedUp:       1,
+	UserEmailVerified:  1,
+	UserProfileUpdated: 1,
+	UserDeleted:        1,
+}
+
+var (
+	ErrUnknownType        = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// Event is the envelope of every message on the bus
+type Event struct {
+	// ID identifies the event, it stays the same when the event is published again so consumers can drop duplicates
+	ID          string
+	Type        string
+	Version     int
+	AggregateID string
+	OccurredAt  time.Time
+	// Data is the JSON encoded data, in the schema of Type and Version
+	Data json.RawMessage
+}
+
+// NewEvent encodes the data of an event of the current schema version
+func NewEvent(eventType, aggregateID string, data interface{}) (Event, error) {
+	version, ok := Versions[eventType]
+	if !ok {
+		return Event{}, fmt.Errorf("%w: %s", ErrUnknownType, eventType)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:          uuid.NewV4().String(),
+		Type:        eventType,
+		Version:     version,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        raw,
+	}, nil
+}
+
+// UserEventV1 is version 1 of the data of the user.* events, Changes lists the fields a profile update changed
+type UserEventV1 struct {
+	User    UserV1   `json:"user"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+type UserV1 struct {
+	Id              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Phone           *string    `json:"phone"`
+	Status          string     `json:"status"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// UserEvent decodes the data of a user.* event. A version this build does not know fails with
+// ErrUnsupportedVersion, the event then stays pending until the consumer is upgraded.
+func (e Event) UserEvent() (UserEventV1, error) {
+	var data UserEventV1
+	switch e.Type {
+	case UserSignedUp, UserEmailVerified, UserProfileUpdated, UserDeleted:
+	default:
+		return data, fmt.Errorf("%w: %s", ErrUnknownType, e.Type)
+	}
+	if e.Version != 1 {
+		return data, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, e.Type, e.Version)
+	}
+	err := json.Unmarshal(e.Data, &data)
+	return data, err
+}
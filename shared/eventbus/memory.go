@@ -0,0 +1,146 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryBus is an IBus kept in process memory, for tests. It follows the rules of the Redis one: every group
+// starts at the first event, unacknowledged events are handed out again after claimIdle and moved to the
+// dead letters after maxDeliveries.
+type MemoryBus struct {
+	claimIdle     time.Duration
+	maxDeliveries int64
+
+	mu      sync.Mutex
+	events  []Event
+	groups  map[string]*memoryGroup
+	dead    []DeadLetter
+	changed chan struct{} // closed and replaced on every change, wakes the waiting subscribers
+}
+
+type memoryGroup struct {
+	next    int                    // index of the first event not handed out yet
+	pending map[int]*memoryPending // handed out and not acknowledged, by event index
+}
+
+type memoryPending struct {
+	consumer    string
+	deliveredAt time.Time
+	deliveries  int64
+}
+
+func NewMemoryBus(claimIdle time.Duration, maxDeliveries int64) *MemoryBus {
+	return &MemoryBus{
+		claimIdle:     claimIdle,
+		maxDeliveries: maxDeliveries,
+		groups:        map[string]*memoryGroup{},
+		changed:       make(chan struct{}),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	b.events = append(b.events, event)
+	b.broadcast()
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, group, consumer string, handler Handler) error {
+	b.mu.Lock()
+	if _, ok := b.groups[group]; !ok {
+		b.groups[group] = &memoryGroup{pending: map[int]*memoryPending{}}
+	}
+	b.mu.Unlock()
+
+	for ctx.Err() == nil {
+		index, event, deliveries, changed := b.take(group, consumer)
+		if index < 0 {
+			select {
+			case <-ctx.Done():
+			case <-changed:
+			case <-time.After(b.claimIdle):
+			}
+			continue
+		}
+
+		if err := handler(ctx, event); err != nil {
+			continue
+		}
+		b.mu.Lock()
+		if p, ok := b.groups[group].pending[index]; ok && p.consumer == consumer && p.deliveries == deliveries {
+			delete(b.groups[group].pending, index)
+		}
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// take hands out the next event of the group, an idle pending one first. The index is -1 when there
+// is none, the channel is closed once that may have changed.
+func (b *MemoryBus) take(group, consumer string) (int, Event, int64, chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g := b.groups[group]
+	now := time.Now()
+
+	for index := 0; index < g.next; index++ {
+		p, ok := g.pending[index]
+		if !ok || now.Sub(p.deliveredAt) < b.claimIdle {
+			continue
+		}
+		if p.deliveries >= b.maxDeliveries {
+			b.dead = append(b.dead, DeadLetter{
+				Event:      b.events[index],
+				Group:      group,
+				Deliveries: p.deliveries,
+				Reason:     fmt.Sprintf("not acknowledged after %d deliveries", p.deliveries),
+			})
+			delete(g.pending, index)
+			continue
+		}
+		p.consumer, p.deliveredAt = consumer, now
+		p.deliveries++
+		return index, b.events[index], p.deliveries, nil
+	}
+
+	if g.next < len(b.events) {
+		index := g.next
+		g.next++
+		g.pending[index] = &memoryPending{consumer: consumer, deliveredAt: now, deliveries: 1}
+		return index, b.events[index], 1, nil
+	}
+	return -1, Event{}, 0, b.changed
+}
+
+func (b *MemoryBus) broadcast() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Events returns every published event
+func (b *MemoryBus) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.events...)
+}
+
+// Pending returns how many events the group has handed out and not seen acknowledged
+func (b *MemoryBus) Pending(group string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if g, ok := b.groups[group]; ok {
+		return len(g.pending)
+	}
+	return 0
+}
+
+// DeadLetters returns the events moved to the dead letters
+func (b *MemoryBus) DeadLetters() []DeadLetter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]DeadLetter(nil), b.dead...)
+}
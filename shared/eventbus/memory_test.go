@@ -0,0 +1,238 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testClaimIdle = 20 * time.Millisecond
+
+// received collects the events the handlers of a test saw
+type received struct {
+	mu     sync.Mutex
+	events map[string][]string // event IDs by consumer
+}
+
+func (r *received) add(consumer string, event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = map[string][]string{}
+	}
+	r.events[consumer] = append(r.events[consumer], event.ID)
+}
+
+func (r *received) of(consumer string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events[consumer]...)
+}
+
+func (r *received) total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, ids := range r.events {
+		n += len(ids)
+	}
+	return n
+}
+
+// subscribe runs the consumer until the test ends
+func subscribe(t *testing.T, bus *MemoryBus, group, consumer string, handler Handler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := bus.Subscribe(ctx, group, consumer, handler); err != nil {
+			t.Errorf("Subscribe %s/%s: %v", group, consumer, err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func publish(t *testing.T, bus *MemoryBus, n int) []string {
+	t.Helper()
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		event, err := NewEvent(UserSignedUp, fmt.Sprintf("user-%d", i), UserEventV1{})
+		if err != nil {
+			t.Fatalf("NewEvent: %v", err)
+		}
+		if err = bus.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+// eventually fails the test when cond does not hold within a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryBusPublishConsume(t *testing.T) {
+	bus := NewMemoryBus(testClaimIdle, 3)
+	var got received
+
+	// a group starts at the first event, the ones published before it joined included
+	before := publish(t, bus, 2)
+	subscribe(t, bus, "mailer", "mailer-1", func(ctx context.Context, event Event) error {
+		got.add("mailer-1", event)
+		return nil
+	})
+	after := publish(t, bus, 2)
+
+	want := append(before, after...)
+	eventually(t, "the events to be consumed", func() bool { return got.total() == len(want) })
+	if ids := got.of("mailer-1"); !equalIDs(ids, want) {
+		t.Fatalf("consumed %v, want %v in publish order", ids, want)
+	}
+	eventually(t, "the events to be acknowledged", func() bool { return bus.Pending("mailer") == 0 })
+	if got := len(bus.Events()); got != len(want) {
+		t.Fatalf("bus holds %d events, want %d", got, len(want))
+	}
+}
+
+func TestMemoryBusRedelivery(t *testing.T) {
+	bus := NewMemoryBus(testClaimIdle, 3)
+	var got received
+	var mu sync.Mutex
+	failed := map[string]bool{}
+
+	// the first delivery of every event fails, the second one is acknowledged
+	subscribe(t, bus, "mailer", "mailer-1", func(ctx context.Context, event Event) error {
+		got.add("mailer-1", event)
+		mu.Lock()
+		defer mu.Unlock()
+		if !failed[event.ID] {
+			failed[event.ID] = true
+			return errors.New("mail server unavailable")
+		}
+		return nil
+	})
+	ids := publish(t, bus, 2)
+
+	eventually(t, "every event to be delivered twice", func() bool { return got.total() == 2*len(ids) })
+	eventually(t, "the redelivered events to be acknowledged", func() bool { return bus.Pending("mailer") == 0 })
+
+	// acknowledged events are not handed out again
+	time.Sleep(3 * testClaimIdle)
+	if n := got.total(); n != 2*len(ids) {
+		t.Fatalf("%d deliveries, want %d", n, 2*len(ids))
+	}
+	if dead := bus.DeadLetters(); len(dead) != 0 {
+		t.Fatalf("%d dead letters, want none", len(dead))
+	}
+}
+
+func TestMemoryBusRedeliveryToOtherConsumer(t *testing.T) {
+	bus := NewMemoryBus(testClaimIdle, 3)
+	var got received
+	ids := publish(t, bus, 1)
+
+	// the event stays pending while its consumer is stuck, another consumer of the group claims it once idle
+	stuck := make(chan struct{})
+	subscribe(t, bus, "mailer", "mailer-1", func(ctx context.Context, event Event) error {
+		got.add("mailer-1", event)
+		<-stuck
+		return errors.New("gave up")
+	})
+	// cleanups run last first, mailer-1 is let go before it is waited for
+	t.Cleanup(func() { close(stuck) })
+	eventually(t, "the first delivery", func() bool { return got.total() == 1 })
+	subscribe(t, bus, "mailer", "mailer-2", func(ctx context.Context, event Event) error {
+		got.add("mailer-2", event)
+		return nil
+	})
+
+	eventually(t, "the event to be claimed by mailer-2", func() bool { return len(got.of("mailer-2")) == 1 })
+	if claimed := got.of("mailer-2"); claimed[0] != ids[0] {
+		t.Fatalf("mailer-2 got %s, want %s", claimed[0], ids[0])
+	}
+	eventually(t, "the event to be acknowledged", func() bool { return bus.Pending("mailer") == 0 })
+}
+
+func TestMemoryBusDeadLetters(t *testing.T) {
+	bus := NewMemoryBus(testClaimIdle, 2)
+	var got received
+	subscribe(t, bus, "mailer", "mailer-1", func(ctx context.Context, event Event) error {
+		got.add("mailer-1", event)
+		return errors.New("malformed event")
+	})
+	ids := publish(t, bus, 1)
+
+	eventually(t, "the event to be dead lettered", func() bool { return len(bus.DeadLetters()) == 1 })
+	dead := bus.DeadLetters()[0]
+	if dead.Event.ID != ids[0] || dead.Group != "mailer" || dead.Deliveries != 2 {
+		t.Fatalf("dead letter is %+v, want event %s of group mailer after 2 deliveries", dead, ids[0])
+	}
+	if n := got.total(); n != 2 {
+		t.Fatalf("%d deliveries, want EventBus.MaxDeliveries", n)
+	}
+	if n := bus.Pending("mailer"); n != 0 {
+		t.Fatalf("%d events pending after dead lettering, want 0", n)
+	}
+}
+
+func TestMemoryBusConsumerGroups(t *testing.T) {
+	bus := NewMemoryBus(testClaimIdle, 3)
+	var got received
+	handler := func(consumer string) Handler {
+		return func(ctx context.Context, event Event) error {
+			got.add(consumer, event)
+			return nil
+		}
+	}
+
+	// every group receives every event, the consumers of a group share them
+	subscribe(t, bus, "mailer", "mailer-1", handler("mailer-1"))
+	subscribe(t, bus, "mailer", "mailer-2", handler("mailer-2"))
+	subscribe(t, bus, "search", "search-1", handler("search-1"))
+	ids := publish(t, bus, 20)
+
+	eventually(t, "both groups to consume every event", func() bool { return got.total() == 2*len(ids) })
+	if search := got.of("search-1"); !equalIDs(search, ids) {
+		t.Fatalf("search consumed %v, want %v", search, ids)
+	}
+
+	seen := map[string]int{}
+	for _, consumer := range []string{"mailer-1", "mailer-2"} {
+		for _, id := range got.of(consumer) {
+			seen[id]++
+		}
+	}
+	for _, id := range ids {
+		if seen[id] != 1 {
+			t.Fatalf("event %s consumed %d times by the mailer group, want once", id, seen[id])
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"test-task/shared/cache"
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+const (
+	deadSuffix = ":dead"         // dead letters of "<stream>" are appended to "<stream>:dead"
+	retryWait  = 1 * time.Second // pause after a failed read before trying again
+)
+
+// RedisBus keeps the events in a Redis stream. Consumer groups track what each group has read,
+// the pending entries list what was handed out and not acknowledged yet.
+type RedisBus struct {
+	config config.IConfig
+}
+
+func NewRedisBus(cf config.IConfig) IBus {
+	return &RedisBus{config: cf}
+}
+
+// Publish appends the event, the stream is trimmed to about EventBus.MaxLen events
+func (b *RedisBus) Publish(ctx context.Context, event Event) error {
+	client, err := cache.GetConnection()
+	if err != nil {
+		return err
+	}
+	cf := b.config.EventBus()
+	err = client.XAdd(ctx, &redis.XAddArgs{
+		Stream: cf.Stream,
+		MaxLen: cf.MaxLen,
+		Approx: true,
+		Values: eventValues(event),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish event to Redis: %w", err)
+	}
+	return nil
+}
+
+// Subscribe joins the group, which starts at the oldest event the stream still holds when it is new, and
+// hands the events to handler until ctx is done. Every EventBus.ClaimIdle seconds the entries pending longer
+// than that, left by crashed consumers or failed handlers, are taken over.
+func (b *RedisBus) Subscribe(ctx context.Context, group, consumer string, handler Handler) error {
+	client, err := cache.GetConnection()
+	if err != nil {
+		return err
+	}
+	cf := b.config.EventBus()
+	err = client.XGroupCreateMkStream(ctx, cf.Stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group in Redis: %w", err)
+	}
+
+	claimIdle := time.Duration(cf.ClaimIdle) * time.Second
+	var claimed time.Time
+	for ctx.Err() == nil {
+		if time.Since(claimed) >= claimIdle {
+			b.reclaim(ctx, client, group, consumer, handler)
+			claimed = time.Now()
+		}
+
+		streams, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{cf.Stream, ">"},
+			Count:    cf.BatchSize,
+			Block:    time.Duration(cf.Block) * time.Millisecond,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.GetLog().Error("ERROR : ", "Event bus read of %s failed: %s", group, err.Error())
+				select {
+				case <-ctx.Done():
+				case <-time.After(retryWait):
+				}
+			}
+			continue
+		}
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				b.handle(ctx, client, group, message, 1, handler)
+			}
+		}
+	}
+	return nil
+}
+
+// reclaim takes over the entries of the group pending longer than EventBus.ClaimIdle. Those handed out
+// EventBus.MaxDeliveries times already are moved to the dead letter stream.
+func (b *RedisBus) reclaim(ctx context.Context, client *redis.Client, group, consumer string, handler Handler) {
+	cf := b.config.EventBus()
+	claimIdle := time.Duration(cf.ClaimIdle) * time.Second
+	pending, err := client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: cf.Stream,
+		Group:  group,
+		Idle:   claimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  cf.BatchSize,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			log.GetLog().Error("ERROR : ", "Pending events of %s not loaded: %s", group, err.Error())
+		}
+		return
+	}
+
+	for _, entry := range pending {
+		// another consumer may have claimed it in the meantime, MinIdle makes the claim fail then
+		messages, err := client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   cf.Stream,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  claimIdle,
+			Messages: []string{entry.ID},
+		}).Result()
+		if err != nil {
+			log.GetLog().Error("ERROR : ", "Pending event %s of %s not claimed: %s", entry.ID, group, err.Error())
+			continue
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		deliveries := entry.RetryCount + 1
+		if entry.RetryCount >= cf.MaxDeliveries {
+			reason := fmt.Sprintf("not acknowledged after %d deliveries", entry.RetryCount)
+			b.bury(ctx, client, group, messages[0], entry.RetryCount, reason)
+			continue
+		}
+		log.GetLog().Info("WARN : ", "Event %s of %s taken over from %s (delivery %d)", entry.ID, group, entry.Consumer, deliveries)
+		b.handle(ctx, client, group, messages[0], deliveries, handler)
+	}
+}
+
+// handle passes the entry to handler and acknowledges it when the handler succeeded
+func (b *RedisBus) handle(ctx context.Context, client *redis.Client, group string, message redis.XMessage, deliveries int64, handler Handler) {
+	event, err := valuesEvent(message.Values)
+	if err != nil {
+		// it can not be read however often it is handed out
+		b.bury(ctx, client, group, message, deliveries, err.Error())
+		return
+	}
+	if err = handler(ctx, event); err != nil {
+		log.GetLog().Info("WARN : ", "Event %s (%s) not handled by %s (delivery %d): %s", message.ID, event.Type, group, deliveries, err.Error())
+		return
+	}
+	if err = client.XAck(ctx, b.config.EventBus().Stream, group, message.ID).Err(); err != nil {
+		log.GetLog().Error("ERROR : ", "Event %s of %s not acknowledged: %s", message.ID, group, err.Error())
+	}
+}
+
+// bury moves the entry to the dead letter stream and acknowledges it, so the group stops handing it out
+func (b *RedisBus) bury(ctx context.Context, client *redis.Client, group string, message redis.XMessage, deliveries int64, reason string) {
+	cf := b.config.EventBus()
+	values := map[string]interface{}{}
+	for key, value := range message.Values {
+		values[key] = value
+	}
+	values["group"] = group
+	values["stream_id"] = message.ID
+	values["deliveries"] = strconv.FormatInt(deliveries, 10)
+	values["reason"] = reason
+
+	err := client.XAdd(ctx, &redis.XAddArgs{Stream: cf.Stream + deadSuffix, MaxLen: cf.MaxLen, Approx: true, Values: values}).Err()
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Event %s of %s not moved to the dead letters: %s", message.ID, group, err.Error())
+		return
+	}
+	if err = client.XAck(ctx, cf.Stream, group, message.ID).Err(); err != nil {
+		log.GetLog().Error("ERROR : ", "Event %s of %s not acknowledged: %s", message.ID, group, err.Error())
+		return
+	}
+	log.GetLog().Info("WARN : ", "Event %s of %s moved to the dead letters: %s", message.ID, group, reason)
+}
+
+func eventValues(event Event) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           event.ID,
+		"type":         event.Type,
+		"version":      strconv.Itoa(event.Version),
+		"aggregate_id": event.AggregateID,
+		"occurred_at":  event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		"data":         string(event.Data),
+	}
+}
+
+func valuesEvent(values map[string]interface{}) (Event, error) {
+	field := func(key string) string {
+		value, _ := values[key].(string)
+		return value
+	}
+	event := Event{
+		ID:          field("id"),
+		Type:        field("type"),
+		AggregateID: field("aggregate_id"),
+		Data:        json.RawMessage(field("data")),
+	}
+	if event.ID == "" || event.Type == "" {
+		return event, fmt.Errorf("event without id or type")
+	}
+	version, err := strconv.Atoi(field("version"))
+	if err != nil {
+		return event, fmt.Errorf("invalid event version %q", field("version"))
+	}
+	event.Version = version
+	if event.OccurredAt, err = time.Parse(time.RFC3339Nano, field("occurred_at")); err != nil {
+		return event, fmt.Errorf("invalid event time %q", field("occurred_at"))
+	}
+	return event, nil
+}
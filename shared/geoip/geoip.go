@@ -0,0 +1,64 @@
+package geoip
+
+import (
+	"net"
+	"sync"
+
+	"github.com/oschwald/geoip2-golang"
+
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+// Location is the approximate place of an IP address
+type Location struct {
+	Country string
+	City    string
+}
+
+var (
+	reader     *geoip2.Reader
+	readerOnce sync.Once
+)
+
+// Open loads the local GeoIP database named by Account.GeoIPDatabase.
+// Without a database every lookup returns an empty location.
+func Open(cf config.IConfig) {
+	readerOnce.Do(func() {
+		path := cf.Account().GeoIPDatabase
+		if path == "" {
+			return
+		}
+		var err error
+		if reader, err = geoip2.Open(path); err != nil {
+			log.GetLog().Error("ERROR : ", "Can not open GeoIP database %s: %s", path, err.Error())
+			reader = nil
+		}
+	})
+}
+
+// Close releases the GeoIP database
+func Close() error {
+	if reader == nil {
+		return nil
+	}
+	return reader.Close()
+}
+
+// Lookup returns the approximate location of the IP address
+func Lookup(ip string) Location {
+	parsed := net.ParseIP(ip)
+	if reader == nil || parsed == nil {
+		return Location{}
+	}
+
+	record, err := reader.City(parsed)
+	if err != nil {
+		log.GetLog().Info("WARN : ", "GeoIP lookup failed for %s: %s", ip, err.Error())
+		return Location{}
+	}
+	return Location{
+		Country: record.Country.IsoCode,
+		City:    record.City.Names["en"],
+	}
+}
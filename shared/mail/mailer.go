@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+// IMailer is
+type IMailer interface {
+	Send(to, subject, body string) error
+	SendTemplate(to, name string, data interface{}) error
+}
+
+type smtpMailer struct {
+	config *config.Mail
+}
+
+type logMailer struct {
+	config *config.Mail
+}
+
+// NewMailer returns the mailer selected by Mail.Driver
+func NewMailer(cf config.IConfig) IMailer {
+	if cf.Mail().Driver == "smtp" {
+		return &smtpMailer{config: cf.Mail()}
+	}
+	return &logMailer{config: cf.Mail()}
+}
+
+// Send is made for delivering a plain text mail through the configured SMTP server
+func (m *smtpMailer) Send(to, subject, body string) error {
+	addr := fmt.Sprintf("%s:%d", m.config.Host, m.config.Port)
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + m.config.From + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	msg.WriteString(body)
+
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// SendTemplate is made for rendering one of the mail templates and delivering it
+func (m *smtpMailer) SendTemplate(to, name string, data interface{}) error {
+	subject, body, err := Render(m.config.TemplateDir, name, data)
+	if err != nil {
+		return err
+	}
+	return m.Send(to, subject, body)
+}
+
+// Send is made for writing the mail to the application log instead of delivering it
+func (m *logMailer) Send(to, subject, body string) error {
+	log.GetLog().Info("", "Mail to %s | %s\n%s", to, subject, body)
+	return nil
+}
+
+// SendTemplate is made for rendering one of the mail templates into the application log
+func (m *logMailer) SendTemplate(to, name string, data interface{}) error {
+	subject, body, err := Render(m.config.TemplateDir, name, data)
+	if err != nil {
+		return err
+	}
+	return m.Send(to, subject, body)
+}
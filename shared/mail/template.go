@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Mail templates, each file defines a "subject" and a "body" template
+const (
+	TemplatePasswordReset  = "password_reset"
+	TemplateNewDeviceAlert = "new_device_alert"
+	TemplateOrgInvitation  = "org_invitation"
+	TemplateVerification   = "email_verification"
+)
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+var (
+	templateCache = map[string]*template.Template{}
+	templateMu    sync.Mutex
+)
+
+// Render executes the named template with data. A file with the same name in
+// Mail.TemplateDir takes the place of the embedded template.
+func Render(templateDir, name string, data interface{}) (subject, body string, err error) {
+	tmpl, err := loadTemplate(templateDir, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	var out strings.Builder
+	if err = tmpl.ExecuteTemplate(&out, "subject", data); err != nil {
+		return "", "", fmt.Errorf("mail template %s: %w", name, err)
+	}
+	subject = strings.TrimSpace(out.String())
+
+	out.Reset()
+	if err = tmpl.ExecuteTemplate(&out, "body", data); err != nil {
+		return "", "", fmt.Errorf("mail template %s: %w", name, err)
+	}
+	return subject, strings.TrimLeft(out.String(), "\n"), nil
+}
+
+func loadTemplate(templateDir, name string) (*template.Template, error) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+
+	key := templateDir + "|" + name
+	if tmpl, ok := templateCache[key]; ok {
+		return tmpl, nil
+	}
+
+	file := name + ".tmpl"
+	var content []byte
+	var err error
+	if templateDir != "" {
+		content, err = os.ReadFile(filepath.Join(templateDir, file))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("mail template %s: %w", name, err)
+		}
+	}
+	if content == nil {
+		content, err = embeddedTemplates.ReadFile("templates/" + file)
+		if err != nil {
+			return nil, fmt.Errorf("mail template %s not found", name)
+		}
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("mail template %s: %w", name, err)
+	}
+	templateCache[key] = tmpl
+	return tmpl, nil
+}
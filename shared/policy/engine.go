@@ -0,0 +1,206 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+// Subject is the signed in user a request is checked for
+type Subject struct {
+	Roles       []string // the roles of the user, group roles included
+	Permissions []string // the permissions granted by Roles
+	OrgRole     string   // the role in the active organization, empty without one
+}
+
+// Request is the call being authorized. Attribute returns the value of a condition attribute
+// ("user.id", "param.id", ...) and whether the request has it.
+type Request struct {
+	Method    string
+	Route     string // the gin route pattern, such as /api/v1/admin/users/:id/status
+	Attribute func(name string) (string, bool)
+}
+
+// Decision is the outcome of the policies matching a request, Reasons explains it
+type Decision struct {
+	Allowed bool
+	// DryRun is set when the request is allowed only because the denying policies are in dry-run
+	DryRun  bool
+	Matched []string
+	Reasons []string
+}
+
+// IEngine evaluates the route policies of the policy file and follows the changes of the file
+type IEngine interface {
+	Matches(method, route string) bool
+	Evaluate(req Request, subject Subject) Decision
+	Run(ctx context.Context)
+}
+
+type Engine struct {
+	config config.IConfig
+
+	mu      sync.RWMutex
+	file    *File
+	content []byte
+}
+
+// NewEngine loads the policy file, the service does not start without valid policies
+func NewEngine(cf config.IConfig) IEngine {
+	e := &Engine{config: cf}
+	if err := e.load(); err != nil {
+		panic(fmt.Sprintf("Policy - %s", err.Error()))
+	}
+	return e
+}
+
+// Matches reports whether any policy covers the route, requests no policy covers are denied
+func (e *Engine) Matches(method, route string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for i := range e.file.Policies {
+		if e.file.Policies[i].matches(method, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks every policy covering the request, all of them have to allow it
+func (e *Engine) Evaluate(req Request, subject Subject) Decision {
+	e.mu.RLock()
+	file := e.file
+	e.mu.RUnlock()
+
+	decision := Decision{Allowed: true}
+	for i := range file.Policies {
+		rule := &file.Policies[i]
+		if !rule.matches(req.Method, req.Route) {
+			continue
+		}
+		decision.Matched = append(decision.Matched, rule.Name)
+
+		reason := rule.denial(req, subject)
+		if reason == "" {
+			decision.Reasons = append(decision.Reasons, fmt.Sprintf("policy %s allows", rule.Name))
+			continue
+		}
+		if file.DryRun || rule.DryRun {
+			decision.DryRun = true
+			decision.Reasons = append(decision.Reasons, fmt.Sprintf("policy %s would deny (dry-run): %s", rule.Name, reason))
+			continue
+		}
+		decision.Allowed = false
+		decision.Reasons = append(decision.Reasons, fmt.Sprintf("policy %s denies: %s", rule.Name, reason))
+	}
+	if len(decision.Matched) == 0 {
+		decision.Reasons = append(decision.Reasons, "no policy covers the route")
+	}
+	if !decision.Allowed {
+		decision.DryRun = false
+	}
+	return decision
+}
+
+// denial returns why the rule refuses the request, empty when it allows it
+func (r *Rule) denial(req Request, subject Subject) string {
+	if len(r.Roles) > 0 && !containsAny(subject.Roles, r.Roles) {
+		return fmt.Sprintf("none of the roles %s", strings.Join(r.Roles, ", "))
+	}
+	for _, permission := range r.Permissions {
+		if !contains(subject.Permissions, permission) {
+			return fmt.Sprintf("missing permission %s", permission)
+		}
+	}
+	if len(r.OrgRoles) > 0 && (subject.OrgRole == "" || !contains(r.OrgRoles, subject.OrgRole)) {
+		return fmt.Sprintf("organization role is not one of %s", strings.Join(r.OrgRoles, ", "))
+	}
+	for _, cond := range r.conditions {
+		if !cond.holds(req) {
+			return fmt.Sprintf("condition %q does not hold", cond.source)
+		}
+	}
+	return ""
+}
+
+func (c condition) holds(req Request) bool {
+	for _, alternative := range c.alternatives {
+		left, okLeft := alternative.left.value(req)
+		right, okRight := alternative.right.value(req)
+		// a missing attribute never satisfies a comparison
+		if okLeft && okRight && (left == right) == alternative.equal {
+			return true
+		}
+	}
+	return false
+}
+
+func (o operand) value(req Request) (string, bool) {
+	if o.attribute == "" {
+		return o.literal, true
+	}
+	return req.Attribute(o.attribute)
+}
+
+// Run checks the policy file every Policy.ReloadInterval seconds until ctx is done. A changed file
+// replaces the policies, an invalid one is logged and the previous policies stay.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(e.config.Policy().ReloadInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.load(); err != nil {
+				log.GetLog().Error("ERROR : ", "Policies not reloaded: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (e *Engine) load() error {
+	path := e.config.Policy().Path
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can not read policy file: %w", err)
+	}
+
+	e.mu.RLock()
+	unchanged := e.file != nil && bytes.Equal(content, e.content)
+	e.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	file, err := Parse(content)
+	if err != nil {
+		return err
+	}
+	// a production service never runs with every policy switched off, single policies may still be watched
+	if file.DryRun && e.config.Env() == "production" {
+		return fmt.Errorf("dry_run for the whole file is not allowed in production")
+	}
+	e.mu.Lock()
+	e.file = file
+	e.content = content
+	e.mu.Unlock()
+	log.GetLog().Info("INFO : ", "Loaded %d policies from %s (dry-run %t)", len(file.Policies), path, file.DryRun)
+	return nil
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, w := range wanted {
+		if contains(values, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package policy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// attribute namespaces conditions can read, see Request.Attribute
+var namespaces = []string{"user.", "param.", "query.", "header."}
+
+// File is the policy file
+type File struct {
+	// DryRun logs the denials of every policy without enforcing them
+	DryRun   bool   `yaml:"dry_run"`
+	Policies []Rule `yaml:"policies"`
+}
+
+// Rule grants access to the routes matching Route and Methods. Every listed requirement must hold:
+// one of Roles, all of Permissions, one of OrgRoles in the active organization and all of Conditions.
+type Rule struct {
+	Name        string   `yaml:"name"`
+	Route       string   `yaml:"route"`   // gin route pattern, "/**" at the end matches the whole subtree
+	Methods     []string `yaml:"methods"` // empty matches every method
+	Roles       []string `yaml:"roles"`
+	Permissions []string `yaml:"permissions"`
+	OrgRoles    []string `yaml:"org_roles"`
+	Conditions  []string `yaml:"conditions"`
+	// DryRun logs the denials of this policy without enforcing them
+	DryRun bool `yaml:"dry_run"`
+
+	conditions []condition
+}
+
+// condition holds when one of its alternatives ("a == b || c != d") does
+type condition struct {
+	source       string
+	alternatives []comparison
+}
+
+type comparison struct {
+	left, right operand
+	equal       bool
+}
+
+// operand is an attribute such as "user.id" or a quoted literal
+type operand struct {
+	attribute string
+	literal   string
+}
+
+// Parse reads and compiles a policy file
+func Parse(data []byte) (*File, error) {
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid policy file: %w", err)
+	}
+
+	names := map[string]bool{}
+	for i := range file.Policies {
+		rule := &file.Policies[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("policy %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("policy %q is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+		if !strings.HasPrefix(rule.Route, "/") {
+			return nil, fmt.Errorf("policy %q: route must start with /", rule.Name)
+		}
+		for j, method := range rule.Methods {
+			rule.Methods[j] = strings.ToUpper(method)
+			if !knownMethod(rule.Methods[j]) {
+				return nil, fmt.Errorf("policy %q: unknown method %q", rule.Name, method)
+			}
+		}
+		for _, source := range rule.Conditions {
+			compiled, err := parseCondition(source)
+			if err != nil {
+				return nil, fmt.Errorf("policy %q: %w", rule.Name, err)
+			}
+			rule.conditions = append(rule.conditions, compiled)
+		}
+	}
+	return &file, nil
+}
+
+// matches reports whether the rule covers the route pattern and method
+func (r *Rule) matches(method, route string) bool {
+	if len(r.Methods) > 0 && !contains(r.Methods, method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Route, "/**"); ok {
+		return route == prefix || strings.HasPrefix(route, prefix+"/")
+	}
+	return route == r.Route
+}
+
+func parseCondition(source string) (condition, error) {
+	compiled := condition{source: source}
+	for _, alternative := range strings.Split(source, "||") {
+		var parts []string
+		equal := true
+		if strings.Contains(alternative, "!=") {
+			parts, equal = strings.SplitN(alternative, "!=", 2), false
+		} else {
+			parts = strings.SplitN(alternative, "==", 2)
+		}
+		if len(parts) != 2 {
+			return condition{}, fmt.Errorf("condition %q: expected a == or != comparison", source)
+		}
+		left, err := parseOperand(parts[0])
+		if err != nil {
+			return condition{}, fmt.Errorf("condition %q: %w", source, err)
+		}
+		right, err := parseOperand(parts[1])
+		if err != nil {
+			return condition{}, fmt.Errorf("condition %q: %w", source, err)
+		}
+		compiled.alternatives = append(compiled.alternatives, comparison{left: left, right: right, equal: equal})
+	}
+	return compiled, nil
+}
+
+func parseOperand(source string) (operand, error) {
+	source = strings.TrimSpace(source)
+	if len(source) >= 2 && (source[0] == '\'' || source[0] == '"') && source[len(source)-1] == source[0] {
+		return operand{literal: source[1 : len(source)-1]}, nil
+	}
+	if source == "true" || source == "false" {
+		return operand{literal: source}, nil
+	}
+	for _, namespace := range namespaces {
+		if strings.HasPrefix(source, namespace) && len(source) > len(namespace) {
+			return operand{attribute: source}, nil
+		}
+	}
+	return operand{}, fmt.Errorf("unknown operand %q, use a quoted string or an attribute of %s", source, strings.Join(namespaces, " "))
+}
+
+func knownMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions, "*":
+		return true
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
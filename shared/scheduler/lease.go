@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"test-task/shared/cache"
+	"test-task/shared/log"
+)
+
+const leaderKey = "scheduler_leader" // holds the instance leading until the lease expires
+
+// renewScript extends the lease only while the caller still holds it
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript drops the lease only while the caller still holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// lease is the leadership of one instance. It is taken with SET NX and renewed every third of its ttl.
+// An instance that can not renew in time steps down and cancels the context of its leadership, so its
+// runs end before another instance can take over.
+type lease struct {
+	instance string
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	leading bool
+	renewed time.Time
+	resign  context.CancelFunc
+	ctx     context.Context
+}
+
+func newLease(instance string, ttl time.Duration) *lease {
+	return &lease{instance: instance, ttl: ttl}
+}
+
+// campaign takes or renews the lease until ctx is done
+func (l *lease) campaign(ctx context.Context) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		l.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			l.stepDown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *lease) refresh(ctx context.Context) {
+	client, err := cache.GetConnection()
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Scheduler lease not refreshed: %s", err.Error())
+		l.expire()
+		return
+	}
+
+	l.mu.Lock()
+	leading := l.leading
+	l.mu.Unlock()
+
+	var held bool
+	if leading {
+		var renewed int64
+		renewed, err = renewScript.Run(ctx, client, []string{leaderKey}, l.instance, l.ttl.Milliseconds()).Int64()
+		held = renewed == 1
+	} else {
+		held, err = client.SetNX(ctx, leaderKey, l.instance, l.ttl).Result()
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.GetLog().Error("ERROR : ", "Scheduler lease not refreshed: %s", err.Error())
+		}
+		l.expire()
+		return
+	}
+
+	switch {
+	case held && !leading:
+		l.mu.Lock()
+		l.leading, l.renewed = true, time.Now()
+		l.ctx, l.resign = context.WithCancel(context.Background())
+		l.mu.Unlock()
+		log.GetLog().Info("INFO : ", "Scheduler leadership taken by %s", l.instance)
+	case held:
+		l.mu.Lock()
+		l.renewed = time.Now()
+		l.mu.Unlock()
+	case leading:
+		log.GetLog().Info("WARN : ", "Scheduler leadership of %s lost", l.instance)
+		l.stepDown()
+	}
+}
+
+// expire steps down when the lease could not be renewed for so long that it may have expired in Redis
+func (l *lease) expire() {
+	l.mu.Lock()
+	stale := l.leading && time.Since(l.renewed) > l.ttl*2/3
+	l.mu.Unlock()
+	if stale {
+		log.GetLog().Info("WARN : ", "Scheduler leadership of %s given up, the lease could not be renewed", l.instance)
+		l.stepDown()
+	}
+}
+
+func (l *lease) stepDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.leading {
+		l.resign()
+		l.leading = false
+	}
+}
+
+// current reports whether the instance leads and the context that ends with the leadership
+func (l *lease) current() (bool, context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.leading, l.ctx
+}
+
+func (l *lease) release(ctx context.Context) {
+	client, err := cache.GetConnection()
+	if err != nil {
+		return
+	}
+	if err = releaseScript.Run(ctx, client, []string{leaderKey}, l.instance).Err(); err != nil {
+		log.GetLog().Error("ERROR : ", "Scheduler lease not released: %s", err.Error())
+	}
+}
+
+func (l *lease) holder(ctx context.Context) (string, error) {
+	client, err := cache.GetConnection()
+	if err != nil {
+		return "", err
+	}
+	instance, err := client.Get(ctx, leaderKey).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return instance, err
+}
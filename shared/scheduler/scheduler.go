@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	uuid "github.com/satori/go.uuid"
+
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusTimedOut  = "timed_out"
+)
+
+const tick = 1 * time.Second // how often due jobs are looked for
+
+// Job is a piece of periodic work. Run returns a short summary kept with the run.
+type Job struct {
+	Name     string
+	Schedule string // cron schedule, five fields or a descriptor like @hourly or @every 10m
+	Run      func(ctx context.Context) (string, error)
+}
+
+// Run is one execution of a job
+type Run struct {
+	ID          uuid.UUID
+	Job         string
+	Instance    string
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	FinishedAt  *time.Time
+	Status      string
+	Result      string
+	Error       string
+}
+
+// IRecorder keeps the history of the runs
+type IRecorder interface {
+	Started(run *Run)
+	Finished(run *Run)
+}
+
+// JobInfo describes a registered job
+type JobInfo struct {
+	Name      string
+	Schedule  string
+	NextRunAt time.Time
+	Running   bool
+}
+
+type IScheduler interface {
+	Register(job Job) error
+	Start()
+	Stop(ctx context.Context) error
+	Jobs() []JobInfo
+	Leader(ctx context.Context) (string, error)
+	Instance() string
+}
+
+// Scheduler runs the registered jobs on their schedule. The instances elect a leader through a Redis lease
+// and only the leader runs jobs, so each scheduled run happens once. A run starts after a random jitter of up
+// to Scheduler.Jitter seconds and is cancelled after Scheduler.Timeout seconds or when the leadership is lost.
+// A job is not started again while its previous run goes on.
+type Scheduler struct {
+	config   config.IConfig
+	recorder IRecorder
+	instance string
+	lease    *lease
+
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	stop    context.CancelFunc
+	stopped chan struct{}
+	runs    sync.WaitGroup
+}
+
+type entry struct {
+	job      Job
+	schedule cron.Schedule
+	next     time.Time
+	running  bool
+}
+
+func NewScheduler(cf config.IConfig, recorder IRecorder) IScheduler {
+	hostname, _ := os.Hostname()
+	instance := fmt.Sprintf("%s-%s", hostname, uuid.NewV4().String()[:8])
+	return &Scheduler{
+		config:   cf,
+		recorder: recorder,
+		instance: instance,
+		lease:    newLease(instance, time.Duration(cf.Scheduler().LeaseTTL)*time.Second),
+		jobs:     map[string]*entry{},
+	}
+}
+
+// Register adds a job, its name must be unique
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.jobs[job.Name] = &entry{job: job, schedule: schedule, next: schedule.Next(time.Now())}
+	return nil
+}
+
+// Start campaigns for the leadership and runs the due jobs while this instance leads, until Stop.
+// Nothing is started when Scheduler.Enabled is off.
+func (s *Scheduler) Start() {
+	if !s.config.Scheduler().Enabled {
+		log.GetLog().Info("INFO : ", "Scheduler disabled on %s", s.instance)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.stopped = make(chan struct{})
+	go s.lease.campaign(ctx)
+	go s.loop(ctx)
+}
+
+// Stop ends the scheduling, cancels the running jobs and waits for them until ctx is done. The leadership
+// is handed back so another instance takes over without waiting for the lease to expire.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	s.stop()
+	<-s.stopped
+
+	finished := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		log.GetLog().Info("WARN : ", "Scheduler stopped with jobs still running")
+	}
+
+	release, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.lease.release(release)
+	return nil
+}
+
+// Jobs lists the registered jobs by name
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]JobInfo, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		jobs = append(jobs, JobInfo{Name: e.job.Name, Schedule: e.job.Schedule, NextRunAt: e.next, Running: e.running})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// Leader returns the instance currently leading, empty when none does
+func (s *Scheduler) Leader(ctx context.Context) (string, error) {
+	return s.lease.holder(ctx)
+}
+
+func (s *Scheduler) Instance() string {
+	return s.instance
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.fire(now)
+		}
+	}
+}
+
+// fire starts the jobs that are due. Every instance moves the schedules on, only the leader runs them.
+func (s *Scheduler) fire(now time.Time) {
+	leading, leadership := s.lease.current()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.jobs {
+		if now.Before(e.next) {
+			continue
+		}
+		scheduledAt := e.next
+		e.next = e.schedule.Next(now)
+		if !leading {
+			continue
+		}
+		if e.running {
+			log.GetLog().Info("WARN : ", "Job %s skipped, its previous run is not done", e.job.Name)
+			continue
+		}
+		e.running = true
+		s.runs.Add(1)
+		go s.run(leadership, e, scheduledAt)
+	}
+}
+
+// run executes the job once, after the jitter, and records the outcome
+func (s *Scheduler) run(ctx context.Context, e *entry, scheduledAt time.Time) {
+	defer func() {
+		s.mu.Lock()
+		e.running = false
+		s.mu.Unlock()
+		s.runs.Done()
+	}()
+
+	cf := s.config.Scheduler()
+	if cf.Jitter > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(rand.Int63n(int64(cf.Jitter) * int64(time.Second)))):
+		}
+	}
+
+	run := &Run{
+		ID:          uuid.NewV4(),
+		Job:         e.job.Name,
+		Instance:    s.instance,
+		ScheduledAt: scheduledAt,
+		StartedAt:   time.Now(),
+		Status:      StatusRunning,
+	}
+	s.recorder.Started(run)
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(cf.Timeout)*time.Second)
+	defer cancel()
+	result, err := safeRun(runCtx, e.job)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Result = result
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded:
+		run.Status = StatusTimedOut
+		run.Error = fmt.Sprintf("cancelled after %ds", cf.Timeout)
+	case err != nil:
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	default:
+		run.Status = StatusSucceeded
+	}
+	s.recorder.Finished(run)
+
+	if run.Status == StatusSucceeded {
+		log.GetLog().Info("INFO : ", "Job %s done in %s: %s", run.Job, finished.Sub(run.StartedAt), run.Result)
+	} else {
+		log.GetLog().Error("ERROR : ", "Job %s %s after %s: %s", run.Job, run.Status, finished.Sub(run.StartedAt), run.Error)
+	}
+}
+
+// safeRun turns a panic of the job into an error, so it can not take the scheduler down
+func safeRun(ctx context.Context, job Job) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
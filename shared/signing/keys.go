@@ -0,0 +1,255 @@
+// Package signing holds the keys the access tokens are signed with. With a key pair (Signing.Algorithm RS256
+// or ES256) the public keys are published as a JWK Set (RFC 7517) and other services verify the tokens
+// without knowing a secret, see the authverify package.
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"test-task/shared/config"
+	"test-task/shared/dpop"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+
+	// minRSABits is the smallest RSA key tokens are signed with
+	minRSABits = 2048
+)
+
+// ErrUnknownKey is returned for a token signed with a key, or an algorithm, the key set does not hold
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JWK is a public key of the JWK Set, kid is the RFC 7638 thumbprint of the key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// IKeySet signs the access tokens and checks their signature
+type IKeySet interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	Parse(token string) (*jwt.Token, error)
+	JWKS() JWKSet
+}
+
+type KeySet struct {
+	method   jwt.SigningMethod
+	kid      string      // empty for HS256
+	private  interface{} // the secret for HS256, a *rsa.PrivateKey or *ecdsa.PrivateKey
+	secret   []byte      // App.AccessTokenKey, nil when HS256 tokens are no longer accepted
+	public   map[string]publicKey
+	jwks     JWKSet
+	methods  []string
+	issuer   string
+	audience string
+}
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// NewKeySet loads the keys of the [Signing] section, the service does not start without them
+func NewKeySet(cf config.IConfig) IKeySet {
+	keys, err := load(cf)
+	if err != nil {
+		panic(fmt.Sprintf("Signing - %s", err.Error()))
+	}
+	return keys
+}
+
+func load(cf config.IConfig) (*KeySet, error) {
+	sc := cf.Signing()
+	k := &KeySet{
+		public:   map[string]publicKey{},
+		jwks:     JWKSet{Keys: []JWK{}},
+		issuer:   sc.Issuer,
+		audience: sc.Audience,
+	}
+
+	if sc.Algorithm == HS256 || sc.AcceptHS256 {
+		k.secret = []byte(cf.App().AccessTokenKey)
+		k.methods = append(k.methods, HS256)
+	}
+	if sc.Algorithm == HS256 {
+		k.method, k.private = jwt.SigningMethodHS256, k.secret
+		return k, nil
+	}
+
+	private, err := readKey(sc.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a private key", sc.KeyFile)
+	}
+	if k.kid, err = k.add(signer.Public()); err != nil {
+		return nil, fmt.Errorf("%s: %s", sc.KeyFile, err.Error())
+	}
+	if alg := k.public[k.kid].alg; alg != sc.Algorithm {
+		return nil, fmt.Errorf("%s is a %s key, not a %s key", sc.KeyFile, alg, sc.Algorithm)
+	}
+	k.method, k.private = jwt.GetSigningMethod(sc.Algorithm), private
+
+	// the keys of a rotation can be given with their private or only their public half
+	for _, file := range sc.PreviousKeyFiles {
+		key, err := readKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			key = signer.Public()
+		}
+		if _, err = k.add(key); err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err.Error())
+		}
+	}
+	return k, nil
+}
+
+// add accepts tokens signed with the key and publishes it, it returns the kid of the key
+func (k *KeySet) add(key crypto.PublicKey) (string, error) {
+	var jwk JWK
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSABits {
+			return "", fmt.Errorf("rsa keys must have at least %d bits", minRSABits)
+		}
+		jwk = JWK{Kty: "RSA", Alg: RS256, N: encode(key.N.Bytes()), E: encode(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", errors.New("ec keys must be on the P-256 curve")
+		}
+		x, y := make([]byte, 32), make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		jwk = JWK{Kty: "EC", Alg: ES256, Crv: "P-256", X: encode(x), Y: encode(y)}
+	default:
+		return "", errors.New("only RSA and P-256 EC keys are supported")
+	}
+
+	thumbprint := dpop.JWK{Kty: jwk.Kty, Crv: jwk.Crv, X: jwk.X, Y: jwk.Y, N: jwk.N, E: jwk.E}
+	kid, err := thumbprint.Thumbprint()
+	if err != nil {
+		return "", err
+	}
+	jwk.Kid, jwk.Use = kid, "sig"
+
+	if _, ok := k.public[kid]; !ok {
+		k.public[kid] = publicKey{alg: jwk.Alg, key: key}
+		k.jwks.Keys = append(k.jwks.Keys, jwk)
+		if !contains(k.methods, jwk.Alg) {
+			k.methods = append(k.methods, jwk.Alg)
+		}
+	}
+	return kid, nil
+}
+
+// Sign creates the token of the claims, adding the issuer and the audience
+func (k *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	if k.issuer != "" {
+		claims["iss"] = k.issuer
+	}
+	if k.audience != "" {
+		claims["aud"] = k.audience
+	}
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.kid != "" {
+		token.Header["kid"] = k.kid
+	}
+	return token.SignedString(k.private)
+}
+
+// Parse checks the signature, the expiry, the issuer and the audience of a token signed by Sign
+func (k *KeySet) Parse(t string) (*jwt.Token, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(k.methods)}
+	if k.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(k.issuer))
+	}
+	if k.audience != "" {
+		opts = append(opts, jwt.WithAudience(k.audience))
+	}
+	return jwt.Parse(t, k.key, opts...)
+}
+
+func (k *KeySet) key(t *jwt.Token) (interface{}, error) {
+	if t.Method.Alg() == HS256 {
+		return k.secret, nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	key, ok := k.public[kid]
+	if !ok || key.alg != t.Method.Alg() {
+		return nil, ErrUnknownKey
+	}
+	return key.key, nil
+}
+
+// JWKS lists the public keys, it is empty while the tokens are signed with HS256
+func (k *KeySet) JWKS() JWKSet {
+	return k.jwks
+}
+
+// readKey reads a PEM file holding a PKCS #8, PKCS #1 or SEC 1 private key or a PKIX public key
+func readKey(file string) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s holds no PEM block", file)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("%s holds an unsupported %q PEM block", file, block.Type)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
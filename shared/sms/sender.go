@@ -0,0 +1,68 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+// ISender is the SMS provider, to is an E.164 number
+type ISender interface {
+	Send(to, body string) error
+}
+
+type logSender struct {
+	config *config.SMS
+}
+
+type fileSender struct {
+	config *config.SMS
+	mu     sync.Mutex
+}
+
+// Message is one line written by the "file" driver, tests read the passcodes back from it
+type Message struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Body   string    `json:"body"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// NewSender returns the sender selected by SMS.Driver
+func NewSender(cf config.IConfig) ISender {
+	if cf.SMS().Driver == "file" {
+		return &fileSender{config: cf.SMS()}
+	}
+	return &logSender{config: cf.SMS()}
+}
+
+// Send is made for writing the message to the application log instead of delivering it
+func (s *logSender) Send(to, body string) error {
+	log.GetLog().Info("", "SMS to %s | %s", to, body)
+	return nil
+}
+
+// Send is made for appending the message to SMS.FilePath
+func (s *fileSender) Send(to, body string) error {
+	line, err := json.Marshal(Message{From: s.config.From, To: to, Body: body, SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.config.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write sms file: %w", err)
+	}
+	return nil
+}
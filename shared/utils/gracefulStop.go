@@ -11,7 +11,7 @@ import (
 )
 
 func GracefulStop(log log.ILogger, callback func(context.Context) error) {
-	var gracefulStop = make(chan os.Signal)
+	var gracefulStop = make(chan os.Signal, 1)
 	signal.Notify(gracefulStop, syscall.SIGINT, syscall.SIGTERM)
 	<-gracefulStop
 
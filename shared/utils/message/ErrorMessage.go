@@ -11,4 +11,63 @@ const (
 	InvalidRefreshToken  = "invalid refresh token"
 	RefreshTokenNotFound = "refresh token not found"
 	RefreshTokenExpired  = "refresh token expired"
+	InvalidResetToken    = "invalid or expired password reset token"
+	SamePassword         = "new password must be different from the current password"
+	Forbidden            = "you are not allowed to access this resource"
+	InvalidActionToken   = "the link is invalid, expired or already used"
+	CannotImpersonate    = "this user can not be impersonated"
+	NotOrgMember         = "you are not a member of this organization"
+	OrgNotFound          = "organization not found"
+	OrgSlugInUse         = "organization slug is already in use"
+	OrgSlugInvalid       = "organization slug may only contain lowercase letters, digits and dashes"
+	OrgNotSelected       = "no organization is selected"
+	OrgMemberNotFound    = "organization member not found"
+	OrgLastOwner         = "an organization must keep at least one owner"
+	SignInMethodDenied   = "this sign-in method is not allowed for the organization"
+	UnknownSignInMethod  = "unknown sign-in method %q"
+	AlreadyOrgMember     = "the user is already a member of this organization"
+	InvitationExists     = "a pending invitation already exists for this email"
+	InvitationNotFound   = "invitation not found"
+	InvalidInvitation    = "the invitation is invalid, expired or no longer pending"
+	InvitationMismatch   = "the invitation was sent to another email address"
+	AccountExists        = "an account already exists for this email, sign in to accept the invitation"
+	GroupNotFound        = "group not found"
+	GroupNameInUse       = "group name is already in use"
+	GroupMemberNotFound  = "the user is not a member of this group"
+	GroupRoleNotFound    = "the group does not have this role"
+	UnknownRole          = "unknown role %q"
+	AccountPending       = "please verify your email address before signing in"
+	AccountSuspended     = "your account has been suspended"
+	AccountLocked        = "your account is locked"
+	AccountInactive      = "your account is not active"
+	InvalidTransition    = "a %s user can not be moved to %s"
+	StatusReasonRequired = "a reason is required for this status"
+	RestoreWindowEnded   = "the restore window of this user has ended"
+	PhoneInUse           = "phone number is already in use"
+	InvalidOTP           = "invalid or expired code"
+	OTPAttemptsExceeded  = "too many wrong codes, try again later"
+	OTPTooSoon           = "a code was sent recently, please wait before requesting another"
+	DPoPRequired         = "a DPoP proof is required"
+	DPoPKeyMismatch      = "the DPoP proof was not made with the key of the token"
+	InvalidCSRFToken     = "invalid or missing CSRF token"
+	WebhookNotFound      = "webhook endpoint not found"
+	DeliveryNotFound     = "webhook delivery not found"
+	UnknownWebhookEvent  = "unknown webhook event %q"
+	InvalidWebhookURL    = "the webhook URL must be an absolute http or https URL"
+	WebhookHTTPSRequired = "the webhook URL must use https"
+	WebhookPrivateHost   = "the webhook URL must not point to a loopback, private or link-local address"
+	JobNotFound          = "job not found"
+
+	AuditChainGap          = "audit event missing from the chain"
+	AuditChainLinkBroken   = "audit event does not link to the previous event"
+	AuditChainHashMismatch = "audit event content does not match its hash"
+
+	PasswordTooShort    = "password must be at least %d characters long"
+	PasswordTooLong     = "password must be at most %d characters long"
+	PasswordNeedsUpper  = "password must contain at least one uppercase letter"
+	PasswordNeedsLower  = "password must contain at least one lowercase letter"
+	PasswordNeedsDigit  = "password must contain at least one digit"
+	PasswordNeedsSymbol = "password must contain at least one symbol"
+	PasswordBreached    = "password appears in a list of breached or common passwords"
+	PasswordTooWeak     = "password is too easy to guess (strength %d, at least %d required)"
 )
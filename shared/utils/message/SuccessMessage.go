@@ -1,8 +1,57 @@
 package message
 
 const (
-	SignUpSuccess       = "signed up successfully"
-	SignInSuccess       = "signed in successfully"
-	UserProfileFetched  = "user profile fetched successfully"
-	TokenRefreshSuccess = "token refreshed successfully"
+	SignUpSuccess        = "signed up successfully"
+	SignUpVerifyEmail    = "signed up successfully, follow the link sent to your email to activate the account"
+	SignInSuccess        = "signed in successfully"
+	UserProfileFetched   = "user profile fetched successfully"
+	TokenRefreshSuccess  = "token refreshed successfully"
+	TokenIntrospected    = "token introspected successfully"
+	PasswordChanged      = "password changed successfully"
+	PasswordResetSent    = "if the email is registered, a password reset link has been sent"
+	PasswordReset        = "password reset successfully"
+	AuditEventsFetched   = "audit events fetched successfully"
+	AuditChainVerified   = "audit chain verified"
+	ActivityFetched      = "account activity fetched successfully"
+	SessionsRevoked      = "all sessions have been signed out"
+	ImpersonationStarted = "impersonation token issued"
+	OrgCreated           = "organization created successfully"
+	OrgsFetched          = "organizations fetched successfully"
+	OrgFetched           = "organization fetched successfully"
+	OrgSwitched          = "organization switched successfully"
+	OrgSettingsUpdated   = "organization settings updated successfully"
+	OrgMembersFetched    = "organization members fetched successfully"
+	OrgMemberUpdated     = "organization member updated successfully"
+	OrgMemberRemoved     = "organization member removed successfully"
+	InvitationSent       = "invitation sent successfully"
+	InvitationsFetched   = "invitations fetched successfully"
+	InvitationFetched    = "invitation fetched successfully"
+	InvitationResent     = "invitation resent successfully"
+	InvitationRevoked    = "invitation revoked successfully"
+	InvitationAccepted   = "invitation accepted successfully"
+	PermissionsFetched   = "permissions fetched successfully"
+	GroupCreated         = "group created successfully"
+	GroupsFetched        = "groups fetched successfully"
+	GroupFetched         = "group fetched successfully"
+	GroupDeleted         = "group deleted successfully"
+	GroupMembersAdded    = "group members added successfully"
+	GroupMemberRemoved   = "group member removed successfully"
+	GroupRoleAdded       = "group role added successfully"
+	GroupRoleRemoved     = "group role removed successfully"
+	EmailVerified        = "email verified successfully"
+	VerificationSent     = "if the account is waiting for verification, a new link has been sent"
+	UserStatusChanged    = "user status changed successfully"
+	UserRestored         = "user restored successfully"
+	OTPSent              = "if the number is registered, a code has been sent to it"
+	PhoneCodeSent        = "a code has been sent to the phone number"
+	PhoneVerified        = "phone number verified successfully"
+	WebhookCreated       = "webhook endpoint created successfully"
+	WebhooksFetched      = "webhook endpoints fetched successfully"
+	WebhookFetched       = "webhook endpoint fetched successfully"
+	WebhookUpdated       = "webhook endpoint updated successfully"
+	WebhookDeleted       = "webhook endpoint deleted successfully"
+	DeliveriesFetched    = "webhook deliveries fetched successfully"
+	WebhookRedelivered   = "webhook delivery scheduled successfully"
+	JobsFetched          = "jobs fetched successfully"
+	JobRunsFetched       = "job runs fetched successfully"
 )
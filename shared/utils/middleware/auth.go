@@ -5,129 +5,291 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"test-task/model"
 	"test-task/shared/cache"
+	"test-task/shared/captcha"
 	"test-task/shared/config"
+	"test-task/shared/denylist"
+	"test-task/shared/dpop"
+	"test-task/shared/policy"
+	"test-task/shared/signing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc"
 )
 
 type UserTokenData struct {
 	Id        uuid.UUID `json:"id"`
 	Email     string    `json:"email"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// OrgId is the active organization of the session, nil when the user has none
+	OrgId      *uuid.UUID `json:"org_id,omitempty"`
+	OrgRole    string     `json:"org_role,omitempty"`
+	AuthMethod string     `json:"auth_method,omitempty"`
+
+	// Actor is the admin impersonating the user, nil for regular tokens
+	Actor *TokenActor `json:"-"`
 }
 type IMiddleware interface {
 	AuthHandler() gin.HandlerFunc
+	RequestIDHandler() gin.HandlerFunc
+	CaptchaHandler() gin.HandlerFunc
+	DPoPHandler() gin.HandlerFunc
+	SessionHandler() gin.HandlerFunc
+	CORSHandler() gin.HandlerFunc
+	SecurityHeadersHandler() gin.HandlerFunc
+	PolicyHandler() gin.HandlerFunc
+	ImpersonationGuard() gin.HandlerFunc
+	IntrospectionClientHandler() gin.HandlerFunc
+	Authenticate(ctx context.Context, token string) (*AccessToken, *AuthError)
+	RequestInterceptor() grpc.UnaryServerInterceptor
+	AuthInterceptor(methods ...string) grpc.UnaryServerInterceptor
+	CaptchaInterceptor(methods ...string) grpc.UnaryServerInterceptor
+	DPoPInterceptor(methods ...string) grpc.UnaryServerInterceptor
+	IntrospectionClientInterceptor(methods ...string) grpc.UnaryServerInterceptor
 }
 
 // Middleware is
 type Middleware struct {
-	Config config.IConfig
+	Config      config.IConfig
+	Recorder    IImpersonationRecorder
+	Permissions IPermissionResolver
+	Status      IUserStatusResolver
+	Denylist    denylist.IDenylist
+	Captcha     captcha.IVerifier
+	DPoP        dpop.IVerifier
+	Policies    policy.IEngine
+	Keys        signing.IKeySet
 }
 
-var AccessTokenKey string
+// AccessTokenKeys signs the access tokens, with App.AccessTokenKey or the key pair of [Signing]
+var AccessTokenKeys signing.IKeySet
 var RefreshTokenKey string
 
-func NewMiddlewareService(cf config.IConfig) IMiddleware {
-	AccessTokenKey = cf.App().AccessTokenKey
+// AccessTokenTTL and RefreshTokenTTL are the default token lifetimes, organizations may override them
+var AccessTokenTTL = 15 * time.Minute
+var RefreshTokenTTL = 7 * 24 * time.Hour
+
+// MaxAccessTokenTTL is the longest lifetime an access token can be issued with
+const MaxAccessTokenTTL = 24 * time.Hour
+
+func NewMiddlewareService(cf config.IConfig, recorder IImpersonationRecorder, permissions IPermissionResolver, status IUserStatusResolver, denied denylist.IDenylist, policies policy.IEngine, keys signing.IKeySet) IMiddleware {
+	AccessTokenKeys = keys
 	RefreshTokenKey = cf.App().RefreshTokenKey
+	AccessTokenTTL = time.Duration(cf.App().AccessTokenTTL) * time.Minute
+	RefreshTokenTTL = time.Duration(cf.App().RefreshTokenTTL) * time.Hour
 	return &Middleware{
-		Config: cf,
+		Config:      cf,
+		Recorder:    recorder,
+		Permissions: permissions,
+		Status:      status,
+		Denylist:    denied,
+		Captcha:     captcha.NewVerifier(cf),
+		DPoP:        dpop.NewVerifier(cf),
+		Policies:    policies,
+		Keys:        keys,
 	}
 }
 
 // AuthHandler is used for User authentication
 func (m *Middleware) AuthHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		bearerToken := c.Request.Header.Get("Authorization")
+		scheme, token, ok := authorizationToken(c.Request.Header.Get("Authorization"))
 
-		if !strings.HasPrefix(bearerToken, "Bearer ") {
+		if !ok {
 			c.JSON(401, gin.H{"message": "Your request is not authorized", "status": http.StatusUnauthorized})
 			c.Abort()
 			return
 		}
 
-		token := strings.Split(bearerToken, "Bearer ")
-		if len(token) < 2 {
+		if token == "" {
 			c.JSON(401, gin.H{"message": "An authorization token was not supplied", "status": http.StatusUnauthorized})
 			c.Abort()
 			return
 		}
 
-		// Validate token
-		valid, err := ValidateToken(token[1], m.Config.App().AccessTokenKey)
-		if err != nil {
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				c.JSON(401, gin.H{"message": "The authorization token is expired", "status": http.StatusUnauthorized})
-				c.Abort()
-				return
-			} else {
-				c.JSON(401, gin.H{"message": "invalid authorization token", "status": http.StatusUnauthorized})
-				c.Abort()
-				return
-			}
-		}
-		c.Set("userData", valid.Claims.(jwt.MapClaims)["userData"])
-
-		var expTime int
-		expInfo := valid.Claims.(jwt.MapClaims)["exp"]
-		if expInfo != nil {
-			val := expInfo.(float64)
-			expTime = int(val)
-		}
-		c.Set("exp", expTime)
-		userObject, err := GetUserDataFromToken(c)
-		if err != nil {
-			c.JSON(401, gin.H{"message": "something went wrong", "status": http.StatusUnauthorized})
+		access, authErr := m.Authenticate(c.Request.Context(), token)
+		if authErr != nil {
+			c.JSON(authErr.Status, gin.H{"message": authErr.Message, "status": authErr.Status})
 			c.Abort()
 			return
 		}
-		cacheKey := fmt.Sprintf("%s_%d", userObject.Id.String(), expTime)
+		c.Set("userData", access.Claims["userData"])
+		if act, ok := access.Claims["act"]; ok {
+			c.Set("actor", act)
+		}
+		c.Set("exp", access.Expiry)
+		c.Set("tokenID", access.TokenID)
 
-		value, err := cache.GetToken(context.TODO(), cacheKey)
-		if err != nil {
-			c.JSON(500, gin.H{"message": "something went wrong", "status": http.StatusInternalServerError})
-			c.Abort()
+		// tokens bound to a DPoP key are only accepted with a proof of that key
+		if !m.checkBinding(c, scheme, token, access.Claims) {
 			return
 		}
 
-		if token[1] == value {
+		m.trackImpersonation(c, access.User)
 
-			c.JSON(401, gin.H{"message": "invalid authorization token", "status": http.StatusUnauthorized})
-			c.Abort()
-			return
+		c.Next()
+	}
+}
+
+// AuthError is an access token refused by Authenticate, Status is the HTTP status to answer with
+type AuthError struct {
+	Status  int
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
+
+// AccessToken is an access token accepted by Authenticate
+type AccessToken struct {
+	Claims jwt.MapClaims
+	User   UserTokenData
+	// TokenID is the jti, or the whole token for tokens issued before jti existed
+	TokenID string
+	Expiry  int
+	// Jkt is the thumbprint of the DPoP key the token is bound to, empty for bearer tokens
+	Jkt string
+}
+
+// Authenticate runs the checks every transport makes on an access token: signature and expiry, sign-out,
+// sessions revoked by the user and the status of the user. The binding to a DPoP key is left to the caller,
+// only it knows the request a proof was made for.
+func (m *Middleware) Authenticate(ctx context.Context, token string) (*AccessToken, *AuthError) {
+	// Validate token
+	valid, err := m.Keys.Parse(token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, &AuthError{http.StatusUnauthorized, "The authorization token is expired"}
 		}
+		return nil, &AuthError{http.StatusUnauthorized, "invalid authorization token"}
+	}
+	claims := valid.Claims.(jwt.MapClaims)
 
-		c.Next()
+	var expTime int
+	expInfo := claims["exp"]
+	if expInfo != nil {
+		val := expInfo.(float64)
+		expTime = int(val)
+	}
+	userObject, err := tokenUserData(claims["userData"], claims["act"])
+	if err != nil {
+		return nil, &AuthError{http.StatusUnauthorized, "something went wrong"}
+	}
+	access := &AccessToken{Claims: claims, User: userObject, Expiry: expTime}
+	if cnf, ok := claims["cnf"].(map[string]interface{}); ok {
+		access.Jkt, _ = cnf["jkt"].(string)
+	}
+
+	// signed out tokens are found by jti, tokens issued before jti existed by the whole token
+	access.TokenID, _ = claims["jti"].(string)
+	if access.TokenID == "" {
+		access.TokenID = token
+		if m.legacyRevoked(ctx, userObject.Id, expTime, token) {
+			return nil, &AuthError{http.StatusUnauthorized, "invalid authorization token"}
+		}
+	}
+
+	revoked, err := m.Denylist.IsRevoked(ctx, access.TokenID)
+	if err != nil {
+		return nil, &AuthError{http.StatusServiceUnavailable, "the authorization token can not be checked right now"}
 	}
+	if revoked {
+		return nil, &AuthError{http.StatusUnauthorized, "invalid authorization token"}
+	}
+
+	// tokens issued before the user revoked all sessions are no longer accepted
+	var issuedAt int64
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = int64(iat)
+	}
+	revokedAt, err := m.Denylist.SessionsRevokedAt(ctx, userObject.Id.String())
+	if err != nil {
+		return nil, &AuthError{http.StatusServiceUnavailable, "the authorization token can not be checked right now"}
+	}
+	if !revokedAt.IsZero() && issuedAt <= revokedAt.Unix() {
+		return nil, &AuthError{http.StatusUnauthorized, "The authorization token has been revoked"}
+	}
+
+	// blocked users lose access at once, not when their token expires
+	status, err := m.Status.UserStatus(ctx, userObject.Id)
+	if err != nil {
+		return nil, &AuthError{http.StatusInternalServerError, "something went wrong"}
+	}
+	if status != model.UserStatusActive {
+		return nil, &AuthError{http.StatusForbidden, "your account is not active"}
+	}
+	return access, nil
+}
+
+// legacyRevoked checks the denylist entry written by sign-out before tokens carried a jti. It can go
+// once MaxAccessTokenTTL has passed since the upgrade, no token without jti is valid anymore by then.
+func (m *Middleware) legacyRevoked(ctx context.Context, userID uuid.UUID, expTime int, token string) bool {
+	value, err := cache.GetToken(ctx, fmt.Sprintf("%s_%d", userID.String(), expTime))
+	return err == nil && value == token
+}
+
+// GetTokenID returns the id AuthHandler put on the denylist lookup, the jti of the access token
+func GetTokenID(c *gin.Context) string {
+	tokenID, _ := c.Get("tokenID")
+	id, _ := tokenID.(string)
+	return id
 }
 
 func GenerateToken(userData interface{}) (string, error) {
-	// Create the token
-	token := jwt.New(jwt.SigningMethodHS256)
+	return GenerateTokenWithTTL(userData, AccessTokenTTL)
+}
+
+// GenerateTokenWithTTL creates an access token valid for ttl, capped at MaxAccessTokenTTL
+func GenerateTokenWithTTL(userData interface{}, ttl time.Duration) (string, error) {
+	return GenerateBoundToken(userData, ttl, "")
+}
+
+// GenerateBoundToken creates an access token bound to the DPoP key with the thumbprint jkt,
+// an empty jkt gives a bearer token
+func GenerateBoundToken(userData interface{}, ttl time.Duration, jkt string) (string, error) {
+	if ttl <= 0 || ttl > MaxAccessTokenTTL {
+		ttl = MaxAccessTokenTTL
+	}
 	// Set some claims
 	claims := make(jwt.MapClaims)
 	claims["userData"] = userData
-	claims["exp"] = time.Now().Add(time.Minute * 15).Unix()
-	token.Claims = claims
+	claims["jti"] = uuid.NewV4().String()
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	if jkt != "" {
+		claims["cnf"] = map[string]string{"jkt": jkt}
+	}
 	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString([]byte(AccessTokenKey))
-	return tokenString, err
+	return AccessTokenKeys.Sign(claims)
 }
 
 func GenerateRefreshToken(id uuid.UUID) (string, error) {
+	return GenerateRefreshTokenWithTTL(id, RefreshTokenTTL)
+}
+
+// GenerateRefreshTokenWithTTL creates a refresh token valid for ttl
+func GenerateRefreshTokenWithTTL(id uuid.UUID, ttl time.Duration) (string, error) {
+	return GenerateBoundRefreshToken(id, ttl, "")
+}
+
+// GenerateBoundRefreshToken creates a refresh token valid for ttl and bound to the DPoP key with the thumbprint jkt
+func GenerateBoundRefreshToken(id uuid.UUID, ttl time.Duration, jkt string) (string, error) {
 	// Create the refresh token
 	token := jwt.New(jwt.SigningMethodHS256)
 	// Set the claims for the refresh token with just the id
 	claims := make(jwt.MapClaims)
 	claims["id"] = id.String() // Only include id in the claims
-	// Set an expiration for the refresh token (e.g., 7 day)
-	// claims["exp"] = time.Now().Add(time.Hour * 24 * 7).Unix()
-	claims["exp"] = time.Now().Add(time.Hour * 24 * 7).Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	if jkt != "" {
+		claims["cnf"] = map[string]string{"jkt": jkt}
+	}
 
 	token.Claims = claims
 
@@ -172,19 +334,81 @@ func ValidateToken(t string, k string) (*jwt.Token, error) {
 
 // GetUserDataFromToken extracts the user's UUID from the token's payload
 func GetUserDataFromToken(c *gin.Context) (UserTokenData, error) {
-	var userData UserTokenData
 	userInfo, userExists := c.Get("userData")
 	if !userExists {
 		return UserTokenData{}, nil
 	}
-	data := userInfo.(map[string]interface{})
+	act, _ := c.Get("actor")
+	return tokenUserData(userInfo, act)
+}
+
+// tokenUserData reads the userData and act claims of an access token
+func tokenUserData(userInfo interface{}, act interface{}) (UserTokenData, error) {
+	var userData UserTokenData
+	data, ok := userInfo.(map[string]interface{})
+	if !ok {
+		return UserTokenData{}, fmt.Errorf("userData not found in the token")
+	}
 
-	userId, err := uuid.FromString(data["id"].(string))
+	idStr, _ := data["id"].(string)
+	userId, err := uuid.FromString(idStr)
 	if err != nil {
 		return UserTokenData{}, err
 	}
-	Email := data["email"].(string)
+	Email, _ := data["email"].(string)
 	userData.Id = userId
 	userData.Email = Email
+	// tokens issued before roles existed carry no role
+	userData.Role, _ = data["role"].(string)
+	if orgID, ok := data["org_id"].(string); ok {
+		if id, err := uuid.FromString(orgID); err == nil {
+			userData.OrgId = &id
+		}
+	}
+	userData.OrgRole, _ = data["org_role"].(string)
+	userData.AuthMethod, _ = data["auth_method"].(string)
+	if act != nil {
+		userData.Actor = actorFromClaim(act)
+	}
 	return userData, nil
 }
+
+// GenerateActionToken creates a signed single purpose token, such as the links mailed to users.
+// It returns the token and its jti.
+func GenerateActionToken(purpose string, id uuid.UUID, ttl time.Duration) (string, string, error) {
+	jti := uuid.NewV4().String()
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := make(jwt.MapClaims)
+	claims["purpose"] = purpose
+	claims["id"] = id.String()
+	claims["jti"] = jti
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	token.Claims = claims
+
+	signed, err := token.SignedString([]byte(RefreshTokenKey))
+	return signed, jti, err
+}
+
+// ValidateActionToken checks a token from GenerateActionToken and returns its subject, id and expiry
+func ValidateActionToken(t, purpose string) (uuid.UUID, string, time.Time, error) {
+	token, err := ValidateToken(t, RefreshTokenKey)
+	if err != nil {
+		return uuid.Nil, "", time.Time{}, err
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if p, _ := claims["purpose"].(string); p != purpose {
+		return uuid.Nil, "", time.Time{}, fmt.Errorf("token is not meant for %s", purpose)
+	}
+	idStr, _ := claims["id"].(string)
+	id, err := uuid.FromString(idStr)
+	if err != nil {
+		return uuid.Nil, "", time.Time{}, fmt.Errorf("invalid id format: %v", err)
+	}
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return uuid.Nil, "", time.Time{}, fmt.Errorf("token has no expiry")
+	}
+	return id, jti, exp.Time, nil
+}
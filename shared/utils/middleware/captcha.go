@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"test-task/shared/cache"
+	"test-task/shared/config"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaHeader carries the token of the solved challenge
+const CaptchaHeader = "X-Captcha-Token"
+
+const captchaFailuresPrefix = "captcha_failures_"
+
+// CaptchaHandler asks for a solved challenge before the handler runs, depending on Captcha.Mode:
+// always, only for IPs in Captcha.IPRanges, or once an IP had Captcha.FailureThreshold failed requests
+// within Captcha.FailureWindow minutes. It does nothing while Captcha.Provider is empty.
+func (m *Middleware) CaptchaHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.Captcha == nil {
+			c.Next()
+			return
+		}
+		ip := c.ClientIP()
+
+		if refused := m.checkCaptcha(c.Request.Context(), ip, c.GetHeader(CaptchaHeader)); refused != nil {
+			body := gin.H{"message": refused.message, "status": refused.status}
+			if refused.status == http.StatusForbidden {
+				body["captcha_required"] = true
+			}
+			c.JSON(refused.status, body)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		// wrong passwords, unknown emails and invalid requests all count towards the threshold
+		if c.Writer.Status() >= 400 && c.Writer.Status() < 500 {
+			m.countCaptchaFailure(ip)
+		}
+	}
+}
+
+// captchaRefusal is why a request was refused for its captcha
+type captchaRefusal struct {
+	status  int
+	message string
+}
+
+// checkCaptcha verifies the token when the request needs a challenge, nil lets the request through
+func (m *Middleware) checkCaptcha(ctx context.Context, ip, token string) *captchaRefusal {
+	if !m.captchaRequired(ctx, m.Config.Captcha(), ip) {
+		return nil
+	}
+	if token == "" {
+		return &captchaRefusal{http.StatusForbidden, "a captcha is required"}
+	}
+	ok, err := m.Captcha.Verify(ctx, token, ip)
+	if err != nil {
+		return &captchaRefusal{http.StatusServiceUnavailable, "the captcha can not be checked right now"}
+	}
+	if !ok {
+		return &captchaRefusal{http.StatusForbidden, "captcha verification failed"}
+	}
+	return nil
+}
+
+// countCaptchaFailure counts a failed request of the IP while Captcha.Mode is after_failures
+func (m *Middleware) countCaptchaFailure(ip string) {
+	cf := m.Config.Captcha()
+	if cf.Mode != config.CaptchaAfterFailures {
+		return
+	}
+	window := time.Duration(cf.FailureWindow) * time.Minute
+	_, _ = cache.IncrementValue(context.Background(), captchaFailuresPrefix+ip, window)
+}
+
+func (m *Middleware) captchaRequired(ctx context.Context, cf *config.Captcha, ip string) bool {
+	switch cf.Mode {
+	case config.CaptchaIPRanges:
+		addr := net.ParseIP(ip)
+		for _, network := range cf.Networks {
+			if addr != nil && network.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	case config.CaptchaAfterFailures:
+		value, err := cache.GetValue(ctx, captchaFailuresPrefix+ip)
+		if err != nil {
+			// asking for a challenge is safer than letting a bot through while failures can not be counted
+			return true
+		}
+		failures, _ := strconv.Atoi(value)
+		return failures >= cf.FailureThreshold
+	}
+	return true
+}
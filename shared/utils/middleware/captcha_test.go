@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"test-task/shared/cache"
+	"test-task/shared/captcha"
+	"test-task/shared/config"
+	"test-task/shared/log"
+)
+
+var testRedis *miniredis.Miniredis
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logs, err := os.MkdirTemp("", "middleware-logs")
+	if err != nil {
+		panic(err)
+	}
+	log.Init("test", "", logs, logrus.WarnLevel, time.Hour)
+
+	testRedis, err = miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	if _, err = cache.CreateConnection(&testConfig{redis: config.Redis{Host: testRedis.Addr()}}); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testRedis.Close()
+	os.RemoveAll(logs)
+	os.Exit(code)
+}
+
+// testConfig answers the config sections the tests set, the other sections are not used by them
+type testConfig struct {
+	config.IConfig
+	redis   config.Redis
+	captcha config.Captcha
+	dpop    config.DPoP
+
+	introspection config.Introspection
+}
+
+func (c *testConfig) Redis() *config.Redis                 { return &c.redis }
+func (c *testConfig) Captcha() *config.Captcha             { return &c.captcha }
+func (c *testConfig) DPoP() *config.DPoP                   { return &c.dpop }
+func (c *testConfig) Introspection() *config.Introspection { return &c.introspection }
+
+// newCaptchaRouter guards a sign in stand-in that fails unless the password is right
+func newCaptchaRouter(t *testing.T, cf config.Captcha) *gin.Engine {
+	t.Helper()
+	testRedis.FlushAll()
+
+	cf.Provider = "stub"
+	cf.StubToken = "pass"
+	conf := &testConfig{captcha: cf}
+	m := &Middleware{Config: conf, Captcha: captcha.NewVerifier(conf)}
+
+	router := gin.New()
+	router.POST("/signin", m.CaptchaHandler(), func(c *gin.Context) {
+		if c.Query("password") != "right" {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func signIn(router *gin.Engine, ip, password, token string) int {
+	req := httptest.NewRequest(http.MethodPost, "/signin?password="+password, nil)
+	req.RemoteAddr = ip + ":40000"
+	if token != "" {
+		req.Header.Set(CaptchaHeader, token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestCaptchaAlways(t *testing.T) {
+	router := newCaptchaRouter(t, config.Captcha{Mode: config.CaptchaAlways})
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"no token", "", http.StatusForbidden},
+		{"wrong token", "fail", http.StatusForbidden},
+		{"stub token", "pass", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signIn(router, "203.0.113.10", "right", tt.token); got != tt.want {
+				t.Fatalf("status %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptchaAfterFailures(t *testing.T) {
+	router := newCaptchaRouter(t, config.Captcha{Mode: config.CaptchaAfterFailures, FailureThreshold: 3, FailureWindow: 15})
+	ip := "203.0.113.20"
+
+	// failures below the threshold are answered by the handler
+	for i := 0; i < 3; i++ {
+		if got := signIn(router, ip, "wrong", ""); got != http.StatusUnauthorized {
+			t.Fatalf("failure %d: status %d, want %d", i+1, got, http.StatusUnauthorized)
+		}
+	}
+	if got := signIn(router, ip, "right", ""); got != http.StatusForbidden {
+		t.Fatalf("after the threshold without a token: status %d, want %d", got, http.StatusForbidden)
+	}
+	if got := signIn(router, ip, "right", "pass"); got != http.StatusOK {
+		t.Fatalf("after the threshold with the stub token: status %d, want %d", got, http.StatusOK)
+	}
+
+	// failures are counted per IP
+	if got := signIn(router, "203.0.113.21", "right", ""); got != http.StatusOK {
+		t.Fatalf("other IP: status %d, want %d", got, http.StatusOK)
+	}
+
+	// and forgotten after Captcha.FailureWindow
+	testRedis.FastForward(15 * time.Minute)
+	if got := signIn(router, ip, "right", ""); got != http.StatusOK {
+		t.Fatalf("after the window: status %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestCaptchaAfterFailuresRedisDown(t *testing.T) {
+	router := newCaptchaRouter(t, config.Captcha{Mode: config.CaptchaAfterFailures, FailureThreshold: 3, FailureWindow: 15})
+
+	testRedis.SetError("server is down")
+	defer testRedis.SetError("")
+	if got := signIn(router, "203.0.113.30", "right", ""); got != http.StatusForbidden {
+		t.Fatalf("status %d while failures can not be counted, want %d", got, http.StatusForbidden)
+	}
+}
+
+func TestCaptchaIPRanges(t *testing.T) {
+	_, network, _ := net.ParseCIDR("198.51.100.0/24")
+	router := newCaptchaRouter(t, config.Captcha{Mode: config.CaptchaIPRanges, IPRanges: []string{network.String()}, Networks: []*net.IPNet{network}})
+
+	tests := []struct {
+		name  string
+		ip    string
+		token string
+		want  int
+	}{
+		{"in range without token", "198.51.100.7", "", http.StatusForbidden},
+		{"in range with stub token", "198.51.100.7", "pass", http.StatusOK},
+		{"out of range", "203.0.113.40", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signIn(router, tt.ip, "right", tt.token); got != tt.want {
+				t.Fatalf("status %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptchaOff(t *testing.T) {
+	conf := &testConfig{captcha: config.Captcha{Mode: config.CaptchaAlways}}
+	m := &Middleware{Config: conf, Captcha: captcha.NewVerifier(conf)}
+	router := gin.New()
+	router.POST("/signin", m.CaptchaHandler(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	if got := signIn(router, "203.0.113.50", "right", ""); got != http.StatusOK {
+		t.Fatalf("status %d with an empty Captcha.Provider, want %d", got, http.StatusOK)
+	}
+}
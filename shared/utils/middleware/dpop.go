@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"test-task/shared/dpop"
+	"test-task/shared/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DPoPHandler checks the DPoP proof sent to an endpoint issuing tokens, the tokens are then bound to
+// the key of the proof. Without a proof bearer tokens are issued, if DPoP.AllowBearer permits it.
+func (m *Middleware) DPoPHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proofs := c.Request.Header.Values(dpop.Header)
+		if len(proofs) == 0 {
+			if !m.Config.DPoP().AllowBearer {
+				c.JSON(400, gin.H{"message": "a DPoP proof is required", "error": "invalid_dpop_proof", "status": http.StatusBadRequest})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(proofs) > 1 {
+			c.JSON(400, gin.H{"message": "only one DPoP proof can be sent", "error": "invalid_dpop_proof", "status": http.StatusBadRequest})
+			c.Abort()
+			return
+		}
+
+		jkt, err := m.DPoP.Verify(c.Request.Context(), proofs[0], dpop.Request{Method: c.Request.Method, URL: m.requestURL(c)})
+		if err != nil {
+			m.dpopError(c, http.StatusBadRequest, err)
+			return
+		}
+		c.Set("dpopJkt", jkt)
+		c.Next()
+	}
+}
+
+// checkBinding makes sure a token bound to a DPoP key comes with a proof of that key, and that bearer
+// tokens are still allowed. It writes the response and returns false when the request is refused.
+func (m *Middleware) checkBinding(c *gin.Context, scheme, token string, claims jwt.MapClaims) bool {
+	cnf, _ := claims["cnf"].(map[string]interface{})
+	jkt, _ := cnf["jkt"].(string)
+
+	if jkt == "" {
+		if scheme == dpop.Header {
+			c.JSON(401, gin.H{"message": "the authorization token is not bound to a DPoP key", "status": http.StatusUnauthorized})
+			c.Abort()
+			return false
+		}
+		if !m.Config.DPoP().AllowBearer {
+			c.Header("WWW-Authenticate", `DPoP error="invalid_token"`)
+			c.JSON(401, gin.H{"message": "a DPoP bound token is required", "status": http.StatusUnauthorized})
+			c.Abort()
+			return false
+		}
+		return true
+	}
+
+	// a bound token sent as a bearer token is a token used by someone not holding the key
+	proofs := c.Request.Header.Values(dpop.Header)
+	if scheme != dpop.Header || len(proofs) != 1 {
+		c.Header("WWW-Authenticate", `DPoP error="invalid_token"`)
+		c.JSON(401, gin.H{"message": "the authorization token must be sent with a DPoP proof", "status": http.StatusUnauthorized})
+		c.Abort()
+		return false
+	}
+
+	proofKey, err := m.DPoP.Verify(c.Request.Context(), proofs[0], dpop.Request{Method: c.Request.Method, URL: m.requestURL(c), AccessToken: token})
+	if err != nil {
+		m.dpopError(c, http.StatusUnauthorized, err)
+		return false
+	}
+	if proofKey != jkt {
+		c.Header("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+		c.JSON(401, gin.H{"message": "the DPoP proof was not made with the key of the token", "status": http.StatusUnauthorized})
+		c.Abort()
+		return false
+	}
+	c.Set("dpopJkt", jkt)
+	return true
+}
+
+// dpopError answers a failed proof check, code is 400 on the token endpoints and 401 on protected ones
+func (m *Middleware) dpopError(c *gin.Context, code int, err error) {
+	switch {
+	case errors.Is(err, dpop.ErrUseNonce):
+		c.Header(dpop.NonceHeader, m.DPoP.Nonce())
+		if code == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `DPoP error="use_dpop_nonce"`)
+		}
+		c.JSON(code, gin.H{"message": err.Error(), "error": "use_dpop_nonce", "status": code})
+	case errors.Is(err, dpop.ErrInvalidProof):
+		if code == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+		}
+		c.JSON(code, gin.H{"message": err.Error(), "error": "invalid_dpop_proof", "status": code})
+	default:
+		log.GetLog().Error("ERROR : ", "DPoP proof not checked: %s", err.Error())
+		c.JSON(503, gin.H{"message": "the DPoP proof can not be checked right now", "status": http.StatusServiceUnavailable})
+	}
+	c.Abort()
+}
+
+// requestURL is the URL the client called, proxies in front of the service do not change App.PublicURL
+func (m *Middleware) requestURL(c *gin.Context) string {
+	return strings.TrimSuffix(m.Config.App().PublicURL, "/") + c.Request.URL.Path
+}
+
+// authorizationToken splits the Authorization header into its scheme, Bearer or DPoP, and the token
+func authorizationToken(header string) (string, string, bool) {
+	for _, scheme := range []string{"Bearer", dpop.Header} {
+		if strings.HasPrefix(header, scheme+" ") {
+			return scheme, strings.TrimPrefix(header, scheme+" "), true
+		}
+	}
+	return "", "", false
+}
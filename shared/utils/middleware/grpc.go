@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	u "test-task/shared/common"
+	"test-task/shared/dpop"
+	"test-task/shared/log"
+
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC metadata keys, always lower case
+const (
+	rpcAuthorization = "authorization"
+	rpcCaptchaToken  = "x-captcha-token"
+	rpcRequestID     = "x-request-id"
+	rpcUserAgent     = "user-agent"
+)
+
+type rpcContextKey int
+
+const (
+	rpcInfoKey rpcContextKey = iota
+	rpcTokenKey
+)
+
+// RequestInterceptor is the gRPC counterpart of RequestIDHandler. It tags every call with an id, reusing the
+// x-request-id metadata when present, keeps the caller details for GetRPCRequestInfo and logs the call.
+// A panic of the handler is answered with Internal instead of taking the server down.
+func (m *Middleware) RequestInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, call *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		requestID := firstValue(md, rpcRequestID)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = uuid.NewV4().String()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(rpcRequestID, requestID))
+
+		info := u.RequestInfo{UserAgent: firstValue(md, rpcUserAgent), RequestID: requestID}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			info.IPAddress = p.Addr.String()
+			if host, _, err := net.SplitHostPort(info.IPAddress); err == nil {
+				info.IPAddress = host
+			}
+		}
+		ctx = context.WithValue(ctx, rpcInfoKey, info)
+
+		started := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				log.GetLog().Error("ERROR : ", "gRPC %s panicked: %v", call.FullMethod, r)
+				err = status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+			}
+			log.GetLog().Info(log.Data{IPAddress: info.IPAddress, Session: requestID}, "gRPC %s %s in %s",
+				call.FullMethod, status.Code(err), time.Since(started))
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// AuthInterceptor is the gRPC counterpart of AuthHandler for the given full method names, the other methods
+// are left alone. The access token is read from the "authorization" metadata as "Bearer <token>".
+// DPoP bound tokens are refused, a proof is made for an HTTP request and a gRPC call has none.
+func (m *Middleware) AuthInterceptor(methods ...string) grpc.UnaryServerInterceptor {
+	protected := map[string]bool{}
+	for _, method := range methods {
+		protected[method] = true
+	}
+	return func(ctx context.Context, req interface{}, call *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !protected[call.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		scheme, token, ok := authorizationToken(firstValue(md, rpcAuthorization))
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "Your request is not authorized")
+		}
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "An authorization token was not supplied")
+		}
+
+		access, authErr := m.Authenticate(ctx, token)
+		if authErr != nil {
+			return nil, status.Error(RPCCode(authErr.Status), authErr.Message)
+		}
+		if scheme == dpop.Header || access.Jkt != "" {
+			return nil, status.Error(codes.Unauthenticated, "DPoP bound tokens can not be used over gRPC")
+		}
+		if !m.Config.DPoP().AllowBearer {
+			return nil, status.Error(codes.Unauthenticated, "a DPoP bound token is required")
+		}
+
+		ctx = context.WithValue(ctx, rpcTokenKey, access)
+		m.recordImpersonation(access.User, GetRPCRequestInfo(ctx), "GRPC", call.FullMethod)
+		return handler(ctx, req)
+	}
+}
+
+// CaptchaInterceptor is the gRPC counterpart of CaptchaHandler for the given full method names. The token of
+// the solved challenge is read from the "x-captcha-token" metadata, failed calls count towards
+// Captcha.FailureThreshold like failed requests do.
+func (m *Middleware) CaptchaInterceptor(methods ...string) grpc.UnaryServerInterceptor {
+	covered := map[string]bool{}
+	for _, method := range methods {
+		covered[method] = true
+	}
+	return func(ctx context.Context, req interface{}, call *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if m.Captcha == nil || !covered[call.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		ip := GetRPCRequestInfo(ctx).IPAddress
+		if refused := m.checkCaptcha(ctx, ip, firstValue(md, rpcCaptchaToken)); refused != nil {
+			return nil, status.Error(RPCCode(refused.status), refused.message)
+		}
+
+		resp, err := handler(ctx, req)
+		if isRPCClientError(status.Code(err)) {
+			m.countCaptchaFailure(ip)
+		}
+		return resp, err
+	}
+}
+
+// DPoPInterceptor is the gRPC counterpart of DPoPHandler for the given full method names. A call can not carry
+// a proof, so tokens are issued over gRPC only while DPoP.AllowBearer is on.
+func (m *Middleware) DPoPInterceptor(methods ...string) grpc.UnaryServerInterceptor {
+	covered := map[string]bool{}
+	for _, method := range methods {
+		covered[method] = true
+	}
+	return func(ctx context.Context, req interface{}, call *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if covered[call.FullMethod] && !m.Config.DPoP().AllowBearer {
+			return nil, status.Error(codes.FailedPrecondition, "a DPoP proof is required, tokens can not be issued over gRPC")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// GetRPCRequestInfo collects the caller details of a gRPC call for audit records, it needs RequestInterceptor
+func GetRPCRequestInfo(ctx context.Context) u.RequestInfo {
+	info, _ := ctx.Value(rpcInfoKey).(u.RequestInfo)
+	if access := GetRPCAccessToken(ctx); access != nil && access.User.Actor != nil {
+		info.ImpersonatorID = access.User.Actor.Id.String()
+	}
+	return info
+}
+
+// GetRPCAccessToken returns the access token AuthInterceptor accepted, nil for calls it does not cover
+func GetRPCAccessToken(ctx context.Context) *AccessToken {
+	access, _ := ctx.Value(rpcTokenKey).(*AccessToken)
+	return access
+}
+
+// RPCCode maps the HTTP status of a response to the closest gRPC code
+func RPCCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// isRPCClientError reports whether the code is one RPCCode gives for a 4xx status
+func isRPCClientError(code codes.Code) bool {
+	switch code {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.NotFound,
+		codes.AlreadyExists, codes.FailedPrecondition, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
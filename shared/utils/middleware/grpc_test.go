@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"test-task/shared/captcha"
+	u "test-task/shared/common"
+	"test-task/shared/config"
+)
+
+const testRPCMethod = "/auth.v1.AuthService/SignIn"
+
+// callSignIn runs the interceptor around a sign in stand-in that fails unless the password is right
+func callSignIn(interceptor grpc.UnaryServerInterceptor, method, ip, password, token string) codes.Code {
+	ctx := context.WithValue(context.Background(), rpcInfoKey, u.RequestInfo{IPAddress: ip})
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(rpcCaptchaToken, token))
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if password != "right" {
+			return nil, status.Error(codes.InvalidArgument, "wrong password")
+		}
+		return "signed in", nil
+	}
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	return status.Code(err)
+}
+
+func TestCaptchaInterceptorAfterFailures(t *testing.T) {
+	testRedis.FlushAll()
+	conf := &testConfig{captcha: config.Captcha{
+		Mode:             config.CaptchaAfterFailures,
+		FailureThreshold: 2,
+		FailureWindow:    15,
+		Provider:         "stub",
+		StubToken:        "pass",
+	}}
+	m := &Middleware{Config: conf, Captcha: captcha.NewVerifier(conf)}
+	interceptor := m.CaptchaInterceptor(testRPCMethod)
+	ip := "203.0.113.70"
+
+	for i := 0; i < 2; i++ {
+		if got := callSignIn(interceptor, testRPCMethod, ip, "wrong", ""); got != codes.InvalidArgument {
+			t.Fatalf("failure %d: code %s, want %s", i+1, got, codes.InvalidArgument)
+		}
+	}
+	if got := callSignIn(interceptor, testRPCMethod, ip, "right", ""); got != codes.PermissionDenied {
+		t.Fatalf("after the threshold without a token: code %s, want %s", got, codes.PermissionDenied)
+	}
+	if got := callSignIn(interceptor, testRPCMethod, ip, "right", "fail"); got != codes.PermissionDenied {
+		t.Fatalf("after the threshold with a wrong token: code %s, want %s", got, codes.PermissionDenied)
+	}
+	if got := callSignIn(interceptor, testRPCMethod, ip, "right", "pass"); got != codes.OK {
+		t.Fatalf("after the threshold with the token: code %s, want %s", got, codes.OK)
+	}
+
+	// other IPs and methods the interceptor does not cover are left alone
+	if got := callSignIn(interceptor, testRPCMethod, "203.0.113.71", "right", ""); got != codes.OK {
+		t.Fatalf("other IP: code %s, want %s", got, codes.OK)
+	}
+	if got := callSignIn(interceptor, "/auth.v1.AuthService/GetProfile", ip, "right", ""); got != codes.OK {
+		t.Fatalf("uncovered method: code %s, want %s", got, codes.OK)
+	}
+}
+
+func TestDPoPInterceptor(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowBearer bool
+		method      string
+		want        codes.Code
+	}{
+		{"bearer allowed", true, testRPCMethod, codes.OK},
+		{"bearer refused", false, testRPCMethod, codes.FailedPrecondition},
+		{"uncovered method", false, "/auth.v1.AuthService/GetProfile", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{Config: &testConfig{dpop: config.DPoP{AllowBearer: tt.allowBearer}}}
+			if got := callSignIn(m.DPoPInterceptor(testRPCMethod), tt.method, "203.0.113.80", "right", ""); got != tt.want {
+				t.Fatalf("code %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	u "test-task/shared/common"
+	"test-task/shared/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	uuid "github.com/satori/go.uuid"
+)
+
+// TokenActor is the admin acting on behalf of the user, carried in the "act" claim (RFC 8693)
+type TokenActor struct {
+	Id    uuid.UUID `json:"sub"`
+	Email string    `json:"email"`
+}
+
+// IImpersonationRecorder receives every request made with an impersonation token
+type IImpersonationRecorder interface {
+	RecordImpersonatedRequest(userData UserTokenData, info u.RequestInfo, method, path string)
+}
+
+// GenerateImpersonationToken creates a short lived access token for the user carrying the admin as actor,
+// bound to the DPoP key of the admin when jkt is set. No refresh token exists for it, so it can not be renewed.
+func GenerateImpersonationToken(userData UserTokenData, actor TokenActor, ttl time.Duration, jkt string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+
+	claims := make(jwt.MapClaims)
+	claims["userData"] = userData
+	claims["act"] = actor
+	claims["jti"] = uuid.NewV4().String()
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = expiresAt.Unix()
+	if jkt != "" {
+		claims["cnf"] = map[string]string{"jkt": jkt}
+	}
+
+	tokenString, err := AccessTokenKeys.Sign(claims)
+	return tokenString, expiresAt, err
+}
+
+// ImpersonationGuard rejects requests made with an impersonation token, whatever the route policies say.
+// It must run after AuthHandler.
+func (m *Middleware) ImpersonationGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("actor"); impersonated {
+			c.JSON(403, gin.H{"message": "this action is not allowed while impersonating a user", "status": http.StatusForbidden})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// trackImpersonation tags the request in the logs and the audit trail when it is made by an admin on behalf of the user
+func (m *Middleware) trackImpersonation(c *gin.Context, userData UserTokenData) {
+	m.recordImpersonation(userData, GetRequestInfo(c), c.Request.Method, c.Request.URL.Path)
+}
+
+func (m *Middleware) recordImpersonation(userData UserTokenData, info u.RequestInfo, method, path string) {
+	if userData.Actor == nil {
+		return
+	}
+
+	log.GetLog().Info(log.Data{
+		IPAddress: info.IPAddress,
+		Session:   info.RequestID,
+		ActorID:   userData.Actor.Id.String(),
+		ActorType: "BOF",
+	}, "IMPERSONATION : %s %s as user %s", method, path, userData.Id.String())
+
+	if m.Recorder != nil {
+		m.Recorder.RecordImpersonatedRequest(userData, info, method, path)
+	}
+}
+
+func actorFromClaim(claim interface{}) *TokenActor {
+	data, ok := claim.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	sub, _ := data["sub"].(string)
+	id, err := uuid.FromString(sub)
+	if err != nil {
+		return nil
+	}
+	email, _ := data["email"].(string)
+	return &TokenActor{Id: id, Email: email}
+}
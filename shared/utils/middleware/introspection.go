@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const introspectionRefused = "the client credentials of the introspection are missing or wrong"
+
+// IntrospectionClientHandler lets only the services of Introspection.Clients ask about tokens, they send their
+// id and secret as HTTP Basic credentials. The answers tell whoever holds a stolen token whether it is still
+// worth using, and who it belongs to.
+func (m *Middleware) IntrospectionClientHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.introspectionClient(c.Request.Header.Get("Authorization")) {
+			c.Header("WWW-Authenticate", `Basic realm="introspection"`)
+			c.JSON(401, gin.H{"message": introspectionRefused, "status": http.StatusUnauthorized})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// IntrospectionClientInterceptor is the gRPC counterpart of IntrospectionClientHandler for the given full
+// method names. The credentials are read from the "authorization" metadata as "Basic <base64 id:secret>".
+func (m *Middleware) IntrospectionClientInterceptor(methods ...string) grpc.UnaryServerInterceptor {
+	covered := map[string]bool{}
+	for _, method := range methods {
+		covered[method] = true
+	}
+	return func(ctx context.Context, req interface{}, call *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !covered[call.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		if !m.introspectionClient(firstValue(md, rpcAuthorization)) {
+			return nil, status.Error(codes.Unauthenticated, introspectionRefused)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// introspectionClient checks the Basic credentials of an Authorization header against Introspection.Clients
+func (m *Middleware) introspectionClient(header string) bool {
+	id, secret, ok := basicCredentials(header)
+	if !ok {
+		return false
+	}
+	expected, found := m.Config.Introspection().Clients[strings.ToLower(id)]
+	// the digests have the same length, so the comparison takes the same time whatever the secret
+	got, want := sha256.Sum256([]byte(secret)), sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(got[:], want[:]) == 1 && found
+}
+
+// basicCredentials splits an Authorization header of the Basic scheme into the id and the secret
+func basicCredentials(header string) (string, string, bool) {
+	scheme, encoded, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
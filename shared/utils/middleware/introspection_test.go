@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"test-task/shared/config"
+)
+
+const testClientSecret = "0123456789abcdef0123456789abcdef"
+
+func basicHeader(id, secret string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(id+":"+secret))
+}
+
+var introspectionClientTests = []struct {
+	name          string
+	clients       map[string]string
+	authorization string
+	allowed       bool
+}{
+	{"listed client", map[string]string{"orders": testClientSecret}, basicHeader("orders", testClientSecret), true},
+	{"id in another case", map[string]string{"orders": testClientSecret}, basicHeader("Orders", testClientSecret), true},
+	{"lower case scheme", map[string]string{"orders": testClientSecret}, "basic " + base64.StdEncoding.EncodeToString([]byte("orders:"+testClientSecret)), true},
+	{"wrong secret", map[string]string{"orders": testClientSecret}, basicHeader("orders", testClientSecret+"x"), false},
+	{"secret of another client", map[string]string{"orders": testClientSecret, "billing": "another secret"}, basicHeader("billing", testClientSecret), false},
+	{"unknown client", map[string]string{"orders": testClientSecret}, basicHeader("billing", testClientSecret), false},
+	{"unknown client without secret", map[string]string{"orders": testClientSecret}, basicHeader("billing", ""), false},
+	{"no clients configured", nil, basicHeader("orders", testClientSecret), false},
+	{"no credentials", map[string]string{"orders": testClientSecret}, "", false},
+	{"bearer token", map[string]string{"orders": testClientSecret}, "Bearer " + testClientSecret, false},
+	{"not base64", map[string]string{"orders": testClientSecret}, "Basic orders:" + testClientSecret, false},
+	{"no colon", map[string]string{"orders": testClientSecret}, "Basic " + base64.StdEncoding.EncodeToString([]byte("orders")), false},
+}
+
+func TestIntrospectionClientHandler(t *testing.T) {
+	for _, tt := range introspectionClientTests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{Config: &testConfig{introspection: config.Introspection{Clients: tt.clients}}}
+			router := gin.New()
+			router.POST("/introspect", m.IntrospectionClientHandler(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/introspect", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			want := http.StatusUnauthorized
+			if tt.allowed {
+				want = http.StatusOK
+			}
+			if w.Code != want {
+				t.Fatalf("status %d, want %d", w.Code, want)
+			}
+			if !tt.allowed && w.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("refused without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestIntrospectionClientInterceptor(t *testing.T) {
+	const validate = "/auth.v1.AuthService/ValidateToken"
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "validated", nil }
+
+	for _, tt := range introspectionClientTests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{Config: &testConfig{introspection: config.Introspection{Clients: tt.clients}}}
+			interceptor := m.IntrospectionClientInterceptor(validate)
+
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(rpcAuthorization, tt.authorization))
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: validate}, handler)
+
+			want := codes.Unauthenticated
+			if tt.allowed {
+				want = codes.OK
+			}
+			if got := status.Code(err); got != want {
+				t.Fatalf("code %s, want %s", got, want)
+			}
+
+			// the methods the interceptor does not cover are left alone
+			_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testRPCMethod}, handler)
+			if err != nil {
+				t.Fatalf("uncovered method: %v", err)
+			}
+		})
+	}
+}
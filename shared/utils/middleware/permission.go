@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// EffectivePermissions are the roles of a user, given directly or through groups, and the permissions they grant
+type EffectivePermissions struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Has reports whether the permission is granted
+func (e *EffectivePermissions) Has(permission string) bool {
+	for _, p := range e.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IPermissionResolver looks up the effective permissions of a user
+type IPermissionResolver interface {
+	EffectivePermissions(ctx context.Context, userID uuid.UUID) (*EffectivePermissions, error)
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"test-task/shared/log"
+	"test-task/shared/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyHandler authorizes the request with the route policies of Policy.Path. Requests no policy covers
+// are denied, the others need every matching policy to allow them. Each decision is logged with its
+// reasons, denials of dry-run policies are only logged. It must run after AuthHandler.
+func (m *Middleware) PolicyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		userData, err := GetUserDataFromToken(c)
+		if err != nil {
+			c.JSON(401, gin.H{"message": "something went wrong", "status": http.StatusUnauthorized})
+			c.Abort()
+			return
+		}
+
+		info := GetRequestInfo(c)
+		data := log.Data{IPAddress: info.IPAddress, Session: info.RequestID, ActorID: userData.Id.String()}
+		// a route added without a policy stays closed until one says who may call it
+		if !m.Policies.Matches(c.Request.Method, route) {
+			log.GetLog().Warn(data, "POLICY : deny %s %s: no policy covers the route", c.Request.Method, route)
+			c.JSON(403, gin.H{"message": "you are not allowed to access this resource", "status": http.StatusForbidden})
+			c.Abort()
+			return
+		}
+
+		effective, err := m.Permissions.EffectivePermissions(c.Request.Context(), userData.Id)
+		if err != nil {
+			c.JSON(500, gin.H{"message": "something went wrong", "status": http.StatusInternalServerError})
+			c.Abort()
+			return
+		}
+
+		subject := policy.Subject{Roles: effective.Roles, Permissions: effective.Permissions}
+		if userData.OrgId != nil {
+			subject.OrgRole = userData.OrgRole
+		}
+		decision := m.Policies.Evaluate(policy.Request{
+			Method:    c.Request.Method,
+			Route:     route,
+			Attribute: func(name string) (string, bool) { return policyAttribute(c, userData, name) },
+		}, subject)
+
+		reasons := strings.Join(decision.Reasons, "; ")
+		switch {
+		case !decision.Allowed:
+			log.GetLog().Warn(data, "POLICY : deny %s %s: %s", c.Request.Method, route, reasons)
+			c.JSON(403, gin.H{"message": "you are not allowed to access this resource", "status": http.StatusForbidden})
+			c.Abort()
+			return
+		case decision.DryRun:
+			log.GetLog().Warn(data, "POLICY : dry-run deny %s %s: %s", c.Request.Method, route, reasons)
+		default:
+			log.GetLog().Debug(data, "POLICY : allow %s %s: %s", c.Request.Method, route, reasons)
+		}
+		c.Next()
+	}
+}
+
+// policyAttribute resolves the attributes policy conditions compare, see the Policies section of the README
+func policyAttribute(c *gin.Context, userData UserTokenData, name string) (string, bool) {
+	switch {
+	case name == "user.id":
+		return userData.Id.String(), true
+	case name == "user.email":
+		return userData.Email, true
+	case name == "user.role":
+		return userData.Role, true
+	case name == "user.org_id":
+		if userData.OrgId == nil {
+			return "", false
+		}
+		return userData.OrgId.String(), true
+	case name == "user.org_role":
+		return userData.OrgRole, userData.OrgId != nil
+	case name == "user.auth_method":
+		return userData.AuthMethod, true
+	case name == "user.impersonated":
+		return strconv.FormatBool(userData.Actor != nil), true
+	case strings.HasPrefix(name, "param."):
+		for _, param := range c.Params {
+			if param.Key == strings.TrimPrefix(name, "param.") {
+				return param.Value, true
+			}
+		}
+	case strings.HasPrefix(name, "query."):
+		return c.GetQuery(strings.TrimPrefix(name, "query."))
+	case strings.HasPrefix(name, "header."):
+		if values := c.Request.Header.Values(strings.TrimPrefix(name, "header.")); len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return "", false
+}
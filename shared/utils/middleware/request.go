@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	u "test-task/shared/common"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDHandler tags every request with an id, reusing the one sent by a proxy when present
+func (m *Middleware) RequestIDHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.Request.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = uuid.NewV4().String()
+		}
+		c.Set("requestId", requestID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// GetRequestInfo collects the caller details of the request for audit records
+func GetRequestInfo(c *gin.Context) u.RequestInfo {
+	info := u.RequestInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("requestId"),
+
+		DPoPThumbprint: c.GetString("dpopJkt"),
+	}
+	if act, ok := c.Get("actor"); ok {
+		if actor := actorFromClaim(act); actor != nil {
+			info.ImpersonatorID = actor.Id.String()
+		}
+	}
+	return info
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-task/shared/dpop"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSHandler answers cross-origin requests from Security.AllowedOrigins. The request and response headers
+// the service works with are always allowed, Security.ExposedHeaders adds to them.
+func (m *Middleware) CORSHandler() gin.HandlerFunc {
+	cf := m.Config.Security()
+	return cors.New(cors.Config{
+		AllowOriginFunc: func(origin string) bool {
+			return originAllowed(cf.AllowedOrigins, origin)
+		},
+		AllowCredentials: cf.AllowCredentials,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders: []string{
+			"Content-Type", "Authorization", RequestIDHeader, CaptchaHeader, dpop.Header,
+			SessionModeHeader, ClientIDHeader, CSRFHeader,
+		},
+		ExposeHeaders: append([]string{"Data-Length", RequestIDHeader, dpop.NonceHeader, "WWW-Authenticate"}, cf.ExposedHeaders...),
+		MaxAge:        time.Duration(cf.MaxAge) * time.Second,
+	})
+}
+
+// SecurityHeadersHandler sets the browser security headers of Security on every response
+func (m *Middleware) SecurityHeadersHandler() gin.HandlerFunc {
+	cf := m.Config.Security()
+	var hsts string
+	if cf.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cf.HSTSMaxAge)
+		if cf.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cf.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		if cf.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", cf.ContentSecurityPolicy)
+		}
+		if cf.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", cf.ReferrerPolicy)
+		}
+		if cf.PermissionsPolicy != "" {
+			header.Set("Permissions-Policy", cf.PermissionsPolicy)
+		}
+		c.Next()
+	}
+}
+
+// originAllowed matches the origin against exact origins and patterns, "*." in the host stands for any
+// subdomain and ":*" for any port
+func originAllowed(patterns []string, origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		allowed, err := url.Parse(strings.Replace(pattern, ":*", "", 1))
+		if err != nil || !strings.EqualFold(allowed.Scheme, parsed.Scheme) {
+			continue
+		}
+		if !strings.HasSuffix(pattern, ":*") && allowed.Port() != parsed.Port() {
+			continue
+		}
+		host, want := strings.ToLower(parsed.Hostname()), strings.ToLower(allowed.Hostname())
+		if strings.HasPrefix(want, "*.") {
+			if strings.HasSuffix(host, want[1:]) && len(host) > len(want)-1 {
+				return true
+			}
+			continue
+		}
+		if host == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	SessionModeHeader = "X-Session-Mode" // "cookie" asks for a cookie session
+	ClientIDHeader    = "X-Client-ID"    // clients listed in Session.Clients always get cookie sessions
+	CSRFHeader        = "X-CSRF-Token"   // echoes the CSRF cookie on requests that use the refresh cookie
+	SessionModeCookie = "cookie"
+)
+
+var (
+	// ErrNoSessionCookie is returned when a cookie session request carries no refresh cookie
+	ErrNoSessionCookie = errors.New("no session cookie")
+	// ErrInvalidCSRFToken is returned when the CSRF header does not belong to the refresh cookie
+	ErrInvalidCSRFToken = errors.New("invalid CSRF token")
+)
+
+// SessionHandler turns the request into a cookie session when the client asks for one with X-Session-Mode
+// or is listed in Session.Clients. The refresh token of the response is then moved into an HttpOnly cookie
+// and a CSRF token readable by scripts is set next to it. Requests using the refresh cookie have to send
+// that CSRF token in X-CSRF-Token and, when the browser tells the origin, come from Session.AllowedOrigins.
+func (m *Middleware) SessionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.cookieMode(c) {
+			c.Next()
+			return
+		}
+		cf := m.Config.Session()
+
+		if origin := c.Request.Header.Get("Origin"); origin != "" && !contains(cf.AllowedOrigins, origin) {
+			c.JSON(403, gin.H{"message": "the request origin is not allowed", "status": http.StatusForbidden})
+			c.Abort()
+			return
+		}
+		c.Set("sessionMode", SessionModeCookie)
+
+		// the cookie is only handed to the handler with the CSRF token that was issued with it
+		if refresh, err := c.Cookie(cf.RefreshCookie); err == nil && refresh != "" {
+			if hmac.Equal([]byte(c.Request.Header.Get(CSRFHeader)), []byte(csrfToken(refresh))) {
+				c.Set("sessionRefreshToken", refresh)
+			} else {
+				c.Set("sessionRefreshError", ErrInvalidCSRFToken)
+			}
+		}
+
+		writer := &sessionWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		m.writeSession(c, writer)
+	}
+}
+
+// IsCookieSession reports whether SessionHandler made the request a cookie session
+func IsCookieSession(c *gin.Context) bool {
+	return c.GetString("sessionMode") == SessionModeCookie
+}
+
+// SessionRefreshToken returns the refresh token of the cookie session after its CSRF token was checked
+func SessionRefreshToken(c *gin.Context) (string, error) {
+	if refresh := c.GetString("sessionRefreshToken"); refresh != "" {
+		return refresh, nil
+	}
+	if err, ok := c.Get("sessionRefreshError"); ok {
+		return "", err.(error)
+	}
+	return "", ErrNoSessionCookie
+}
+
+// EndSession makes SessionHandler clear the cookies of the session once the handler is done
+func EndSession(c *gin.Context) {
+	c.Set("sessionEnded", true)
+}
+
+func (m *Middleware) cookieMode(c *gin.Context) bool {
+	if c.Request.Header.Get(SessionModeHeader) == SessionModeCookie {
+		return true
+	}
+	client := c.Request.Header.Get(ClientIDHeader)
+	return client != "" && contains(m.Config.Session().Clients, client)
+}
+
+// writeSession sends the buffered response, with the refresh token of a successful response moved into the cookies
+func (m *Middleware) writeSession(c *gin.Context, writer *sessionWriter) {
+	body := writer.body.Bytes()
+	if writer.status < http.StatusBadRequest {
+		if c.GetBool("sessionEnded") {
+			m.setSessionCookies(c, "", "", -1)
+		} else if rewritten, refresh := extractRefreshToken(body); refresh != "" {
+			maxAge := int(RefreshTokenTTL.Seconds())
+			if exp, err := refreshTokenExpiry(refresh); err == nil {
+				maxAge = int(time.Until(exp).Seconds())
+			}
+			m.setSessionCookies(c, refresh, csrfToken(refresh), maxAge)
+			body = rewritten
+		}
+	}
+
+	c.Writer.WriteHeader(writer.status)
+	if len(body) > 0 {
+		_, _ = c.Writer.Write(body)
+	}
+}
+
+func (m *Middleware) setSessionCookies(c *gin.Context, refresh, csrf string, maxAge int) {
+	cf := m.Config.Session()
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     cf.RefreshCookie,
+		Value:    refresh,
+		Path:     cf.Path,
+		Domain:   cf.Domain,
+		MaxAge:   maxAge,
+		Secure:   cf.Secure,
+		HttpOnly: true,
+		SameSite: cf.SameSiteMode,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     cf.CSRFCookie,
+		Value:    csrf,
+		Path:     "/",
+		Domain:   cf.Domain,
+		MaxAge:   maxAge,
+		Secure:   cf.Secure,
+		SameSite: cf.SameSiteMode,
+	})
+}
+
+// extractRefreshToken removes data.refresh_token from a JSON response and returns it
+func extractRefreshToken(body []byte) ([]byte, string) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body, ""
+	}
+	data, _ := response["data"].(map[string]interface{})
+	refresh, _ := data["refresh_token"].(string)
+	if refresh == "" {
+		return body, ""
+	}
+	delete(data, "refresh_token")
+
+	rewritten, err := json.Marshal(response)
+	if err != nil {
+		return body, ""
+	}
+	return append(rewritten, '\n'), refresh
+}
+
+func refreshTokenExpiry(refresh string) (time.Time, error) {
+	token, err := ValidateToken(refresh, RefreshTokenKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	exp, err := token.Claims.(jwt.MapClaims).GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}, errors.New("refresh token has no expiry")
+	}
+	return exp.Time, nil
+}
+
+// csrfToken is derived from the refresh token, so a cookie planted by another site does not come with a valid one
+func csrfToken(refresh string) string {
+	mac := hmac.New(sha256.New, []byte(RefreshTokenKey))
+	mac.Write([]byte("csrf:" + refresh))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sessionWriter holds the response back until the refresh token is moved into the cookies
+type sessionWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *sessionWriter) WriteHeaderNow() {}
+
+func (w *sessionWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *sessionWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *sessionWriter) Status() int {
+	return w.status
+}
+
+func (w *sessionWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
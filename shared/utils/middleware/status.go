@@ -0,0 +1,12 @@
+package middleware
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// IUserStatusResolver looks up the current status of a user, an empty status means the user does not exist
+type IUserStatusResolver interface {
+	UserStatus(ctx context.Context, userID uuid.UUID) (string, error)
+}
@@ -0,0 +1,247 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"test-task/shared/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported hashing algorithms for Password.HashAlgorithm
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// bcryptMaxInput is the number of bytes bcrypt reads, longer inputs are pre-hashed
+const bcryptMaxInput = 72
+
+// ErrUnknownHash is returned when a stored hash is in a format the hasher does not understand
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// IHasher is
+type IHasher interface {
+	// Hash returns the PHC string of the password using the configured algorithm and parameters
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash, and whether the
+	// hash was produced by an older algorithm or weaker parameters and should be replaced
+	Verify(password, encoded string) (match bool, rehash bool, err error)
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// Hasher is
+type Hasher struct {
+	algorithm  string
+	argon2     argon2Params
+	bcryptCost int
+	pepper     []byte
+	// pepperID marks the hashes made with the pepper, so a failed verification of them runs the KDF once
+	pepperID string
+}
+
+// NewHasher returns the password hasher described by the Password config section
+func NewHasher(cf config.IConfig) IHasher {
+	pc := cf.Password()
+	return &Hasher{
+		algorithm: pc.HashAlgorithm,
+		argon2: argon2Params{
+			memory:      uint32(pc.Argon2Memory),
+			iterations:  uint32(pc.Argon2Iterations),
+			parallelism: uint8(pc.Argon2Parallelism),
+			saltLength:  uint32(pc.Argon2SaltLength),
+			keyLength:   uint32(pc.Argon2KeyLength),
+		},
+		bcryptCost: pc.BcryptCost,
+		pepper:     []byte(pc.Pepper),
+		pepperID:   pepperID([]byte(pc.Pepper)),
+	}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	input := h.peppered(password)
+
+	if h.algorithm == AlgorithmBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword(bcryptInput(input), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("bcrypt: %w", err)
+		}
+		if h.pepperID != "" {
+			return fmt.Sprintf("%s$keyid=%s", hashed, h.pepperID), nil
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, h.argon2.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("argon2id: generating salt: %w", err)
+	}
+	p := h.argon2
+	key := argon2.IDKey(input, salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", p.memory, p.iterations, p.parallelism)
+	if h.pepperID != "" {
+		params += ",keyid=" + h.pepperID
+	}
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+		argon2.Version, params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Hasher) Verify(password, encoded string) (bool, bool, error) {
+	var verify func(input []byte) (bool, bool, error)
+	var keyID string
+
+	switch {
+	case encoded == "":
+		// accounts without a password, e.g. made by an invitation or SMS sign-in, match nothing
+		return false, false, nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		keyID = argon2KeyID(encoded)
+		verify = func(input []byte) (bool, bool, error) { return h.verifyArgon2id(input, encoded) }
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		encoded, keyID, _ = strings.Cut(encoded, "$keyid=")
+		verify = func(input []byte) (bool, bool, error) { return h.verifyBcrypt(input, encoded) }
+	default:
+		return false, false, ErrUnknownHash
+	}
+
+	switch {
+	case keyID != "":
+		// made with a pepper, one that was replaced since can not be checked
+		if keyID != h.pepperID {
+			return false, false, nil
+		}
+		return verify(h.peppered(password))
+	case len(h.pepper) == 0:
+		return verify([]byte(password))
+	}
+
+	// hashes without a key id were made before a pepper was configured, or with it before the hashes were
+	// marked. Both are accepted once and upgraded.
+	match, _, err := verify(h.peppered(password))
+	if err != nil || match {
+		return match, match, err
+	}
+	match, _, err = verify([]byte(password))
+	return match, match, err
+}
+
+func (h *Hasher) verifyArgon2id(input []byte, encoded string) (bool, bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false, ErrUnknownHash
+	}
+
+	var p argon2Params
+	params, _, _ := strings.Cut(parts[3], ",keyid=")
+	if _, err := fmt.Sscanf(params, "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return false, false, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrUnknownHash
+	}
+	p.saltLength = uint32(len(salt))
+	p.keyLength = uint32(len(key))
+
+	computed := argon2.IDKey(input, salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return false, false, nil
+	}
+
+	rehash := h.algorithm != AlgorithmArgon2id || version != argon2.Version || p.weakerThan(h.argon2)
+	return true, rehash, nil
+}
+
+func (h *Hasher) verifyBcrypt(input []byte, encoded string) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), bcryptInput(input))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("bcrypt: %w", err)
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, fmt.Errorf("bcrypt: %w", err)
+	}
+	return true, h.algorithm != AlgorithmBcrypt || cost < h.bcryptCost, nil
+}
+
+// peppered mixes the server side secret into the password, so a leaked
+// database alone is not enough to run an offline attack
+func (h *Hasher) peppered(password string) []byte {
+	if len(h.pepper) == 0 {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	// bcrypt stops reading at the first NUL byte, so the digest is kept printable
+	return []byte(base64.RawStdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// pepperID identifies the pepper in the hashes made with it, without revealing it
+func pepperID(pepper []byte) string {
+	if len(pepper) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte("password pepper id"))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))[:8]
+}
+
+// argon2KeyID returns the keyid parameter of an argon2id PHC string
+func argon2KeyID(encoded string) string {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return ""
+	}
+	_, keyID, _ := strings.Cut(parts[3], ",keyid=")
+	return keyID
+}
+
+// bcryptInput pre-hashes inputs bcrypt would refuse as too long. Hashes of those never existed,
+// so the ones already stored are not affected.
+func bcryptInput(input []byte) []byte {
+	if len(input) <= bcryptMaxInput {
+		return input
+	}
+	sum := sha256.Sum256(input)
+	return []byte(base64.RawStdEncoding.EncodeToString(sum[:]))
+}
+
+func (p argon2Params) weakerThan(target argon2Params) bool {
+	return p.memory < target.memory ||
+		p.iterations < target.iterations ||
+		p.parallelism < target.parallelism ||
+		p.saltLength < target.saltLength ||
+		p.keyLength < target.keyLength
+}
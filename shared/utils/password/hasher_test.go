@@ -0,0 +1,173 @@
+package password
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"test-task/shared/config"
+)
+
+const testPassword = "correct horse battery staple"
+
+// testHasherConfig is a cheap configuration of the algorithm, the tests change a field of it at a time
+func testHasherConfig(algorithm string) config.Password {
+	return config.Password{
+		HashAlgorithm:     algorithm,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+		BcryptCost:        4,
+	}
+}
+
+func newTestHasher(pc config.Password) IHasher {
+	return NewHasher(&testConfig{password: pc})
+}
+
+func mustHash(t *testing.T, h IHasher, password string) string {
+	t.Helper()
+	encoded, err := h.Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestHashRoundTrip(t *testing.T) {
+	peppered := func(pc config.Password) config.Password {
+		pc.Pepper = "server-secret"
+		return pc
+	}
+	tests := []struct {
+		name     string
+		config   config.Password
+		password string
+		format   *regexp.Regexp
+	}{
+		{"argon2id", testHasherConfig(AlgorithmArgon2id), testPassword,
+			regexp.MustCompile(`^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`)},
+		{"argon2id with pepper", peppered(testHasherConfig(AlgorithmArgon2id)), testPassword,
+			regexp.MustCompile(`^\$argon2id\$v=19\$m=1024,t=1,p=1,keyid=[A-Za-z0-9_-]{8}\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`)},
+		{"bcrypt", testHasherConfig(AlgorithmBcrypt), testPassword,
+			regexp.MustCompile(`^\$2a\$04\$[./A-Za-z0-9]{53}$`)},
+		{"bcrypt with pepper", peppered(testHasherConfig(AlgorithmBcrypt)), testPassword,
+			regexp.MustCompile(`^\$2a\$04\$[./A-Za-z0-9]{53}\$keyid=[A-Za-z0-9_-]{8}$`)},
+		{"bcrypt longer than 72 bytes", testHasherConfig(AlgorithmBcrypt), strings.Repeat("long password ", 10),
+			regexp.MustCompile(`^\$2a\$04\$`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHasher(tt.config)
+			encoded := mustHash(t, h, tt.password)
+			if !tt.format.MatchString(encoded) {
+				t.Fatalf("hash %q does not match %s", encoded, tt.format)
+			}
+			if again := mustHash(t, h, tt.password); again == encoded {
+				t.Fatal("two hashes of the password are equal, the salt is not random")
+			}
+
+			match, rehash, err := h.Verify(tt.password, encoded)
+			if err != nil || !match || rehash {
+				t.Fatalf("Verify of the password = %v, %v, %v, want a match without rehash", match, rehash, err)
+			}
+			match, _, err = h.Verify(tt.password+"!", encoded)
+			if err != nil || match {
+				t.Fatalf("Verify of another password = %v, %v, want no match", match, err)
+			}
+		})
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	argon2id := testHasherConfig(AlgorithmArgon2id)
+	bcrypt := testHasherConfig(AlgorithmBcrypt)
+	with := func(pc config.Password, change func(*config.Password)) config.Password {
+		change(&pc)
+		return pc
+	}
+	pepperA := func(pc *config.Password) { pc.Pepper = "pepper-a" }
+	pepperB := func(pc *config.Password) { pc.Pepper = "pepper-b" }
+
+	tests := []struct {
+		name       string
+		hashedWith config.Password
+		verifyWith config.Password
+		match      bool
+		rehash     bool
+	}{
+		{"same argon2id params", argon2id, argon2id, true, false},
+		{"bcrypt to argon2id", bcrypt, argon2id, true, true},
+		{"argon2id to bcrypt", argon2id, bcrypt, true, true},
+		{"old argon2id memory", argon2id, with(argon2id, func(pc *config.Password) { pc.Argon2Memory = 2048 }), true, true},
+		{"old argon2id iterations", argon2id, with(argon2id, func(pc *config.Password) { pc.Argon2Iterations = 2 }), true, true},
+		{"old argon2id parallelism", argon2id, with(argon2id, func(pc *config.Password) { pc.Argon2Parallelism = 2 }), true, true},
+		{"old argon2id salt length", argon2id, with(argon2id, func(pc *config.Password) { pc.Argon2SaltLength = 32 }), true, true},
+		{"old argon2id key length", argon2id, with(argon2id, func(pc *config.Password) { pc.Argon2KeyLength = 64 }), true, true},
+		{"stronger argon2id params", with(argon2id, func(pc *config.Password) { pc.Argon2Iterations = 2 }), argon2id, true, false},
+		{"old bcrypt cost", bcrypt, with(bcrypt, func(pc *config.Password) { pc.BcryptCost = 5 }), true, true},
+		{"stronger bcrypt cost", with(bcrypt, func(pc *config.Password) { pc.BcryptCost = 5 }), bcrypt, true, false},
+		{"argon2id pepper added", argon2id, with(argon2id, pepperA), true, true},
+		{"bcrypt pepper added", bcrypt, with(bcrypt, pepperA), true, true},
+		{"argon2id same pepper keyid", with(argon2id, pepperA), with(argon2id, pepperA), true, false},
+		{"bcrypt same pepper keyid", with(bcrypt, pepperA), with(bcrypt, pepperA), true, false},
+		{"argon2id pepper replaced", with(argon2id, pepperA), with(argon2id, pepperB), false, false},
+		{"bcrypt pepper replaced", with(bcrypt, pepperA), with(bcrypt, pepperB), false, false},
+		{"argon2id pepper removed", with(argon2id, pepperA), argon2id, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := mustHash(t, newTestHasher(tt.hashedWith), testPassword)
+			match, rehash, err := newTestHasher(tt.verifyWith).Verify(testPassword, encoded)
+			if err != nil || match != tt.match || rehash != tt.rehash {
+				t.Fatalf("Verify = %v, %v, %v, want %v, %v", match, rehash, err, tt.match, tt.rehash)
+			}
+		})
+	}
+}
+
+func TestVerifyPepperedWithoutKeyID(t *testing.T) {
+	pc := testHasherConfig(AlgorithmArgon2id)
+	pc.Pepper = "pepper-a"
+	h := newTestHasher(pc)
+
+	// hashes made with the pepper before they were marked with its key id are accepted once and upgraded
+	encoded := regexp.MustCompile(`,keyid=[^$]+`).ReplaceAllString(mustHash(t, h, testPassword), "")
+	match, rehash, err := h.Verify(testPassword, encoded)
+	if err != nil || !match || !rehash {
+		t.Fatalf("Verify = %v, %v, %v, want a match with rehash", match, rehash, err)
+	}
+	if match, _, _ = h.Verify("wrong password", encoded); match {
+		t.Fatal("Verify of another password matched")
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	h := newTestHasher(testHasherConfig(AlgorithmArgon2id))
+	tests := []struct {
+		name    string
+		encoded string
+		err     error
+	}{
+		// accounts without a password match nothing, not even an empty one
+		{"empty hash", "", nil},
+		{"plain text", testPassword, ErrUnknownHash},
+		{"unknown algorithm", "$1$salt$hash", ErrUnknownHash},
+		{"argon2id missing parts", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", ErrUnknownHash},
+		{"argon2id bad params", "$argon2id$v=19$memory=1024$c2FsdA$a2V5", ErrUnknownHash},
+		{"argon2id bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", ErrUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, password := range []string{testPassword, ""} {
+				match, rehash, err := h.Verify(password, tt.encoded)
+				if match || rehash || !errors.Is(err, tt.err) {
+					t.Fatalf("Verify(%q) = %v, %v, %v, want no match and error %v", password, match, rehash, err, tt.err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"test-task/shared/config"
+	"test-task/shared/log"
+	msg "test-task/shared/utils/message"
+)
+
+// Rule names reported by RuleError
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUpper     = "upper"
+	RuleLower     = "lower"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleBreached  = "breached"
+	RuleStrength  = "strength"
+)
+
+// RuleError is returned when a password fails one of the policy rules
+type RuleError struct {
+	Rule    string
+	Message string
+}
+
+func (e *RuleError) Error() string {
+	return e.Message
+}
+
+// IPolicy is
+type IPolicy interface {
+	// Validate checks the password against every rule of the policy.
+	// userInputs are values such as the name or email of the user which
+	// should not make up the password.
+	Validate(password string, userInputs ...string) error
+}
+
+// Policy is
+type Policy struct {
+	config *config.Password
+
+	breached     map[string]struct{}
+	breachedOnce sync.Once
+}
+
+// NewPolicy returns the password policy described by the Password config section
+func NewPolicy(cf config.IConfig) IPolicy {
+	return &Policy{
+		config: cf.Password(),
+	}
+}
+
+func (p *Policy) Validate(password string, userInputs ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		return &RuleError{RuleMinLength, fmt.Sprintf(msg.PasswordTooShort, p.config.MinLength)}
+	}
+	if length > p.config.MaxLength {
+		return &RuleError{RuleMaxLength, fmt.Sprintf(msg.PasswordTooLong, p.config.MaxLength)}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.config.RequireUpper && !hasUpper {
+		return &RuleError{RuleUpper, msg.PasswordNeedsUpper}
+	}
+	if p.config.RequireLower && !hasLower {
+		return &RuleError{RuleLower, msg.PasswordNeedsLower}
+	}
+	if p.config.RequireDigit && !hasDigit {
+		return &RuleError{RuleDigit, msg.PasswordNeedsDigit}
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		return &RuleError{RuleSymbol, msg.PasswordNeedsSymbol}
+	}
+
+	if p.isBreached(password) {
+		return &RuleError{RuleBreached, msg.PasswordBreached}
+	}
+
+	if score := Strength(password, userInputs...); score < p.config.MinStrength {
+		return &RuleError{RuleStrength, fmt.Sprintf(msg.PasswordTooWeak, score, p.config.MinStrength)}
+	}
+
+	return nil
+}
+
+func (p *Policy) isBreached(password string) bool {
+	p.breachedOnce.Do(p.loadBreached)
+	_, found := p.breached[strings.ToLower(password)]
+	return found
+}
+
+// loadBreached reads the breached/common password list once, on first use
+func (p *Policy) loadBreached() {
+	p.breached = map[string]struct{}{}
+	if p.config.BreachedListPath == "" {
+		return
+	}
+
+	file, err := os.Open(p.config.BreachedListPath)
+	if err != nil {
+		log.GetLog().Error("ERROR : ", "Can not open breached password list %s: %s", p.config.BreachedListPath, err.Error())
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		log.GetLog().Error("ERROR : ", "Can not read breached password list: %s", err.Error())
+	}
+}
@@ -0,0 +1,80 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"test-task/shared/config"
+)
+
+// testConfig answers the Password section, the other sections are not used by the package
+type testConfig struct {
+	config.IConfig
+	password config.Password
+}
+
+func (c *testConfig) Password() *config.Password { return &c.password }
+
+func TestPolicyValidate(t *testing.T) {
+	breached := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(breached, []byte("# leaked passwords\n\nWinter!2019Zz\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	strict := config.Password{
+		MinLength:        10,
+		MaxLength:        20,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		MinStrength:      3,
+		BreachedListPath: breached,
+	}
+	relaxed := config.Password{MinLength: 8, MaxLength: 128}
+
+	tests := []struct {
+		name     string
+		config   config.Password
+		password string
+		inputs   []string
+		want     string // the rule refusing the password, empty when it is accepted
+	}{
+		{"strong", strict, "kT9#vLq2$Wm8zR", nil, ""},
+		{"too short", strict, "kT9#vLq2$", nil, RuleMinLength},
+		{"length counts runes", strict, "kT9#vLq2ßé", nil, ""},
+		{"too long", strict, "kT9#vLq2$Wm8zR-kT9#vL", nil, RuleMaxLength},
+		{"no upper", strict, "kt9#vlq2$wm8zr", nil, RuleUpper},
+		{"no lower", strict, "KT9#VLQ2$WM8ZR", nil, RuleLower},
+		{"no digit", strict, "kTx#vLqy$WmazR", nil, RuleDigit},
+		{"no symbol", strict, "kT9xvLq2yWm8zR", nil, RuleSymbol},
+		{"space is a symbol", strict, "kT9 vLq2 Wm8zR", nil, ""},
+		{"breached", strict, "winter!2019zZ", nil, RuleBreached},
+		{"weak", strict, "Password1234!", nil, RuleStrength},
+		{"email of the user", strict, "Jane.Doe!1", []string{"jane.doe@example.com"}, RuleStrength},
+		{"name of the user", strict, "Janedoe2024!", []string{"Jane", "Doe"}, RuleStrength},
+		{"other user", strict, "Jane.Doe!1", []string{"john.smith@example.com"}, ""},
+		{"classes not required", relaxed, "correct horse battery staple", nil, ""},
+		{"no strength required", relaxed, "password", nil, ""},
+		{"no breached list", relaxed, "winter!2019zz", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPolicy(&testConfig{password: tt.config}).Validate(tt.password, tt.inputs...)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate(%q) = %v, want nil", tt.password, err)
+				}
+				return
+			}
+			var ruleErr *RuleError
+			if !errors.As(err, &ruleErr) || ruleErr.Rule != tt.want {
+				t.Fatalf("Validate(%q) = %v, want the %s rule", tt.password, err, tt.want)
+			}
+			if ruleErr.Message == "" {
+				t.Fatalf("Validate(%q) refused by %s without a message", tt.password, tt.want)
+			}
+		})
+	}
+}
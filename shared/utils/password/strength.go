@@ -0,0 +1,273 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonWords are fragments that show up in a large share of leaked passwords.
+// A match costs the attacker almost nothing, the same way a zxcvbn dictionary hit does.
+var commonWords = []string{
+	"password", "passw0rd", "qwerty", "letmein", "welcome", "admin", "login",
+	"iloveyou", "monkey", "dragon", "master", "sunshine", "princess", "football",
+	"baseball", "shadow", "superman", "batman", "trustno1", "secret", "hello",
+	"freedom", "whatever", "starwars", "cricket", "india", "default", "abc",
+	"test", "guest", "user", "root", "love", "pass", "god", "money",
+}
+
+// keyboardRows are walked left to right and right to left when looking for keyboard patterns
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// Strength estimates how hard the password is to guess and returns a score
+// from 0 (too guessable) to 4 (very unguessable), following the zxcvbn scale.
+//
+// The password is split into the cheapest sequence of patterns (dictionary
+// words, user inputs, repeats, sequences, keyboard walks, years and brute
+// force) and the guesses of every pattern are multiplied together.
+func Strength(password string, userInputs ...string) int {
+	guesses := estimateGuesses(password, userInputs)
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	}
+	return 4
+}
+
+type match struct {
+	end     int
+	guesses float64 // log10 of the guesses needed for this match
+}
+
+// estimateGuesses returns the log10 of the guesses needed to crack the password
+func estimateGuesses(password string, userInputs []string) float64 {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return 0
+	}
+
+	lower := []rune(strings.ToLower(password))
+	unleet := make([]rune, n)
+	for i, r := range lower {
+		if s, ok := leetSubstitutions[r]; ok {
+			unleet[i] = s
+		} else {
+			unleet[i] = r
+		}
+	}
+
+	var inputs []string
+	for _, in := range userInputs {
+		for _, part := range strings.FieldsFunc(strings.ToLower(in), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len(part) >= 3 {
+				inputs = append(inputs, part)
+			}
+		}
+	}
+
+	matches := make([][]match, n)
+	for i := 0; i < n; i++ {
+		matches[i] = append(matches[i], dictionaryMatches(runes, lower, unleet, i, inputs)...)
+		matches[i] = append(matches[i], repeatMatches(lower, i)...)
+		matches[i] = append(matches[i], sequenceMatches(lower, i)...)
+		matches[i] = append(matches[i], keyboardMatches(lower, i)...)
+		matches[i] = append(matches[i], yearMatches(lower, i)...)
+	}
+
+	// best[i] is the cheapest way to cover runes[:i]; segments[i] counts the patterns used,
+	// a run of brute forced characters counts as a single pattern
+	best := make([]float64, n+1)
+	segments := make([]int, n+1)
+	bruteForced := make([]bool, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(1)
+	}
+	for i := 0; i < n; i++ {
+		if math.IsInf(best[i], 1) {
+			continue
+		}
+		// a single brute forced character
+		if cost := best[i] + 1; cost < best[i+1] {
+			best[i+1] = cost
+			segments[i+1] = segments[i]
+			if !bruteForced[i] {
+				segments[i+1]++
+			}
+			bruteForced[i+1] = true
+		}
+		for _, m := range matches[i] {
+			if cost := best[i] + m.guesses; cost < best[m.end] {
+				best[m.end] = cost
+				segments[m.end] = segments[i] + 1
+				bruteForced[m.end] = false
+			}
+		}
+	}
+
+	// the attacker also has to guess how the patterns are combined
+	return best[n] + math.Log10(factorial(segments[n]))
+}
+
+func dictionaryMatches(runes, lower, unleet []rune, start int, inputs []string) []match {
+	var found []match
+	check := func(word string, rank float64) {
+		w := []rune(word)
+		end := start + len(w)
+		if end > len(lower) {
+			return
+		}
+		plain := string(lower[start:end]) == word
+		if !plain && string(unleet[start:end]) != word {
+			return
+		}
+		guesses := rank + uppercaseVariations(runes[start:end])
+		if !plain {
+			guesses += math.Log10(2)
+		}
+		found = append(found, match{end, guesses})
+	}
+
+	for _, in := range inputs {
+		check(in, 1)
+	}
+	for _, word := range commonWords {
+		check(word, 2)
+	}
+	return found
+}
+
+// uppercaseVariations returns the log10 of the capitalisation choices an attacker tries for a word
+func uppercaseVariations(word []rune) float64 {
+	var upper int
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case upper == len(word), upper == 1 && unicode.IsUpper(word[0]):
+		return math.Log10(2)
+	}
+	return math.Log10(float64(len(word)))
+}
+
+func repeatMatches(lower []rune, start int) []match {
+	end := start + 1
+	for end < len(lower) && lower[end] == lower[start] {
+		end++
+	}
+	if end-start < 3 {
+		return nil
+	}
+	return []match{{end, math.Log10(cardinality(lower[start]) * float64(end-start))}}
+}
+
+func sequenceMatches(lower []rune, start int) []match {
+	if start+1 >= len(lower) {
+		return nil
+	}
+	delta := lower[start+1] - lower[start]
+	if delta != 1 && delta != -1 {
+		return nil
+	}
+	end := start + 2
+	for end < len(lower) && lower[end]-lower[end-1] == delta {
+		end++
+	}
+	if end-start < 3 {
+		return nil
+	}
+	return []match{{end, math.Log10(cardinality(lower[start]) * float64(end-start) * 2)}}
+}
+
+func keyboardMatches(lower []rune, start int) []match {
+	var found []match
+	for _, row := range keyboardRows {
+		for _, walk := range []string{row, reverse(row)} {
+			w := []rune(walk)
+			end := start
+			for pos := indexRune(w, lower[start]); pos >= 0 && end < len(lower) && pos < len(w) && lower[end] == w[pos]; pos++ {
+				end++
+			}
+			if end-start >= 3 {
+				found = append(found, match{end, math.Log10(float64(len(keyboardRows)*2) * float64(end-start) * 10)})
+			}
+		}
+	}
+	return found
+}
+
+func yearMatches(lower []rune, start int) []match {
+	if start+4 > len(lower) {
+		return nil
+	}
+	year := string(lower[start : start+4])
+	if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) {
+		return []match{{start + 4, math.Log10(120)}}
+	}
+	return nil
+}
+
+func cardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLetter(r):
+		return 26
+	}
+	return 33
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func indexRune(s []rune, r rune) int {
+	for i, c := range s {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
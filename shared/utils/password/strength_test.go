@@ -0,0 +1,61 @@
+package password
+
+import "testing"
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"password", 0},
+		{"P@ssw0rd", 0},
+		{"aaaaaaaaaa", 0},
+		{"abcdefgh", 0},
+		{"12345678", 0},
+		{"zxcvbnm", 0},
+		{"Qwertyuiop", 0},
+		{"Password1", 1},
+		{"qwerty123", 1},
+		{"19871987", 1},
+		{"Sunshine2020", 1},
+		{"jane.doe", 3},
+		{"Tr0ub4dor&3", 4},
+		{"kT9#vLq2$Wm8zR", 4},
+		{"correct horse battery staple", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := Strength(tt.password); got != tt.want {
+				t.Fatalf("Strength(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrengthUserInputs(t *testing.T) {
+	inputs := []string{"jane.doe@example.com", "Jane", "Doe"}
+	tests := []struct {
+		password string
+		without  int
+		with     int
+	}{
+		// the parts of the email and the name are as cheap as the common words
+		{"jane.doe", 3, 1},
+		{"JaneDoe2024", 3, 1},
+		{"janedoe!Xq", 4, 1},
+		// values unrelated to the user keep their score
+		{"smith2024!", 3, 3},
+		{"correct horse battery staple", 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := Strength(tt.password); got != tt.without {
+				t.Errorf("Strength(%q) = %d, want %d", tt.password, got, tt.without)
+			}
+			if got := Strength(tt.password, inputs...); got != tt.with {
+				t.Errorf("Strength(%q, %q) = %d, want %d", tt.password, inputs, got, tt.with)
+			}
+		})
+	}
+}
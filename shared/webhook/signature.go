@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	IDHeader        = "X-Webhook-Id"        // the delivery, changes on manual redelivery
+	EventIDHeader   = "X-Webhook-Event-Id"  // the event, the same for every delivery of it
+	EventHeader     = "X-Webhook-Event"     // the event type
+	TimestampHeader = "X-Webhook-Timestamp" // unix seconds the signature was made at
+	SignatureHeader = "X-Webhook-Signature" // "v1=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+)
+
+const signatureVersion = "v1="
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp out of tolerance")
+)
+
+// Sign returns the signature header value of the body sent at the timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a received delivery. Timestamps further than
+// tolerance from now are refused, so a captured delivery can not be replayed later.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return ErrExpiredTimestamp
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}